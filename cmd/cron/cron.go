@@ -33,7 +33,7 @@ var Cmd = &cobra.Command{
 	Example: "costanza cron -p 8585",
 }
 
-// Use UTC for scheduled times. I hope I don't regret this
+// Use UTC for shared scheduled times. Guild reports are scheduled in the guild's own timezone
 var tz = time.UTC
 
 type cronConfig struct {
@@ -93,50 +93,47 @@ func runCron(_ *cobra.Command, _ []string) error {
 		if err != nil {
 			return fmt.Errorf("failed to parse config time %s: %w", lconfig.StartTime, err)
 		}
-		_, err = s.Every(1).Month(1).At(runtime).Do(func(lconfig config.ListenConfig) {
-			promLabels := prometheus.Labels{listenGuildIdLabel: lconfig.GuildId}
-			start := time.Now()
-			if c.m.enabled {
-				defer func() {
-					c.m.reportDurationSeconds.With(promLabels).Observe(time.Since(start).Seconds())
-				}()
+		for _, period := range lconfig.ReportPeriods() {
+			spec := periodCronSpec(period, lconfig.Location(), runtime.Hour(), runtime.Minute(), 0)
+			_, err = s.Cron(spec).Do(c.postReports, lconfig, period)
+			if err != nil {
+				return fmt.Errorf("failed to schedule %s job: %w", period, err)
 			}
-			ctx := util.ContextFromListenConfig(context.Background(), lconfig.GuildId, lconfig.ReportChannelId)
-			month := util.GetLastMonth(time.Now().UTC())
-			var err *multierror.Error
-			err = multierror.Append(err, c.reportMessageStats(ctx, lconfig, month))
-			err = multierror.Append(err, c.reportDailyGameWins(ctx, lconfig, month))
-			err = multierror.Append(err, c.reportReactionScores(ctx, lconfig, month))
-			err = multierror.Append(err, c.reportContainedUsers(ctx, lconfig, month))
-			err = multierror.Append(err, c.reportCursedPosts(ctx, lconfig, month))
-			errCount := 0
-			if err != nil && err.Len() > 0 {
-				errCount = err.Len()
-				if c.m.enabled {
-					c.m.failedReports.With(promLabels).Add(float64(err.Len()))
-				}
-				slog.ErrorContext(ctx, "report(s) failed: "+err.Error())
-			}
-			if c.m.enabled {
-				c.m.successfulReports.With(promLabels).Add(float64(4 - errCount))
+		}
+		// Wrapped covers the year so far, so it's posted on its last day
+		_, err = s.Cron(wrappedCronSpec(lconfig.Location(), runtime.Hour(), runtime.Minute())).Do(c.postWrapped, lconfig)
+		if err != nil {
+			return fmt.Errorf("failed to schedule wrapped job: %w", err)
+		}
+	}
+
+	// Clean up each period the day after it's reported, once every guild's report has run
+	for _, period := range stats.Periods {
+		_, err = s.Cron(periodCronSpec(period, tz, 16, 0, 1)).Do(func(period stats.Period) {
+			periodKey := period.PreviousKey(time.Now().In(tz))
+			err := c.removeStats(period, periodKey)
+			if err != nil {
+				slog.Error("report log cleanup failed: " + err.Error())
+			} else {
+				slog.Info("cleaned up report log for " + periodKey)
 			}
-		}, lconfig)
+		}, period)
 		if err != nil {
-			return fmt.Errorf("failed to schedule job: %w", err)
+			return fmt.Errorf("failed to schedule %s cleanup job: %w", period, err)
 		}
 	}
 
-	_, err = s.Every(1).Month(2).At(time.Date(0, 0, 0, 16, 0, 0, 0, tz)).Do(func() {
-		month := util.GetLastMonth(time.Now().UTC())
-		err := c.removeStats(month)
+	_, err = s.Every(1).Day().At("16:00").Do(func() {
+		day := stats.DayKey(time.Now().In(tz).AddDate(0, 0, -stats.DailyRetentionDays))
+		err := c.app.Stats.RemoveDailyCountersBefore(context.Background(), day)
 		if err != nil {
-			slog.Error("report log cleanup failed: " + err.Error())
+			slog.Error("daily counter cleanup failed: " + err.Error())
 		} else {
-			slog.Info("cleaned up report log for " + month)
+			slog.Info("cleaned up daily counters before " + day)
 		}
 	})
 	if err != nil {
-		return fmt.Errorf("failed to schedule cleanup job: %w", err)
+		return fmt.Errorf("failed to schedule daily counter cleanup job: %w", err)
 	}
 
 	metricsServerStarted.Wait()
@@ -150,117 +147,192 @@ func runCron(_ *cobra.Command, _ []string) error {
 	return nil
 }
 
-func (c *cronConfig) reportMessageStats(ctx context.Context, listenConfig config.ListenConfig, month string) error {
-	guildId, err := strconv.ParseUint(listenConfig.GuildId, 10, 64)
-	if err != nil {
-		return fmt.Errorf("unable to parse guild id %s: %w", listenConfig.GuildId, err)
+// periodCronSpec builds the cron spec for running at the start of each period in the given timezone, offset by
+// dayOffset days
+func periodCronSpec(period stats.Period, loc *time.Location, hour, minute, dayOffset int) string {
+	var dates string
+	switch period {
+	case stats.WeeklyPeriod:
+		dates = fmt.Sprintf("* * %d", int(time.Monday)+dayOffset) // ISO weeks start on Monday
+	case stats.QuarterlyPeriod:
+		dates = fmt.Sprintf("%d 1,4,7,10 *", 1+dayOffset)
+	case stats.YearlyPeriod:
+		dates = fmt.Sprintf("%d 1 *", 1+dayOffset)
+	default:
+		dates = fmt.Sprintf("%d * *", 1+dayOffset)
 	}
+	return fmt.Sprintf("CRON_TZ=%s %d %d %s", loc, minute, hour, dates)
+}
 
-	topUsers, err := c.app.Stats.GetLeaders(ctx, guildId, month)
-	if err != nil {
-		return fmt.Errorf("failed to get leaders: %w", err)
+// postReports sends every enabled leaderboard section for the guild's previous period to its report channel
+func (c *cronConfig) postReports(lconfig config.ListenConfig, period stats.Period) {
+	promLabels := prometheus.Labels{listenGuildIdLabel: lconfig.GuildId}
+	start := time.Now()
+	if c.m.enabled {
+		defer func() {
+			c.m.reportDurationSeconds.With(promLabels).Observe(time.Since(start).Seconds())
+		}()
 	}
-	if len(topUsers) < 1 {
-		return nil
+	ctx := util.ContextFromListenConfig(context.Background(), lconfig.GuildId, lconfig.ReportChannelId)
+	sections := stats.FilterSections(lconfig.SectionEnabled)
+	err := c.sendReports(ctx, lconfig, period, sections)
+	errCount := 0
+	if err != nil && err.Len() > 0 {
+		errCount = err.Len()
+		if c.m.enabled {
+			c.m.failedReports.With(promLabels).Add(float64(err.Len()))
+		}
+		slog.ErrorContext(ctx, "report(s) failed: "+err.Error(), "period", period)
 	}
-	message := stats.BuildMessageReport(topUsers)
-	_, err = c.sess.ChannelMessageSend(listenConfig.ReportChannelId, message)
-	if err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
+	if c.m.enabled {
+		c.m.successfulReports.With(promLabels).Add(float64(max(len(sections)-errCount, 0)))
 	}
-	return nil
 }
 
-func (c *cronConfig) reportDailyGameWins(ctx context.Context, listenConfig config.ListenConfig, month string) error {
-	guildId, err := strconv.ParseUint(listenConfig.GuildId, 10, 64)
-	if err != nil {
-		return fmt.Errorf("unable to parse guild id %s, %w", listenConfig.GuildId, err)
+func (c *cronConfig) sendReports(ctx context.Context, lconfig config.ListenConfig, period stats.Period, sections []stats.Section) *multierror.Error {
+	var err *multierror.Error
+	guildId, e := strconv.ParseUint(lconfig.GuildId, 10, 64)
+	if e != nil {
+		return multierror.Append(err, fmt.Errorf("unable to parse guild id %s: %w", lconfig.GuildId, e))
 	}
-	topWinners, err := c.app.Stats.GetDailyGameLeaders(ctx, guildId, month)
-	if err != nil {
-		return fmt.Errorf("failed to get winners: %w", err)
+	// don't risk naming users who opted out if they can't be looked up
+	exclude, e := c.app.OptOutCache.Get(ctx, guildId)
+	if e != nil {
+		return multierror.Append(err, fmt.Errorf("failed to get opted out users: %w", e))
 	}
-	if len(topWinners) < 1 {
-		return nil
+	query := stats.LeaderboardQuery{
+		GuildId:   guildId,
+		Period:    period,
+		PeriodKey: period.PreviousKey(time.Now().In(lconfig.Location())),
+		Limit:     lconfig.LeaderboardLimit(),
+		Exclude:   exclude,
 	}
-	message := stats.BuildGameWinReport(topWinners)
-	_, err = c.sess.ChannelMessageSend(listenConfig.ReportChannelId, message)
-	if err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
+	reports, e := stats.BuildLeaderboard(ctx, c.app.Stats, query, sections)
+	if e != nil {
+		err = multierror.Append(err, e)
 	}
-	return nil
+	if len(reports) == 0 {
+		return err
+	}
+	// the period's stats are cleaned up the day after, so page buttons would stop working. Every page is sent instead.
+	for _, page := range stats.RenderEmbeds(stats.LeaderboardTitle(period, query.PeriodKey), reports) {
+		if _, e = c.sess.ChannelMessageSendEmbed(lconfig.ReportChannelId, page); e != nil {
+			break
+		}
+	}
+	if e != nil {
+		slog.WarnContext(ctx, "failed to send report embed, falling back to text: "+e.Error())
+		for _, message := range stats.RenderText(reports) {
+			_, e = c.sess.ChannelMessageSend(lconfig.ReportChannelId, message)
+			if e != nil {
+				err = multierror.Append(err, fmt.Errorf("failed to send message: %w", e))
+			}
+		}
+	}
+	if period == stats.MonthlyPeriod && lconfig.SectionEnabled(stats.MessagesMetric) {
+		if e = c.sendActivityChart(ctx, lconfig, guildId); e != nil {
+			err = multierror.Append(err, e)
+		}
+	}
+	return err
 }
 
-func (c *cronConfig) reportReactionScores(ctx context.Context, listenConfig config.ListenConfig, month string) error {
-	guildId, err := strconv.ParseUint(listenConfig.GuildId, 10, 64)
-	if err != nil {
-		return fmt.Errorf("unable to parse guild id %s: %w", listenConfig.GuildId, err)
+// sendActivityChart posts a chart of messages per day for the month that just ended
+func (c *cronConfig) sendActivityChart(ctx context.Context, lconfig config.ListenConfig, guildId uint64) error {
+	now := time.Now().In(lconfig.Location())
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	query := stats.ChartQuery{
+		GuildId: guildId,
+		Metric:  stats.MessagesMetric,
+		From:    thisMonth.AddDate(0, -1, 0),
+		To:      thisMonth.AddDate(0, 0, -1),
+		Bucket:  stats.DayBucket,
 	}
-	topReactionScores, err := c.app.Stats.GetReactionLeadersForMonth(ctx, guildId, month)
+	image, err := stats.RenderChart(ctx, c.app.Stats, query)
 	if err != nil {
-		return fmt.Errorf("failed to get response rankings: %w", err)
-	}
-	if len(topReactionScores) < 1 {
-		return nil
+		return fmt.Errorf("failed to render activity chart: %w", err)
 	}
-	message := stats.BuildReactionScoreReport(topReactionScores)
-	_, err = c.sess.ChannelMessageSend(listenConfig.ReportChannelId, message)
+	_, err = c.sess.ChannelMessageSendComplex(lconfig.ReportChannelId, &discordgo.MessageSend{
+		Files: []*discordgo.File{image},
+	})
 	if err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
+		return fmt.Errorf("failed to send activity chart: %w", err)
 	}
 	return nil
 }
 
-func (c *cronConfig) reportContainedUsers(ctx context.Context, listenConfig config.ListenConfig, month string) error {
-	guildId, err := strconv.ParseUint(listenConfig.GuildId, 10, 64)
+// wrappedCronSpec builds the cron spec for posting the year's Wrapped on December 31st in the given timezone
+func wrappedCronSpec(loc *time.Location, hour, minute int) string {
+	return fmt.Sprintf("CRON_TZ=%s %d %d 31 12 *", loc, minute, hour)
+}
+
+// postWrapped sends the guild's Wrapped for the year to its report channel
+func (c *cronConfig) postWrapped(lconfig config.ListenConfig) {
+	promLabels := prometheus.Labels{listenGuildIdLabel: lconfig.GuildId}
+	ctx := util.ContextFromListenConfig(context.Background(), lconfig.GuildId, lconfig.ReportChannelId)
+	err := c.sendWrapped(ctx, lconfig)
 	if err != nil {
-		return fmt.Errorf("unable to parse guild id %s: %w", listenConfig.GuildId, err)
+		if c.m.enabled {
+			c.m.failedReports.With(promLabels).Inc()
+		}
+		slog.ErrorContext(ctx, "wrapped failed: "+err.Error())
+	} else if c.m.enabled {
+		c.m.successfulReports.With(promLabels).Inc()
 	}
-	containedUsers, err := c.app.Stats.GetTopCursedChannelPosters(ctx, guildId, month)
+}
+
+func (c *cronConfig) sendWrapped(ctx context.Context, lconfig config.ListenConfig) error {
+	guildId, err := strconv.ParseUint(lconfig.GuildId, 10, 64)
 	if err != nil {
-		return fmt.Errorf("failed to get contained user rankings: %w", err)
-	}
-	if len(containedUsers) < 1 {
-		return nil
+		return fmt.Errorf("unable to parse guild id %s: %w", lconfig.GuildId, err)
 	}
-	message := stats.BuildCursedChannelPostReport(containedUsers)
-	_, err = c.sess.ChannelMessageSend(listenConfig.ReportChannelId, message)
+	exclude, err := c.app.OptOutCache.Get(ctx, guildId)
 	if err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
+		return fmt.Errorf("failed to get opted out users: %w", err)
 	}
-
-	return nil
-}
-
-func (c *cronConfig) reportCursedPosts(ctx context.Context, listenConfig config.ListenConfig, month string) error {
-	guildId, err := strconv.ParseUint(listenConfig.GuildId, 10, 64)
+	year := time.Now().In(lconfig.Location()).Format("2006")
+	wrapped, err := stats.BuildWrapped(ctx, c.app.Stats, guildId, year, exclude)
 	if err != nil {
-		return fmt.Errorf("unable to parse guild id %s: %w", listenConfig.GuildId, err)
+		return err
 	}
-	cursedWordPosts, err := c.app.Stats.GetTopCursedPosters(ctx, guildId, month)
-	if err != nil {
-		return fmt.Errorf("failed to get cursed word user rankings: %w", err)
+	if len(wrapped.Members) == 0 {
+		return nil
 	}
-	if len(cursedWordPosts) < 1 {
+	sections := wrapped.Sections()
+	// page buttons only page through leaderboards, so every page is sent
+	for _, page := range stats.RenderEmbeds(stats.WrappedTitle(year), sections) {
+		_, err = c.sess.ChannelMessageSendEmbed(lconfig.ReportChannelId, page)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to send wrapped embed, falling back to text: "+err.Error())
+			break
+		}
+	}
+	if err == nil {
 		return nil
 	}
-	message := stats.BuildCursedPostReport(cursedWordPosts)
-	_, err = c.sess.ChannelMessageSend(listenConfig.ReportChannelId, message)
-	if err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
+	var sendErr *multierror.Error
+	for _, message := range stats.RenderText(sections) {
+		if _, e := c.sess.ChannelMessageSend(lconfig.ReportChannelId, message); e != nil {
+			sendErr = multierror.Append(sendErr, fmt.Errorf("failed to send message: %w", e))
+		}
 	}
-
-	return nil
-
+	return sendErr.ErrorOrNil()
 }
 
-func (c *cronConfig) removeStats(month string) error {
+// removeStats deletes a period's stats after it's been reported. Months are first rolled up for the year's Wrapped, &
+// kept if the rollup fails.
+func (c *cronConfig) removeStats(period stats.Period, periodKey string) error {
 	var err *multierror.Error
 	ctx := context.Background()
-	err = multierror.Append(err, c.app.Stats.RemoveMonthActivity(ctx, month))
-	err = multierror.Append(err, c.app.Stats.RemoveDailyGameLeadersForMonth(ctx, month))
-	err = multierror.Append(err, c.app.Stats.RemoveReactionLogForMonth(ctx, month))
-	err = multierror.Append(err, c.app.Stats.RemoveCursedChannelPostStatsForMonth(ctx, month))
-	err = multierror.Append(err, c.app.Stats.RemoveCursedPostStatsForMonth(ctx, month))
+	if period == stats.MonthlyPeriod {
+		if e := c.app.Stats.RollupPeriod(ctx, periodKey); e != nil {
+			return fmt.Errorf("not removing stats that weren't rolled up: %w", e)
+		}
+	}
+	err = multierror.Append(err, c.app.Stats.RemoveCountersForPeriod(ctx, periodKey))
+	err = multierror.Append(err, c.app.Stats.RemoveDailyGameLeadersForPeriod(ctx, periodKey))
+	err = multierror.Append(err, c.app.Stats.RemoveDailyGameTimesForPeriod(ctx, periodKey))
+	err = multierror.Append(err, c.app.Stats.RemoveReactionsForPeriod(ctx, periodKey))
+	err = multierror.Append(err, c.app.Stats.RemoveGamePlaysForPeriod(ctx, periodKey))
 	return err.ErrorOrNil()
 }
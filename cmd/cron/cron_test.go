@@ -0,0 +1,67 @@
+package cron
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/model"
+	"github.com/dmtaylor/costanza/internal/stats"
+)
+
+func Test_periodCronSpec(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err, "failed to load timezone")
+	tests := []struct {
+		name      string
+		period    stats.Period
+		loc       *time.Location
+		dayOffset int
+		want      string
+	}{
+		{"weekly", stats.WeeklyPeriod, chicago, 0, "CRON_TZ=America/Chicago 30 9 * * 1"},
+		{"monthly", stats.MonthlyPeriod, chicago, 0, "CRON_TZ=America/Chicago 30 9 1 * *"},
+		{"quarterly", stats.QuarterlyPeriod, time.UTC, 0, "CRON_TZ=UTC 30 9 1 1,4,7,10 *"},
+		{"yearly", stats.YearlyPeriod, time.UTC, 0, "CRON_TZ=UTC 30 9 1 1 *"},
+		{"weekly_offset", stats.WeeklyPeriod, time.UTC, 1, "CRON_TZ=UTC 30 9 * * 2"},
+		{"monthly_offset", stats.MonthlyPeriod, time.UTC, 1, "CRON_TZ=UTC 30 9 2 * *"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, periodCronSpec(tt.period, tt.loc, 9, 30, tt.dayOffset))
+		})
+	}
+}
+
+func Test_wrappedCronSpec(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err, "failed to load timezone")
+	assert.Equal(t, "CRON_TZ=America/Chicago 30 9 31 12 *", wrappedCronSpec(chicago, 9, 30))
+}
+
+func TestCronConfig_removeStats(t *testing.T) {
+	ctx := context.Background()
+	store := stats.NewMemoryStore()
+	c := &cronConfig{app: &config.App{Stats: store}}
+	for _, period := range []string{"2024-03", "2024-W10"} {
+		require.NoError(t, store.LogCounter(ctx, stats.Counter{Metric: stats.MessagesMetric, GuildId: 1, UserId: 10, Period: period, Increment: 5}))
+	}
+
+	require.NoError(t, c.removeStats(stats.WeeklyPeriod, "2024-W10"))
+	require.NoError(t, c.removeStats(stats.MonthlyPeriod, "2024-03"))
+	messages, _ := stats.GetMetric(stats.MessagesMetric)
+	for _, period := range []string{"2024-03", "2024-W10"} {
+		got, err := store.GetCounterLeaders(ctx, messages, 1, period, 5)
+		require.NoError(t, err)
+		assert.Empty(t, got, "stats for %s not removed", period)
+	}
+	rollups, err := store.GetYearlyRollups(ctx, 1, "2024")
+	require.NoError(t, err)
+	assert.Equal(t, []*model.RollupStat{
+		{GuildId: 1, UserId: 10, Period: "2024", Stat: stats.MessagesMetric, Value: 5},
+	}, rollups, "only the month should be rolled up")
+}
@@ -0,0 +1,145 @@
+package listen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/achievements"
+	"github.com/dmtaylor/costanza/internal/util"
+)
+
+const achievementsCommandName = "achievements"
+const achievementsUserOptionName = "user"
+
+var achievementsSlashCommand = &discordgo.ApplicationCommand{
+	Name:        achievementsCommandName,
+	Type:        discordgo.ChatApplicationCommand,
+	Description: "List the achievements earned on this server",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:        achievementsUserOptionName,
+			Description: "User to list achievements for, defaults to you",
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Required:    false,
+		},
+	},
+}
+
+func (s *Server) achievementsCommand(sess *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand || i.ApplicationCommandData().Name != achievementsCommandName {
+		return
+	}
+
+	var err error
+	if s.m.enabled {
+		start := time.Now()
+		defer func() {
+			s.m.eventDuration.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: achievementsCommandName}).Observe(time.Since(start).Seconds())
+			if err != nil {
+				isTimeout := strconv.FormatBool(errors.Is(err, context.DeadlineExceeded))
+				s.m.eventErrors.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: achievementsCommandName, isTimeoutLabel: isTimeout}).Inc()
+			} else {
+				s.m.eventSuccess.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: achievementsCommandName}).Inc()
+			}
+		}()
+	}
+	ctx, cancel := util.ContextFromDiscordInteractionCreate(context.Background(), i, interactionTimeout)
+	defer cancel()
+
+	var content string
+	if _, ok := config.GlobalConfig.Discord.ListenChannelSet[i.GuildID]; !ok || i.Member == nil || i.Member.User == nil {
+		content = "Achievements aren't enabled on this guild. Please reach out to admin to enable"
+	} else {
+		data := i.ApplicationCommandData()
+		user := i.Member.User.ID
+		empty := "You haven't earned any achievements yet"
+		for _, option := range data.Options {
+			if option.Name == achievementsUserOptionName {
+				user = option.Value.(string)
+				empty = displayName(data.Resolved, user) + " hasn't earned any achievements yet"
+			}
+		}
+		content, err = s.achievementsContent(ctx, i.GuildID, user, empty)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to get achievements: "+err.Error())
+			content = "Failed to get achievements, please try again later"
+		}
+	}
+	ierr := sess.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+	if ierr != nil {
+		slog.ErrorContext(ctx, "failed to send achievements response: "+ierr.Error())
+		err = ierr
+	}
+}
+
+// achievementsContent lists the achievements the user has earned in the guild, or gives the empty message if none
+func (s *Server) achievementsContent(ctx context.Context, guild, user, empty string) (string, error) {
+	guildId, err := strconv.ParseUint(guild, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("bad guild id: %w", err)
+	}
+	userId, err := strconv.ParseUint(user, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("bad user id: %w", err)
+	}
+	awards, err := s.app.Achievements.Awards(ctx, guildId, userId)
+	if err != nil {
+		return "", err
+	}
+	if len(awards) == 0 {
+		return empty, nil
+	}
+	var content strings.Builder
+	content.WriteString("Achievements earned by <@" + user + ">:")
+	for _, award := range awards {
+		content.WriteString(fmt.Sprintf("\n🏆 **%s**: %s (earned %s)", award.Name, award.Description, award.AwardedOn))
+	}
+	return content.String(), nil
+}
+
+// checkAchievements evaluates the event against the achievement rules & announces any earned in the guild's
+// achievement channel. Events in guilds that aren't listened to, or from users who opted out of tracking, are skipped.
+func (s *Server) checkAchievements(ctx context.Context, sess *discordgo.Session, event achievements.Event) error {
+	listenConfig, ok := config.GlobalConfig.Discord.ListenChannelSet[strconv.FormatUint(event.GuildId, 10)]
+	if !ok {
+		return nil
+	}
+	optedOut, err := s.isOptedOut(ctx, event.GuildId, event.UserId)
+	if err != nil || optedOut {
+		return err
+	}
+	event.Time = event.Time.In(listenConfig.Location())
+	earned, err := s.app.Achievements.Evaluate(ctx, event)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate achievements: %w", err)
+	}
+	if listenConfig.AchievementChannelId == "" {
+		return nil
+	}
+	for _, rule := range earned {
+		callStart := time.Now()
+		_, err = sess.ChannelMessageSend(listenConfig.AchievementChannelId,
+			fmt.Sprintf("🏆 <@%d> earned **%s**: %s", event.UserId, rule.Name, rule.Description))
+		if s.m.enabled {
+			s.m.externalApiDuration.With(prometheus.Labels{eventNameLabel: achievementsCommandName, externalApiLabel: externalDiscordCallName}).Observe(time.Since(callStart).Seconds())
+		}
+		if err != nil {
+			return fmt.Errorf("failed to announce achievement %s: %w", rule.Id, err)
+		}
+	}
+	return nil
+}
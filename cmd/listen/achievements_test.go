@@ -0,0 +1,43 @@
+package listen
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/config"
+)
+
+func TestServer_achievementsContent(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, config.ListenConfig{GuildId: "100", Timezone: "America/Chicago"})
+	require.NoError(t, s.app.Privacy.OptOut(ctx, 100, 202))
+	// first message of the day in the guild's timezone, which is the previous day in UTC
+	timestamp := time.Date(2024, 3, 15, 3, 0, 0, 0, time.UTC)
+	for _, author := range []string{"202", "200", "201"} {
+		message := &discordgo.Message{GuildID: "100", ChannelID: "300", Author: &discordgo.User{ID: author}, Type: discordgo.MessageTypeDefault, Timestamp: timestamp}
+		s.logMessageActivity(newTestSession(), &discordgo.MessageCreate{Message: message})
+	}
+
+	got, err := s.achievementsContent(ctx, "100", "200", "none")
+	require.NoError(t, err)
+	assert.Equal(t, "Achievements earned by <@200>:\n🏆 **Early bird**: Send the first message of the day (earned 2024-03-14)", got)
+
+	got, err = s.achievementsContent(ctx, "100", "201", "none")
+	require.NoError(t, err)
+	assert.Equal(t, "none", got)
+
+	got, err = s.achievementsContent(ctx, "100", "202", "none")
+	require.NoError(t, err)
+	assert.Equal(t, "none", got, "opted out user earned achievement")
+
+	_, err = s.applyPrivacyCommand(ctx, privacyDeleteCommand, "100", "200")
+	require.NoError(t, err)
+	got, err = s.achievementsContent(ctx, "100", "200", "none")
+	require.NoError(t, err)
+	assert.Equal(t, "none", got, "deleted user kept achievements")
+}
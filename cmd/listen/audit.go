@@ -0,0 +1,169 @@
+package listen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/audit"
+	"github.com/dmtaylor/costanza/internal/util"
+)
+
+const auditLogEventName = "audit_log"
+
+// auditConfig gets the guild's config if it has an audit log, skipping events in the audit log channel itself
+func auditConfig(guildId, channelId string) (*config.ListenConfig, bool) {
+	listenConfig, found := config.GlobalConfig.Discord.ListenChannelSet[guildId]
+	if !found || !listenConfig.AuditLogEnabled() || (channelId != "" && channelId == listenConfig.AuditLogChannelId) {
+		return nil, false
+	}
+	return listenConfig, true
+}
+
+// auditMessageCreate keeps new posts in guilds with an audit log, so later edits & deletes can show what they said
+func (s *Server) auditMessageCreate(sess *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.Author == nil || m.Author.ID == sess.State.User.ID {
+		return
+	}
+	if _, ok := auditConfig(m.GuildID, m.ChannelID); !ok {
+		return
+	}
+	ctx := util.ContextFromDiscordMessageCreate(context.Background(), m)
+	s.app.AuditMessages.Set(ctx, m.ID, audit.NewMessage(m.Message))
+}
+
+func (s *Server) auditMessageUpdate(sess *discordgo.Session, m *discordgo.MessageUpdate) {
+	// updates without an author are link previews being added
+	if m.Author == nil || m.Author.ID == sess.State.User.ID {
+		return
+	}
+	if _, ok := auditConfig(m.GuildID, m.ChannelID); !ok {
+		return
+	}
+	ctx := context.WithValue(context.Background(), "messageId", m.ID)
+	ctx = context.WithValue(ctx, "guildId", m.GuildID)
+	var err error
+	defer s.observeAudit(messageUpdateGatewayEvent, time.Now(), &err)
+	after := audit.NewMessage(m.Message)
+	before, cached := s.app.AuditMessages.Peek(m.ID)
+	if !cached && m.BeforeUpdate != nil {
+		before, cached = audit.NewMessage(m.BeforeUpdate), true
+	}
+	s.app.AuditMessages.Set(ctx, m.ID, after)
+	if cached && before.Content == after.Content {
+		return
+	}
+	var previous *audit.Message
+	if cached {
+		previous = &before
+	}
+	err = s.auditLog(ctx, sess, m.GuildID, audit.EditEmbed(previous, after, time.Now()))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to audit message edit: "+err.Error())
+	}
+}
+
+func (s *Server) auditMessageDelete(sess *discordgo.Session, m *discordgo.MessageDelete) {
+	if _, ok := auditConfig(m.GuildID, m.ChannelID); !ok {
+		return
+	}
+	ctx := context.WithValue(context.Background(), "messageId", m.ID)
+	ctx = context.WithValue(ctx, "guildId", m.GuildID)
+	deleted, cached := s.app.AuditMessages.Peek(m.ID)
+	if !cached && m.BeforeDelete != nil {
+		deleted, cached = audit.NewMessage(m.BeforeDelete), true
+	}
+	if !cached {
+		deleted = audit.Message{Id: m.ID, GuildId: m.GuildID, ChannelId: m.ChannelID}
+	}
+	s.app.AuditMessages.Invalidate(ctx, m.ID)
+	if deleted.AuthorId == sess.State.User.ID {
+		return
+	}
+	var err error
+	defer s.observeAudit(messageDeleteGatewayEvent, time.Now(), &err)
+	err = s.auditLog(ctx, sess, m.GuildID, audit.DeleteEmbed(deleted, cached, time.Now()))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to audit message delete: "+err.Error())
+	}
+}
+
+func (s *Server) auditMemberAdd(sess *discordgo.Session, j *discordgo.GuildMemberAdd) {
+	if j.User == nil {
+		return
+	}
+	s.auditMember(sess, guildMemberAddGatewayEvent, j.Member, audit.JoinEmbed(j.Member, time.Now()))
+}
+
+func (s *Server) auditMemberRemove(sess *discordgo.Session, r *discordgo.GuildMemberRemove) {
+	if r.User == nil {
+		return
+	}
+	s.auditMember(sess, guildMemberRemoveGatewayEvent, r.Member, audit.LeaveEmbed(r.Member, time.Now()))
+}
+
+// auditMemberUpdate logs role changes. Changes to members not in the state cache can't be worked out, so are skipped.
+func (s *Server) auditMemberUpdate(sess *discordgo.Session, u *discordgo.GuildMemberUpdate) {
+	if u.User == nil || u.BeforeUpdate == nil {
+		return
+	}
+	if embed := audit.RoleEmbed(u.Member, u.BeforeUpdate.Roles, time.Now()); embed != nil {
+		s.auditMember(sess, guildMemberUpdateGatewayEvent, u.Member, embed)
+	}
+}
+
+func (s *Server) auditMember(sess *discordgo.Session, gatewayEvent string, member *discordgo.Member, embed *discordgo.MessageEmbed) {
+	if _, ok := auditConfig(member.GuildID, ""); !ok {
+		return
+	}
+	ctx := context.WithValue(context.Background(), "memberId", member.User.ID)
+	ctx = context.WithValue(ctx, "guildId", member.GuildID)
+	var err error
+	defer s.observeAudit(gatewayEvent, time.Now(), &err)
+	err = s.auditLog(ctx, sess, member.GuildID, embed)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to audit member change: "+err.Error())
+	}
+}
+
+// auditLog posts the entry to the guild's audit log channel, if it has one
+func (s *Server) auditLog(ctx context.Context, sess *discordgo.Session, guildId string, embed *discordgo.MessageEmbed) error {
+	listenConfig, ok := auditConfig(guildId, "")
+	if !ok {
+		return nil
+	}
+	callStart := time.Now()
+	_, err := sess.ChannelMessageSendComplex(listenConfig.AuditLogChannelId, &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{embed},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if s.m.enabled {
+		s.m.externalApiDuration.With(prometheus.Labels{eventNameLabel: auditLogEventName, externalApiLabel: externalDiscordCallName}).Observe(time.Since(callStart).Seconds())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to post to audit log: %w", err)
+	}
+	return nil
+}
+
+// observeAudit records the audit log handler's metrics, deferred with a pointer to its error
+func (s *Server) observeAudit(gatewayEvent string, start time.Time, err *error) {
+	if !s.m.enabled {
+		return
+	}
+	labels := prometheus.Labels{gatewayEventTypeLabel: gatewayEvent, eventNameLabel: auditLogEventName}
+	s.m.eventDuration.With(labels).Observe(time.Since(start).Seconds())
+	if *err != nil {
+		labels[isTimeoutLabel] = strconv.FormatBool(errors.Is(*err, context.DeadlineExceeded))
+		s.m.eventErrors.With(labels).Inc()
+	} else {
+		s.m.eventSuccess.With(labels).Inc()
+	}
+}
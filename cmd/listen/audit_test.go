@@ -0,0 +1,45 @@
+package listen
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dmtaylor/costanza/config"
+)
+
+func TestAuditConfig(t *testing.T) {
+	newTestServer(t, config.ListenConfig{GuildId: "100", AuditLogChannelId: "400"}, config.ListenConfig{GuildId: "101"})
+	_, ok := auditConfig("100", "300")
+	assert.True(t, ok)
+	_, ok = auditConfig("100", "")
+	assert.True(t, ok)
+	_, ok = auditConfig("100", "400")
+	assert.False(t, ok, "audit log channel audited")
+	_, ok = auditConfig("101", "300")
+	assert.False(t, ok, "guild without audit log audited")
+	_, ok = auditConfig("102", "300")
+	assert.False(t, ok, "unknown guild audited")
+}
+
+func TestServer_auditMessageCreate(t *testing.T) {
+	s := newTestServer(t, config.ListenConfig{GuildId: "100", AuditLogChannelId: "400"}, config.ListenConfig{GuildId: "101"})
+	sess := newTestSession()
+	post := func(id, guild, channel, author string) *discordgo.MessageCreate {
+		return &discordgo.MessageCreate{Message: &discordgo.Message{ID: id, GuildID: guild, ChannelID: channel, Content: "hello", Author: &discordgo.User{ID: author}}}
+	}
+	s.auditMessageCreate(sess, post("500", "100", "300", "200"))
+	s.auditMessageCreate(sess, post("501", "100", "400", "200"))
+	s.auditMessageCreate(sess, post("502", "101", "300", "200"))
+	s.auditMessageCreate(sess, post("503", "100", "300", "1"))
+
+	got, ok := s.app.AuditMessages.Peek("500")
+	assert.True(t, ok)
+	assert.Equal(t, "hello", got.Content)
+	assert.Equal(t, "200", got.AuthorId)
+	for _, id := range []string{"501", "502", "503"} {
+		_, ok = s.app.AuditMessages.Peek(id)
+		assert.False(t, ok, "message %s cached", id)
+	}
+}
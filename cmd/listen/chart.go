@@ -0,0 +1,213 @@
+package listen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/stats"
+	"github.com/dmtaylor/costanza/internal/util"
+)
+
+const chartCommandName = "chart"
+const chartMetricOptionName = "metric"
+const chartRangeOptionName = "range"
+const chartUserOptionName = "user"
+
+var chartSlashCommand = &discordgo.ApplicationCommand{
+	Name:        chartCommandName,
+	Type:        discordgo.ChatApplicationCommand,
+	Description: "Chart guild activity over time",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:        chartMetricOptionName,
+			Description: "Stat to chart",
+			Type:        discordgo.ApplicationCommandOptionString,
+			Required:    true,
+			Choices:     chartMetricChoices(),
+		},
+		{
+			Name:        chartRangeOptionName,
+			Description: "How far back to chart, defaults to a month",
+			Type:        discordgo.ApplicationCommandOptionString,
+			Required:    false,
+			Choices:     chartRangeChoices(),
+		},
+		{
+			Name:        chartUserOptionName,
+			Description: "Chart a single user instead of the whole guild",
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Required:    false,
+		},
+	},
+}
+
+func chartMetricChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, metric := range stats.Registry {
+		if !metric.Hidden {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: metric.Name, Value: metric.Name})
+		}
+	}
+	return append(choices, &discordgo.ApplicationCommandOptionChoice{Name: stats.WinRateChart, Value: stats.WinRateChart})
+}
+
+func chartRangeChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, len(stats.ChartRanges))
+	for i, chartRange := range stats.ChartRanges {
+		choices[i] = &discordgo.ApplicationCommandOptionChoice{Name: string(chartRange), Value: string(chartRange)}
+	}
+	return choices
+}
+
+func (s *Server) chartCommand(sess *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand || i.ApplicationCommandData().Name != chartCommandName {
+		return
+	}
+
+	var err error
+	if s.m.enabled {
+		start := time.Now()
+		defer func() {
+			s.m.eventDuration.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: chartCommandName}).Observe(time.Since(start).Seconds())
+			if err != nil {
+				isTimeout := strconv.FormatBool(errors.Is(err, context.DeadlineExceeded))
+				s.m.eventErrors.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: chartCommandName, isTimeoutLabel: isTimeout}).Inc()
+			} else {
+				s.m.eventSuccess.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: chartCommandName}).Inc()
+			}
+		}()
+	}
+	ctx, cancel := util.ContextFromDiscordInteractionCreate(context.Background(), i, interactionTimeout)
+	defer cancel()
+
+	listenConfig, ok := config.GlobalConfig.Discord.ListenChannelSet[i.GuildID]
+	if !ok {
+		err = sess.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Stats aren't enabled on this guild. Please reach out to admin to enable",
+			},
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to send empty response: "+err.Error())
+		}
+		return
+	}
+	err = sess.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create deferred response: "+err.Error())
+		return
+	}
+
+	guildId, err := strconv.ParseUint(i.GuildID, 10, 64)
+	if err != nil {
+		err = fmt.Errorf("failed to format guild id: %w", err)
+		slog.ErrorContext(ctx, "bad guild id: "+err.Error())
+		chartFailed(ctx, sess, i)
+		return
+	}
+	query, err := chartQuery(i.ApplicationCommandData(), guildId, time.Now().In(listenConfig.Location()))
+	if err != nil {
+		slog.ErrorContext(ctx, "bad chart options: "+err.Error())
+		chartFailed(ctx, sess, i)
+		return
+	}
+	if query.UserId != 0 {
+		optedOut, oerr := s.isOptedOut(ctx, guildId, query.UserId)
+		if oerr != nil || optedOut {
+			err = oerr
+			content := "There are no stats to chart for " + query.UserName
+			if oerr != nil {
+				slog.ErrorContext(ctx, "failed to check opt out: "+oerr.Error())
+				content = "Failed to draw chart, please try again later"
+			}
+			_, ierr := sess.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{Content: content})
+			if ierr != nil {
+				slog.ErrorContext(ctx, "failed to send no stats response: "+ierr.Error())
+			}
+			return
+		}
+	}
+	image, err := stats.RenderChart(ctx, s.app.Stats, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to render chart: "+err.Error())
+		chartFailed(ctx, sess, i)
+		return
+	}
+	_, err = sess.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
+		Files: []*discordgo.File{image},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to send chart: "+err.Error())
+	}
+}
+
+// chartFailed resolves the deferred response with an error, so the member isn't left waiting on it
+func chartFailed(ctx context.Context, sess *discordgo.Session, i *discordgo.InteractionCreate) {
+	_, err := sess.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
+		Content: "Failed to draw chart, please try again later",
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to send chart error: "+err.Error())
+	}
+}
+
+// chartQuery builds the query from the command options, defaulting to a month of guild activity
+func chartQuery(data discordgo.ApplicationCommandInteractionData, guildId uint64, now time.Time) (stats.ChartQuery, error) {
+	metric := ""
+	chartRange := stats.MonthRange
+	var userId string
+	for _, option := range data.Options {
+		switch option.Name {
+		case chartMetricOptionName:
+			metric = option.StringValue()
+		case chartRangeOptionName:
+			chartRange = stats.ChartRange(option.StringValue())
+		case chartUserOptionName:
+			userId = option.Value.(string)
+		}
+	}
+	if !stats.IsChartMetric(metric) {
+		return stats.ChartQuery{}, fmt.Errorf("can't chart metric %s", metric)
+	}
+	query := stats.NewChartQuery(guildId, metric, chartRange, now)
+	if userId == "" {
+		return query, nil
+	}
+	var err error
+	query.UserId, err = strconv.ParseUint(userId, 10, 64)
+	if err != nil {
+		return stats.ChartQuery{}, fmt.Errorf("failed to parse user id %s: %w", userId, err)
+	}
+	query.UserName = displayName(data.Resolved, userId)
+	return query, nil
+}
+
+// displayName gets the guild nickname or global name of a user from the command's resolved data, as mentions don't
+// render in images
+func displayName(resolved *discordgo.ApplicationCommandInteractionDataResolved, userId string) string {
+	if resolved == nil {
+		return userId
+	}
+	if member, ok := resolved.Members[userId]; ok && member.Nick != "" {
+		return member.Nick
+	}
+	if user, ok := resolved.Users[userId]; ok {
+		if user.GlobalName != "" {
+			return user.GlobalName
+		}
+		return user.Username
+	}
+	return userId
+}
@@ -0,0 +1,92 @@
+package listen
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/stats"
+)
+
+func Test_chartQuery(t *testing.T) {
+	now := time.Date(2024, 3, 14, 12, 0, 0, 0, time.UTC)
+	option := func(name string, optionType discordgo.ApplicationCommandOptionType, value string) *discordgo.ApplicationCommandInteractionDataOption {
+		return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: optionType, Value: value}
+	}
+	tests := []struct {
+		name         string
+		data         discordgo.ApplicationCommandInteractionData
+		wantBucket   stats.Bucket
+		wantUserId   uint64
+		wantUserName string
+		wantErr      bool
+	}{
+		{
+			"default_range",
+			discordgo.ApplicationCommandInteractionData{Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				option(chartMetricOptionName, discordgo.ApplicationCommandOptionString, stats.MessagesMetric),
+			}},
+			stats.DayBucket, 0, "", false,
+		},
+		{
+			"user_year",
+			discordgo.ApplicationCommandInteractionData{
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{
+					option(chartMetricOptionName, discordgo.ApplicationCommandOptionString, stats.WinRateChart),
+					option(chartRangeOptionName, discordgo.ApplicationCommandOptionString, string(stats.YearRange)),
+					option(chartUserOptionName, discordgo.ApplicationCommandOptionUser, "200"),
+				},
+				Resolved: &discordgo.ApplicationCommandInteractionDataResolved{
+					Users:   map[string]*discordgo.User{"200": {ID: "200", Username: "danny", GlobalName: "Danny"}},
+					Members: map[string]*discordgo.Member{"200": {}},
+				},
+			},
+			stats.MonthBucket, 200, "Danny", false,
+		},
+		{
+			"hidden_metric",
+			discordgo.ApplicationCommandInteractionData{Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				option(chartMetricOptionName, discordgo.ApplicationCommandOptionString, stats.GamePlaysMetric),
+			}},
+			0, 0, "", true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := chartQuery(tt.data, 100, now)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, uint64(100), got.GuildId)
+			assert.Equal(t, tt.wantBucket, got.Bucket)
+			assert.Equal(t, tt.wantUserId, got.UserId)
+			assert.Equal(t, tt.wantUserName, got.UserName)
+		})
+	}
+}
+
+func TestServer_chartCommand_badOptions(t *testing.T) {
+	s := newTestServer(t, config.ListenConfig{GuildId: "100"})
+	transport := &testTransport{}
+	sess := newRecordingSession(transport)
+	s.chartCommand(sess, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:      "900",
+		AppID:   "800",
+		Token:   "token",
+		Type:    discordgo.InteractionApplicationCommand,
+		GuildID: "100",
+		Data: discordgo.ApplicationCommandInteractionData{Name: chartCommandName, Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: chartMetricOptionName, Type: discordgo.ApplicationCommandOptionString, Value: stats.GamePlaysMetric},
+		}},
+	}})
+
+	// the deferred response is resolved, so the member isn't left waiting
+	require.Equal(t, []string{"POST /interactions/900/token/callback", "POST /webhooks/800/token"}, transport.requests)
+	assert.Contains(t, transport.bodies[1], "Failed to draw chart")
+}
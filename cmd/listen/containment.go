@@ -0,0 +1,234 @@
+package listen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/hashicorp/go-multierror"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/cursed"
+	"github.com/dmtaylor/costanza/internal/model"
+	"github.com/dmtaylor/costanza/internal/util"
+)
+
+const containmentEventName = "containment"
+const containmentWebhookName = "Costanza"
+
+// containmentFallback is used when a quote can't be given in a reminder
+const containmentFallback = "We're living in a society here!"
+
+// errNoContainmentChannel returned when containing members on a guild without anywhere to contain them to
+var errNoContainmentChannel = errors.New("no containment channel, set containment_channel_id or curse a channel")
+
+var containmentBaseLabels = prometheus.Labels{gatewayEventTypeLabel: messageCreateGatewayEvent, eventNameLabel: containmentEventName}
+
+// enforceContainment handles posts by contained members outside the guild's cursed channels. Depending on the guild's
+// containment mode the post is either replied to with a quote pointing them back, or reposted in a cursed channel. The
+// reminders have a per member cooldown; moves don't.
+func (s *Server) enforceContainment(sess *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.Author.Bot || m.Author.ID == sess.State.User.ID {
+		return
+	}
+	listenConfig, found := config.GlobalConfig.Discord.ListenChannelSet[m.GuildID]
+	if !found || !isContainedMember(listenConfig, m.Author, m.Member) {
+		return
+	}
+	ctx := util.ContextFromDiscordMessageCreate(context.Background(), m)
+	var err error
+	if s.m.enabled {
+		start := time.Now()
+		defer func() {
+			s.m.eventDuration.With(containmentBaseLabels).Observe(time.Since(start).Seconds())
+			if err != nil {
+				isTimeout := strconv.FormatBool(errors.Is(err, context.DeadlineExceeded))
+				s.m.eventErrors.With(prometheus.Labels{gatewayEventTypeLabel: messageCreateGatewayEvent, eventNameLabel: containmentEventName, isTimeoutLabel: isTimeout}).Inc()
+			} else {
+				s.m.eventSuccess.With(containmentBaseLabels).Inc()
+			}
+		}()
+	}
+	err = s.contain(ctx, sess, m, listenConfig)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to enforce containment: "+err.Error())
+	}
+}
+
+func (s *Server) contain(ctx context.Context, sess *discordgo.Session, m *discordgo.MessageCreate, listenConfig *config.ListenConfig) error {
+	guildId, err := strconv.ParseUint(m.GuildID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("bad guild id: %w", err)
+	}
+	userId, err := strconv.ParseUint(m.Author.ID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("bad user id: %w", err)
+	}
+	channels, err := s.app.CursedChannelCache.Get(ctx, guildId)
+	if err != nil {
+		return fmt.Errorf("failed to get cursed channel list: %w", err)
+	}
+	targetId, err := containmentTarget(listenConfig, channels)
+	if err != nil {
+		return err
+	}
+	if inContainment(sess, append(slices.Clone(channels), targetId), m.ChannelID) {
+		return nil
+	}
+	target := strconv.FormatUint(targetId, 10)
+	moved := false
+	if listenConfig.ContainMode() == cursed.ContainMoveMode {
+		if err = s.moveToContainment(ctx, sess, m, target); err != nil {
+			return err
+		}
+		moved = true
+	}
+	// every post is moved, but the reminder & moderation log entry wait out the cooldown, so they can't be spammed
+	if !s.app.ContainmentCooldowns.Ready(guildId, userId, listenConfig.ContainCooldown(), m.Timestamp) {
+		return nil
+	}
+	var errs *multierror.Error
+	if moved {
+		err = s.modLog(ctx, sess, m.GuildID, fmt.Sprintf("🚧 Moved a post by <@%s> from <#%s> to <#%s>", m.Author.ID, m.ChannelID, target))
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("failed to log contained post: %w", err))
+		}
+	}
+	content := containmentContent(s.containmentQuote(ctx, m.ChannelID), m.Author.ID, target, moved)
+	callStart := time.Now()
+	if moved {
+		// the post is gone, so there's nothing to reply to
+		_, err = sess.ChannelMessageSend(m.ChannelID, content)
+	} else {
+		_, err = sess.ChannelMessageSendReply(m.ChannelID, content, m.Reference())
+	}
+	if s.m.enabled {
+		s.m.externalApiDuration.With(prometheus.Labels{eventNameLabel: containmentEventName, externalApiLabel: externalDiscordCallName}).Observe(time.Since(callStart).Seconds())
+	}
+	if err != nil {
+		errs = multierror.Append(errs, fmt.Errorf("failed to send containment reminder: %w", err))
+	}
+	return errs.ErrorOrNil()
+}
+
+// containmentTarget gets the channel contained posts are pointed or moved to. Without a configured channel it's the
+// oldest cursed channel, so it's the same whatever order the channels were loaded in.
+func containmentTarget(listenConfig *config.ListenConfig, channels []uint64) (uint64, error) {
+	if channel := listenConfig.ContainmentChannel(); channel != 0 {
+		return channel, nil
+	}
+	if len(channels) == 0 {
+		return 0, errNoContainmentChannel
+	}
+	return slices.Min(channels), nil
+}
+
+// moveToContainment reposts the post in the target channel as its author through a webhook, then deletes it
+func (s *Server) moveToContainment(ctx context.Context, sess *discordgo.Session, m *discordgo.MessageCreate, target string) error {
+	hook, err := s.containmentWebhook(sess, target)
+	if err != nil {
+		return err
+	}
+	name := m.Author.Username
+	if m.Member != nil && m.Member.Nick != "" {
+		name = m.Member.Nick
+	}
+	content := m.Content
+	for _, attachment := range m.Attachments {
+		content += "\n" + attachment.URL
+	}
+	callStart := time.Now()
+	_, err = sess.WebhookExecute(hook.ID, hook.Token, false, &discordgo.WebhookParams{
+		Content:         content,
+		Username:        name,
+		AvatarURL:       m.Author.AvatarURL(""),
+		AllowedMentions: &discordgo.MessageAllowedMentions{}, // mentions were already sent by the original post
+	})
+	if s.m.enabled {
+		s.m.externalApiDuration.With(prometheus.Labels{eventNameLabel: containmentEventName, externalApiLabel: externalDiscordCallName}).Observe(time.Since(callStart).Seconds())
+	}
+	if err != nil {
+		s.containmentHooks.Delete(target) // the webhook may have been deleted, so find it again next time
+		return fmt.Errorf("failed to repost contained post: %w", err)
+	}
+	if err = sess.ChannelMessageDelete(m.ChannelID, m.ID); err != nil {
+		return fmt.Errorf("failed to delete contained post: %w", err)
+	}
+	return nil
+}
+
+// containmentWebhook gets the bot's webhook for the channel, creating one if there isn't one yet
+func (s *Server) containmentWebhook(sess *discordgo.Session, channel string) (*discordgo.Webhook, error) {
+	if hook, ok := s.containmentHooks.Load(channel); ok {
+		return hook.(*discordgo.Webhook), nil
+	}
+	hooks, err := sess.ChannelWebhooks(channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	index := slices.IndexFunc(hooks, func(hook *discordgo.Webhook) bool {
+		return hook.User != nil && hook.User.ID == sess.State.User.ID && hook.Token != ""
+	})
+	var hook *discordgo.Webhook
+	if index != -1 {
+		hook = hooks[index]
+	} else if hook, err = sess.WebhookCreate(channel, containmentWebhookName, ""); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	s.containmentHooks.Store(channel, hook)
+	return hook, nil
+}
+
+// containmentQuote gets a text quote for reminders, or the fallback if there isn't one
+func (s *Server) containmentQuote(ctx context.Context, channelId string) string {
+	quote, err := s.app.Quotes.GetQuote(ctx, channelId, "")
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get containment quote: "+err.Error())
+		return containmentFallback
+	}
+	if quote.Type != model.TextQuoteType {
+		return containmentFallback
+	}
+	return quote.Data
+}
+
+func containmentContent(quote, user, target string, moved bool) string {
+	if moved {
+		return fmt.Sprintf("%s\n\n<@%s>, your post belongs in <#%s>, so I moved it there", quote, user, target)
+	}
+	return fmt.Sprintf("%s\n\n<@%s>, take it to <#%s>", quote, user, target)
+}
+
+// isContainedMember checks if the member should only post in the guild's cursed channels
+func isContainedMember(listenConfig *config.ListenConfig, user *discordgo.User, member *discordgo.Member) bool {
+	if !listenConfig.ContainmentEnabled() || user == nil {
+		return false
+	}
+	if slices.Contains(listenConfig.ContainedIds, user.ID) {
+		return true
+	}
+	return member != nil && slices.ContainsFunc(member.Roles, func(role string) bool {
+		return slices.Contains(listenConfig.ContainedRoles, role)
+	})
+}
+
+// inContainment checks if the channel is one of the cursed channels, or a thread in one
+func inContainment(sess *discordgo.Session, channels []uint64, channelId string) bool {
+	ids := []string{channelId}
+	if channel, err := sess.State.Channel(channelId); err == nil && channel.IsThread() {
+		ids = append(ids, channel.ParentID)
+	}
+	for _, id := range ids {
+		parsed, err := strconv.ParseUint(id, 10, 64)
+		if err == nil && slices.Contains(channels, parsed) {
+			return true
+		}
+	}
+	return false
+}
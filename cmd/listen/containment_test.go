@@ -0,0 +1,154 @@
+package listen
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/cursed"
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+// testQuotes always gives the same quote
+type testQuotes struct {
+	quote model.Quote
+	err   error
+}
+
+func (q testQuotes) GetQuote(_ context.Context, _ string, _ string) (model.Quote, error) {
+	return q.quote, q.err
+}
+
+func (q testQuotes) GetQuoteById(_ context.Context, _ int) (model.Quote, error) {
+	return q.quote, q.err
+}
+
+func TestIsContainedMember(t *testing.T) {
+	listenConfig := &config.ListenConfig{GuildId: "100", ContainedIds: []string{"200"}, ContainedRoles: []string{"900"}}
+	tests := []struct {
+		name   string
+		config *config.ListenConfig
+		user   *discordgo.User
+		member *discordgo.Member
+		want   bool
+	}{
+		{"contained user", listenConfig, &discordgo.User{ID: "200"}, nil, true},
+		{"contained role", listenConfig, &discordgo.User{ID: "201"}, &discordgo.Member{Roles: []string{"800", "900"}}, true},
+		{"free member", listenConfig, &discordgo.User{ID: "201"}, &discordgo.Member{Roles: []string{"800"}}, false},
+		{"containment disabled", &config.ListenConfig{GuildId: "100"}, &discordgo.User{ID: "200"}, nil, false},
+		{"no user", listenConfig, nil, &discordgo.Member{Roles: []string{"900"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isContainedMember(tt.config, tt.user, tt.member))
+		})
+	}
+}
+
+func TestInContainment(t *testing.T) {
+	sess := newTestSession()
+	require.NoError(t, sess.State.GuildAdd(&discordgo.Guild{ID: "100"}))
+	require.NoError(t, sess.State.ChannelAdd(&discordgo.Channel{ID: "301", GuildID: "100", Type: discordgo.ChannelTypeGuildText}))
+	require.NoError(t, sess.State.ChannelAdd(&discordgo.Channel{ID: "400", GuildID: "100", ParentID: "300", Type: discordgo.ChannelTypeGuildPublicThread}))
+	require.NoError(t, sess.State.ChannelAdd(&discordgo.Channel{ID: "401", GuildID: "100", ParentID: "301", Type: discordgo.ChannelTypeGuildPublicThread}))
+	channels := []uint64{300}
+
+	assert.True(t, inContainment(sess, channels, "300"))
+	assert.True(t, inContainment(sess, channels, "400"), "thread in cursed channel not contained")
+	assert.False(t, inContainment(sess, channels, "301"))
+	assert.False(t, inContainment(sess, channels, "401"))
+	assert.False(t, inContainment(sess, channels, "302"), "unknown channel contained")
+}
+
+func TestServer_containmentQuote(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t)
+	s.app.Quotes = testQuotes{quote: model.Quote{Type: model.TextQuoteType, Data: "Serenity now!"}}
+	assert.Equal(t, "Serenity now!", s.containmentQuote(ctx, "300"))
+	s.app.Quotes = testQuotes{quote: model.Quote{Type: model.FileQuoteType, Data: "quote.png"}}
+	assert.Equal(t, containmentFallback, s.containmentQuote(ctx, "300"), "file quote used")
+	s.app.Quotes = testQuotes{err: errors.New("no quotes")}
+	assert.Equal(t, containmentFallback, s.containmentQuote(ctx, "300"))
+}
+
+func TestContainmentContent(t *testing.T) {
+	assert.Equal(t, "Serenity now!\n\n<@200>, take it to <#300>", containmentContent("Serenity now!", "200", "300", false))
+	assert.Equal(t, "Serenity now!\n\n<@200>, your post belongs in <#300>, so I moved it there", containmentContent("Serenity now!", "200", "300", true))
+}
+
+func TestServer_contain(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, config.ListenConfig{GuildId: "100", ContainedIds: []string{"200"}, ContainmentMode: cursed.ContainMoveMode})
+	s.app.CursedChannelCache = &testChannelCache{}
+	sess := newTestSession()
+	post := &discordgo.MessageCreate{Message: &discordgo.Message{ID: "500", ChannelID: "301", GuildID: "100", Author: &discordgo.User{ID: "200"}}}
+	listenConfig := config.GlobalConfig.Discord.ListenChannelSet["100"]
+
+	// members can't be contained without a channel to contain them to, & posts already in one are left alone
+	assert.ErrorIs(t, s.contain(ctx, sess, post, listenConfig), errNoContainmentChannel)
+	s.app.CursedChannelCache = &testChannelCache{channels: []uint64{301}}
+	require.NoError(t, s.contain(ctx, sess, post, listenConfig))
+	s.app.CursedChannelCache = &testChannelCache{}
+	configured := &config.ListenConfig{GuildId: "100", ContainedIds: []string{"200"}, ContainmentChannelId: "301"}
+	require.NoError(t, configured.Load())
+	require.NoError(t, s.contain(ctx, sess, post, configured))
+}
+
+func TestServer_contain_moveCooldown(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, config.ListenConfig{GuildId: "100", ContainedIds: []string{"200"}, ContainmentMode: cursed.ContainMoveMode,
+		ContainmentChannelId: "302", ModLogChannelId: "900"})
+	s.app.CursedChannelCache = &testChannelCache{}
+	s.app.ContainmentCooldowns = cursed.NewCooldowns()
+	s.app.Quotes = testQuotes{err: errors.New("no quotes")}
+	s.containmentHooks.Store("302", &discordgo.Webhook{ID: "700", Token: "token"})
+	transport := &testTransport{}
+	sess := newRecordingSession(transport)
+	listenConfig := config.GlobalConfig.Discord.ListenChannelSet["100"]
+	now := time.Now()
+	for i, id := range []string{"500", "501"} {
+		post := &discordgo.MessageCreate{Message: &discordgo.Message{ID: id, ChannelID: "301", GuildID: "100", Author: &discordgo.User{ID: "200"},
+			Timestamp: now.Add(time.Duration(i) * time.Second)}}
+		require.NoError(t, s.contain(ctx, sess, post, listenConfig))
+	}
+
+	// both posts are moved, but only the first is reminded & logged
+	assert.Equal(t, []string{
+		"POST /webhooks/700/token",
+		"DELETE /channels/301/messages/500",
+		"POST /channels/900/messages",
+		"POST /channels/301/messages",
+		"POST /webhooks/700/token",
+		"DELETE /channels/301/messages/501",
+	}, transport.requests)
+}
+
+func TestContainmentTarget(t *testing.T) {
+	configured := &config.ListenConfig{GuildId: "100", ContainmentChannelId: "305"}
+	require.NoError(t, configured.Load())
+	tests := []struct {
+		name     string
+		config   *config.ListenConfig
+		channels []uint64
+		want     uint64
+		wantErr  error
+	}{
+		{"oldest cursed channel", &config.ListenConfig{GuildId: "100"}, []uint64{303, 301, 302}, 301, nil},
+		{"configured channel", configured, []uint64{301}, 305, nil},
+		{"configured channel without cursed channels", configured, nil, 305, nil},
+		{"no channels", &config.ListenConfig{GuildId: "100"}, nil, 0, errNoContainmentChannel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := containmentTarget(tt.config, tt.channels)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
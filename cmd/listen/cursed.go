@@ -0,0 +1,336 @@
+package listen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/cursed"
+	"github.com/dmtaylor/costanza/internal/util"
+)
+
+const cursedCommandName = "cursed"
+const cursedWordGroup = "word"
+const cursedChannelGroup = "channel"
+const cursedAllowGroup = "allow"
+const cursedAddCommand = "add"
+const cursedRemoveCommand = "remove"
+const cursedListCommand = "list"
+
+var manageServerPermission int64 = discordgo.PermissionManageServer
+
+var cursedSlashCommand = &discordgo.ApplicationCommand{
+	Name:                     cursedCommandName,
+	Type:                     discordgo.ChatApplicationCommand,
+	Description:              "Manage the server's cursed words & channels",
+	DefaultMemberPermissions: &manageServerPermission,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:        cursedWordGroup,
+			Description: "Manage cursed words",
+			Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        cursedAddCommand,
+					Description: "Curse a word. Use * as a wildcard, or /regex/ for a regular expression",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Name: cursedWordGroup, Description: "Word to curse", Type: discordgo.ApplicationCommandOptionString, Required: true},
+					},
+				},
+				{
+					Name:        cursedRemoveCommand,
+					Description: "Uncurse a word",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Name: cursedWordGroup, Description: "Word to uncurse", Type: discordgo.ApplicationCommandOptionString, Required: true},
+					},
+				},
+				{
+					Name:        cursedListCommand,
+					Description: "List the cursed words",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+			},
+		},
+		{
+			Name:        cursedAllowGroup,
+			Description: "Manage words that are never counted as cursed",
+			Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        cursedAddCommand,
+					Description: "Allow a word",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Name: cursedWordGroup, Description: "Word to allow", Type: discordgo.ApplicationCommandOptionString, Required: true},
+					},
+				},
+				{
+					Name:        cursedRemoveCommand,
+					Description: "Stop allowing a word",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Name: cursedWordGroup, Description: "Word to stop allowing", Type: discordgo.ApplicationCommandOptionString, Required: true},
+					},
+				},
+				{
+					Name:        cursedListCommand,
+					Description: "List the allowed words",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+			},
+		},
+		{
+			Name:        cursedChannelGroup,
+			Description: "Manage cursed channels",
+			Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        cursedAddCommand,
+					Description: "Curse a channel",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Name: cursedChannelGroup, Description: "Channel to curse", Type: discordgo.ApplicationCommandOptionChannel, Required: true},
+					},
+				},
+				{
+					Name:        cursedRemoveCommand,
+					Description: "Uncurse a channel",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{Name: cursedChannelGroup, Description: "Channel to uncurse", Type: discordgo.ApplicationCommandOptionChannel, Required: true},
+					},
+				},
+				{
+					Name:        cursedListCommand,
+					Description: "List the cursed channels",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+			},
+		},
+	},
+}
+
+// canManageServer checks the member has the Manage Server permission. The command's default permissions hide it from
+// other members, but guild admins can override those, so it's checked again.
+func canManageServer(member *discordgo.Member) bool {
+	return member != nil && member.Permissions&discordgo.PermissionManageServer != 0
+}
+
+func (s *Server) cursedCommand(sess *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand || i.ApplicationCommandData().Name != cursedCommandName {
+		return
+	}
+
+	var err error
+	if s.m.enabled {
+		start := time.Now()
+		defer func() {
+			s.m.eventDuration.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: cursedCommandName}).Observe(time.Since(start).Seconds())
+			if err != nil {
+				isTimeout := strconv.FormatBool(errors.Is(err, context.DeadlineExceeded))
+				s.m.eventErrors.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: cursedCommandName, isTimeoutLabel: isTimeout}).Inc()
+			} else {
+				s.m.eventSuccess.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: cursedCommandName}).Inc()
+			}
+		}()
+	}
+	ctx, cancel := util.ContextFromDiscordInteractionCreate(context.Background(), i, interactionTimeout)
+	defer cancel()
+
+	var content string
+	options := i.ApplicationCommandData().Options
+	if _, ok := config.GlobalConfig.Discord.ListenChannelSet[i.GuildID]; !ok {
+		content = "Cursed words & channels aren't tracked on this guild"
+	} else if !canManageServer(i.Member) {
+		content = "You need the Manage Server permission to change cursed words & channels"
+	} else if len(options) == 0 || len(options[0].Options) == 0 {
+		return
+	} else {
+		group, command := options[0].Name, options[0].Options[0]
+		var value string
+		if len(command.Options) > 0 {
+			value = command.Options[0].Value.(string)
+		}
+		content, err = s.applyCursedCommand(ctx, i.GuildID, group, command.Name, value)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to update cursed list: "+err.Error())
+			content = "Failed to update the cursed list, please try again later"
+		}
+	}
+	// only the admin sees the response, so the cursed words aren't repeated in the channel
+	ierr := sess.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if ierr != nil {
+		slog.ErrorContext(ctx, "failed to send cursed response: "+ierr.Error())
+		err = ierr
+	}
+}
+
+// applyCursedCommand runs the cursed subcommand for the guild, returning the response to send. Changes are written
+// through to the db & the guild's cached list is dropped, so they apply straight away.
+func (s *Server) applyCursedCommand(ctx context.Context, guild, group, command, value string) (string, error) {
+	guildId, err := strconv.ParseUint(guild, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("bad guild id: %w", err)
+	}
+	switch group {
+	case cursedWordGroup:
+		return s.applyCursedWordCommand(ctx, guildId, command, value)
+	case cursedChannelGroup:
+		return s.applyCursedChannelCommand(ctx, guildId, command, value)
+	case cursedAllowGroup:
+		return s.applyCursedAllowCommand(ctx, guildId, command, value)
+	default:
+		return "", fmt.Errorf("unknown cursed command group %s", group)
+	}
+}
+
+func (s *Server) applyCursedWordCommand(ctx context.Context, guildId uint64, command, value string) (string, error) {
+	word := strings.TrimSpace(value)
+	if !cursed.IsRegexEntry(word) {
+		// matching ignores case, so words are stored lower cased to keep the list tidy
+		word = strings.ToLower(word)
+	}
+	switch command {
+	case cursedAddCommand:
+		if word == "" {
+			return "Can't curse an empty word", nil
+		}
+		if err := cursed.ValidateEntry(word); err != nil {
+			return fmt.Sprintf("Can't curse `%s`: %s", word, err.Error()), nil
+		}
+		added, err := s.app.Cursed.AddWord(ctx, guildId, word)
+		if err != nil {
+			return "", err
+		}
+		s.app.CursedWordCache.Invalidate(ctx, guildId)
+		if !added {
+			return fmt.Sprintf("`%s` is already cursed", word), nil
+		}
+		return fmt.Sprintf("`%s` is now cursed", word), nil
+	case cursedRemoveCommand:
+		removed, err := s.app.Cursed.RemoveWord(ctx, guildId, word)
+		if err != nil {
+			return "", err
+		}
+		s.app.CursedWordCache.Invalidate(ctx, guildId)
+		if !removed {
+			return fmt.Sprintf("`%s` isn't cursed", word), nil
+		}
+		return fmt.Sprintf("`%s` is no longer cursed", word), nil
+	case cursedListCommand:
+		words, err := s.app.Cursed.Words(ctx, guildId)
+		if err != nil {
+			return "", err
+		}
+		if len(words) == 0 {
+			return "There are no cursed words", nil
+		}
+		return "Cursed words: `" + strings.Join(words, "`, `") + "`", nil
+	default:
+		return "", fmt.Errorf("unknown cursed word command %s", command)
+	}
+}
+
+func (s *Server) applyCursedChannelCommand(ctx context.Context, guildId uint64, command, value string) (string, error) {
+	var channelId uint64
+	if command != cursedListCommand {
+		var err error
+		channelId, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("bad channel id: %w", err)
+		}
+	}
+	switch command {
+	case cursedAddCommand:
+		added, err := s.app.Cursed.AddChannel(ctx, guildId, channelId)
+		if err != nil {
+			return "", err
+		}
+		s.app.CursedChannelCache.Invalidate(ctx, guildId)
+		if !added {
+			return fmt.Sprintf("<#%d> is already cursed", channelId), nil
+		}
+		return fmt.Sprintf("<#%d> is now cursed", channelId), nil
+	case cursedRemoveCommand:
+		removed, err := s.app.Cursed.RemoveChannel(ctx, guildId, channelId)
+		if err != nil {
+			return "", err
+		}
+		s.app.CursedChannelCache.Invalidate(ctx, guildId)
+		if !removed {
+			return fmt.Sprintf("<#%d> isn't cursed", channelId), nil
+		}
+		return fmt.Sprintf("<#%d> is no longer cursed", channelId), nil
+	case cursedListCommand:
+		channels, err := s.app.Cursed.Channels(ctx, guildId)
+		if err != nil {
+			return "", err
+		}
+		if len(channels) == 0 {
+			return "There are no cursed channels", nil
+		}
+		mentions := make([]string, len(channels))
+		for i, channelId := range channels {
+			mentions[i] = fmt.Sprintf("<#%d>", channelId)
+		}
+		return "Cursed channels: " + strings.Join(mentions, ", "), nil
+	default:
+		return "", fmt.Errorf("unknown cursed channel command %s", command)
+	}
+}
+
+func (s *Server) applyCursedAllowCommand(ctx context.Context, guildId uint64, command, value string) (string, error) {
+	word := strings.ToLower(strings.TrimSpace(value))
+	switch command {
+	case cursedAddCommand:
+		if word == "" {
+			return "Can't allow an empty word", nil
+		}
+		added, err := s.app.Cursed.AddAllowed(ctx, guildId, word)
+		if err != nil {
+			return "", err
+		}
+		s.app.CursedAllowCache.Invalidate(ctx, guildId)
+		if !added {
+			return fmt.Sprintf("`%s` is already allowed", word), nil
+		}
+		return fmt.Sprintf("`%s` is now allowed", word), nil
+	case cursedRemoveCommand:
+		removed, err := s.app.Cursed.RemoveAllowed(ctx, guildId, word)
+		if err != nil {
+			return "", err
+		}
+		s.app.CursedAllowCache.Invalidate(ctx, guildId)
+		if !removed {
+			return fmt.Sprintf("`%s` isn't allowed", word), nil
+		}
+		return fmt.Sprintf("`%s` is no longer allowed", word), nil
+	case cursedListCommand:
+		words, err := s.app.Cursed.Allowed(ctx, guildId)
+		if err != nil {
+			return "", err
+		}
+		if len(words) == 0 {
+			return "There are no allowed words", nil
+		}
+		return "Allowed words: `" + strings.Join(words, "`, `") + "`", nil
+	default:
+		return "", fmt.Errorf("unknown cursed allow command %s", command)
+	}
+}
@@ -3,6 +3,7 @@ package listen
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"slices"
 	"strconv"
@@ -10,9 +11,14 @@ import (
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/hashicorp/go-multierror"
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/cursed"
+	"github.com/dmtaylor/costanza/internal/infractions"
+	"github.com/dmtaylor/costanza/internal/model"
+	"github.com/dmtaylor/costanza/internal/stats"
 	"github.com/dmtaylor/costanza/internal/util"
 )
 
@@ -67,7 +73,12 @@ func (s *Server) logCursedChannelStat(sess *discordgo.Session, m *discordgo.Mess
 		return
 	}
 	if slices.Index(cursedChannels, channelId) != -1 {
-		err = s.app.Stats.LogCursedChannelPost(ctx, guildId, userId, time.Now().Format("2006-01"))
+		err = s.logCounter(ctx, stats.Counter{
+			Metric:    stats.CursedChannelPostsMetric,
+			GuildId:   guildId,
+			UserId:    userId,
+			Increment: 1,
+		}, m.Timestamp)
 		if err != nil {
 			slog.ErrorContext(ctx, "failed to update cursed channel log: "+err.Error())
 			return
@@ -109,21 +120,111 @@ func (s *Server) logCursedPostStat(sess *discordgo.Session, m *discordgo.Message
 		slog.ErrorContext(ctx, "error logging activity: "+err.Error())
 		return
 	}
-	cursedWords, err := s.app.CursedWordCache.Get(ctx, guildId)
+	matcher, err := s.app.CursedMatchers.Get(ctx, guildId)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to get cursed word list: "+err.Error())
+		slog.ErrorContext(ctx, "failed to get cursed word matcher: "+err.Error())
 		return
 	}
-	msg := strings.ToLower(m.Message.Content)
-	count := 0
-	for _, word := range cursedWords {
-		count += strings.Count(msg, word)
-	}
-	if count > 0 {
-		err = s.app.Stats.LogCursedPost(ctx, guildId, userId, time.Now().Format("2006-01"), count)
+	words := matcher.Find(m.Message.Content)
+	if len(words) > 0 {
+		err = s.logCounter(ctx, stats.Counter{
+			Metric:    stats.CursedPostsMetric,
+			GuildId:   guildId,
+			UserId:    userId,
+			Increment: len(words),
+		}, m.Timestamp)
 		if err != nil {
 			slog.ErrorContext(ctx, "failed to update cursed post log: "+err.Error())
-			return
+		}
+		// the post is still handled, so moderation doesn't depend on the stats being written
+		if e := s.handleCursedPost(ctx, sess, m, guildId, userId, words); e != nil {
+			slog.ErrorContext(ctx, "failed to handle cursed post: "+e.Error())
+			err = multierror.Append(err, e)
+		}
+	}
+}
+
+// handleCursedPost adds the post's cursed words to the author's swear jar & the infractions ledger if the guild keeps
+// them there, then takes the guild's actions for the tiers the words are in. Each action taken is posted to the
+// moderation log. Actions are still taken if the swear jar or ledger can't be updated, so moderation doesn't depend on
+// the db.
+func (s *Server) handleCursedPost(ctx context.Context, sess *discordgo.Session, m *discordgo.MessageCreate, guildId, userId uint64, words []string) error {
+	var errs *multierror.Error
+	optedOut, err := s.isOptedOut(ctx, guildId, userId)
+	if err != nil {
+		errs = multierror.Append(errs, fmt.Errorf("not adding to swear jar: %w", err))
+	} else if !optedOut {
+		if _, err = s.app.Cursed.AddToSwearJar(ctx, guildId, userId, len(words)); err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("failed to add to swear jar: %w", err))
+		}
+	}
+	listenConfig := config.GlobalConfig.Discord.ListenChannelSet[m.GuildID]
+	if listenConfig.CursedInfractions {
+		_, err = s.app.Infractions.Add(ctx, model.Infraction{
+			GuildId:   guildId,
+			UserId:    userId,
+			Kind:      infractions.CursedKind,
+			Reason:    fmt.Sprintf("posted %s in <#%s>", cursedWordList(words), m.ChannelID),
+			CreatedAt: m.Timestamp.Unix(),
+		})
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("failed to record infraction: %w", err))
+		}
+	}
+	for _, action := range cursed.ActionsFor(listenConfig.CursedActions, words) {
+		taken, err := s.takeCursedAction(ctx, sess, m, guildId, userId, action)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("failed cursed action for tier %s: %w", action.Tier, err))
+		}
+		if len(taken) == 0 {
+			continue
+		}
+		err = s.modLog(ctx, sess, m.GuildID, fmt.Sprintf("🤬 <@%s> posted %s in <#%s> (%s): %s",
+			m.Author.ID, cursedWordList(words), m.ChannelID, action.Tier, strings.Join(taken, ", ")))
+		errs = multierror.Append(errs, err)
+	}
+	return errs.ErrorOrNil()
+}
+
+// takeCursedAction takes the action for the post, returning a description of each part taken. Reactions are skipped
+// for posts the action deletes.
+func (s *Server) takeCursedAction(ctx context.Context, sess *discordgo.Session, m *discordgo.MessageCreate, guildId, userId uint64, action *cursed.Action) ([]string, error) {
+	var taken []string
+	if action.React != "" && !action.Delete {
+		if err := sess.MessageReactionAdd(m.ChannelID, m.ID, action.React); err != nil {
+			return taken, fmt.Errorf("failed to react: %w", err)
+		}
+		taken = append(taken, "reacted "+action.React)
+	}
+	if action.Reply {
+		if err := s.sendQuote(ctx, sess, m); err != nil {
+			return taken, fmt.Errorf("failed to reply: %w", err)
+		}
+		taken = append(taken, "replied with a quote")
+	}
+	if action.Delete {
+		if err := sess.ChannelMessageDelete(m.ChannelID, m.ID); err != nil {
+			return taken, fmt.Errorf("failed to delete post: %w", err)
+		}
+		taken = append(taken, "deleted the post")
+	}
+	if s.app.CursedIncidents.Record(guildId, userId, action, m.Timestamp) {
+		until := m.Timestamp.Add(action.Duration())
+		if err := sess.GuildMemberTimeout(m.GuildID, m.Author.ID, &until); err != nil {
+			return taken, fmt.Errorf("failed to time out member: %w", err)
+		}
+		taken = append(taken, fmt.Sprintf("timed out for %s after %d posts in %s", action.Duration(), action.TimeoutAfter, action.Window()))
+	}
+	return taken, nil
+}
+
+// cursedWordList formats the distinct cursed words for messages
+func cursedWordList(words []string) string {
+	var distinct []string
+	for _, word := range words {
+		if !slices.Contains(distinct, word) {
+			distinct = append(distinct, word)
 		}
 	}
+	return "`" + strings.Join(distinct, "`, `") + "`"
 }
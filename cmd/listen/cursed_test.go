@@ -0,0 +1,308 @@
+package listen
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/cursed"
+	"github.com/dmtaylor/costanza/internal/infractions"
+	"github.com/dmtaylor/costanza/internal/model"
+	"github.com/dmtaylor/costanza/internal/stats"
+)
+
+// testCursed is an in memory cursed store
+type testCursed struct {
+	words    map[uint64][]string
+	channels map[uint64][]uint64
+	allowed  map[uint64][]string
+	jars     map[uint64]map[uint64]int
+	jarErr   error // returned when adding to swear jars
+}
+
+func newTestCursed() *testCursed {
+	return &testCursed{words: make(map[uint64][]string), channels: make(map[uint64][]uint64), allowed: make(map[uint64][]string), jars: make(map[uint64]map[uint64]int)}
+}
+
+func (c *testCursed) AddWord(_ context.Context, guildId uint64, word string) (bool, error) {
+	if slices.Contains(c.words[guildId], word) {
+		return false, nil
+	}
+	c.words[guildId] = append(c.words[guildId], word)
+	return true, nil
+}
+
+func (c *testCursed) RemoveWord(_ context.Context, guildId uint64, word string) (bool, error) {
+	before := len(c.words[guildId])
+	c.words[guildId] = slices.DeleteFunc(c.words[guildId], func(w string) bool { return w == word })
+	return len(c.words[guildId]) < before, nil
+}
+
+func (c *testCursed) Words(_ context.Context, guildId uint64) ([]string, error) {
+	return slices.Sorted(slices.Values(c.words[guildId])), nil
+}
+
+func (c *testCursed) AddChannel(_ context.Context, guildId, channelId uint64) (bool, error) {
+	if slices.Contains(c.channels[guildId], channelId) {
+		return false, nil
+	}
+	c.channels[guildId] = append(c.channels[guildId], channelId)
+	return true, nil
+}
+
+func (c *testCursed) RemoveChannel(_ context.Context, guildId, channelId uint64) (bool, error) {
+	before := len(c.channels[guildId])
+	c.channels[guildId] = slices.DeleteFunc(c.channels[guildId], func(id uint64) bool { return id == channelId })
+	return len(c.channels[guildId]) < before, nil
+}
+
+func (c *testCursed) Channels(_ context.Context, guildId uint64) ([]uint64, error) {
+	return slices.Sorted(slices.Values(c.channels[guildId])), nil
+}
+
+func (c *testCursed) AddAllowed(_ context.Context, guildId uint64, word string) (bool, error) {
+	if slices.Contains(c.allowed[guildId], word) {
+		return false, nil
+	}
+	c.allowed[guildId] = append(c.allowed[guildId], word)
+	return true, nil
+}
+
+func (c *testCursed) RemoveAllowed(_ context.Context, guildId uint64, word string) (bool, error) {
+	before := len(c.allowed[guildId])
+	c.allowed[guildId] = slices.DeleteFunc(c.allowed[guildId], func(w string) bool { return w == word })
+	return len(c.allowed[guildId]) < before, nil
+}
+
+func (c *testCursed) Allowed(_ context.Context, guildId uint64) ([]string, error) {
+	return slices.Sorted(slices.Values(c.allowed[guildId])), nil
+}
+
+func (c *testCursed) AddToSwearJar(_ context.Context, guildId, userId uint64, count int) (int, error) {
+	if c.jarErr != nil {
+		return 0, c.jarErr
+	}
+	if c.jars[guildId] == nil {
+		c.jars[guildId] = make(map[uint64]int)
+	}
+	c.jars[guildId][userId] += count
+	return c.jars[guildId][userId], nil
+}
+
+func (c *testCursed) SwearJar(_ context.Context, guildId, userId uint64) (int, error) {
+	return c.jars[guildId][userId], nil
+}
+
+func (c *testCursed) SwearJarLeaders(_ context.Context, guildId uint64, limit int) ([]model.SwearJarTotal, error) {
+	var totals []model.SwearJarTotal
+	for userId, total := range c.jars[guildId] {
+		totals = append(totals, model.SwearJarTotal{GuildId: guildId, UserId: userId, Total: total})
+	}
+	slices.SortFunc(totals, func(a, b model.SwearJarTotal) int {
+		if a.Total != b.Total {
+			return b.Total - a.Total
+		}
+		return cmp.Compare(a.UserId, b.UserId)
+	})
+	return totals[:min(limit, len(totals))], nil
+}
+
+func (c *testCursed) RemoveUser(_ context.Context, guildId, userId uint64) error {
+	delete(c.jars[guildId], userId)
+	return nil
+}
+
+// testInvalidations records the keys invalidated in a cache
+type testInvalidations struct {
+	keys []uint64
+}
+
+func (c *testInvalidations) Invalidate(_ context.Context, key uint64) {
+	c.keys = append(c.keys, key)
+}
+
+func (c *testInvalidations) Clear(_ context.Context) {}
+
+type testWordCache struct {
+	testInvalidations
+	words []string
+}
+
+func (c *testWordCache) Get(_ context.Context, _ uint64) ([]string, error) { return c.words, nil }
+
+func (c *testWordCache) Set(_ context.Context, _ uint64, _ []string) {}
+
+type testChannelCache struct {
+	testInvalidations
+	channels []uint64
+}
+
+func (c *testChannelCache) Get(_ context.Context, _ uint64) ([]uint64, error) { return c.channels, nil }
+
+func (c *testChannelCache) Set(_ context.Context, _ uint64, _ []uint64) {}
+
+func TestCanManageServer(t *testing.T) {
+	assert.False(t, canManageServer(nil))
+	assert.False(t, canManageServer(&discordgo.Member{Permissions: discordgo.PermissionSendMessages}))
+	assert.True(t, canManageServer(&discordgo.Member{Permissions: discordgo.PermissionSendMessages | discordgo.PermissionManageServer}))
+}
+
+func TestServer_applyCursedCommand(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, config.ListenConfig{GuildId: "100"})
+	store := newTestCursed()
+	words := &testWordCache{}
+	allowed := &testWordCache{}
+	channels := &testChannelCache{}
+	s.app.Cursed = store
+	s.app.CursedWordCache = words
+	s.app.CursedAllowCache = allowed
+	s.app.CursedChannelCache = channels
+
+	tests := []struct {
+		group   string
+		command string
+		value   string
+		want    string
+	}{
+		{cursedWordGroup, cursedListCommand, "", "There are no cursed words"},
+		{cursedWordGroup, cursedAddCommand, " Heck ", "`heck` is now cursed"},
+		{cursedWordGroup, cursedAddCommand, "heck", "`heck` is already cursed"},
+		{cursedWordGroup, cursedAddCommand, "darn", "`darn` is now cursed"},
+		{cursedWordGroup, cursedAddCommand, " ", "Can't curse an empty word"},
+		{cursedWordGroup, cursedAddCommand, "/h(eck/", "Can't curse `/h(eck/`: invalid cursed word regex /h(eck/: error parsing regexp: missing closing ): `(?i)h(eck`"},
+		{cursedWordGroup, cursedAddCommand, "/H+eck/", "`/H+eck/` is now cursed"},
+		{cursedWordGroup, cursedListCommand, "", "Cursed words: `/H+eck/`, `darn`, `heck`"},
+		{cursedWordGroup, cursedRemoveCommand, "HECK", "`heck` is no longer cursed"},
+		{cursedWordGroup, cursedRemoveCommand, "heck", "`heck` isn't cursed"},
+		{cursedChannelGroup, cursedListCommand, "", "There are no cursed channels"},
+		{cursedChannelGroup, cursedAddCommand, "300", "<#300> is now cursed"},
+		{cursedChannelGroup, cursedAddCommand, "301", "<#301> is now cursed"},
+		{cursedChannelGroup, cursedListCommand, "", "Cursed channels: <#300>, <#301>"},
+		{cursedChannelGroup, cursedRemoveCommand, "300", "<#300> is no longer cursed"},
+		{cursedChannelGroup, cursedRemoveCommand, "300", "<#300> isn't cursed"},
+		{cursedAllowGroup, cursedAddCommand, "Class", "`class` is now allowed"},
+		{cursedAllowGroup, cursedAddCommand, "class", "`class` is already allowed"},
+		{cursedAllowGroup, cursedListCommand, "", "Allowed words: `class`"},
+		{cursedAllowGroup, cursedRemoveCommand, "class", "`class` is no longer allowed"},
+	}
+	for _, tt := range tests {
+		got, err := s.applyCursedCommand(ctx, "100", tt.group, tt.command, tt.value)
+		require.NoError(t, err, "%s %s %s", tt.group, tt.command, tt.value)
+		assert.Equal(t, tt.want, got, "%s %s %s", tt.group, tt.command, tt.value)
+	}
+	assert.Equal(t, []string{"darn", "/H+eck/"}, store.words[100])
+	assert.Equal(t, []uint64{301}, store.channels[100])
+	assert.Equal(t, []uint64{100, 100, 100, 100, 100, 100}, words.keys, "word cache not invalidated on every change")
+	assert.Equal(t, []uint64{100, 100, 100}, allowed.keys, "allow cache not invalidated on every change")
+	assert.Equal(t, []uint64{100, 100, 100, 100}, channels.keys, "channel cache not invalidated on every change")
+
+	_, err := s.applyCursedCommand(ctx, "100", cursedChannelGroup, cursedAddCommand, "general")
+	assert.Error(t, err)
+}
+
+func TestServer_handleCursedPost(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, config.ListenConfig{GuildId: "100"})
+	store := newTestCursed()
+	s.app.Cursed = store
+	sess := newTestSession()
+	post := func(author string) *discordgo.MessageCreate {
+		return &discordgo.MessageCreate{Message: &discordgo.Message{ID: "500", ChannelID: "300", GuildID: "100", Author: &discordgo.User{ID: author}}}
+	}
+
+	// no actions configured, so only the swear jar is updated
+	require.NoError(t, s.handleCursedPost(ctx, sess, post("200"), 100, 200, []string{"heck", "darn"}))
+	require.NoError(t, s.handleCursedPost(ctx, sess, post("200"), 100, 200, []string{"heck"}))
+	require.NoError(t, s.app.Privacy.OptOut(ctx, 100, 201))
+	require.NoError(t, s.handleCursedPost(ctx, sess, post("201"), 100, 201, []string{"heck"}))
+	assert.Equal(t, map[uint64]int{200: 3}, store.jars[100], "opted out user added to swear jar")
+	assert.Empty(t, s.app.Infractions.(*testInfractions).ledger, "cursed infractions added without cursed_infractions")
+}
+
+func TestServer_handleCursedPost_infractions(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, config.ListenConfig{GuildId: "100", CursedInfractions: true})
+	timestamp := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	post := &discordgo.MessageCreate{Message: &discordgo.Message{ID: "500", ChannelID: "300", GuildID: "100", Author: &discordgo.User{ID: "200"}, Timestamp: timestamp}}
+
+	require.NoError(t, s.handleCursedPost(ctx, newTestSession(), post, 100, 200, []string{"heck", "darn", "heck"}))
+	assert.Equal(t, []model.Infraction{
+		{Id: 1, GuildId: 100, UserId: 200, Kind: infractions.CursedKind, Reason: "posted `heck`, `darn` in <#300>", CreatedAt: timestamp.Unix()},
+	}, s.app.Infractions.(*testInfractions).ledger)
+}
+
+func TestServer_handleCursedPost_dbErrors(t *testing.T) {
+	ctx := context.Background()
+	actions := []cursed.Action{{Tier: "worst", Delete: true, TimeoutAfter: 1, TimeoutWindow: "1h", TimeoutDuration: "10m"}}
+	s := newTestServer(t, config.ListenConfig{GuildId: "100", CursedInfractions: true, CursedActions: actions})
+	store := newTestCursed()
+	store.jarErr = errors.New("jar db err")
+	s.app.Cursed = store
+	s.app.Infractions.(*testInfractions).err = errors.New("ledger db err")
+	transport := &testTransport{}
+	post := &discordgo.MessageCreate{Message: &discordgo.Message{ID: "500", ChannelID: "300", GuildID: "100", Author: &discordgo.User{ID: "200"}, Timestamp: time.Now()}}
+
+	err := s.handleCursedPost(ctx, newRecordingSession(transport), post, 100, 200, []string{"heck"})
+	assert.ErrorContains(t, err, "jar db err")
+	assert.ErrorContains(t, err, "ledger db err")
+	assert.Equal(t, []string{"DELETE /channels/300/messages/500", "PATCH /guilds/100/members/200"}, transport.requests,
+		"actions not taken when bookkeeping failed")
+}
+
+// failingStats fails every counter write & read
+type failingStats struct {
+	*stats.MemoryStore
+	err error
+}
+
+func (f failingStats) LogCounter(_ context.Context, _ stats.Counter) error {
+	return f.err
+}
+
+func (f failingStats) GetCounterLeaders(_ context.Context, _ stats.Metric, _ uint64, _ string, _ int) ([]*model.CounterStat, error) {
+	return nil, f.err
+}
+
+func TestServer_logCursedPostStat_statsError(t *testing.T) {
+	actions := []cursed.Action{{Tier: "worst", Delete: true}}
+	s := newTestServer(t, config.ListenConfig{GuildId: "100", CursedActions: actions})
+	s.app.Stats = failingStats{MemoryStore: stats.NewMemoryStore(), err: errors.New("stats db err")}
+	s.app.CursedMatchers = cursed.NewMatchers(&testWordCache{words: []string{"heck"}}, &testWordCache{})
+	transport := &testTransport{}
+	post := &discordgo.MessageCreate{Message: &discordgo.Message{ID: "500", ChannelID: "300", GuildID: "100", Content: "oh heck", Author: &discordgo.User{ID: "200"}, Timestamp: time.Now()}}
+
+	s.logCursedPostStat(newRecordingSession(transport), post)
+	assert.Equal(t, []string{"DELETE /channels/300/messages/500"}, transport.requests, "action not taken when stats failed")
+	assert.Equal(t, map[uint64]int{200: 1}, s.app.Cursed.(*testCursed).jars[100], "swear jar not updated when stats failed")
+}
+
+func TestServer_swearJarContent(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, config.ListenConfig{GuildId: "100", SwearJarFine: 0.5, LeaderboardSize: 2}, config.ListenConfig{GuildId: "101"})
+	store := newTestCursed()
+	s.app.Cursed = store
+	store.jars[100] = map[uint64]int{200: 3, 201: 10, 202: 1}
+	store.jars[101] = map[uint64]int{200: 2}
+
+	got, err := s.swearJarContent(ctx, "100", "200")
+	require.NoError(t, err)
+	assert.Equal(t, "🫙 <@200> owes the swear jar **$1.50** for 3 cursed words\n\nBiggest swear jars:\n1. <@201>: $5.00 (10)\n2. <@200>: $1.50 (3)", got)
+	got, err = s.swearJarContent(ctx, "101", "200")
+	require.NoError(t, err)
+	assert.Equal(t, "🫙 <@200> owes the swear jar **$0.50** for 2 cursed words\n\nBiggest swear jars:\n1. <@200>: $0.50 (2)", got, "default fine not used")
+	got, err = s.swearJarContent(ctx, "101", "203")
+	require.NoError(t, err)
+	assert.Equal(t, "🫙 <@203> has a clean mouth, nothing owed to the swear jar\n\nBiggest swear jars:\n1. <@200>: $0.50 (2)", got)
+
+	_, err = s.swearJarContent(ctx, "100", "someone")
+	assert.Error(t, err)
+}
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"regexp"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,16 +15,23 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/achievements"
 	"github.com/dmtaylor/costanza/internal/model"
+	"github.com/dmtaylor/costanza/internal/stats"
 	"github.com/dmtaylor/costanza/internal/util"
 )
 
 const dailyGameHandlerEventName = "dailyGameHandler"
 const dailyGameReactionEventName = "dailyGameReaction"
+const dailyTimedGameHandlerEventName = "dailyTimedGameHandler"
 
 var gamePattern = regexp.MustCompile(`(?s)#?(Framed|Tradle|Wordle|Worldle|Heardle|GuessTheGame|Episode|Flashback|Costcodle|Acted|Rogule)\s+.*[🟩⬛⬜🟥🟨✅]`)
 var wordleAndTradleCapturePattern = regexp.MustCompile(`(?s)#?(Tradle|Wordle|Worldle|Costcodle)\s.*#?\d+\s+(\d+|X)/(\d+)`)
 
+// solve time patterns for duration scored games. First group is the game name, second is the solve time
+var nytTimedGamePattern = regexp.MustCompile(`(?is)I solved the\s.*?\b(Mini|Crossword)\b.*?\bin\s+(\d+(?::\d{2}){1,2})`)
+var linkedInTimedGamePattern = regexp.MustCompile(`(?s)\b(Queens|Zip|Tango)\s+#?\d+\s*\|?\s*(\d+(?::\d{2}){1,2})`)
+
 // dailyGameHandler performs handling of daily game events
 func (s *Server) dailyGameHandler(sess *discordgo.Session, m *discordgo.MessageCreate) {
 	if m.Author.ID == sess.State.User.ID {
@@ -80,7 +88,24 @@ func (s *Server) dailyGameHandler(sess *discordgo.Session, m *discordgo.MessageC
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
-				handleError = multierror.Append(handleError, s.app.Stats.LogDailyGameActivity(ctx, gameResult, m.Timestamp.Format("2006-01")))
+				optedOut, e := s.isOptedOut(ctx, guildId, userId)
+				if e != nil || optedOut {
+					handleError = multierror.Append(handleError, e)
+					return
+				}
+				for _, period := range periodKeys(m.GuildID, m.Timestamp) {
+					handleError = multierror.Append(handleError, s.app.Stats.LogDailyGameActivity(ctx, gameResult, period))
+					handleError = multierror.Append(handleError, s.app.Stats.LogGamePlay(ctx, guildId, userId, gameResult.Game, period))
+				}
+				handleError = multierror.Append(handleError, s.logGameDayCounters(ctx, gameResult, m.Timestamp))
+				handleError = multierror.Append(handleError, s.checkAchievements(ctx, sess, achievements.Event{
+					Type:    achievements.GameEvent,
+					GuildId: guildId,
+					UserId:  userId,
+					Time:    m.Timestamp,
+					Game:    gameResult.Game,
+					Win:     gameResult.Win,
+				}))
 			}()
 		}
 
@@ -94,6 +119,17 @@ func (s *Server) dailyGameHandler(sess *discordgo.Session, m *discordgo.MessageC
 	}
 }
 
+// logGameDayCounters counts the play & any win for the day, for win rate charts
+func (s *Server) logGameDayCounters(ctx context.Context, gameResult model.DailyGamePlay, t time.Time) error {
+	counter := stats.Counter{Metric: stats.GamePlaysMetric, GuildId: gameResult.GuildId, UserId: gameResult.UserId, Increment: 1}
+	err := s.logDayCounter(ctx, counter, t)
+	if err != nil || !gameResult.Win {
+		return err
+	}
+	counter.Metric = stats.GameWinsMetric
+	return s.logDayCounter(ctx, counter, t)
+}
+
 func (s *Server) doWinReaction(sess *discordgo.Session, m *discordgo.MessageCreate) error {
 	callStart := time.Now()
 	err := sess.MessageReactionAdd(m.ChannelID, m.Message.ID, "💯")
@@ -107,6 +143,77 @@ func (s *Server) doWinReaction(sess *discordgo.Session, m *discordgo.MessageCrea
 	return nil
 }
 
+// dailyTimedGameHandler performs handling of daily games that are scored by solve time rather than guesses
+func (s *Server) dailyTimedGameHandler(sess *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.Author.ID == sess.State.User.ID || m.Author.Bot {
+		return
+	}
+	// Only log game results if configured to listen to guild
+	if _, found := config.GlobalConfig.Discord.ListenChannelSet[m.GuildID]; !found {
+		return
+	}
+	game, solveTime, ok := matchTimedGame(m.Content)
+	if !ok {
+		return
+	}
+
+	var err error
+	if s.m.enabled {
+		start := time.Now()
+		defer func() {
+			s.m.eventDuration.With(prometheus.Labels{gatewayEventTypeLabel: messageCreateGatewayEvent, eventNameLabel: dailyTimedGameHandlerEventName}).Observe(time.Since(start).Seconds())
+			if err != nil {
+				s.m.eventErrors.With(prometheus.Labels{gatewayEventTypeLabel: messageCreateGatewayEvent, eventNameLabel: dailyTimedGameHandlerEventName, isTimeoutLabel: "false"}).Inc()
+			} else {
+				s.m.eventSuccess.With(prometheus.Labels{gatewayEventTypeLabel: messageCreateGatewayEvent, eventNameLabel: dailyTimedGameHandlerEventName}).Inc()
+			}
+		}()
+	}
+	ctx := util.ContextFromDiscordMessageCreate(context.Background(), m)
+
+	guildId, err := strconv.ParseUint(m.GuildID, 10, 64)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to parse guild id as uint64", "guildId", m.GuildID)
+		return
+	}
+	userId, err := strconv.ParseUint(m.Author.ID, 10, 64)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to parse user id as uint64", "userId", m.Author.ID)
+		return
+	}
+	optedOut, err := s.isOptedOut(ctx, guildId, userId)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to check opt out: "+err.Error())
+		return
+	}
+	if optedOut {
+		return
+	}
+	gameResult, err := createTimedGameResult(guildId, userId, game, solveTime)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get timed game results", "error", err.Error())
+		return
+	}
+	slog.DebugContext(ctx, "parsed timed game results", "gameResults", fmt.Sprintf("%+v", gameResult))
+	var logErr *multierror.Error
+	for _, period := range periodKeys(m.GuildID, m.Timestamp) {
+		logErr = multierror.Append(logErr, s.app.Stats.LogDailyGameTime(ctx, gameResult, period))
+		logErr = multierror.Append(logErr, s.app.Stats.LogGamePlay(ctx, guildId, userId, gameResult.Game, period))
+	}
+	logErr = multierror.Append(logErr, s.checkAchievements(ctx, sess, achievements.Event{
+		Type:    achievements.GameEvent,
+		GuildId: guildId,
+		UserId:  userId,
+		Time:    m.Timestamp,
+		Game:    gameResult.Game,
+		Win:     true, // solve times are only shared once solved
+	}))
+	err = logErr.ErrorOrNil()
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to log game time: "+err.Error())
+	}
+}
+
 func isGameMessage(message string) bool {
 	return gamePattern.MatchString(message)
 }
@@ -118,6 +225,7 @@ func createGameResult(guildId, userId uint64, gameType, message string) (model.D
 		UserId:  userId,
 		Tries:   0,
 		Win:     false,
+		Game:    gameType,
 	}
 	switch gameType {
 	case "Framed":
@@ -186,3 +294,63 @@ func createGameResult(guildId, userId uint64, gameType, message string) (model.D
 
 	return result, nil
 }
+
+// matchTimedGame checks if the message is a result for a duration scored game, returning the game name & solve time text
+func matchTimedGame(message string) (string, string, bool) {
+	for _, pattern := range []*regexp.Regexp{nytTimedGamePattern, linkedInTimedGamePattern} {
+		if groups := pattern.FindStringSubmatch(message); groups != nil {
+			return groups[1], groups[2], true
+		}
+	}
+	return "", "", false
+}
+
+// createTimedGameResult converts matched message data into a DailyGameTimePlay
+func createTimedGameResult(guildId, userId uint64, gameType, solveTime string) (model.DailyGameTimePlay, error) {
+	result := model.DailyGameTimePlay{
+		GuildId: guildId,
+		UserId:  userId,
+	}
+	switch strings.ToLower(gameType) {
+	case "mini":
+		result.Game = "Mini"
+	case "crossword":
+		result.Game = "Crossword"
+	case "queens":
+		result.Game = "Queens"
+	case "zip":
+		result.Game = "Zip"
+	case "tango":
+		result.Game = "Tango"
+	default:
+		return result, fmt.Errorf("invalid timed game type: %s", gameType)
+	}
+	duration, err := parseSolveTime(solveTime)
+	if err != nil {
+		return result, fmt.Errorf("failed parsing solve time: %w", err)
+	}
+	result.Duration = duration
+
+	return result, nil
+}
+
+// parseSolveTime parses times in the form m:ss or h:mm:ss
+func parseSolveTime(solveTime string) (time.Duration, error) {
+	parts := strings.Split(solveTime, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid solve time \"%s\"", solveTime)
+	}
+	var total time.Duration
+	for i, part := range parts {
+		value, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid solve time component \"%s\": %w", part, err)
+		}
+		if i > 0 && value > 59 {
+			return 0, fmt.Errorf("invalid solve time \"%s\"", solveTime)
+		}
+		total = total*60 + time.Duration(value)
+	}
+
+	return total * time.Second, nil
+}
@@ -3,6 +3,7 @@ package listen
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -49,6 +50,7 @@ https://framed.wtf/`,
 				UserId:  102,
 				Tries:   3,
 				Win:     true,
+				Game:    "Framed",
 			},
 			expectedError: nil,
 		},
@@ -67,6 +69,7 @@ https://framed.wtf/`,
 				GuildId: 111,
 				UserId:  112,
 				Tries:   6,
+				Game:    "Framed",
 			},
 			nil,
 		},
@@ -88,6 +91,7 @@ https://guessthe.game/`,
 				UserId:  202,
 				Tries:   1,
 				Win:     true,
+				Game:    "GuessTheGame",
 			},
 			nil,
 		},
@@ -107,6 +111,7 @@ https://guessthe.game/`,
 				UserId:  302,
 				Tries:   2,
 				Win:     true,
+				Game:    "Wordle",
 			},
 			nil,
 		},
@@ -129,6 +134,7 @@ https://guessthe.game/`,
 				GuildId: 401,
 				UserId:  402,
 				Tries:   6,
+				Game:    "Wordle",
 			},
 			nil,
 		},
@@ -151,6 +157,7 @@ Play here:
 				UserId:  502,
 				Tries:   3,
 				Win:     true,
+				Game:    "Flashback",
 			},
 			nil,
 		},
@@ -167,6 +174,7 @@ Play here:
 				UserId:  602,
 				Tries:   4,
 				Win:     true,
+				Game:    "GuessTheGame",
 			},
 			nil,
 		},
@@ -190,6 +198,7 @@ https://worldle.teuteuf.fr`,
 				UserId:  702,
 				Tries:   6,
 				Win:     false,
+				Game:    "Worldle",
 			},
 			nil,
 		},
@@ -210,6 +219,7 @@ https://worldle.teuteuf.fr`,
 				UserId:  802,
 				Tries:   3,
 				Win:     true,
+				Game:    "Costcodle",
 			},
 			nil,
 		},
@@ -228,6 +238,7 @@ https://costcodle.com/`,
 				UserId:  802,
 				Tries:   1,
 				Win:     true,
+				Game:    "Costcodle",
 			},
 			nil,
 		},
@@ -247,6 +258,7 @@ https://acted.wtf/`,
 				UserId:  802,
 				Tries:   5,
 				Win:     true,
+				Game:    "Acted",
 			},
 			expectedError: nil,
 		},
@@ -263,6 +275,7 @@ https://acted.wtf/`,
 				UserId:  802,
 				Tries:   1,
 				Win:     true,
+				Game:    "Rogule",
 			},
 		},
 		{
@@ -278,6 +291,7 @@ https://acted.wtf/`,
 				UserId:  802,
 				Tries:   1,
 				Win:     false,
+				Game:    "Rogule",
 			},
 			expectedError: nil,
 		},
@@ -416,3 +430,116 @@ https://rogule.com/`,
 		})
 	}
 }
+
+func Test_matchTimedGame(t *testing.T) {
+	tests := []struct {
+		name      string
+		message   string
+		wantGame  string
+		wantTime  string
+		wantMatch bool
+	}{
+		{
+			"mini_short",
+			"I solved the Mini in 0:42",
+			"Mini",
+			"0:42",
+			true,
+		},
+		{
+			"mini_share",
+			"I solved the 7/14/2024 New York Times Mini Crossword in 0:31! https://www.nytimes.com/crosswords/game/mini",
+			"Mini",
+			"0:31",
+			true,
+		},
+		{
+			"crossword_share",
+			"I solved the Monday 7/15/2024 New York Times Daily Crossword in 12:04! https://www.nytimes.com/crosswords/game/daily",
+			"Crossword",
+			"12:04",
+			true,
+		},
+		{
+			"queens",
+			`Queens #75 | 1:49 and flawless
+First 👑: 🟪
+lnkd.in/queens.`,
+			"Queens",
+			"1:49",
+			true,
+		},
+		{
+			"zip",
+			"Zip #12 | 0:35 🏁\nlnkd.in/zip.",
+			"Zip",
+			"0:35",
+			true,
+		},
+		{
+			"no_match",
+			"I solved the mystery of the missing bread",
+			"",
+			"",
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game, solveTime, ok := matchTimedGame(tt.message)
+			assert.Equal(t, tt.wantMatch, ok)
+			assert.Equal(t, tt.wantGame, game)
+			assert.Equal(t, tt.wantTime, solveTime)
+		})
+	}
+}
+
+func Test_createTimedGameResult(t *testing.T) {
+	tests := []struct {
+		name          string
+		gameType      string
+		solveTime     string
+		want          model.DailyGameTimePlay
+		expectedError bool
+	}{
+		{
+			"mini",
+			"mini",
+			"0:42",
+			model.DailyGameTimePlay{GuildId: 1, UserId: 2, Game: "Mini", Duration: 42 * time.Second},
+			false,
+		},
+		{
+			"crossword_hours",
+			"Crossword",
+			"1:02:03",
+			model.DailyGameTimePlay{GuildId: 1, UserId: 2, Game: "Crossword", Duration: time.Hour + 2*time.Minute + 3*time.Second},
+			false,
+		},
+		{
+			"invalid_game",
+			"Sudoku",
+			"1:00",
+			model.DailyGameTimePlay{GuildId: 1, UserId: 2},
+			true,
+		},
+		{
+			"invalid_time",
+			"Queens",
+			"1:75",
+			model.DailyGameTimePlay{GuildId: 1, UserId: 2, Game: "Queens"},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := createTimedGameResult(1, 2, tt.gameType, tt.solveTime)
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
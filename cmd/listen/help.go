@@ -23,7 +23,16 @@ Costanza commands:
               Can be modified with '8again', '9again' and 'chance'. Rolls of < 1 dice are done as chance rolls.
 /dhtest:      parse text as d-notation, evaluate, and use result for FF Warhammer 40k RPG roll (over-under on 1d100).
 /weather:     get weather information for given location, or default
-/leaderboard: print the leaderboard for the month so far for the given server, if configured
+/leaderboard [period]: print the leaderboard for the current period (default monthly) so far for the given server, if configured
+/chart {metric} [range] [user]: draw a chart of the server's activity over the last week, month (default), quarter or year
+/privacy {optout|optin|delete}: stop or resume tracking your stats on the server, or delete the stats already recorded
+/wrapped [user]: show the server's year in review so far, or a single user's
+/achievements [user]: list the achievements you or another user have earned on the server
+/cursed {word|channel|allow} {add|remove|list}: manage the server's cursed words, cursed channels & allowed words (Manage Server only)
+/swearjar [user]: show what you or another user owe the swear jar for cursed words
+/warn {user} {reason}: warn a member, timing them out at the server's warning limits (Timeout Members only)
+/infractions {user}: list a member's warnings & other infractions (Timeout Members only)
+/pardon {id}: pardon an infraction so it no longer counts towards timeouts (Timeout Members only)
 ` +
 	"```"
 
@@ -0,0 +1,280 @@
+package listen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/infractions"
+	"github.com/dmtaylor/costanza/internal/model"
+	"github.com/dmtaylor/costanza/internal/util"
+)
+
+const warnCommandName = "warn"
+const infractionsCommandName = "infractions"
+const pardonCommandName = "pardon"
+const infractionUserOptionName = "user"
+const warnReasonOptionName = "reason"
+const pardonIdOptionName = "id"
+
+// maxListedInfractions keeps /infractions responses under the message length limit
+const maxListedInfractions = 15
+
+var moderateMembersPermission int64 = discordgo.PermissionModerateMembers
+
+var warnSlashCommand = &discordgo.ApplicationCommand{
+	Name:                     warnCommandName,
+	Type:                     discordgo.ChatApplicationCommand,
+	Description:              "Warn a member, timing them out if they reach the server's warning limits",
+	DefaultMemberPermissions: &moderateMembersPermission,
+	Options: []*discordgo.ApplicationCommandOption{
+		{Name: infractionUserOptionName, Description: "Member to warn", Type: discordgo.ApplicationCommandOptionUser, Required: true},
+		{Name: warnReasonOptionName, Description: "Why they're being warned", Type: discordgo.ApplicationCommandOptionString, Required: true},
+	},
+}
+
+var infractionsSlashCommand = &discordgo.ApplicationCommand{
+	Name:                     infractionsCommandName,
+	Type:                     discordgo.ChatApplicationCommand,
+	Description:              "Show a member's warnings & other infractions",
+	DefaultMemberPermissions: &moderateMembersPermission,
+	Options: []*discordgo.ApplicationCommandOption{
+		{Name: infractionUserOptionName, Description: "Member to show infractions for", Type: discordgo.ApplicationCommandOptionUser, Required: true},
+	},
+}
+
+var pardonSlashCommand = &discordgo.ApplicationCommand{
+	Name:                     pardonCommandName,
+	Type:                     discordgo.ChatApplicationCommand,
+	Description:              "Pardon an infraction, so it no longer counts towards timeouts",
+	DefaultMemberPermissions: &moderateMembersPermission,
+	Options: []*discordgo.ApplicationCommandOption{
+		{Name: pardonIdOptionName, Description: "Id of the infraction, from /infractions", Type: discordgo.ApplicationCommandOptionInteger, Required: true},
+	},
+}
+
+func canModerate(member *discordgo.Member) bool {
+	return member != nil && member.Permissions&discordgo.PermissionModerateMembers != 0
+}
+
+// infractionCommand handles /warn, /infractions & /pardon
+func (s *Server) infractionCommand(sess *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	name := i.ApplicationCommandData().Name
+	if name != warnCommandName && name != infractionsCommandName && name != pardonCommandName {
+		return
+	}
+
+	var err error
+	if s.m.enabled {
+		start := time.Now()
+		defer func() {
+			s.m.eventDuration.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: name}).Observe(time.Since(start).Seconds())
+			if err != nil {
+				isTimeout := strconv.FormatBool(errors.Is(err, context.DeadlineExceeded))
+				s.m.eventErrors.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: name, isTimeoutLabel: isTimeout}).Inc()
+			} else {
+				s.m.eventSuccess.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: name}).Inc()
+			}
+		}()
+	}
+	ctx, cancel := util.ContextFromDiscordInteractionCreate(context.Background(), i, interactionTimeout)
+	defer cancel()
+
+	var content string
+	// warnings are posted in the channel so the member sees them, the ledger is only shown to the moderator
+	flags := discordgo.MessageFlagsEphemeral
+	mentions := &discordgo.MessageAllowedMentions{}
+	if _, ok := config.GlobalConfig.Discord.ListenChannelSet[i.GuildID]; !ok {
+		content = "Infractions aren't tracked on this guild"
+	} else if !canModerate(i.Member) {
+		content = "You need the Timeout Members permission to manage infractions"
+	} else {
+		options := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+		for _, option := range i.ApplicationCommandData().Options {
+			options[option.Name] = option
+		}
+		switch name {
+		case warnCommandName:
+			user, reason := options[infractionUserOptionName].Value.(string), options[warnReasonOptionName].StringValue()
+			content, err = s.warnCommand(ctx, sess, i.GuildID, user, i.Member.User.ID, reason)
+			if content != "" { // the warning was recorded, even if timing them out failed
+				flags = 0
+				mentions.Users = []string{user}
+			}
+		case infractionsCommandName:
+			content, err = s.infractionsContent(ctx, i.GuildID, options[infractionUserOptionName].Value.(string))
+		case pardonCommandName:
+			content, err = s.pardonCommand(ctx, sess, i.GuildID, int(options[pardonIdOptionName].IntValue()), i.Member.User.ID)
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to run infraction command: "+err.Error())
+			if content == "" {
+				content = "Failed to update infractions, please try again later"
+			}
+		}
+	}
+	ierr := sess.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         content,
+			Flags:           flags,
+			AllowedMentions: mentions,
+		},
+	})
+	if ierr != nil {
+		slog.ErrorContext(ctx, "failed to send infraction response: "+ierr.Error())
+		err = ierr
+	}
+}
+
+// warnCommand warns the user, timing them out if they've reached one of the guild's escalations, & posts the warning
+// to the moderation log. If the warning is recorded but the timeout fails, the content says so & the error is returned
+// for logging, so the moderator doesn't warn them again.
+func (s *Server) warnCommand(ctx context.Context, sess *discordgo.Session, guild, user, moderator, reason string) (string, error) {
+	id, warnings, escalation, err := s.addWarning(ctx, guild, user, moderator, reason, time.Now())
+	if err != nil {
+		return "", err
+	}
+	content := fmt.Sprintf("⚠️ <@%s> has been warned: %s", user, reason)
+	logContent := fmt.Sprintf("⚠️ <@%s> warned <@%s> (#%d, %d active): %s", moderator, user, id, warnings, reason)
+	var timeoutErr error
+	if escalation != nil {
+		until := time.Now().Add(escalation.Duration())
+		if timeoutErr = sess.GuildMemberTimeout(guild, user, &until); timeoutErr != nil {
+			// the warning's posted publicly, so the error's only returned for logging
+			content += fmt.Sprintf("\nWarning recorded, but timing them out for %s failed", escalation.Duration())
+			logContent += fmt.Sprintf(", timeout for %s failed", escalation.Duration())
+			timeoutErr = fmt.Errorf("failed to time out warned member: %w", timeoutErr)
+		} else {
+			content += fmt.Sprintf("\nThat's %d warnings, so they're timed out for %s", warnings, escalation.Duration())
+			logContent += fmt.Sprintf(", timed out for %s", escalation.Duration())
+		}
+	}
+	if err = s.modLog(ctx, sess, guild, logContent); err != nil {
+		slog.ErrorContext(ctx, "failed to log warning: "+err.Error())
+	}
+	return content, timeoutErr
+}
+
+// addWarning records the warning, returning its id, the user's active warnings & the escalation they've reached, if any
+func (s *Server) addWarning(ctx context.Context, guild, user, moderator, reason string, t time.Time) (int, int, *infractions.Escalation, error) {
+	guildId, err := strconv.ParseUint(guild, 10, 64)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("bad guild id: %w", err)
+	}
+	userId, err := strconv.ParseUint(user, 10, 64)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("bad user id: %w", err)
+	}
+	moderatorId, err := strconv.ParseUint(moderator, 10, 64)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("bad moderator id: %w", err)
+	}
+	id, err := s.app.Infractions.Add(ctx, model.Infraction{
+		GuildId:     guildId,
+		UserId:      userId,
+		ModeratorId: moderatorId,
+		Kind:        infractions.WarningKind,
+		Reason:      reason,
+		CreatedAt:   t.Unix(),
+	})
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	warnings, err := s.app.Infractions.ActiveWarnings(ctx, guildId, userId)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	listenConfig := config.GlobalConfig.Discord.ListenChannelSet[guild]
+	return id, warnings, infractions.EscalationFor(listenConfig.WarnEscalations, warnings), nil
+}
+
+// infractionsContent lists the user's most recent infractions, with pardoned ones struck through
+func (s *Server) infractionsContent(ctx context.Context, guild, user string) (string, error) {
+	guildId, err := strconv.ParseUint(guild, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("bad guild id: %w", err)
+	}
+	userId, err := strconv.ParseUint(user, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("bad user id: %w", err)
+	}
+	ledger, err := s.app.Infractions.ForUser(ctx, guildId, userId)
+	if err != nil {
+		return "", err
+	}
+	if len(ledger) == 0 {
+		return fmt.Sprintf("<@%s> has no infractions", user), nil
+	}
+	warnings := 0
+	for _, infraction := range ledger {
+		if infraction.Kind == infractions.WarningKind && infraction.PardonedBy == 0 {
+			warnings++
+		}
+	}
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("<@%s> has %d infractions, %d active warnings:", user, len(ledger), warnings))
+	for _, infraction := range ledger[:min(len(ledger), maxListedInfractions)] {
+		content.WriteString("\n" + infractionLine(infraction))
+	}
+	if len(ledger) > maxListedInfractions {
+		content.WriteString(fmt.Sprintf("\n…and %d older", len(ledger)-maxListedInfractions))
+	}
+	return content.String(), nil
+}
+
+func infractionLine(infraction model.Infraction) string {
+	var line string
+	switch infraction.Kind {
+	case infractions.WarningKind:
+		line = fmt.Sprintf("⚠️ Warned by <@%d>: %s", infraction.ModeratorId, infraction.Reason)
+	case infractions.CursedKind:
+		line = "🤬 Cursed post: " + infraction.Reason
+	default:
+		line = infraction.Kind + ": " + infraction.Reason
+	}
+	line = fmt.Sprintf("`#%d` <t:%d:d> %s", infraction.Id, infraction.CreatedAt, line)
+	if infraction.PardonedBy != 0 {
+		line = fmt.Sprintf("~~%s~~ (pardoned by <@%d>)", line, infraction.PardonedBy)
+	}
+	return line
+}
+
+// pardonCommand pardons the guild's infraction & posts the pardon to the moderation log
+func (s *Server) pardonCommand(ctx context.Context, sess *discordgo.Session, guild string, id int, moderator string) (string, error) {
+	infraction, err := s.pardon(ctx, guild, id, moderator)
+	if err != nil {
+		return "", err
+	}
+	if infraction == nil {
+		return fmt.Sprintf("There's no unpardoned infraction `#%d`", id), nil
+	}
+	err = s.modLog(ctx, sess, guild, fmt.Sprintf("🕊️ <@%s> pardoned <@%d>'s infraction #%d: %s", moderator, infraction.UserId, id, infraction.Reason))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to log pardon: "+err.Error())
+	}
+	return fmt.Sprintf("Pardoned <@%d>'s infraction `#%d`", infraction.UserId, id), nil
+}
+
+func (s *Server) pardon(ctx context.Context, guild string, id int, moderator string) (*model.Infraction, error) {
+	guildId, err := strconv.ParseUint(guild, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("bad guild id: %w", err)
+	}
+	moderatorId, err := strconv.ParseUint(moderator, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("bad moderator id: %w", err)
+	}
+	return s.app.Infractions.Pardon(ctx, guildId, id, moderatorId)
+}
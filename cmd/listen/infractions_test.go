@@ -0,0 +1,162 @@
+package listen
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/infractions"
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+// testInfractions is an in memory infractions store
+type testInfractions struct {
+	ledger []model.Infraction
+	err    error // returned when adding infractions
+}
+
+func (l *testInfractions) Add(_ context.Context, infraction model.Infraction) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+	infraction.Id = len(l.ledger) + 1
+	l.ledger = append(l.ledger, infraction)
+	return infraction.Id, nil
+}
+
+func (l *testInfractions) ForUser(_ context.Context, guildId, userId uint64) ([]model.Infraction, error) {
+	var found []model.Infraction
+	for _, infraction := range slices.Backward(l.ledger) {
+		if infraction.GuildId == guildId && infraction.UserId == userId {
+			found = append(found, infraction)
+		}
+	}
+	return found, nil
+}
+
+func (l *testInfractions) Pardon(_ context.Context, guildId uint64, id int, moderatorId uint64) (*model.Infraction, error) {
+	for i := range l.ledger {
+		if l.ledger[i].Id == id && l.ledger[i].GuildId == guildId && l.ledger[i].PardonedBy == 0 {
+			l.ledger[i].PardonedBy = moderatorId
+			pardoned := l.ledger[i]
+			return &pardoned, nil
+		}
+	}
+	return nil, nil
+}
+
+func (l *testInfractions) ActiveWarnings(_ context.Context, guildId, userId uint64) (int, error) {
+	count := 0
+	for _, infraction := range l.ledger {
+		if infraction.GuildId == guildId && infraction.UserId == userId && infraction.Kind == infractions.WarningKind && infraction.PardonedBy == 0 {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func TestCanModerate(t *testing.T) {
+	assert.False(t, canModerate(nil))
+	assert.False(t, canModerate(&discordgo.Member{Permissions: discordgo.PermissionManageServer}))
+	assert.True(t, canModerate(&discordgo.Member{Permissions: discordgo.PermissionModerateMembers}))
+}
+
+func TestServer_addWarning(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, config.ListenConfig{GuildId: "100", WarnEscalations: []infractions.Escalation{
+		{Warnings: 2, Timeout: "1h"},
+		{Warnings: 4, Timeout: "24h"},
+	}})
+	ledger := s.app.Infractions.(*testInfractions)
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	wantTimeouts := []time.Duration{0, time.Hour, time.Hour, 24 * time.Hour}
+	for i, want := range wantTimeouts {
+		id, warnings, escalation, err := s.addWarning(ctx, "100", "200", "300", "spam", now)
+		require.NoError(t, err)
+		assert.Equal(t, i+1, id)
+		assert.Equal(t, i+1, warnings)
+		if want == 0 {
+			assert.Nil(t, escalation, "escalation at %d warnings", warnings)
+		} else {
+			require.NotNil(t, escalation, "no escalation at %d warnings", warnings)
+			assert.Equal(t, want, escalation.Duration())
+		}
+	}
+	assert.Equal(t, model.Infraction{Id: 1, GuildId: 100, UserId: 200, ModeratorId: 300, Kind: infractions.WarningKind, Reason: "spam", CreatedAt: now.Unix()}, ledger.ledger[0])
+
+	_, _, _, err := s.addWarning(ctx, "100", "someone", "300", "spam", now)
+	assert.Error(t, err)
+}
+
+func TestServer_warnCommand(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, config.ListenConfig{GuildId: "100", WarnEscalations: []infractions.Escalation{{Warnings: 1, Timeout: "1h"}}})
+	transport := &testTransport{}
+	sess := newRecordingSession(transport)
+
+	got, err := s.warnCommand(ctx, sess, "100", "200", "300", "spam")
+	require.NoError(t, err)
+	assert.Equal(t, "⚠️ <@200> has been warned: spam\nThat's 1 warnings, so they're timed out for 1h0m0s", got)
+
+	// the warning is still reported when the timeout fails, so it isn't given again
+	transport.fail = map[string]bool{"PATCH /guilds/100/members/201": true}
+	got, err = s.warnCommand(ctx, sess, "100", "201", "300", "spam")
+	assert.ErrorContains(t, err, "failed to time out warned member")
+	assert.Equal(t, "⚠️ <@201> has been warned: spam\nWarning recorded, but timing them out for 1h0m0s failed", got,
+		"error detail posted publicly")
+	assert.Len(t, s.app.Infractions.(*testInfractions).ledger, 2)
+}
+
+func TestServer_infractionsContent(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, config.ListenConfig{GuildId: "100"})
+	ledger := s.app.Infractions.(*testInfractions)
+	ledger.ledger = []model.Infraction{
+		{Id: 1, GuildId: 100, UserId: 200, ModeratorId: 300, Kind: infractions.WarningKind, Reason: "spam", CreatedAt: 1700000000, PardonedBy: 301},
+		{Id: 2, GuildId: 100, UserId: 200, Kind: infractions.CursedKind, Reason: "posted `heck` in <#400>", CreatedAt: 1700000100},
+		{Id: 3, GuildId: 101, UserId: 200, ModeratorId: 300, Kind: infractions.WarningKind, Reason: "other guild", CreatedAt: 1700000200},
+		{Id: 4, GuildId: 100, UserId: 200, ModeratorId: 300, Kind: infractions.WarningKind, Reason: "rude", CreatedAt: 1700000300},
+	}
+
+	got, err := s.infractionsContent(ctx, "100", "200")
+	require.NoError(t, err)
+	assert.Equal(t, "<@200> has 3 infractions, 1 active warnings:\n"+
+		"`#4` <t:1700000300:d> ⚠️ Warned by <@300>: rude\n"+
+		"`#2` <t:1700000100:d> 🤬 Cursed post: posted `heck` in <#400>\n"+
+		"~~`#1` <t:1700000000:d> ⚠️ Warned by <@300>: spam~~ (pardoned by <@301>)", got)
+	got, err = s.infractionsContent(ctx, "100", "201")
+	require.NoError(t, err)
+	assert.Equal(t, "<@201> has no infractions", got)
+
+	for range maxListedInfractions {
+		_, err = ledger.Add(ctx, model.Infraction{GuildId: 100, UserId: 200, Kind: infractions.CursedKind, Reason: "posted `heck`"})
+		require.NoError(t, err)
+	}
+	got, err = s.infractionsContent(ctx, "100", "200")
+	require.NoError(t, err)
+	assert.Contains(t, got, "\n…and 3 older")
+}
+
+func TestServer_pardonCommand(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, config.ListenConfig{GuildId: "100"})
+	ledger := s.app.Infractions.(*testInfractions)
+	ledger.ledger = []model.Infraction{{Id: 1, GuildId: 100, UserId: 200, ModeratorId: 300, Kind: infractions.WarningKind, Reason: "spam"}}
+
+	got, err := s.pardonCommand(ctx, nil, "100", 1, "301")
+	require.NoError(t, err)
+	assert.Equal(t, "Pardoned <@200>'s infraction `#1`", got)
+	assert.Equal(t, uint64(301), ledger.ledger[0].PardonedBy)
+	got, err = s.pardonCommand(ctx, nil, "100", 1, "301")
+	require.NoError(t, err)
+	assert.Equal(t, "There's no unpardoned infraction `#1`", got)
+	got, err = s.pardonCommand(ctx, nil, "101", 1, "301")
+	require.NoError(t, err)
+	assert.Equal(t, "There's no unpardoned infraction `#1`", got, "other guild's infraction pardoned")
+}
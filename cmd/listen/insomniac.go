@@ -32,7 +32,7 @@ func (s *Server) echoInsomniac(sess *discordgo.Session, m *discordgo.MessageCrea
 	}
 	ctx := util.ContextFromDiscordMessageCreate(context.Background(), m)
 
-	if isAfterHours(ctx) && s.isInsomniacUser(ctx, m.Author, m.Member) {
+	if isAfterHours(ctx, time.Now().In(config.GuildLocation(m.GuildID))) && s.isInsomniacUser(ctx, m.Author, m.Member) {
 		callStart := time.Now()
 		_, err := sess.ChannelMessageSendReply(
 			m.ChannelID,
@@ -83,7 +83,8 @@ func (s *Server) isInsomniacUser(ctx context.Context, user *discordgo.User, memb
 
 }
 
-func isAfterHours(ctx context.Context) bool {
+// isAfterHours checks if now falls in the late night window. now should be in the guild's timezone
+func isAfterHours(ctx context.Context, now time.Time) bool {
 	var err error
 	timeLoader.Do(func() {
 		startLateHours, err = time.Parse(time.Kitchen, "12:30AM")
@@ -97,7 +98,7 @@ func isAfterHours(ctx context.Context) bool {
 			panic(err)
 		}
 	})
-	currentTime, err := time.Parse(time.Kitchen, time.Now().Format(time.Kitchen))
+	currentTime, err := time.Parse(time.Kitchen, now.Format(time.Kitchen))
 	if err != nil {
 		slog.WarnContext(ctx, "failed to parse current time: "+err.Error())
 		return false
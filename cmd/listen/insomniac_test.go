@@ -0,0 +1,34 @@
+package listen
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_isAfterHours(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	require.NoError(t, err, "failed to load timezone")
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{"late_night", time.Date(2024, 3, 5, 2, 15, 0, 0, time.UTC), true},
+		{"afternoon", time.Date(2024, 3, 5, 14, 0, 0, 0, time.UTC), false},
+		{"before_window", time.Date(2024, 3, 5, 0, 15, 0, 0, time.UTC), false},
+		{"after_window", time.Date(2024, 3, 5, 6, 30, 0, 0, time.UTC), false},
+		// 08:00 UTC is 02:00 in Chicago
+		{"guild_timezone", time.Date(2024, 3, 5, 8, 0, 0, 0, time.UTC).In(chicago), true},
+		// 02:00 UTC is still the previous evening in Chicago
+		{"guild_timezone_evening", time.Date(2024, 3, 5, 2, 0, 0, 0, time.UTC).In(chicago), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isAfterHours(context.Background(), tt.now))
+		})
+	}
+}
@@ -2,6 +2,7 @@
 package listen
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -9,13 +10,16 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/stats"
 	"github.com/dmtaylor/costanza/internal/util"
 )
 
@@ -28,9 +32,21 @@ var Cmd = &cobra.Command{
 	Example: "costanza listen -i \"1234,2345\" -r \"9876,8765\"",
 }
 
+// statsDrainTimeout max time to wait for buffered stats to be written on shutdown
+const statsDrainTimeout = time.Second * 10
+
+// reactionMessageCacheSize number of recent messages kept per channel for looking up reacted message authors
+const reactionMessageCacheSize = 200
+
 type Server struct {
-	app config.App
-	m   metrics
+	app           config.App
+	m             metrics
+	starboardLock sync.Mutex
+	// containmentHooks has the webhook for each channel contained posts are moved to
+	containmentHooks sync.Map
+	// slowmodes has the timer turning off each channel's protection slowmode
+	slowmodes    map[string]*time.Timer
+	slowmodeLock sync.Mutex
 }
 
 func init() {
@@ -98,6 +114,26 @@ func runListen(_ *cobra.Command, _ []string) error {
 		return err
 	}
 	defer server.app.ConnPool.Close()
+	listenCtx, stopListening := context.WithCancel(context.Background())
+	defer stopListening() // before the pool is closed
+	go server.app.CacheListener.Listen(listenCtx)
+	if config.GlobalConfig.Db.StatsFlushSeconds > 0 {
+		flushInterval := time.Duration(config.GlobalConfig.Db.StatsFlushSeconds) * time.Second
+		if pgStats, ok := server.app.Stats.(*stats.Stats); ok {
+			pgStats.StartWriteBuffer(flushInterval)
+		}
+		server.app.Achievements.StartWriteBuffer(flushInterval)
+	}
+	defer func() { // drain buffered stats before the pool is closed
+		ctx, cancel := context.WithTimeout(context.Background(), statsDrainTimeout)
+		defer cancel()
+		if err := server.app.Stats.Close(ctx); err != nil {
+			slog.Error("failed to flush buffered stats: " + err.Error())
+		}
+		if err := server.app.Achievements.Close(ctx); err != nil {
+			slog.Error("failed to flush achievement progress: " + err.Error())
+		}
+	}()
 
 	dg, err := discordgo.New("Bot " + config.GlobalConfig.Discord.Token)
 	if err != nil {
@@ -105,6 +141,9 @@ func runListen(_ *cobra.Command, _ []string) error {
 		return err
 	}
 
+	// Keep recent messages so reactions can be credited to their author without an API call
+	dg.State.MaxMessageCount = reactionMessageCacheSize
+
 	// Set shard info in discord ws connection
 	if config.GlobalConfig.Discord.ShardCount > 1 {
 		dg.ShardID = int(config.GlobalConfig.Discord.ShardId)
@@ -138,15 +177,38 @@ func runListen(_ *cobra.Command, _ []string) error {
 	dg.AddHandler(server.messageCreateMetricsMiddleware(server.echoInsomniac))
 	dg.AddHandler(server.interactionCreateMetricsMiddleware(server.dispatchRollCommands))
 	dg.AddHandler(server.messageCreateMetricsMiddleware(server.dailyGameHandler))
+	dg.AddHandler(server.messageCreateMetricsMiddleware(server.dailyTimedGameHandler))
 	dg.AddHandler(server.messageCreateMetricsMiddleware(server.logMessageActivity))
 	dg.AddHandler(server.interactionCreateMetricsMiddleware(server.weatherCommand))
 	dg.AddHandler(server.guildMemberAddMetricsMiddleware(server.welcomeMessage))
+	dg.AddHandler(server.guildMemberAddMetricsMiddleware(server.protectJoins))
 	dg.AddHandler(server.messageReactionAddMetricsMiddleware(server.logReactionActivity))
+	dg.AddHandler(server.messageReactionRemoveMetricsMiddleware(server.logReactionRemoval))
+	dg.AddHandler(server.messageReactionAddMetricsMiddleware(server.starboardReactionAdd))
+	dg.AddHandler(server.messageReactionRemoveMetricsMiddleware(server.starboardReactionRemove))
 	dg.AddHandler(server.interactionCreateMetricsMiddleware(server.getLeaderboardStats))
+	dg.AddHandler(server.interactionCreateMetricsMiddleware(server.changeLeaderboardPage))
+	dg.AddHandler(server.interactionCreateMetricsMiddleware(server.chartCommand))
+	dg.AddHandler(server.interactionCreateMetricsMiddleware(server.privacyCommand))
+	dg.AddHandler(server.interactionCreateMetricsMiddleware(server.wrappedCommand))
+	dg.AddHandler(server.interactionCreateMetricsMiddleware(server.achievementsCommand))
+	dg.AddHandler(server.interactionCreateMetricsMiddleware(server.cursedCommand))
+	dg.AddHandler(server.interactionCreateMetricsMiddleware(server.swearJarCommand))
+	dg.AddHandler(server.interactionCreateMetricsMiddleware(server.infractionCommand))
 	dg.AddHandler(server.messageCreateMetricsMiddleware(server.logCursedChannelStat))
 	dg.AddHandler(server.messageCreateMetricsMiddleware(server.logCursedPostStat))
+	dg.AddHandler(server.messageCreateMetricsMiddleware(server.enforceContainment))
+	dg.AddHandler(server.messageCreateMetricsMiddleware(server.protectMessages))
+	dg.AddHandler(server.messageCreateMetricsMiddleware(server.auditMessageCreate))
+	dg.AddHandler(server.messageUpdateMetricsMiddleware(server.auditMessageUpdate))
+	dg.AddHandler(server.messageDeleteMetricsMiddleware(server.auditMessageDelete))
+	dg.AddHandler(server.guildMemberAddMetricsMiddleware(server.auditMemberAdd))
+	dg.AddHandler(server.guildMemberRemoveMetricsMiddleware(server.auditMemberRemove))
+	dg.AddHandler(server.guildMemberUpdateMetricsMiddleware(server.auditMemberUpdate))
 	// dg.AddHandler(server.interactionCreateMetricsMiddleware(server.quoteTestCommand)) // Uncomment this to add test quote command handler
-	dg.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentsDirectMessages | discordgo.IntentsGuildMembers | discordgo.IntentsGuildMessageReactions
+	// Guilds keeps members in the state, so audit log role changes can be worked out, & MessageContent shows edits
+	dg.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildMessages | discordgo.IntentsDirectMessages | discordgo.IntentsGuildMembers |
+		discordgo.IntentsGuildMessageReactions | discordgo.IntentMessageContent
 
 	err = dg.Open()
 	if err != nil {
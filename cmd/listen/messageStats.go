@@ -5,8 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strconv"
-	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -14,21 +14,39 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/achievements"
 	"github.com/dmtaylor/costanza/internal/stats"
 	"github.com/dmtaylor/costanza/internal/util"
 )
 
 const logActivityMetricEventName = "logActivity"
 const logReactionMetricEventName = "logReaction"
+const logReactionRemoveMetricEventName = "logReactionRemove"
 
 const leaderboardCommandName = "leaderboard"
-
-const reportCount = 5 // update this count for number of reports pulled
+const leaderboardPeriodOptionName = "period"
 
 var leaderboardSlashCommand = &discordgo.ApplicationCommand{
 	Name:        leaderboardCommandName,
 	Type:        discordgo.ChatApplicationCommand,
 	Description: "Get the current guild leaderboard standings",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:        leaderboardPeriodOptionName,
+			Description: "Report period to show, defaults to monthly",
+			Type:        discordgo.ApplicationCommandOptionString,
+			Required:    false,
+			Choices:     periodChoices(),
+		},
+	},
+}
+
+func periodChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, len(stats.Periods))
+	for i, period := range stats.Periods {
+		choices[i] = &discordgo.ApplicationCommandOptionChoice{Name: string(period), Value: string(period)}
+	}
+	return choices
 }
 
 func (s *Server) logMessageActivity(sess *discordgo.Session, m *discordgo.MessageCreate) {
@@ -69,27 +87,98 @@ func (s *Server) logMessageActivity(sess *discordgo.Session, m *discordgo.Messag
 			slog.ErrorContext(ctx, "error logging activity: "+err.Error())
 			return
 		}
-		err = s.app.Stats.LogActivity(ctx, guildId, userId, m.Timestamp.Format("2006-01"))
+		channelId, err := strconv.ParseUint(m.ChannelID, 10, 64)
+		if err != nil {
+			slog.ErrorContext(ctx, "error logging activity: "+err.Error())
+			return
+		}
+		err = s.logCounter(ctx, stats.Counter{
+			Metric:    stats.MessagesMetric,
+			GuildId:   guildId,
+			UserId:    userId,
+			ChannelId: channelId,
+			Increment: 1,
+		}, m.Timestamp)
 		if err != nil {
 			slog.ErrorContext(ctx, "error creating activity log: "+err.Error())
 			return
 		}
+		err = s.checkAchievements(ctx, sess, achievements.Event{
+			Type:    achievements.MessageEvent,
+			GuildId: guildId,
+			UserId:  userId,
+			Time:    m.Timestamp,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "error checking achievements: "+err.Error())
+			return
+		}
 	}
 	if s.m.enabled {
 		s.m.eventSuccess.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: logActivityMetricEventName}).Inc()
 	}
 }
 
-func (s *Server) logReactionActivity(sess *discordgo.Session, r *discordgo.MessageReactionAdd) {
-	// Don't log bot reactions
-	if r.UserID == sess.State.User.ID {
-		return
+// logCounter increments the counter for each report period enabled for the guild, using the periods containing t, &
+// the day containing t for charts. Counters for users who opted out of tracking are skipped.
+func (s *Server) logCounter(ctx context.Context, counter stats.Counter, t time.Time) error {
+	return s.logCounterSince(ctx, counter, t, t)
+}
+
+// logCounterSince increments the counter like logCounter, but only for the periods & day containing both since & t.
+// Removals of earlier activity are only taken from periods the activity could have been counted in, so later periods
+// don't go negative.
+func (s *Server) logCounterSince(ctx context.Context, counter stats.Counter, since time.Time, t time.Time) error {
+	optedOut, e := s.isOptedOut(ctx, counter.GuildId, counter.UserId)
+	if e != nil || optedOut {
+		return e
 	}
-	if r.Member != nil && r.Member.User != nil && r.Member.User.Bot {
-		return
+	var err *multierror.Error
+	for _, key := range sharedPeriodKeys(strconv.FormatUint(counter.GuildId, 10), since, t) {
+		counter.Period = key
+		err = multierror.Append(err, s.app.Stats.LogCounter(ctx, counter))
 	}
-	// Only log stats if channel included in configs
-	if _, found := config.GlobalConfig.Discord.ListenChannelSet[r.GuildID]; !found {
+	location := config.GuildLocation(strconv.FormatUint(counter.GuildId, 10))
+	if stats.DayKey(since.In(location)) == stats.DayKey(t.In(location)) {
+		err = multierror.Append(err, s.logDayCounter(ctx, counter, t))
+	}
+	return err.ErrorOrNil()
+}
+
+// sharedPeriodKeys gets the keys from periodKeys for t that also contain since
+func sharedPeriodKeys(guildId string, since time.Time, t time.Time) []string {
+	sinceKeys := periodKeys(guildId, since)
+	return slices.DeleteFunc(periodKeys(guildId, t), func(key string) bool {
+		return !slices.Contains(sinceKeys, key)
+	})
+}
+
+// logDayCounter increments the counter for the day containing t in the guild's timezone. Days aren't broken down by
+// channel to keep the number of rows down.
+func (s *Server) logDayCounter(ctx context.Context, counter stats.Counter, t time.Time) error {
+	counter.Period = stats.DayKey(t.In(config.GuildLocation(strconv.FormatUint(counter.GuildId, 10))))
+	counter.ChannelId = 0
+	return s.app.Stats.LogCounter(ctx, counter)
+}
+
+// periodKeys gets the keys for each report period enabled for the guild containing t, in the guild's timezone. The
+// month is always included, as monthly stats are rolled up for the year's Wrapped.
+func periodKeys(guildId string, t time.Time) []string {
+	listenConfig := config.GlobalConfig.Discord.ListenChannelSet[guildId]
+	local := t.In(listenConfig.Location())
+	periods := listenConfig.ReportPeriods()
+	if !slices.Contains(periods, stats.MonthlyPeriod) {
+		periods = append(slices.Clone(periods), stats.MonthlyPeriod)
+	}
+	keys := make([]string, len(periods))
+	for i, period := range periods {
+		keys[i] = period.Key(local)
+	}
+	return keys
+}
+
+func (s *Server) logReactionActivity(sess *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	if r.Member != nil && r.Member.User != nil && r.Member.User.Bot {
 		return
 	}
 	if s.m.enabled {
@@ -98,31 +187,136 @@ func (s *Server) logReactionActivity(sess *discordgo.Session, r *discordgo.Messa
 			s.m.eventDuration.With(prometheus.Labels{gatewayEventTypeLabel: messageReactionAddGatewayEvent, eventNameLabel: logReactionMetricEventName}).Observe(time.Since(start).Seconds())
 		}()
 	}
-	var err error
-	defer func() {
-		if err != nil && s.m.enabled {
+	ctx := util.ContextFromDiscordReactionAdd(context.Background(), r)
+	logged, err := s.logReaction(ctx, sess, r.MessageReaction, 1)
+	if err != nil {
+		slog.ErrorContext(ctx, "error creating activity log: "+err.Error())
+		if s.m.enabled {
 			s.m.eventErrors.With(prometheus.Labels{gatewayEventTypeLabel: messageReactionAddGatewayEvent, eventNameLabel: logReactionMetricEventName, isTimeoutLabel: "false"}).Inc()
 		}
-	}()
-	ctx := util.ContextFromDiscordReactionAdd(context.Background(), r)
+	} else if logged && s.m.enabled {
+		s.m.eventSuccess.With(prometheus.Labels{gatewayEventTypeLabel: messageReactionAddGatewayEvent, eventNameLabel: logReactionMetricEventName}).Inc()
+	}
+}
+
+// logReactionRemoval reverses the stats for a removed reaction
+func (s *Server) logReactionRemoval(sess *discordgo.Session, r *discordgo.MessageReactionRemove) {
+	if s.m.enabled {
+		start := time.Now()
+		defer func() {
+			s.m.eventDuration.With(prometheus.Labels{gatewayEventTypeLabel: messageReactionRemoveGatewayEvent, eventNameLabel: logReactionRemoveMetricEventName}).Observe(time.Since(start).Seconds())
+		}()
+	}
+	// the remove event has no member, so bots are looked up in the state
+	if member, err := sess.State.Member(r.GuildID, r.UserID); err == nil && member.User != nil && member.User.Bot {
+		return
+	}
+	ctx := util.ContextFromDiscordReactionRemove(context.Background(), r)
+	logged, err := s.logReaction(ctx, sess, r.MessageReaction, -1)
+	if err != nil {
+		slog.ErrorContext(ctx, "error removing activity log: "+err.Error())
+		if s.m.enabled {
+			s.m.eventErrors.With(prometheus.Labels{gatewayEventTypeLabel: messageReactionRemoveGatewayEvent, eventNameLabel: logReactionRemoveMetricEventName, isTimeoutLabel: "false"}).Inc()
+		}
+	} else if logged && s.m.enabled {
+		s.m.eventSuccess.With(prometheus.Labels{gatewayEventTypeLabel: messageReactionRemoveGatewayEvent, eventNameLabel: logReactionRemoveMetricEventName}).Inc()
+	}
+}
+
+// logReaction applies increment to the reactions given by the user, & to the reactions received by the message's
+// author, the message & the emoji. Reactions to the bot's or your own messages only count as given, & reactions to
+// messages from users who opted out of tracking aren't counted as received. The author is only looked up in the state's
+// message cache, as fetching every reacted message would hit rate limits, so reactions to older messages only count as
+// given too. Removals are only taken from periods containing the message's post time, as the reaction can't have been
+// counted in earlier ones. Returns false if the reaction isn't tracked.
+func (s *Server) logReaction(ctx context.Context, sess *discordgo.Session, r *discordgo.MessageReaction, increment int) (bool, error) {
+	// Don't log bot reactions
+	if r.UserID == sess.State.User.ID {
+		return false, nil
+	}
+	// Only log stats if channel included in configs
+	if _, found := config.GlobalConfig.Discord.ListenChannelSet[r.GuildID]; !found {
+		return false, nil
+	}
 	guildId, err := strconv.ParseUint(r.GuildID, 10, 64)
 	if err != nil {
-		slog.ErrorContext(ctx, "error logging activity: "+err.Error())
-		return
+		return false, fmt.Errorf("bad guild id: %w", err)
 	}
 	userId, err := strconv.ParseUint(r.UserID, 10, 64)
 	if err != nil {
-		slog.ErrorContext(ctx, "error logging activity: "+err.Error())
-		return
+		return false, fmt.Errorf("bad user id: %w", err)
 	}
-	err = s.app.Stats.LogReaction(ctx, guildId, userId, time.Now().Format("2006-01"))
+	// everything's looked up before any counter is written, so a failure can't leave only one of them updated
+	received, err := s.receivedReaction(ctx, sess, r, guildId)
 	if err != nil {
-		slog.ErrorContext(ctx, "error creating activity log: "+err.Error())
-	} else {
-		if s.m.enabled {
-			s.m.eventSuccess.With(prometheus.Labels{gatewayEventTypeLabel: messageReactionAddGatewayEvent, eventNameLabel: logReactionMetricEventName}).Inc()
+		return false, err
+	}
+	now := time.Now()
+	since := now
+	if increment < 0 {
+		if since, err = discordgo.SnowflakeTimestamp(r.MessageID); err != nil {
+			return false, fmt.Errorf("bad message id: %w", err)
 		}
 	}
+	err = s.logCounterSince(ctx, stats.Counter{
+		Metric:    stats.ReactionsMetric,
+		GuildId:   guildId,
+		UserId:    userId,
+		Increment: increment,
+	}, since, now)
+	if err != nil {
+		return false, err
+	}
+	if received == nil {
+		return true, nil
+	}
+	var merr *multierror.Error
+	merr = multierror.Append(merr, s.logCounterSince(ctx, stats.Counter{
+		Metric:    stats.ReactionsReceivedMetric,
+		GuildId:   guildId,
+		UserId:    received.AuthorId,
+		ChannelId: received.ChannelId,
+		Increment: increment,
+	}, since, now))
+	for _, key := range sharedPeriodKeys(r.GuildID, since, now) {
+		reaction := *received
+		reaction.Period = key
+		reaction.Increment = increment
+		merr = multierror.Append(merr, s.app.Stats.LogReaction(ctx, reaction))
+	}
+	return true, merr.ErrorOrNil()
+}
+
+// receivedReaction gets the reaction to count as received by the message's author, or nil if it isn't counted because
+// the message isn't in the state's cache, it's the bot's or the reactor's own, or the author opted out of tracking
+func (s *Server) receivedReaction(ctx context.Context, sess *discordgo.Session, r *discordgo.MessageReaction, guildId uint64) (*stats.Reaction, error) {
+	message, err := sess.State.Message(r.ChannelID, r.MessageID)
+	if err != nil || message.Author == nil || message.Author.Bot || message.Author.ID == r.UserID {
+		return nil, nil
+	}
+	authorId, err := strconv.ParseUint(message.Author.ID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("bad author id: %w", err)
+	}
+	channelId, err := strconv.ParseUint(r.ChannelID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("bad channel id: %w", err)
+	}
+	messageId, err := strconv.ParseUint(r.MessageID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("bad message id: %w", err)
+	}
+	optedOut, err := s.isOptedOut(ctx, guildId, authorId)
+	if err != nil || optedOut {
+		return nil, err
+	}
+	return &stats.Reaction{
+		GuildId:   guildId,
+		ChannelId: channelId,
+		MessageId: messageId,
+		AuthorId:  authorId,
+		Emoji:     r.Emoji.MessageFormat(),
+	}, nil
 }
 
 func (s *Server) getLeaderboardStats(sess *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -147,7 +341,8 @@ func (s *Server) getLeaderboardStats(sess *discordgo.Session, i *discordgo.Inter
 	defer cancel()
 
 	// if guild isn't configured to listen, send message saying so
-	if _, ok := config.GlobalConfig.Discord.ListenChannelSet[i.GuildID]; !ok {
+	listenConfig, ok := config.GlobalConfig.Discord.ListenChannelSet[i.GuildID]
+	if !ok {
 		err = sess.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
@@ -159,6 +354,19 @@ func (s *Server) getLeaderboardStats(sess *discordgo.Session, i *discordgo.Inter
 		}
 		return
 	}
+	period := leaderboardPeriod(i.ApplicationCommandData().Options, listenConfig.ReportPeriods())
+	if !slices.Contains(listenConfig.ReportPeriods(), period) {
+		err = sess.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("The %s leaderboard isn't enabled on this guild. Please reach out to admin to enable", period),
+			},
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to send disabled period response: "+err.Error())
+		}
+		return
+	}
 	err = sess.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{},
@@ -174,111 +382,161 @@ func (s *Server) getLeaderboardStats(sess *discordgo.Session, i *discordgo.Inter
 		slog.ErrorContext(ctx, "bad guild id: "+err.Error())
 		return
 	}
-	var wg sync.WaitGroup
-	errs := make(chan error, reportCount)
-	var merr *multierror.Error
-	wg.Add(reportCount) // TODO update this value for number of stats to be pulled
-	go func() {
-		wg.Wait()
-		close(errs)
-	}()
-	go func() { // Messages stats
-		defer wg.Done()
-		messageStats, ierr := s.app.Stats.GetLeaders(ctx, guildId, time.Now().Format("2006-01"))
-		if ierr != nil {
-			errs <- ierr
-			return
-		}
-		if len(messageStats) < 1 {
-			return
-		}
-		msg := stats.BuildMessageReport(messageStats)
-		_, ierr = sess.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
-			Content: msg,
-		})
-		if ierr != nil {
-			errs <- ierr
-		}
-	}()
-	go func() { // Daily game stats
-		defer wg.Done()
-		gameStats, ierr := s.app.Stats.GetDailyGameLeaders(ctx, guildId, time.Now().Format("2006-01"))
-		if ierr != nil {
-			errs <- ierr
-			return
-		}
-		if len(gameStats) < 1 {
-			return
-		}
-		msg := stats.BuildGameWinReport(gameStats)
-		_, ierr = sess.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
-			Content: msg,
-		})
-		if ierr != nil {
-			errs <- ierr
-		}
-	}()
-	go func() { // Reaction score report
-		defer wg.Done()
-		scores, ierr := s.app.Stats.GetReactionLeadersForMonth(ctx, guildId, time.Now().Format("2006-01"))
-		if ierr != nil {
-			errs <- ierr
-			return
-		}
-		if len(scores) < 1 {
-			return
-		}
-		msg := stats.BuildReactionScoreReport(scores)
-		_, ierr = sess.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
-			Content: msg,
+	exclude, err := s.app.OptOutCache.Get(ctx, guildId)
+	if err != nil {
+		// don't risk showing users who opted out
+		slog.ErrorContext(ctx, "failed to get opted out users: "+err.Error())
+		_, ierr := sess.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
+			Content: "Failed to get the leaderboard, please try again later",
 		})
 		if ierr != nil {
-			errs <- ierr
-		}
-	}()
-	go func() {
-		defer wg.Done()
-		containedUsers, ierr := s.app.Stats.GetTopCursedChannelPosters(ctx, guildId, time.Now().Format("2006-01"))
-		if ierr != nil {
-			errs <- ierr
-			return
+			slog.ErrorContext(ctx, "failed to send leaderboard error: "+ierr.Error())
 		}
-		if len(containedUsers) < 1 {
-			return
-		}
-		msg := stats.BuildCursedChannelPostReport(containedUsers)
-		_, ierr = sess.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
-			Content: msg,
+		return
+	}
+	query := stats.LeaderboardQuery{
+		GuildId:   guildId,
+		Period:    period,
+		PeriodKey: period.Key(time.Now().In(listenConfig.Location())),
+		Limit:     listenConfig.LeaderboardLimit(),
+		Exclude:   exclude,
+	}
+	sections, buildErr := stats.BuildLeaderboard(ctx, s.app.Stats, query, stats.FilterSections(listenConfig.SectionEnabled))
+	var merr *multierror.Error
+	if buildErr != nil {
+		slog.ErrorContext(ctx, "failed to pull stat: "+buildErr.Error())
+		merr = multierror.Append(merr, buildErr)
+	}
+	if len(sections) == 0 {
+		_, ierr := sess.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
+			Content: fmt.Sprintf("No stats have been recorded for this %s yet", period.Noun()),
 		})
 		if ierr != nil {
-			errs <- ierr
-		}
-	}()
-	go func() {
-		defer wg.Done()
-		cursedWordPosts, ierr := s.app.Stats.GetTopCursedPosters(ctx, guildId, time.Now().Format("2006-01"))
-		if ierr != nil {
-			errs <- ierr
-			return
+			slog.ErrorContext(ctx, "failed to send empty leaderboard: "+ierr.Error())
+			merr = multierror.Append(merr, ierr)
 		}
-		if len(cursedWordPosts) < 1 {
-			return
-		}
-		msg := stats.BuildCursedPostReport(cursedWordPosts)
-		_, ierr = sess.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
-			Content: msg,
-		})
-		if ierr != nil {
-			errs <- ierr
+		err = merr.ErrorOrNil()
+		return
+	}
+	page := stats.LeaderboardPage{Period: period, PeriodKey: query.PeriodKey}
+	pages := stats.RenderEmbeds(stats.LeaderboardTitle(period, query.PeriodKey), sections)
+	_, ierr := sess.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
+		Embeds:     pages[0:1],
+		Components: stats.PageButtons(page, len(pages)),
+	})
+	if ierr != nil {
+		slog.WarnContext(ctx, "failed to send leaderboard embed, falling back to text: "+ierr.Error())
+		for _, msg := range stats.RenderText(sections) {
+			_, ierr = sess.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
+				Content: msg,
+			})
+			if ierr != nil {
+				slog.ErrorContext(ctx, "failed to send stat: "+ierr.Error())
+				merr = multierror.Append(merr, ierr)
+			}
 		}
-	}()
+	}
+	err = merr.ErrorOrNil()
+}
 
-	for e := range errs {
-		slog.ErrorContext(ctx, "failed to pull stat: "+e.Error())
-		merr = multierror.Append(merr, e)
+// changeLeaderboardPage handles the leaderboard page buttons by rebuilding the leaderboard & showing the requested
+// page in place. Once the period's stats are cleaned up the buttons are removed, leaving the page that was showing.
+func (s *Server) changeLeaderboardPage(sess *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionMessageComponent {
+		return
 	}
-	if merr != nil {
-		err = merr.ErrorOrNil()
+	page, err := stats.ParseLeaderboardPage(i.MessageComponentData().CustomID)
+	if errors.Is(err, stats.ErrNotPageButton) {
+		return
+	}
+	ctx, cancel := util.ContextFromDiscordInteractionCreate(context.Background(), i, interactionTimeout)
+	defer cancel()
+	if err != nil {
+		slog.WarnContext(ctx, "bad leaderboard page button: "+err.Error())
+		return
+	}
+	listenConfig, ok := config.GlobalConfig.Discord.ListenChannelSet[i.GuildID]
+	if !ok {
+		return
+	}
+	guildId, err := strconv.ParseUint(i.GuildID, 10, 64)
+	if err != nil {
+		slog.ErrorContext(ctx, "bad guild id: "+err.Error())
+		return
+	}
+	exclude, err := s.app.OptOutCache.Get(ctx, guildId)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get opted out users: "+err.Error())
+		leaderboardPageFailed(ctx, sess, i)
+		return
 	}
+	query := stats.LeaderboardQuery{
+		GuildId:   guildId,
+		Period:    page.Period,
+		PeriodKey: page.PeriodKey,
+		Limit:     listenConfig.LeaderboardLimit(),
+		Exclude:   exclude,
+	}
+	sections, err := stats.BuildLeaderboard(ctx, s.app.Stats, query, stats.FilterSections(listenConfig.SectionEnabled))
+	if err != nil {
+		// no sections would read as the period being cleaned up, so the page is left alone
+		slog.ErrorContext(ctx, "failed to pull stat: "+err.Error())
+		leaderboardPageFailed(ctx, sess, i)
+		return
+	}
+	err = sess.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: leaderboardPageUpdate(page, sections, i.Message),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to update leaderboard page: "+err.Error())
+	}
+}
+
+// leaderboardPageFailed tells the member who pressed the button that the page couldn't be loaded, leaving the
+// leaderboard as it was
+func leaderboardPageFailed(ctx context.Context, sess *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := sess.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Failed to load the leaderboard page, please try again later",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to send leaderboard page error: "+err.Error())
+	}
+}
 
+// leaderboardPageUpdate gets the requested page of the leaderboard to show in place of the message. If the period has no
+// stats left, it's been cleaned up, so the message is kept with its buttons removed.
+func leaderboardPageUpdate(page stats.LeaderboardPage, sections []stats.ReportSection, message *discordgo.Message) *discordgo.InteractionResponseData {
+	if len(sections) == 0 {
+		data := &discordgo.InteractionResponseData{Components: []discordgo.MessageComponent{}}
+		if message != nil {
+			data.Embeds = message.Embeds
+		}
+		return data
+	}
+	pages := stats.RenderEmbeds(stats.LeaderboardTitle(page.Period, page.PeriodKey), sections)
+	// stats may have changed since the message was sent, so the page count can too
+	page.Page = min(page.Page, len(pages)-1)
+	return &discordgo.InteractionResponseData{
+		Embeds:     pages[page.Page : page.Page+1],
+		Components: stats.PageButtons(page, len(pages)),
+	}
+}
+
+// leaderboardPeriod gets the period requested in the command options. Defaults to monthly when the guild tracks it,
+// otherwise the guild's first enabled period.
+func leaderboardPeriod(options []*discordgo.ApplicationCommandInteractionDataOption, enabled []stats.Period) stats.Period {
+	for _, option := range options {
+		if option.Name == leaderboardPeriodOptionName {
+			return stats.Period(option.StringValue())
+		}
+	}
+	if slices.Contains(enabled, stats.MonthlyPeriod) || len(enabled) == 0 {
+		return stats.MonthlyPeriod
+	}
+	return enabled[0]
 }
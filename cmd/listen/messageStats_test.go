@@ -0,0 +1,349 @@
+package listen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/achievements"
+	"github.com/dmtaylor/costanza/internal/audit"
+	"github.com/dmtaylor/costanza/internal/cache"
+	"github.com/dmtaylor/costanza/internal/cursed"
+	"github.com/dmtaylor/costanza/internal/stats"
+)
+
+func newTestSession() *discordgo.Session {
+	state := discordgo.NewState()
+	state.User = &discordgo.User{ID: "1"}
+	return &discordgo.Session{State: state}
+}
+
+// testTransport records the REST requests & their bodies made by a session instead of sending them, failing those in
+// fail
+type testTransport struct {
+	requests []string
+	bodies   []string
+	fail     map[string]bool
+}
+
+func (tr *testTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	request := req.Method + " " + strings.TrimPrefix(req.URL.Path, "/api/v"+discordgo.APIVersion)
+	tr.requests = append(tr.requests, request)
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+	}
+	tr.bodies = append(tr.bodies, string(body))
+	status := http.StatusOK
+	if tr.fail[request] {
+		status = http.StatusForbidden
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader("{}")),
+		Request:    req,
+	}, nil
+}
+
+// newRecordingSession gets a test session that makes REST requests through the transport
+func newRecordingSession(transport *testTransport) *discordgo.Session {
+	sess, _ := discordgo.New("Bot test")
+	sess.State = newTestSession().State
+	sess.Client = &http.Client{Transport: transport}
+	return sess
+}
+
+func newTestServer(t *testing.T, listenConfigs ...config.ListenConfig) *Server {
+	t.Helper()
+	prevSet := config.GlobalConfig.Discord.ListenChannelSet
+	t.Cleanup(func() { config.GlobalConfig.Discord.ListenChannelSet = prevSet })
+	config.GlobalConfig.Discord.ListenChannelSet = make(map[string]*config.ListenConfig, len(listenConfigs))
+	for _, listenConfig := range listenConfigs {
+		require.NoError(t, listenConfig.Load(), "failed to load listen config")
+		config.GlobalConfig.Discord.ListenChannelSet[listenConfig.GuildId] = &listenConfig
+	}
+	optOuts := newTestPrivacy()
+	return &Server{app: config.App{
+		Stats:           stats.NewMemoryStore(),
+		OptOutCache:     optOuts,
+		Privacy:         optOuts,
+		Achievements:    achievements.NewEngine(achievements.NewMemoryStore(), achievements.Rules),
+		Cursed:          newTestCursed(),
+		CursedIncidents: cursed.NewIncidents(),
+		Infractions:     &testInfractions{},
+		AuditMessages:   audit.NewMessageCache(cache.Options{}),
+	}}
+}
+
+func TestServer_logMessageActivity(t *testing.T) {
+	timestamp := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		message   *discordgo.Message
+		wantCount int
+	}{
+		{
+			"counted",
+			&discordgo.Message{GuildID: "100", ChannelID: "300", Author: &discordgo.User{ID: "200"}, Type: discordgo.MessageTypeDefault, Timestamp: timestamp},
+			1,
+		},
+		{
+			"reply_counted",
+			&discordgo.Message{GuildID: "100", ChannelID: "300", Author: &discordgo.User{ID: "200"}, Type: discordgo.MessageTypeReply, Timestamp: timestamp},
+			1,
+		},
+		{
+			"self",
+			&discordgo.Message{GuildID: "100", ChannelID: "300", Author: &discordgo.User{ID: "1"}, Type: discordgo.MessageTypeDefault, Timestamp: timestamp},
+			0,
+		},
+		{
+			"bot",
+			&discordgo.Message{GuildID: "100", ChannelID: "300", Author: &discordgo.User{ID: "200", Bot: true}, Type: discordgo.MessageTypeDefault, Timestamp: timestamp},
+			0,
+		},
+		{
+			"unconfigured_guild",
+			&discordgo.Message{GuildID: "101", ChannelID: "300", Author: &discordgo.User{ID: "200"}, Type: discordgo.MessageTypeDefault, Timestamp: timestamp},
+			0,
+		},
+		{
+			"join_message",
+			&discordgo.Message{GuildID: "100", ChannelID: "300", Author: &discordgo.User{ID: "200"}, Type: discordgo.MessageTypeGuildMemberJoin, Timestamp: timestamp},
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, config.ListenConfig{GuildId: "100"})
+			s.logMessageActivity(newTestSession(), &discordgo.MessageCreate{Message: tt.message})
+
+			metric, _ := stats.GetMetric(stats.MessagesMetric)
+			got, err := s.app.Stats.GetCounterLeaders(context.Background(), metric, 100, "2024-03", 5)
+			require.NoError(t, err)
+			if tt.wantCount == 0 {
+				assert.Empty(t, got)
+				return
+			}
+			if assert.Len(t, got, 1) {
+				assert.Equal(t, uint64(200), got[0].UserId)
+				assert.Equal(t, tt.wantCount, got[0].Value)
+			}
+			channels, err := s.app.Stats.GetChannelLeaders(context.Background(), metric, 100, "2024-03", 5)
+			require.NoError(t, err)
+			if assert.Len(t, channels, 1) {
+				assert.Equal(t, uint64(300), channels[0].ChannelId)
+			}
+		})
+	}
+}
+
+func TestServer_logMessageActivityPeriods(t *testing.T) {
+	s := newTestServer(t, config.ListenConfig{
+		GuildId:  "100",
+		Timezone: "America/New_York",
+		Periods:  []string{"weekly", "monthly", "yearly"},
+	})
+	// late on new year's eve in New York, already the next year in UTC. Dec 31 2024 falls in ISO week 2025-W01
+	timestamp := time.Date(2025, 1, 1, 3, 0, 0, 0, time.UTC)
+	message := &discordgo.Message{GuildID: "100", ChannelID: "300", Author: &discordgo.User{ID: "200"}, Type: discordgo.MessageTypeDefault, Timestamp: timestamp}
+	s.logMessageActivity(newTestSession(), &discordgo.MessageCreate{Message: message})
+
+	metric, _ := stats.GetMetric(stats.MessagesMetric)
+	for _, periodKey := range []string{"2025-W01", "2024-12", "2024"} {
+		got, err := s.app.Stats.GetCounterLeaders(context.Background(), metric, 100, periodKey, 5)
+		require.NoError(t, err)
+		if assert.Len(t, got, 1, "missing count for %s", periodKey) {
+			assert.Equal(t, 1, got[0].Value)
+		}
+	}
+	got, err := s.app.Stats.GetCounterLeaders(context.Background(), metric, 100, "2025-01", 5)
+	require.NoError(t, err)
+	assert.Empty(t, got, "disabled or wrong timezone period logged")
+}
+
+func Test_leaderboardPeriod(t *testing.T) {
+	periodOption := &discordgo.ApplicationCommandInteractionDataOption{
+		Name:  leaderboardPeriodOptionName,
+		Type:  discordgo.ApplicationCommandOptionString,
+		Value: "weekly",
+	}
+	tests := []struct {
+		name    string
+		options []*discordgo.ApplicationCommandInteractionDataOption
+		enabled []stats.Period
+		want    stats.Period
+	}{
+		{"requested", []*discordgo.ApplicationCommandInteractionDataOption{periodOption}, []stats.Period{stats.MonthlyPeriod}, stats.WeeklyPeriod},
+		{"default_monthly", nil, []stats.Period{stats.WeeklyPeriod, stats.MonthlyPeriod}, stats.MonthlyPeriod},
+		{"default_first_enabled", nil, []stats.Period{stats.QuarterlyPeriod, stats.YearlyPeriod}, stats.QuarterlyPeriod},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, leaderboardPeriod(tt.options, tt.enabled))
+		})
+	}
+}
+
+func Test_leaderboardPageUpdate(t *testing.T) {
+	page := stats.LeaderboardPage{Period: stats.WeeklyPeriod, PeriodKey: "2025-W01", Page: 3}
+	var sections []stats.ReportSection
+	for i := range 30 {
+		sections = append(sections, stats.ReportSection{
+			Title:   fmt.Sprintf("Section %d", i),
+			Entries: []stats.ReportEntry{{Rank: 1, Text: "a user"}},
+		})
+	}
+
+	// clamped to the last page
+	got := leaderboardPageUpdate(page, sections, nil)
+	require.Len(t, got.Embeds, 1)
+	assert.Equal(t, "Page 2/2", got.Embeds[0].Footer.Text)
+	assert.Len(t, got.Components, 1)
+
+	// period cleaned up, so the buttons are removed
+	message := &discordgo.Message{Embeds: []*discordgo.MessageEmbed{{Title: "old page"}}}
+	got = leaderboardPageUpdate(page, nil, message)
+	assert.Equal(t, message.Embeds, got.Embeds)
+	assert.NotNil(t, got.Components, "nil components leave the buttons")
+	assert.Empty(t, got.Components)
+}
+
+func TestServer_changeLeaderboardPage_errors(t *testing.T) {
+	page := stats.LeaderboardPage{Period: stats.MonthlyPeriod, PeriodKey: stats.MonthlyPeriod.Key(time.Now()), Page: 1}
+	s := newTestServer(t, config.ListenConfig{GuildId: "100"})
+	s.app.Stats = failingStats{MemoryStore: stats.NewMemoryStore(), err: errors.New("stats db err")}
+	transport := &testTransport{}
+	sess := newRecordingSession(transport)
+	s.changeLeaderboardPage(sess, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:      "900",
+		Token:   "token",
+		Type:    discordgo.InteractionMessageComponent,
+		GuildID: "100",
+		Data:    discordgo.MessageComponentInteractionData{CustomID: page.CustomId()},
+		Message: &discordgo.Message{ID: "500", Embeds: []*discordgo.MessageEmbed{{Title: "old page"}}},
+	}})
+
+	// the page isn't updated, as that would remove the buttons
+	require.Equal(t, []string{"POST /interactions/900/token/callback"}, transport.requests)
+	var response discordgo.InteractionResponse
+	require.NoError(t, json.Unmarshal([]byte(transport.bodies[0]), &response))
+	assert.Equal(t, discordgo.InteractionResponseChannelMessageWithSource, response.Type)
+	require.NotNil(t, response.Data)
+	assert.Equal(t, discordgo.MessageFlagsEphemeral, response.Data.Flags)
+	assert.Contains(t, response.Data.Content, "try again")
+}
+
+func TestServer_logReaction(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, config.ListenConfig{GuildId: "100"})
+	sess := newTestSession()
+	sess.State.MaxMessageCount = 10
+	require.NoError(t, sess.State.GuildAdd(&discordgo.Guild{ID: "100"}))
+	require.NoError(t, sess.State.ChannelAdd(&discordgo.Channel{ID: "300", GuildID: "100"}))
+	// removals only count in periods containing the message, so ids are from now
+	first, second, uncached := snowflake(time.Now(), 0), snowflake(time.Now(), 1), snowflake(time.Now(), 2)
+	sess.State.MessageAdd(&discordgo.Message{ID: first, ChannelID: "300", GuildID: "100", Author: &discordgo.User{ID: "201"}})
+	sess.State.MessageAdd(&discordgo.Message{ID: second, ChannelID: "300", GuildID: "100", Author: &discordgo.User{ID: "200"}})
+	reaction := func(userId, messageId string) *discordgo.MessageReaction {
+		return &discordgo.MessageReaction{UserID: userId, MessageID: messageId, ChannelID: "300", GuildID: "100", Emoji: discordgo.Emoji{Name: "🔥"}}
+	}
+
+	for _, r := range []*discordgo.MessageReaction{reaction("200", first), reaction("202", first), reaction("202", second)} {
+		logged, err := s.logReaction(ctx, sess, r, 1)
+		require.NoError(t, err)
+		assert.True(t, logged)
+	}
+	// removed reaction & reaction to own message only count as given
+	logged, err := s.logReaction(ctx, sess, reaction("202", second), -1)
+	require.NoError(t, err)
+	assert.True(t, logged)
+	logged, err = s.logReaction(ctx, sess, reaction("201", first), 1)
+	require.NoError(t, err)
+	assert.True(t, logged)
+	logged, err = s.logReaction(ctx, sess, reaction("1", first), 1)
+	require.NoError(t, err)
+	assert.False(t, logged, "bot reaction logged")
+	// message isn't cached, so only counts as given
+	logged, err = s.logReaction(ctx, sess, reaction("203", uncached), 1)
+	require.NoError(t, err)
+	assert.True(t, logged)
+
+	period := stats.MonthlyPeriod.Key(time.Now())
+	received, _ := stats.GetMetric(stats.ReactionsReceivedMetric)
+	got, err := s.app.Stats.GetCounterLeaders(ctx, received, 100, period, 5)
+	require.NoError(t, err)
+	if assert.Len(t, got, 2) {
+		assert.Equal(t, uint64(201), got[0].UserId)
+		assert.Equal(t, 2, got[0].Value)
+		assert.Equal(t, uint64(200), got[1].UserId)
+		assert.Equal(t, 0, got[1].Value, "removed reaction not decremented")
+	}
+	messages, err := s.app.Stats.GetTopMessages(ctx, 100, period, 5)
+	require.NoError(t, err)
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, first, strconv.FormatUint(messages[0].MessageId, 10))
+		assert.Equal(t, 2, messages[0].Value)
+	}
+	emoji, err := s.app.Stats.GetTopEmoji(ctx, 100, period, 5)
+	require.NoError(t, err)
+	if assert.Len(t, emoji, 1) {
+		assert.Equal(t, "🔥", emoji[0].Emoji)
+	}
+	reactions, _ := stats.GetMetric(stats.ReactionsMetric)
+	given, err := s.app.Stats.GetCounterLeaders(ctx, reactions, 100, period, 5)
+	require.NoError(t, err)
+	assert.Len(t, given, 4)
+}
+
+func TestServer_logReaction_removals(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, config.ListenConfig{GuildId: "100"})
+	sess := newTestSession()
+	sess.State.MaxMessageCount = 10
+	require.NoError(t, sess.State.GuildAdd(&discordgo.Guild{ID: "100"}))
+	require.NoError(t, sess.State.ChannelAdd(&discordgo.Channel{ID: "300", GuildID: "100"}))
+	require.NoError(t, sess.State.MemberAdd(&discordgo.Member{GuildID: "100", User: &discordgo.User{ID: "210", Bot: true}}))
+	message := snowflake(time.Now(), 0)
+	old := snowflake(time.Now().AddDate(-1, 0, 0), 0)
+	sess.State.MessageAdd(&discordgo.Message{ID: message, ChannelID: "300", GuildID: "100", Author: &discordgo.User{ID: "200"}})
+	sess.State.MessageAdd(&discordgo.Message{ID: old, ChannelID: "300", GuildID: "100", Author: &discordgo.User{ID: "200"}})
+	remove := func(userId, messageId string) *discordgo.MessageReactionRemove {
+		return &discordgo.MessageReactionRemove{MessageReaction: &discordgo.MessageReaction{UserID: userId, MessageID: messageId, ChannelID: "300", GuildID: "100", Emoji: discordgo.Emoji{Name: "🔥"}}}
+	}
+
+	// bot reactions aren't counted when added, so removing them doesn't count either
+	s.logReactionRemoval(sess, remove("210", message))
+	// reaction added in an earlier period, so it was never counted in this one
+	s.logReactionRemoval(sess, remove("202", old))
+
+	period := stats.MonthlyPeriod.Key(time.Now())
+	for _, metric := range []string{stats.ReactionsMetric, stats.ReactionsReceivedMetric} {
+		m, _ := stats.GetMetric(metric)
+		got, err := s.app.Stats.GetCounterLeaders(ctx, m, 100, period, 5)
+		require.NoError(t, err)
+		assert.Empty(t, got, metric)
+	}
+	messages, err := s.app.Stats.GetTopMessages(ctx, 100, period, 5)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
+
+// snowflake makes a discord id for t, with n as the increment
+func snowflake(t time.Time, n uint64) string {
+	return strconv.FormatUint(uint64(t.UnixMilli()-1420070400000)<<22|n, 10)
+}
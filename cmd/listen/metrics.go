@@ -32,6 +32,18 @@ const guildMemberAddGatewayEvent = "guildMemberAdd"
 
 const messageReactionAddGatewayEvent = "messageReactionAdd"
 
+const messageReactionRemoveGatewayEvent = "messageReactionRemove"
+
+const messageUpdateGatewayEvent = "messageUpdate"
+
+const messageDeleteGatewayEvent = "messageDelete"
+
+// guildMemberRemoveGatewayEvent is the gateway event type when a user leaves or is removed from a guild
+const guildMemberRemoveGatewayEvent = "guildMemberRemove"
+
+// guildMemberUpdateGatewayEvent is the gateway event type when a member's roles or nickname change
+const guildMemberUpdateGatewayEvent = "guildMemberUpdate"
+
 // externalDiscordCallName used for external API calls to Discord
 const externalDiscordCallName = "discord"
 
@@ -89,6 +101,56 @@ func (s *Server) messageReactionAddMetricsMiddleware(f func(session *discordgo.S
 	}
 }
 
+func (s *Server) messageReactionRemoveMetricsMiddleware(f func(session *discordgo.Session, remove *discordgo.MessageReactionRemove)) func(session *discordgo.Session, remove *discordgo.MessageReactionRemove) {
+	return func(sess *discordgo.Session, r *discordgo.MessageReactionRemove) {
+		if s.m.enabled {
+			s.m.eventReceives.With(prometheus.Labels{gatewayEventTypeLabel: messageReactionRemoveGatewayEvent}).Inc()
+			defer s.m.eventsHandled.With(prometheus.Labels{gatewayEventTypeLabel: messageReactionRemoveGatewayEvent}).Inc()
+		}
+		f(sess, r)
+	}
+}
+
+func (s *Server) messageUpdateMetricsMiddleware(f func(*discordgo.Session, *discordgo.MessageUpdate)) func(*discordgo.Session, *discordgo.MessageUpdate) {
+	return func(sess *discordgo.Session, m *discordgo.MessageUpdate) {
+		if s.m.enabled {
+			s.m.eventReceives.With(prometheus.Labels{gatewayEventTypeLabel: messageUpdateGatewayEvent}).Inc()
+			defer s.m.eventsHandled.With(prometheus.Labels{gatewayEventTypeLabel: messageUpdateGatewayEvent}).Inc()
+		}
+		f(sess, m)
+	}
+}
+
+func (s *Server) messageDeleteMetricsMiddleware(f func(*discordgo.Session, *discordgo.MessageDelete)) func(*discordgo.Session, *discordgo.MessageDelete) {
+	return func(sess *discordgo.Session, m *discordgo.MessageDelete) {
+		if s.m.enabled {
+			s.m.eventReceives.With(prometheus.Labels{gatewayEventTypeLabel: messageDeleteGatewayEvent}).Inc()
+			defer s.m.eventsHandled.With(prometheus.Labels{gatewayEventTypeLabel: messageDeleteGatewayEvent}).Inc()
+		}
+		f(sess, m)
+	}
+}
+
+func (s *Server) guildMemberRemoveMetricsMiddleware(f func(*discordgo.Session, *discordgo.GuildMemberRemove)) func(*discordgo.Session, *discordgo.GuildMemberRemove) {
+	return func(sess *discordgo.Session, r *discordgo.GuildMemberRemove) {
+		if s.m.enabled {
+			s.m.eventReceives.With(prometheus.Labels{gatewayEventTypeLabel: guildMemberRemoveGatewayEvent}).Inc()
+			defer s.m.eventsHandled.With(prometheus.Labels{gatewayEventTypeLabel: guildMemberRemoveGatewayEvent}).Inc()
+		}
+		f(sess, r)
+	}
+}
+
+func (s *Server) guildMemberUpdateMetricsMiddleware(f func(*discordgo.Session, *discordgo.GuildMemberUpdate)) func(*discordgo.Session, *discordgo.GuildMemberUpdate) {
+	return func(sess *discordgo.Session, u *discordgo.GuildMemberUpdate) {
+		if s.m.enabled {
+			s.m.eventReceives.With(prometheus.Labels{gatewayEventTypeLabel: guildMemberUpdateGatewayEvent}).Inc()
+			defer s.m.eventsHandled.With(prometheus.Labels{gatewayEventTypeLabel: guildMemberUpdateGatewayEvent}).Inc()
+		}
+		f(sess, u)
+	}
+}
+
 // setupMetrics configures prometheus metrics & modifies the Server object to support logging.
 // Metrics should only be logged if this function has been run, and metrics.enabled should only be set to true here.
 func (s *Server) setupMetrics() http.Handler {
@@ -0,0 +1,55 @@
+package listen
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/hashicorp/go-multierror"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/audit"
+)
+
+const modLogEventName = "mod_log"
+
+// modLog posts the moderation action to the guild's moderation log channel, if it has one
+func (s *Server) modLog(ctx context.Context, sess *discordgo.Session, guildId string, content string) error {
+	// don't ping members named in the log
+	return s.postModLog(ctx, sess, guildId, content, &discordgo.MessageAllowedMentions{})
+}
+
+// modAlert posts the alert to the guild's moderation log channel, pinging the role if one's given
+func (s *Server) modAlert(ctx context.Context, sess *discordgo.Session, guildId string, roleId string, content string) error {
+	mentions := &discordgo.MessageAllowedMentions{}
+	if roleId != "" {
+		content = "<@&" + roleId + "> " + content
+		mentions.Roles = []string{roleId}
+	}
+	return s.postModLog(ctx, sess, guildId, content, mentions)
+}
+
+// postModLog posts to the moderation log, & mirrors it into the audit log if that's a different channel
+func (s *Server) postModLog(ctx context.Context, sess *discordgo.Session, guildId string, content string, mentions *discordgo.MessageAllowedMentions) error {
+	listenConfig := config.GlobalConfig.Discord.ListenChannelSet[guildId]
+	var errs *multierror.Error
+	if listenConfig.ModLogEnabled() {
+		callStart := time.Now()
+		_, err := sess.ChannelMessageSendComplex(listenConfig.ModLogChannelId, &discordgo.MessageSend{
+			Content:         content,
+			AllowedMentions: mentions,
+		})
+		if s.m.enabled {
+			s.m.externalApiDuration.With(prometheus.Labels{eventNameLabel: modLogEventName, externalApiLabel: externalDiscordCallName}).Observe(time.Since(callStart).Seconds())
+		}
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("failed to post to moderation log: %w", err))
+		}
+	}
+	if listenConfig.AuditLogEnabled() && listenConfig.AuditLogChannelId != listenConfig.ModLogChannelId {
+		errs = multierror.Append(errs, s.auditLog(ctx, sess, guildId, audit.ModerationEmbed(content, time.Now())))
+	}
+	return errs.ErrorOrNil()
+}
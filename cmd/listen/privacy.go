@@ -0,0 +1,143 @@
+package listen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/util"
+)
+
+const privacyCommandName = "privacy"
+const privacyOptOutCommand = "optout"
+const privacyOptInCommand = "optin"
+const privacyDeleteCommand = "delete"
+
+var privacySlashCommand = &discordgo.ApplicationCommand{
+	Name:        privacyCommandName,
+	Type:        discordgo.ChatApplicationCommand,
+	Description: "Manage stat tracking for yourself on this server",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:        privacyOptOutCommand,
+			Description: "Stop tracking your stats & leave you off leaderboards",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+		},
+		{
+			Name:        privacyOptInCommand,
+			Description: "Resume tracking your stats",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+		},
+		{
+			Name:        privacyDeleteCommand,
+			Description: "Delete all of your recorded stats",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+		},
+	},
+}
+
+// isOptedOut checks if the user opted out of stat tracking in the guild
+func (s *Server) isOptedOut(ctx context.Context, guildId, userId uint64) (bool, error) {
+	optOuts, err := s.app.OptOutCache.Get(ctx, guildId)
+	if err != nil {
+		return false, fmt.Errorf("failed to get opted out users: %w", err)
+	}
+	return slices.Contains(optOuts, userId), nil
+}
+
+func (s *Server) privacyCommand(sess *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand || i.ApplicationCommandData().Name != privacyCommandName {
+		return
+	}
+
+	var err error
+	if s.m.enabled {
+		start := time.Now()
+		defer func() {
+			s.m.eventDuration.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: privacyCommandName}).Observe(time.Since(start).Seconds())
+			if err != nil {
+				isTimeout := strconv.FormatBool(errors.Is(err, context.DeadlineExceeded))
+				s.m.eventErrors.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: privacyCommandName, isTimeoutLabel: isTimeout}).Inc()
+			} else {
+				s.m.eventSuccess.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: privacyCommandName}).Inc()
+			}
+		}()
+	}
+	ctx, cancel := util.ContextFromDiscordInteractionCreate(context.Background(), i, interactionTimeout)
+	defer cancel()
+
+	var content string
+	if _, ok := config.GlobalConfig.Discord.ListenChannelSet[i.GuildID]; !ok || i.Member == nil || i.Member.User == nil {
+		content = "Stats aren't tracked on this guild"
+	} else {
+		options := i.ApplicationCommandData().Options
+		if len(options) == 0 {
+			return
+		}
+		content, err = s.applyPrivacyCommand(ctx, options[0].Name, i.GuildID, i.Member.User.ID)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to update privacy: "+err.Error())
+			content = "Failed to update your privacy settings, please try again later"
+		}
+	}
+	// only the member sees the response, so others aren't told who opted out
+	ierr := sess.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if ierr != nil {
+		slog.ErrorContext(ctx, "failed to send privacy response: "+ierr.Error())
+		err = ierr
+	}
+}
+
+// applyPrivacyCommand runs the privacy subcommand for the member, returning the response to send
+func (s *Server) applyPrivacyCommand(ctx context.Context, command, guild, user string) (string, error) {
+	guildId, err := strconv.ParseUint(guild, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("bad guild id: %w", err)
+	}
+	userId, err := strconv.ParseUint(user, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("bad user id: %w", err)
+	}
+	switch command {
+	case privacyOptOutCommand:
+		if err = s.app.Privacy.OptOut(ctx, guildId, userId); err != nil {
+			return "", err
+		}
+		s.app.OptOutCache.Clear(ctx)
+		return "Your stats won't be tracked on this server any more, & you won't be shown on leaderboards. " +
+			"Use `/privacy delete` to also remove the stats already recorded", nil
+	case privacyOptInCommand:
+		if err = s.app.Privacy.OptIn(ctx, guildId, userId); err != nil {
+			return "", err
+		}
+		s.app.OptOutCache.Clear(ctx)
+		return "Your stats will be tracked on this server again", nil
+	case privacyDeleteCommand:
+		if err = s.app.Stats.RemoveUser(ctx, guildId, userId); err != nil {
+			return "", err
+		}
+		if err = s.app.Achievements.RemoveUser(ctx, guildId, userId); err != nil {
+			return "", err
+		}
+		if err = s.app.Cursed.RemoveUser(ctx, guildId, userId); err != nil {
+			return "", err
+		}
+		return "Your recorded stats on this server have been deleted. Use `/privacy optout` to stop new stats being tracked", nil
+	default:
+		return "", fmt.Errorf("unknown privacy command %s", command)
+	}
+}
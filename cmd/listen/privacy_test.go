@@ -0,0 +1,130 @@
+package listen
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/stats"
+)
+
+// testPrivacy is an in memory privacy store, which also serves as an always current opt out cache
+type testPrivacy struct {
+	lock    sync.Mutex
+	optOuts map[uint64][]uint64
+}
+
+func newTestPrivacy() *testPrivacy {
+	return &testPrivacy{optOuts: make(map[uint64][]uint64)}
+}
+
+func (p *testPrivacy) OptOut(_ context.Context, guildId, userId uint64) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if !slices.Contains(p.optOuts[guildId], userId) {
+		p.optOuts[guildId] = append(p.optOuts[guildId], userId)
+	}
+	return nil
+}
+
+func (p *testPrivacy) OptIn(_ context.Context, guildId, userId uint64) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.optOuts[guildId] = slices.DeleteFunc(p.optOuts[guildId], func(id uint64) bool { return id == userId })
+	return nil
+}
+
+func (p *testPrivacy) Get(_ context.Context, key uint64) ([]uint64, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return slices.Clone(p.optOuts[key]), nil
+}
+
+func (p *testPrivacy) Set(_ context.Context, key uint64, value []uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.optOuts[key] = value
+}
+
+func (p *testPrivacy) Clear(_ context.Context) {}
+
+func (p *testPrivacy) Invalidate(_ context.Context, _ uint64) {}
+
+func TestServer_applyPrivacyCommand(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, config.ListenConfig{GuildId: "100"})
+	sess := newTestSession()
+	timestamp := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	post := func(userId string) {
+		s.logMessageActivity(sess, &discordgo.MessageCreate{Message: &discordgo.Message{
+			GuildID: "100", ChannelID: "300", Author: &discordgo.User{ID: userId}, Type: discordgo.MessageTypeDefault, Timestamp: timestamp,
+		}})
+	}
+	metric, _ := stats.GetMetric(stats.MessagesMetric)
+	counts := func() map[uint64]int {
+		leaders, err := s.app.Stats.GetCounterLeaders(ctx, metric, 100, "2024-03", 5)
+		require.NoError(t, err)
+		got := make(map[uint64]int)
+		for _, leader := range leaders {
+			got[leader.UserId] = leader.Value
+		}
+		return got
+	}
+
+	post("200")
+	post("201")
+	_, err := s.applyPrivacyCommand(ctx, privacyOptOutCommand, "100", "200")
+	require.NoError(t, err)
+	post("200")
+	post("201")
+	assert.Equal(t, map[uint64]int{200: 1, 201: 2}, counts(), "opted out user counted")
+
+	_, err = s.applyPrivacyCommand(ctx, privacyDeleteCommand, "100", "200")
+	require.NoError(t, err)
+	assert.Equal(t, map[uint64]int{201: 2}, counts(), "stats not deleted")
+
+	_, err = s.applyPrivacyCommand(ctx, privacyOptInCommand, "100", "200")
+	require.NoError(t, err)
+	post("200")
+	assert.Equal(t, map[uint64]int{200: 1, 201: 2}, counts(), "opted in user not counted")
+
+	_, err = s.applyPrivacyCommand(ctx, "forget", "100", "200")
+	assert.Error(t, err)
+}
+
+func TestServer_logReactionOptedOut(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t, config.ListenConfig{GuildId: "100"})
+	sess := newTestSession()
+	sess.State.MaxMessageCount = 10
+	require.NoError(t, sess.State.GuildAdd(&discordgo.Guild{ID: "100"}))
+	require.NoError(t, sess.State.ChannelAdd(&discordgo.Channel{ID: "300", GuildID: "100"}))
+	sess.State.MessageAdd(&discordgo.Message{ID: "500", ChannelID: "300", GuildID: "100", Author: &discordgo.User{ID: "201"}})
+	require.NoError(t, s.app.Privacy.OptOut(ctx, 100, 201))
+
+	logged, err := s.logReaction(ctx, sess, &discordgo.MessageReaction{UserID: "200", MessageID: "500", ChannelID: "300", GuildID: "100", Emoji: discordgo.Emoji{Name: "🔥"}}, 1)
+	require.NoError(t, err)
+	assert.True(t, logged)
+
+	period := stats.MonthlyPeriod.Key(time.Now())
+	given, _ := stats.GetMetric(stats.ReactionsMetric)
+	got, err := s.app.Stats.GetCounterLeaders(ctx, given, 100, period, 5)
+	require.NoError(t, err)
+	if assert.Len(t, got, 1, "reaction given should still count") {
+		assert.Equal(t, uint64(200), got[0].UserId)
+	}
+	received, _ := stats.GetMetric(stats.ReactionsReceivedMetric)
+	got, err = s.app.Stats.GetCounterLeaders(ctx, received, 100, period, 5)
+	require.NoError(t, err)
+	assert.Empty(t, got, "opted out author counted")
+	messages, err := s.app.Stats.GetTopMessages(ctx, 100, period, 5)
+	require.NoError(t, err)
+	assert.Empty(t, messages, "opted out author's message counted")
+}
@@ -0,0 +1,294 @@
+package listen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/hashicorp/go-multierror"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/protection"
+	"github.com/dmtaylor/costanza/internal/util"
+)
+
+const protectionEventName = "protection"
+const raidProtectionEventName = "raid_protection"
+
+// maxBulkDelete is the most messages discord deletes in one bulk delete
+const maxBulkDelete = 100
+
+var protectionBaseLabels = prometheus.Labels{gatewayEventTypeLabel: messageCreateGatewayEvent, eventNameLabel: protectionEventName}
+var raidProtectionBaseLabels = prometheus.Labels{gatewayEventTypeLabel: guildMemberAddGatewayEvent, eventNameLabel: raidProtectionEventName}
+
+// tripNames are the rule names used in moderation log posts
+var tripNames = map[string]string{
+	protection.FloodKind:     "Message flood",
+	protection.DuplicateKind: "Repeated messages",
+	protection.MentionsKind:  "Mention spam",
+	protection.JoinsKind:     "Mass join",
+}
+
+// protectMessages counts posts against the guild's protection rules, responding to any tripped
+func (s *Server) protectMessages(sess *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.Author == nil || m.Author.Bot || m.Author.ID == sess.State.User.ID {
+		return
+	}
+	listenConfig, found := config.GlobalConfig.Discord.ListenChannelSet[m.GuildID]
+	if !found || !listenConfig.Protection.Enabled() {
+		return
+	}
+	ctx := util.ContextFromDiscordMessageCreate(context.Background(), m)
+	var err error
+	if s.m.enabled {
+		start := time.Now()
+		defer func() {
+			s.m.eventDuration.With(protectionBaseLabels).Observe(time.Since(start).Seconds())
+			if err != nil {
+				isTimeout := strconv.FormatBool(errors.Is(err, context.DeadlineExceeded))
+				s.m.eventErrors.With(prometheus.Labels{gatewayEventTypeLabel: messageCreateGatewayEvent, eventNameLabel: protectionEventName, isTimeoutLabel: isTimeout}).Inc()
+			} else {
+				s.m.eventSuccess.With(protectionBaseLabels).Inc()
+			}
+		}()
+	}
+	guildId, err := strconv.ParseUint(m.GuildID, 10, 64)
+	if err != nil {
+		slog.ErrorContext(ctx, "error checking protection rules: "+err.Error())
+		return
+	}
+	userId, err := strconv.ParseUint(m.Author.ID, 10, 64)
+	if err != nil {
+		slog.ErrorContext(ctx, "error checking protection rules: "+err.Error())
+		return
+	}
+	trips := s.app.Protection.Message(&listenConfig.Protection, guildId, userId, protection.Message{
+		Id:        m.ID,
+		ChannelId: m.ChannelID,
+		Content:   m.Content,
+		Mentions:  mentionCount(m.Message),
+		Time:      m.Timestamp,
+	})
+	var errs *multierror.Error
+	for _, trip := range trips {
+		errs = multierror.Append(errs, s.respondToTrip(ctx, sess, m.GuildID, m.Author.ID, &listenConfig.Protection, trip))
+	}
+	err = errs.ErrorOrNil()
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to respond to protection rule: "+err.Error())
+	}
+}
+
+// protectJoins counts joins against the guild's mass join rule, responding if it's tripped
+func (s *Server) protectJoins(sess *discordgo.Session, j *discordgo.GuildMemberAdd) {
+	if j.User == nil || j.User.ID == sess.State.User.ID {
+		return
+	}
+	listenConfig, found := config.GlobalConfig.Discord.ListenChannelSet[j.GuildID]
+	if !found || !listenConfig.Protection.Joins.Enabled() {
+		return
+	}
+	ctx := context.WithValue(context.Background(), "memberId", j.User.ID)
+	ctx = context.WithValue(ctx, "guildId", j.GuildID)
+	ctx = context.WithValue(ctx, "type", raidProtectionEventName)
+	var err error
+	if s.m.enabled {
+		start := time.Now()
+		defer func() {
+			s.m.eventDuration.With(raidProtectionBaseLabels).Observe(time.Since(start).Seconds())
+			if err != nil {
+				isTimeout := strconv.FormatBool(errors.Is(err, context.DeadlineExceeded))
+				s.m.eventErrors.With(prometheus.Labels{gatewayEventTypeLabel: guildMemberAddGatewayEvent, eventNameLabel: raidProtectionEventName, isTimeoutLabel: isTimeout}).Inc()
+			} else {
+				s.m.eventSuccess.With(raidProtectionBaseLabels).Inc()
+			}
+		}()
+	}
+	guildId, err := strconv.ParseUint(j.GuildID, 10, 64)
+	if err != nil {
+		slog.ErrorContext(ctx, "error checking raid protection: "+err.Error())
+		return
+	}
+	userId, err := strconv.ParseUint(j.User.ID, 10, 64)
+	if err != nil {
+		slog.ErrorContext(ctx, "error checking raid protection: "+err.Error())
+		return
+	}
+	joined := j.JoinedAt
+	if joined.IsZero() {
+		joined = time.Now()
+	}
+	trip := s.app.Protection.Join(&listenConfig.Protection, guildId, userId, joined)
+	if trip == nil {
+		return
+	}
+	err = s.respondToTrip(ctx, sess, j.GuildID, j.User.ID, &listenConfig.Protection, *trip)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to respond to mass join: "+err.Error())
+	}
+}
+
+// respondToTrip takes the tripped rule's actions, then posts what happened to the moderation log. Alerts ping the
+// guild's alert role.
+func (s *Server) respondToTrip(ctx context.Context, sess *discordgo.Session, guild, user string, rules *protection.Config, trip protection.Trip) error {
+	var errs *multierror.Error
+	var taken []string
+	rule := trip.Rule
+	if rule.Has(protection.DeleteAction) {
+		deleted := 0
+		for channel, ids := range messagesByChannel(trip.Messages) {
+			n, err := deleteMessages(sess, channel, ids)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("failed to delete messages: %w", err))
+			}
+			deleted += n
+		}
+		if deleted > 0 {
+			taken = append(taken, fmt.Sprintf("deleted %d messages", deleted))
+		}
+	}
+	if rule.Has(protection.TimeoutAction) {
+		users := []string{user}
+		if trip.Kind == protection.JoinsKind {
+			users = users[:0]
+			for _, id := range trip.Users {
+				users = append(users, strconv.FormatUint(id, 10))
+			}
+		}
+		until := time.Now().Add(rules.Timeout())
+		timedOut := 0
+		for _, id := range users {
+			if err := sess.GuildMemberTimeout(guild, id, &until); err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("failed to time out member %s: %w", id, err))
+				continue
+			}
+			timedOut++
+		}
+		taken = append(taken, fmt.Sprintf("timed out %d members for %s", timedOut, rules.Timeout()))
+	}
+	if rule.Has(protection.SlowmodeAction) {
+		seconds, duration := rules.Slowmode()
+		for channel := range messagesByChannel(trip.Messages) {
+			if err := s.slowmode(ctx, sess, channel, seconds, duration); err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("failed to turn on slowmode: %w", err))
+				continue
+			}
+			taken = append(taken, fmt.Sprintf("turned on %ds slowmode in <#%s> for %s", seconds, channel, duration))
+		}
+	}
+	content := tripSummary(trip, user)
+	if len(taken) > 0 {
+		content += "\nActions: " + strings.Join(taken, ", ")
+	}
+	if rule.Has(protection.AlertAction) {
+		errs = multierror.Append(errs, s.modAlert(ctx, sess, guild, rules.AlertRoleId, content))
+	} else if len(taken) > 0 {
+		errs = multierror.Append(errs, s.modLog(ctx, sess, guild, content))
+	}
+	return errs.ErrorOrNil()
+}
+
+// slowmode turns on slowmode in the channel, turning it back off once the duration passes. Slowmode the bot already
+// turned on is extended instead.
+func (s *Server) slowmode(ctx context.Context, sess *discordgo.Session, channelId string, seconds int, duration time.Duration) error {
+	s.slowmodeLock.Lock()
+	defer s.slowmodeLock.Unlock()
+	if timer, ok := s.slowmodes[channelId]; ok {
+		timer.Reset(duration)
+		return nil
+	}
+	channel, err := sess.State.Channel(channelId)
+	if err != nil {
+		if channel, err = sess.Channel(channelId); err != nil {
+			return fmt.Errorf("failed to get channel: %w", err)
+		}
+	}
+	previous := channel.RateLimitPerUser
+	if previous >= seconds {
+		return nil
+	}
+	if _, err = sess.ChannelEdit(channelId, &discordgo.ChannelEdit{RateLimitPerUser: &seconds}); err != nil {
+		return fmt.Errorf("failed to edit channel: %w", err)
+	}
+	if s.slowmodes == nil {
+		s.slowmodes = make(map[string]*time.Timer)
+	}
+	s.slowmodes[channelId] = time.AfterFunc(duration, func() {
+		s.slowmodeLock.Lock()
+		delete(s.slowmodes, channelId)
+		s.slowmodeLock.Unlock()
+		if _, err := sess.ChannelEdit(channelId, &discordgo.ChannelEdit{RateLimitPerUser: &previous}); err != nil {
+			slog.ErrorContext(ctx, "failed to turn off slowmode: "+err.Error(), "channelId", channelId)
+		}
+	})
+	return nil
+}
+
+// deleteMessages deletes the messages from the channel in chunks of up to maxBulkDelete, returning how many were deleted
+func deleteMessages(sess *discordgo.Session, channel string, ids []string) (int, error) {
+	var errs *multierror.Error
+	deleted := 0
+	for chunk := range slices.Chunk(ids, maxBulkDelete) {
+		var err error
+		if len(chunk) == 1 {
+			err = sess.ChannelMessageDelete(channel, chunk[0])
+		} else {
+			err = sess.ChannelMessagesBulkDelete(channel, chunk)
+		}
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		deleted += len(chunk)
+	}
+	return deleted, errs.ErrorOrNil()
+}
+
+// messagesByChannel groups the message ids by the channel they're in
+func messagesByChannel(messages []protection.Message) map[string][]string {
+	channels := make(map[string][]string)
+	for _, message := range messages {
+		if !slices.Contains(channels[message.ChannelId], message.Id) {
+			channels[message.ChannelId] = append(channels[message.ChannelId], message.Id)
+		}
+	}
+	return channels
+}
+
+// mentionCount counts the users & roles the message mentions, with everyone counting as one
+func mentionCount(m *discordgo.Message) int {
+	count := len(m.Mentions) + len(m.MentionRoles)
+	if m.MentionEveryone {
+		count++
+	}
+	return count
+}
+
+// tripSummary describes the tripped rule for the moderation log
+func tripSummary(trip protection.Trip, user string) string {
+	name := tripNames[trip.Kind]
+	window := trip.Rule.Window
+	switch trip.Kind {
+	case protection.JoinsKind:
+		members := make([]string, len(trip.Users))
+		for i, id := range trip.Users {
+			members[i] = fmt.Sprintf("<@%d>", id)
+		}
+		return fmt.Sprintf("🚨 **%s**: %d members joined within %s: %s", name, len(trip.Users), window, strings.Join(members, ", "))
+	case protection.MentionsKind:
+		mentions := 0
+		for _, message := range trip.Messages {
+			mentions += message.Mentions
+		}
+		return fmt.Sprintf("🚨 **%s** by <@%s>: %d mentions in %d messages within %s", name, user, mentions, len(trip.Messages), window)
+	default:
+		return fmt.Sprintf("🚨 **%s** by <@%s>: %d messages within %s", name, user, len(trip.Messages), window)
+	}
+}
@@ -0,0 +1,107 @@
+package listen
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/protection"
+)
+
+func TestMentionCount(t *testing.T) {
+	assert.Equal(t, 0, mentionCount(&discordgo.Message{}))
+	assert.Equal(t, 4, mentionCount(&discordgo.Message{
+		Mentions:        []*discordgo.User{{ID: "1"}, {ID: "2"}},
+		MentionRoles:    []string{"900"},
+		MentionEveryone: true,
+	}))
+}
+
+func TestMessagesByChannel(t *testing.T) {
+	got := messagesByChannel([]protection.Message{
+		{Id: "500", ChannelId: "300"},
+		{Id: "501", ChannelId: "301"},
+		{Id: "502", ChannelId: "300"},
+		{Id: "500", ChannelId: "300"},
+	})
+	assert.Equal(t, map[string][]string{"300": {"500", "502"}, "301": {"501"}}, got)
+}
+
+func TestDeleteMessages(t *testing.T) {
+	ids := make([]string, 201)
+	for i := range ids {
+		ids[i] = strconv.Itoa(500 + i)
+	}
+	transport := &testTransport{}
+	sess := newRecordingSession(transport)
+
+	// bulk deletes are capped, so the messages are deleted in chunks
+	deleted, err := deleteMessages(sess, "300", ids)
+	require.NoError(t, err)
+	assert.Equal(t, 201, deleted)
+	assert.Equal(t, []string{
+		"POST /channels/300/messages/bulk-delete",
+		"POST /channels/300/messages/bulk-delete",
+		"DELETE /channels/300/messages/700",
+	}, transport.requests)
+
+	// failed chunks aren't counted
+	transport.fail = map[string]bool{"DELETE /channels/300/messages/700": true}
+	deleted, err = deleteMessages(sess, "300", ids)
+	assert.Error(t, err)
+	assert.Equal(t, 200, deleted)
+}
+
+func TestTripSummary(t *testing.T) {
+	flood := &protection.Rule{Limit: 2, Window: "10s"}
+	tests := []struct {
+		name string
+		trip protection.Trip
+		want string
+	}{
+		{
+			"flood",
+			protection.Trip{Kind: protection.FloodKind, Rule: flood, Messages: []protection.Message{{Id: "500"}, {Id: "501"}}},
+			"🚨 **Message flood** by <@200>: 2 messages within 10s",
+		},
+		{
+			"mentions",
+			protection.Trip{Kind: protection.MentionsKind, Rule: flood, Messages: []protection.Message{{Mentions: 3}, {Mentions: 4}}},
+			"🚨 **Mention spam** by <@200>: 7 mentions in 2 messages within 10s",
+		},
+		{
+			"joins",
+			protection.Trip{Kind: protection.JoinsKind, Rule: &protection.Rule{Window: "1m"}, Users: []uint64{201, 202}},
+			"🚨 **Mass join**: 2 members joined within 1m: <@201>, <@202>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tripSummary(tt.trip, "200"))
+		})
+	}
+}
+
+func TestServer_protectMessages(t *testing.T) {
+	// alerts only, with no moderation log, so tripping the rule makes no calls
+	s := newTestServer(t, config.ListenConfig{GuildId: "100", Protection: protection.Config{
+		Flood: protection.Rule{Limit: 2, Window: "10s", Actions: []string{protection.AlertAction}},
+	}})
+	s.app.Protection = protection.NewDetector()
+	sess := newTestSession()
+	now := time.Now()
+	for i := range 3 {
+		s.protectMessages(sess, &discordgo.MessageCreate{Message: &discordgo.Message{
+			ID: "500", ChannelID: "300", GuildID: "100", Author: &discordgo.User{ID: "200"}, Timestamp: now.Add(time.Duration(i) * time.Second),
+		}})
+	}
+
+	trip := protection.Trip{Kind: protection.FloodKind, Rule: &config.GlobalConfig.Discord.ListenChannelSet["100"].Protection.Flood}
+	require.NoError(t, s.respondToTrip(context.Background(), sess, "100", "200", &config.GlobalConfig.Discord.ListenChannelSet["100"].Protection, trip))
+}
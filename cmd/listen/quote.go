@@ -50,7 +50,7 @@ func (s *Server) echoQuote(sess *discordgo.Session, m *discordgo.MessageCreate)
 }
 
 func (s *Server) sendQuote(ctx context.Context, sess *discordgo.Session, m *discordgo.MessageCreate) error {
-	quoteData, err := s.app.Quotes.GetQuoteSql(ctx)
+	quoteData, err := s.app.Quotes.GetQuote(ctx, m.ChannelID, m.Content)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to get quote: "+err.Error())
 		_, err := sess.ChannelMessageSendReply(
@@ -4,12 +4,15 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/dmtaylor/costanza/internal/achievements"
+	"github.com/dmtaylor/costanza/internal/parser"
 	"github.com/dmtaylor/costanza/internal/roller"
 	"github.com/dmtaylor/costanza/internal/util"
 )
@@ -139,6 +142,7 @@ func (s *Server) dispatchRollCommands(sess *discordgo.Session, i *discordgo.Inte
 	slog.DebugContext(ctx, "starting roll", "roll", rollInput)
 
 	var result string
+	var dice []parser.DieRoll
 	var err error
 	roll := util.PreprocessRoll(rollInput)
 	switch cmdName {
@@ -150,7 +154,7 @@ func (s *Server) dispatchRollCommands(sess *discordgo.Session, i *discordgo.Inte
 			slog.ErrorContext(ctx, "missing roll input for interaction")
 			return
 		}
-		result, err = s.doDNotationRoll(roll)
+		result, dice, err = s.doDNotationRoll(roll)
 	case shadowrunCommandName:
 		if roll == "" {
 			if s.m.enabled {
@@ -239,17 +243,41 @@ func (s *Server) dispatchRollCommands(sess *discordgo.Session, i *discordgo.Inte
 		return
 	}
 	slog.DebugContext(ctx, "completed roll", "roll", rollInput)
+	if len(dice) > 0 && i.Member != nil && i.Member.User != nil {
+		if err = s.checkRollAchievements(ctx, sess, i.GuildID, i.Member.User.ID, dice); err != nil {
+			slog.ErrorContext(ctx, "failed to check roll achievements: "+err.Error())
+		}
+	}
 	if s.m.enabled {
 		s.m.eventSuccess.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: cmdName}).Inc()
 	}
 }
 
-func (s *Server) doDNotationRoll(input string) (string, error) {
+func (s *Server) doDNotationRoll(input string) (string, []parser.DieRoll, error) {
 	res, err := s.app.DNotationParser.DoParse(input)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse roll: %w", err)
+		return "", nil, fmt.Errorf("failed to parse roll: %w", err)
 	}
-	return fmt.Sprintf("%s = %d", res.StrValue, res.Value), nil
+	return fmt.Sprintf("%s = %d", res.StrValue, res.Value), res.Dice, nil
+}
+
+// checkRollAchievements checks the dice rolled by a member against the achievement rules
+func (s *Server) checkRollAchievements(ctx context.Context, sess *discordgo.Session, guild, user string, dice []parser.DieRoll) error {
+	guildId, err := strconv.ParseUint(guild, 10, 64)
+	if err != nil {
+		return fmt.Errorf("bad guild id: %w", err)
+	}
+	userId, err := strconv.ParseUint(user, 10, 64)
+	if err != nil {
+		return fmt.Errorf("bad user id: %w", err)
+	}
+	return s.checkAchievements(ctx, sess, achievements.Event{
+		Type:    achievements.RollEvent,
+		GuildId: guildId,
+		UserId:  userId,
+		Time:    time.Now(),
+		Dice:    dice,
+	})
 }
 
 func (s *Server) doShadowrunRoll(input string) (string, error) {
@@ -17,5 +17,14 @@ var Commands = []*discordgo.ApplicationCommand{
 	worldOfDarknessCommand,
 	darkHeresyTestSlashCommand,
 	leaderboardSlashCommand,
+	chartSlashCommand,
+	privacySlashCommand,
+	wrappedSlashCommand,
+	achievementsSlashCommand,
+	cursedSlashCommand,
+	swearJarSlashCommand,
+	warnSlashCommand,
+	infractionsSlashCommand,
+	pardonSlashCommand,
 	// testQuoteCommand, // Uncomment this to add test quote command
 }
@@ -0,0 +1,168 @@
+package listen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/model"
+	"github.com/dmtaylor/costanza/internal/starboard"
+	"github.com/dmtaylor/costanza/internal/util"
+)
+
+const starboardMetricEventName = "starboard"
+
+func (s *Server) starboardReactionAdd(sess *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	ctx := util.ContextFromDiscordReactionAdd(context.Background(), r)
+	s.handleStarboardReaction(ctx, sess, r.MessageReaction, messageReactionAddGatewayEvent)
+}
+
+func (s *Server) starboardReactionRemove(sess *discordgo.Session, r *discordgo.MessageReactionRemove) {
+	ctx := util.ContextFromDiscordReactionRemove(context.Background(), r)
+	s.handleStarboardReaction(ctx, sess, r.MessageReaction, messageReactionRemoveGatewayEvent)
+}
+
+func (s *Server) handleStarboardReaction(ctx context.Context, sess *discordgo.Session, r *discordgo.MessageReaction, gatewayEvent string) {
+	if s.m.enabled {
+		start := time.Now()
+		defer func() {
+			s.m.eventDuration.With(prometheus.Labels{gatewayEventTypeLabel: gatewayEvent, eventNameLabel: starboardMetricEventName}).Observe(time.Since(start).Seconds())
+		}()
+	}
+	updated, err := s.updateStarboard(ctx, sess, r)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to update starboard: "+err.Error())
+		if s.m.enabled {
+			s.m.eventErrors.With(prometheus.Labels{gatewayEventTypeLabel: gatewayEvent, eventNameLabel: starboardMetricEventName, isTimeoutLabel: "false"}).Inc()
+		}
+	} else if updated && s.m.enabled {
+		s.m.eventSuccess.With(prometheus.Labels{gatewayEventTypeLabel: gatewayEvent, eventNameLabel: starboardMetricEventName}).Inc()
+	}
+}
+
+// updateStarboard reposts the reacted message to the guild's starboard once it has enough of the starboard emoji,
+// updates the count on an existing repost & removes the repost if it drops below the threshold. Returns false if the
+// reaction isn't for the starboard.
+func (s *Server) updateStarboard(ctx context.Context, sess *discordgo.Session, r *discordgo.MessageReaction) (bool, error) {
+	listenConfig, ok := config.GlobalConfig.Discord.ListenChannelSet[r.GuildID]
+	if !ok || !listenConfig.StarboardEnabled() || r.ChannelID == listenConfig.StarboardChannelId {
+		return false, nil
+	}
+	emoji := listenConfig.StarboardReaction()
+	if r.Emoji.APIName() != emoji {
+		return false, nil
+	}
+	guildId, err := strconv.ParseUint(r.GuildID, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("bad guild id: %w", err)
+	}
+	channelId, err := strconv.ParseUint(r.ChannelID, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("bad channel id: %w", err)
+	}
+	messageId, err := strconv.ParseUint(r.MessageID, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("bad message id: %w", err)
+	}
+
+	// serialize updates so concurrent reactions can't create duplicate reposts
+	s.starboardLock.Lock()
+	defer s.starboardLock.Unlock()
+
+	// always fetch the message, as cached messages don't have current reaction counts
+	message, err := sess.ChannelMessage(r.ChannelID, r.MessageID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get reacted message: %w", err)
+	}
+	if message.Author != nil && message.Author.ID == sess.State.User.ID {
+		return false, nil
+	}
+	message.GuildID = r.GuildID
+	count := starboard.Count(message, emoji)
+	if message.Author != nil {
+		authorId, err := strconv.ParseUint(message.Author.ID, 10, 64)
+		if err != nil {
+			return false, fmt.Errorf("bad author id: %w", err)
+		}
+		optedOut, err := s.isOptedOut(ctx, guildId, authorId)
+		if err != nil {
+			return false, err
+		}
+		if optedOut {
+			count = 0 // messages from users who opted out aren't reposted, & existing reposts are removed
+		}
+	}
+	post, err := s.app.Starboard.GetPost(ctx, messageId)
+	if err != nil {
+		return false, err
+	}
+
+	if count < listenConfig.StarboardMinimum() {
+		if post == nil {
+			return true, nil
+		}
+		err = sess.ChannelMessageDelete(listenConfig.StarboardChannelId, strconv.FormatUint(post.StarboardMessageId, 10))
+		if err != nil && !isUnknownMessage(err) {
+			return false, fmt.Errorf("failed to delete starboard post: %w", err)
+		}
+		return true, s.app.Starboard.RemovePost(ctx, messageId)
+	}
+	if post != nil && post.Count == count {
+		return true, nil
+	}
+
+	content := starboard.Content(message, emoji, count)
+	embeds := []*discordgo.MessageEmbed{starboard.Embed(message)}
+	var repost *discordgo.Message
+	if post != nil {
+		repost, err = sess.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:      strconv.FormatUint(post.StarboardMessageId, 10),
+			Channel: listenConfig.StarboardChannelId,
+			Content: &content,
+			Embeds:  &embeds,
+		})
+		if err != nil && !isUnknownMessage(err) {
+			return false, fmt.Errorf("failed to edit starboard post: %w", err)
+		}
+	}
+	if repost == nil { // not posted yet, or the repost was deleted from the starboard
+		repost, err = sess.ChannelMessageSendComplex(listenConfig.StarboardChannelId, &discordgo.MessageSend{
+			Content: content,
+			Embeds:  embeds,
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to send starboard post: %w", err)
+		}
+	}
+	repostId, err := strconv.ParseUint(repost.ID, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("bad starboard message id: %w", err)
+	}
+	return true, s.app.Starboard.SavePost(ctx, model.StarboardPost{
+		GuildId:            guildId,
+		ChannelId:          channelId,
+		MessageId:          messageId,
+		StarboardMessageId: repostId,
+		Count:              count,
+	})
+}
+
+// isUnknownMessage checks if a discord API error is because the message doesn't exist
+func isUnknownMessage(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) {
+		return false
+	}
+	if restErr.Message != nil {
+		return restErr.Message.Code == discordgo.ErrCodeUnknownMessage
+	}
+	return restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,44 @@
+package listen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/config"
+)
+
+func TestServer_updateStarboardIgnored(t *testing.T) {
+	tests := []struct {
+		name     string
+		reaction *discordgo.MessageReaction
+	}{
+		{
+			"unconfigured_guild",
+			&discordgo.MessageReaction{GuildID: "999", ChannelID: "300", MessageID: "500", UserID: "200", Emoji: discordgo.Emoji{Name: "⭐"}},
+		},
+		{
+			"other_emoji",
+			&discordgo.MessageReaction{GuildID: "100", ChannelID: "300", MessageID: "500", UserID: "200", Emoji: discordgo.Emoji{Name: "🔥"}},
+		},
+		{
+			"starboard_channel",
+			&discordgo.MessageReaction{GuildID: "100", ChannelID: "400", MessageID: "500", UserID: "200", Emoji: discordgo.Emoji{Name: "⭐"}},
+		},
+		{
+			"disabled_starboard",
+			&discordgo.MessageReaction{GuildID: "101", ChannelID: "300", MessageID: "500", UserID: "200", Emoji: discordgo.Emoji{Name: "⭐"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, config.ListenConfig{GuildId: "100", StarboardChannelId: "400"}, config.ListenConfig{GuildId: "101"})
+			updated, err := s.updateStarboard(context.Background(), newTestSession(), tt.reaction)
+			require.NoError(t, err)
+			assert.False(t, updated)
+		})
+	}
+}
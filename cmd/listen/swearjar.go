@@ -0,0 +1,120 @@
+package listen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/util"
+)
+
+const swearJarCommandName = "swearjar"
+const swearJarUserOptionName = "user"
+
+var swearJarSlashCommand = &discordgo.ApplicationCommand{
+	Name:        swearJarCommandName,
+	Type:        discordgo.ChatApplicationCommand,
+	Description: "Check how much is owed to the swear jar",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:        swearJarUserOptionName,
+			Description: "User to check the swear jar for, defaults to you",
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Required:    false,
+		},
+	},
+}
+
+func (s *Server) swearJarCommand(sess *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand || i.ApplicationCommandData().Name != swearJarCommandName {
+		return
+	}
+
+	var err error
+	if s.m.enabled {
+		start := time.Now()
+		defer func() {
+			s.m.eventDuration.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: swearJarCommandName}).Observe(time.Since(start).Seconds())
+			if err != nil {
+				isTimeout := strconv.FormatBool(errors.Is(err, context.DeadlineExceeded))
+				s.m.eventErrors.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: swearJarCommandName, isTimeoutLabel: isTimeout}).Inc()
+			} else {
+				s.m.eventSuccess.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: swearJarCommandName}).Inc()
+			}
+		}()
+	}
+	ctx, cancel := util.ContextFromDiscordInteractionCreate(context.Background(), i, interactionTimeout)
+	defer cancel()
+
+	var content string
+	if _, ok := config.GlobalConfig.Discord.ListenChannelSet[i.GuildID]; !ok || i.Member == nil || i.Member.User == nil {
+		content = "The swear jar isn't enabled on this guild. Please reach out to admin to enable"
+	} else {
+		data := i.ApplicationCommandData()
+		user := i.Member.User.ID
+		for _, option := range data.Options {
+			if option.Name == swearJarUserOptionName {
+				user = option.Value.(string)
+			}
+		}
+		content, err = s.swearJarContent(ctx, i.GuildID, user)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to get swear jar: "+err.Error())
+			content = "Failed to get the swear jar, please try again later"
+		}
+	}
+	ierr := sess.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         content,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+	if ierr != nil {
+		slog.ErrorContext(ctx, "failed to send swear jar response: "+ierr.Error())
+		err = ierr
+	}
+}
+
+// swearJarContent gives the user's swear jar total & what they owe, followed by the guild's biggest swear jars
+func (s *Server) swearJarContent(ctx context.Context, guild, user string) (string, error) {
+	guildId, err := strconv.ParseUint(guild, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("bad guild id: %w", err)
+	}
+	userId, err := strconv.ParseUint(user, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("bad user id: %w", err)
+	}
+	listenConfig := config.GlobalConfig.Discord.ListenChannelSet[guild]
+	fine := listenConfig.SwearJarRate()
+	total, err := s.app.Cursed.SwearJar(ctx, guildId, userId)
+	if err != nil {
+		return "", err
+	}
+	leaders, err := s.app.Cursed.SwearJarLeaders(ctx, guildId, listenConfig.LeaderboardLimit())
+	if err != nil {
+		return "", err
+	}
+	var content strings.Builder
+	if total == 0 {
+		content.WriteString(fmt.Sprintf("🫙 <@%s> has a clean mouth, nothing owed to the swear jar", user))
+	} else {
+		content.WriteString(fmt.Sprintf("🫙 <@%s> owes the swear jar **$%.2f** for %d cursed words", user, float64(total)*fine, total))
+	}
+	if len(leaders) > 0 {
+		content.WriteString("\n\nBiggest swear jars:")
+		for i, leader := range leaders {
+			content.WriteString(fmt.Sprintf("\n%d. <@%d>: $%.2f (%d)", i+1, leader.UserId, float64(leader.Total)*fine, leader.Total))
+		}
+	}
+	return content.String(), nil
+}
@@ -33,7 +33,7 @@ func (s *Server) quoteTestCommand(sess *discordgo.Session, i *discordgo.Interact
 	var quote model.Quote
 	var err error
 	if quoteId == 0 {
-		quote, err = s.app.Quotes.GetQuoteSql(ctx)
+		quote, err = s.app.Quotes.GetQuote(ctx, i.ChannelID, "")
 	} else {
 		quote, err = s.app.Quotes.GetQuoteById(ctx, int(quoteId))
 	}
@@ -0,0 +1,149 @@
+package listen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/stats"
+	"github.com/dmtaylor/costanza/internal/util"
+)
+
+const wrappedCommandName = "wrapped"
+const wrappedUserOptionName = "user"
+
+var wrappedSlashCommand = &discordgo.ApplicationCommand{
+	Name:        wrappedCommandName,
+	Type:        discordgo.ChatApplicationCommand,
+	Description: "Show the guild's year in review so far",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:        wrappedUserOptionName,
+			Description: "Show a single user's year instead of the whole guild",
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Required:    false,
+		},
+	},
+}
+
+func (s *Server) wrappedCommand(sess *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand || i.ApplicationCommandData().Name != wrappedCommandName {
+		return
+	}
+
+	var err error
+	if s.m.enabled {
+		start := time.Now()
+		defer func() {
+			s.m.eventDuration.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: wrappedCommandName}).Observe(time.Since(start).Seconds())
+			if err != nil {
+				isTimeout := strconv.FormatBool(errors.Is(err, context.DeadlineExceeded))
+				s.m.eventErrors.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: wrappedCommandName, isTimeoutLabel: isTimeout}).Inc()
+			} else {
+				s.m.eventSuccess.With(prometheus.Labels{gatewayEventTypeLabel: interactionCreateGatewayEvent, eventNameLabel: wrappedCommandName}).Inc()
+			}
+		}()
+	}
+	ctx, cancel := util.ContextFromDiscordInteractionCreate(context.Background(), i, interactionTimeout)
+	defer cancel()
+
+	listenConfig, ok := config.GlobalConfig.Discord.ListenChannelSet[i.GuildID]
+	if !ok {
+		err = sess.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Stats aren't enabled on this guild. Please reach out to admin to enable",
+			},
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to send empty response: "+err.Error())
+		}
+		return
+	}
+	err = sess.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create deferred response: "+err.Error())
+		return
+	}
+
+	guildId, err := strconv.ParseUint(i.GuildID, 10, 64)
+	if err != nil {
+		err = fmt.Errorf("failed to format guild id: %w", err)
+		slog.ErrorContext(ctx, "bad guild id: "+err.Error())
+		return
+	}
+	data := i.ApplicationCommandData()
+	var userId uint64
+	var userName string
+	for _, option := range data.Options {
+		if option.Name == wrappedUserOptionName {
+			userName = displayName(data.Resolved, option.Value.(string))
+			userId, err = strconv.ParseUint(option.Value.(string), 10, 64)
+			if err != nil {
+				slog.ErrorContext(ctx, "bad user id: "+err.Error())
+				return
+			}
+		}
+	}
+	year := time.Now().In(listenConfig.Location()).Format("2006")
+	sections, err := s.wrappedSections(ctx, guildId, year, userId)
+	if err != nil || len(sections) == 0 {
+		content := "There are no stats for this year yet"
+		if userId != 0 {
+			content = "There are no stats for " + userName + " this year"
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to build wrapped: "+err.Error())
+			content = "Failed to get this year's stats, please try again later"
+		}
+		_, ierr := sess.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{Content: content})
+		if ierr != nil {
+			slog.ErrorContext(ctx, "failed to send no stats response: "+ierr.Error())
+		}
+		return
+	}
+	// page buttons only page through leaderboards, so every page is sent
+	for _, page := range stats.RenderEmbeds(stats.WrappedTitle(year), sections) {
+		_, err = sess.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
+			Embeds: []*discordgo.MessageEmbed{page},
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to send wrapped: "+err.Error())
+			return
+		}
+	}
+}
+
+// wrappedSections gets the guild's Wrapped for the year, or a single user's if userId is set. Returns no sections if
+// there's nothing to show, including for users who opted out of tracking.
+func (s *Server) wrappedSections(ctx context.Context, guildId uint64, year string, userId uint64) ([]stats.ReportSection, error) {
+	exclude, err := s.app.OptOutCache.Get(ctx, guildId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get opted out users: %w", err)
+	}
+	wrapped, err := stats.BuildWrapped(ctx, s.app.Stats, guildId, year, exclude)
+	if err != nil {
+		return nil, err
+	}
+	if userId == 0 {
+		if len(wrapped.Members) == 0 {
+			return nil, nil
+		}
+		return wrapped.Sections(), nil
+	}
+	member, ok := wrapped.Member(userId)
+	if !ok {
+		return nil, nil
+	}
+	return []stats.ReportSection{member.Section()}, nil
+}
@@ -0,0 +1,46 @@
+package listen
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/stats"
+)
+
+func TestServer_wrappedSections(t *testing.T) {
+	ctx := context.Background()
+	// monthly stats are kept for the year's Wrapped even when only weekly reports are enabled
+	s := newTestServer(t, config.ListenConfig{GuildId: "100", Periods: []string{"weekly"}})
+	timestamp := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	for _, author := range []string{"200", "200", "201"} {
+		message := &discordgo.Message{GuildID: "100", ChannelID: "300", Author: &discordgo.User{ID: author}, Type: discordgo.MessageTypeDefault, Timestamp: timestamp}
+		s.logMessageActivity(newTestSession(), &discordgo.MessageCreate{Message: message})
+	}
+	require.NoError(t, s.app.Privacy.OptOut(ctx, 100, 201))
+
+	got, err := s.wrappedSections(ctx, 100, "2024", 200)
+	require.NoError(t, err)
+	if assert.Len(t, got, 1) {
+		assert.Contains(t, got[0].Entries, stats.ReportEntry{Text: "Messages sent: 2"})
+	}
+
+	got, err = s.wrappedSections(ctx, 100, "2024", 201)
+	require.NoError(t, err)
+	assert.Empty(t, got, "opted out user shown")
+
+	got, err = s.wrappedSections(ctx, 100, "2024", 0)
+	require.NoError(t, err)
+	if assert.NotEmpty(t, got) {
+		assert.Equal(t, stats.ReportEntry{Text: "2 messages from 1 members"}, got[0].Entries[0])
+	}
+
+	got, err = s.wrappedSections(ctx, 100, "2023", 0)
+	require.NoError(t, err)
+	assert.Empty(t, got, "no stats for the year")
+}
@@ -0,0 +1,92 @@
+package privacyCmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/internal/achievements"
+	"github.com/dmtaylor/costanza/internal/cursed"
+	"github.com/dmtaylor/costanza/internal/privacy"
+	"github.com/dmtaylor/costanza/internal/stats"
+)
+
+// Cmd represents the privacy command
+var Cmd = &cobra.Command{
+	Use:   "privacy",
+	Short: "Handle member privacy requests",
+	Long: `Opt members out of or back in to stat tracking, or delete their stats, the same as the /privacy command.
+Running listen processes pick up opt outs straight away, & drop any stats for deleted members they haven't written yet.`,
+}
+
+var optOutCmd = &cobra.Command{
+	Use:     "optout",
+	Short:   "Stop tracking a member's stats",
+	Example: "costanza privacy optout --guild 12345 --user 67890",
+	RunE: runPrivacy(func(ctx context.Context, pool *pgxpool.Pool) error {
+		return privacy.New(pool).OptOut(ctx, guildId, userId)
+	}),
+}
+
+var optInCmd = &cobra.Command{
+	Use:     "optin",
+	Short:   "Resume tracking a member's stats",
+	Example: "costanza privacy optin --guild 12345 --user 67890",
+	RunE: runPrivacy(func(ctx context.Context, pool *pgxpool.Pool) error {
+		return privacy.New(pool).OptIn(ctx, guildId, userId)
+	}),
+}
+
+var deleteCmd = &cobra.Command{
+	Use:     "delete",
+	Short:   "Delete every stat, achievement & swear jar recorded for a member",
+	Example: "costanza privacy delete --guild 12345 --user 67890",
+	RunE: runPrivacy(func(ctx context.Context, pool *pgxpool.Pool) error {
+		if err := stats.New(pool).RemoveUser(ctx, guildId, userId); err != nil {
+			return err
+		}
+		if err := achievements.New(pool).RemoveUser(ctx, guildId, userId); err != nil {
+			return err
+		}
+		return cursed.New(pool).RemoveUser(ctx, guildId, userId)
+	}),
+}
+
+var (
+	guildId uint64
+	userId  uint64
+)
+
+func init() {
+	for _, c := range []*cobra.Command{optOutCmd, optInCmd, deleteCmd} {
+		c.Flags().Uint64VarP(&guildId, "guild", "g", 0, "Guild ID of the member")
+		c.MarkFlagRequired("guild")
+		c.Flags().Uint64VarP(&userId, "user", "u", 0, "User ID of the member")
+		c.MarkFlagRequired("user")
+		Cmd.AddCommand(c)
+	}
+}
+
+// runPrivacy connects to the db & applies the change for the member
+func runPrivacy(apply func(ctx context.Context, pool *pgxpool.Pool) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, _ []string) error {
+		err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		pool, err := pgxpool.New(context.Background(), config.GlobalConfig.Db.Connection)
+		if err != nil {
+			return fmt.Errorf("failed to build conn pool: %w", err)
+		}
+		defer pool.Close()
+		if err = apply(context.Background(), pool); err != nil {
+			return err
+		}
+		slog.Info("updated member privacy", "action", cmd.Name(), "guild", guildId, "user", userId)
+		return nil
+	}
+}
@@ -23,6 +23,7 @@ var Cmd = &cobra.Command{
 }
 
 var n uint
+var message string
 
 func init() {
 	Cmd.PersistentFlags().UintVarP(
@@ -32,6 +33,13 @@ func init() {
 		1,
 		"Number of quotes to get",
 	)
+	Cmd.PersistentFlags().StringVarP(
+		&message,
+		"message",
+		"m",
+		"",
+		"Message to pick relevant quotes for, random if empty",
+	)
 }
 
 func runQuote(_ *cobra.Command, _ []string) error {
@@ -53,7 +61,7 @@ func runQuote(_ *cobra.Command, _ []string) error {
 		return fmt.Errorf("failed to build engine: %w", err)
 	}
 	for i := uint(0); i < n; i++ {
-		quote, err := engine.GetQuoteSql(context.Background())
+		quote, err := engine.GetQuote(context.Background(), "", message)
 		if err != nil {
 			return fmt.Errorf("failed to get quote: %w", err)
 		}
@@ -6,9 +6,11 @@ import (
 
 	"github.com/dmtaylor/costanza/cmd/cron"
 	"github.com/dmtaylor/costanza/cmd/listen"
+	"github.com/dmtaylor/costanza/cmd/privacyCmd"
 	"github.com/dmtaylor/costanza/cmd/quoteCmd"
 	"github.com/dmtaylor/costanza/cmd/register"
 	"github.com/dmtaylor/costanza/cmd/roll"
+	"github.com/dmtaylor/costanza/cmd/statsCmd"
 	"github.com/dmtaylor/costanza/config"
 )
 
@@ -41,5 +43,5 @@ func init() {
 	)
 	viper.BindPFlag("db.connection", rootCmd.PersistentFlags().Lookup("connectionStr"))
 	viper.BindEnv("db.connection", "COSTANZA_DB_URL")
-	rootCmd.AddCommand(listen.Cmd, roll.Cmd, quoteCmd.Cmd, cfgCmd, register.Cmd, cron.Cmd)
+	rootCmd.AddCommand(listen.Cmd, roll.Cmd, quoteCmd.Cmd, cfgCmd, register.Cmd, cron.Cmd, statsCmd.Cmd, privacyCmd.Cmd)
 }
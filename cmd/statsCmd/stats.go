@@ -0,0 +1,210 @@
+package statsCmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+
+	"github.com/dmtaylor/costanza/config"
+	"github.com/dmtaylor/costanza/docs"
+	"github.com/dmtaylor/costanza/internal/export"
+)
+
+const dateLayout = "2006-01-02"
+
+// Cmd represents the stats command
+var Cmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Manage guild stats data",
+	Long:  `Export & import a guild's stats tables, e.g. for backups or moving between databases`,
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a guild's stats",
+	Long: `Export every guild table to a file per table in the output directory. Periods are filtered by their start
+date, and tables without periods are exported in full.`,
+	Example: "costanza stats export --guild 12345 --from 2024-01-01 --to 2024-12-31 --format csv --dir ./backup",
+	RunE:    runExport,
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import a guild's stats",
+	Long: `Import files written by export from the input directory. Every file is validated against the table schemas
+before anything is written, and importing the same files again leaves the data unchanged.`,
+	Example: "costanza stats import --guild 12345 --format csv --dir ./backup",
+	RunE:    runImport,
+}
+
+var (
+	guildId uint64
+	from    string
+	to      string
+	format  string
+	dir     string
+	dryRun  bool
+)
+
+func init() {
+	for _, c := range []*cobra.Command{exportCmd, importCmd} {
+		c.Flags().Uint64VarP(&guildId, "guild", "g", 0, "Guild ID to export or import")
+		c.MarkFlagRequired("guild")
+		c.Flags().StringVarP(&format, "format", "f", string(export.CSVFormat), "File format, one of csv, json or parquet")
+		c.Flags().StringVarP(&dir, "dir", "d", ".", "Directory for the table files")
+	}
+	exportCmd.Flags().StringVar(&from, "from", "", "Earliest period start date to export, as YYYY-MM-DD")
+	exportCmd.Flags().StringVar(&to, "to", "", "Latest period start date to export, as YYYY-MM-DD")
+	importCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Validate the files without importing them")
+	Cmd.AddCommand(exportCmd, importCmd)
+}
+
+func runExport(_ *cobra.Command, _ []string) error {
+	f, err := export.ParseFormat(format)
+	if err != nil {
+		return err
+	}
+	periods, err := parseRange(from, to)
+	if err != nil {
+		return err
+	}
+	schemas, err := export.LoadSchemas(docs.Schemas, "schemas")
+	if err != nil {
+		return fmt.Errorf("failed to load schemas: %w", err)
+	}
+	pool, err := connect()
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output dir: %w", err)
+	}
+	ctx := context.Background()
+	for _, schema := range export.GuildTables(schemas) {
+		table, err := export.Export(ctx, pool, schema, guildId, periods)
+		if err != nil {
+			return err
+		}
+		if err = writeTable(filepath.Join(dir, schema.Table+"."+string(f)), f, schema, table); err != nil {
+			return err
+		}
+		slog.Info("exported table", "guild", guildId, "table", schema.Table, "rows", len(table.Rows))
+	}
+	return nil
+}
+
+func runImport(_ *cobra.Command, _ []string) error {
+	f, err := export.ParseFormat(format)
+	if err != nil {
+		return err
+	}
+	schemas, err := export.LoadSchemas(docs.Schemas, "schemas")
+	if err != nil {
+		return fmt.Errorf("failed to load schemas: %w", err)
+	}
+	var tables []export.Table
+	for _, schema := range export.GuildTables(schemas) {
+		table, err := readTable(filepath.Join(dir, schema.Table+"."+string(f)), f, schema)
+		if errors.Is(err, fs.ErrNotExist) {
+			slog.Info("skipping table without file", "table", schema.Table)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		tables = append(tables, table)
+	}
+	if len(tables) == 0 {
+		return fmt.Errorf("no %s files found in %s", f, dir)
+	}
+	if err = export.ValidateGuild(schemas, guildId, tables); err != nil {
+		return fmt.Errorf("invalid import files: %w", err)
+	}
+	if dryRun {
+		for _, table := range tables {
+			slog.Info("validated table", "table", table.Name, "rows", len(table.Rows))
+		}
+		return nil
+	}
+	pool, err := connect()
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	if err = export.Import(context.Background(), pool, schemas, guildId, tables); err != nil {
+		return err
+	}
+	for _, table := range tables {
+		slog.Info("imported table", "guild", guildId, "table", table.Name, "rows", len(table.Rows))
+	}
+	return nil
+}
+
+func connect() (*pgxpool.Pool, error) {
+	err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	pool, err := pgxpool.New(context.Background(), config.GlobalConfig.Db.Connection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build conn pool: %w", err)
+	}
+	return pool, nil
+}
+
+func parseRange(from, to string) (export.PeriodRange, error) {
+	var periods export.PeriodRange
+	var err error
+	if from != "" {
+		if periods.From, err = time.Parse(dateLayout, from); err != nil {
+			return periods, fmt.Errorf("invalid from date %s: %w", from, err)
+		}
+	}
+	if to != "" {
+		if periods.To, err = time.Parse(dateLayout, to); err != nil {
+			return periods, fmt.Errorf("invalid to date %s: %w", to, err)
+		}
+	}
+	if !periods.From.IsZero() && !periods.To.IsZero() && periods.To.Before(periods.From) {
+		return periods, fmt.Errorf("to date %s is before from date %s", to, from)
+	}
+	return periods, nil
+}
+
+func writeTable(path string, f export.Format, schema export.Schema, table export.Table) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close %s: %w", path, closeErr)
+		}
+	}()
+	if err = export.Write(file, f, schema, table); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func readTable(path string, f export.Format, schema export.Schema) (export.Table, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return export.Table{}, err
+	}
+	defer file.Close()
+	table, err := export.Read(file, f, schema)
+	if err != nil {
+		return export.Table{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return table, nil
+}
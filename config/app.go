@@ -12,11 +12,18 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/dmtaylor/costanza/internal/achievements"
+	"github.com/dmtaylor/costanza/internal/audit"
 	"github.com/dmtaylor/costanza/internal/cache"
+	"github.com/dmtaylor/costanza/internal/cursed"
+	"github.com/dmtaylor/costanza/internal/infractions"
 	"github.com/dmtaylor/costanza/internal/model"
 	"github.com/dmtaylor/costanza/internal/parser"
+	"github.com/dmtaylor/costanza/internal/privacy"
+	"github.com/dmtaylor/costanza/internal/protection"
 	"github.com/dmtaylor/costanza/internal/quotes"
 	"github.com/dmtaylor/costanza/internal/roller"
+	"github.com/dmtaylor/costanza/internal/starboard"
 	"github.com/dmtaylor/costanza/internal/stats"
 )
 
@@ -25,13 +32,26 @@ const VersionString = "v1.6.0"
 
 // App represents the current app components & state
 type App struct {
-	Quotes             quotes.QuoteEngine
-	DNotationParser    *parser.DNotationParser
-	ThresholdRoller    *roller.ThresholdRoller
-	ConnPool           model.DbPool
-	Stats              *stats.Stats
-	CursedChannelCache cache.ChannelCache
-	CursedWordCache    cache.StringListCache
+	Quotes               quotes.QuoteEngine
+	DNotationParser      *parser.DNotationParser
+	ThresholdRoller      *roller.ThresholdRoller
+	ConnPool             model.DbPool
+	Stats                stats.StatsStore
+	CursedChannelCache   cache.ChannelCache
+	CursedWordCache      cache.StringListCache
+	CursedAllowCache     cache.StringListCache // words exempt from cursed word matching in each guild
+	CursedMatchers       *cursed.Matchers
+	Cursed               cursed.Store
+	Infractions          infractions.Store
+	CursedIncidents      *cursed.Incidents
+	ContainmentCooldowns *cursed.Cooldowns // limits reminders to contained members posting outside cursed channels
+	Protection           *protection.Detector
+	AuditMessages        *audit.MessageCache // recent posts, so audit log entries for edits & deletes can show what they said
+	OptOutCache          cache.ChannelCache  // users who opted out of stat tracking in each guild
+	CacheListener        *cache.Listener     // invalidates the caches above as their tables change
+	Starboard            starboard.Store
+	Privacy              privacy.Store
+	Achievements         *achievements.Engine
 }
 
 var loader sync.Once
@@ -41,65 +61,7 @@ var app App // use singleton App
 func LoadApp() (*App, error) {
 	var err error
 	loader.Do(func() {
-		err = LoadConfig()
-		if err != nil {
-			err = fmt.Errorf("failed to load cfgs while loading app: %w", err)
-			return
-		}
-		pool, err := pgxpool.New(context.Background(), GlobalConfig.Db.Connection)
-		if err != nil {
-			err = fmt.Errorf("failed to build connection pool: %w", err)
-			return
-		}
-		// test db connection before starting app
-		err = pool.Ping(context.Background())
-		if err != nil {
-			err = fmt.Errorf("failed to get db connection: %w", err)
-			return
-		}
-		buf := make([]byte, 8)
-		_, err = rand.Read(buf)
-		if err != nil {
-			err = fmt.Errorf("failed to get crypto seed: %w", err)
-			return
-		}
-		qEngine, err := quotes.NewQuoteEngine(pool, uint64(time.Now().UnixNano()), binary.NativeEndian.Uint64(buf))
-		if err != nil {
-			err = fmt.Errorf("server failed to build quote engine: %w", err)
-			return
-		}
-		statsSvc := stats.New(pool)
-		dNotationParser, err := parser.NewDNotationParser()
-		if err != nil {
-			err = fmt.Errorf("failed to build parser: %w", err)
-			return
-		}
-		thRoller, err := roller.NewThresholdRoller()
-		if err != nil {
-			err = fmt.Errorf("failed to build threshold roller: %w", err)
-			return
-		}
-		cursedChannelCache := cache.NewDbChannelCache(pool)
-		err = preloadCache(cursedChannelCache)
-		if err != nil {
-			err = fmt.Errorf("failed to create channel cache: %w", err)
-			return
-		}
-		cursedWordCache := cache.NewPgxStringListCache(pool)
-		err = preloadCache(cursedWordCache)
-		if err != nil {
-			err = fmt.Errorf("failed to build word cache: %w", err)
-			return
-		}
-		app = App{
-			Quotes:             qEngine,
-			DNotationParser:    dNotationParser,
-			ThresholdRoller:    thRoller,
-			ConnPool:           pool,
-			Stats:              &statsSvc,
-			CursedChannelCache: cursedChannelCache,
-			CursedWordCache:    cursedWordCache,
-		}
+		err = buildApp()
 	})
 	if err != nil {
 		return nil, err
@@ -107,6 +69,107 @@ func LoadApp() (*App, error) {
 	return &app, nil
 }
 
+func buildApp() error {
+	err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load cfgs while loading app: %w", err)
+	}
+	pool, err := pgxpool.New(context.Background(), GlobalConfig.Db.Connection)
+	if err != nil {
+		return fmt.Errorf("failed to build connection pool: %w", err)
+	}
+	// test db connection before starting app
+	err = pool.Ping(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to get db connection: %w", err)
+	}
+	buf := make([]byte, 8)
+	_, err = rand.Read(buf)
+	if err != nil {
+		return fmt.Errorf("failed to get crypto seed: %w", err)
+	}
+	qEngine, err := quotes.NewQuoteEngine(pool, uint64(time.Now().UnixNano()), binary.NativeEndian.Uint64(buf))
+	if err != nil {
+		return fmt.Errorf("server failed to build quote engine: %w", err)
+	}
+	statsSvc, err := newStatsStore(pool)
+	if err != nil {
+		return fmt.Errorf("failed to build stats store: %w", err)
+	}
+	dNotationParser, err := parser.NewDNotationParser()
+	if err != nil {
+		return fmt.Errorf("failed to build parser: %w", err)
+	}
+	thRoller, err := roller.NewThresholdRoller()
+	if err != nil {
+		return fmt.Errorf("failed to build threshold roller: %w", err)
+	}
+	cacheOpts := GlobalConfig.Db.CacheOptions()
+	cacheListener := cache.NewListener(pool)
+	cursedChannelCache := cache.NewCursedChannelCache(pool, cacheOpts)
+	err = preloadCache(cursedChannelCache)
+	if err != nil {
+		return fmt.Errorf("failed to create channel cache: %w", err)
+	}
+	cacheListener.Register(cache.CursedChannelsTable, cursedChannelCache)
+	cursedWordCache := cache.NewCursedWordCache(pool, cacheOpts)
+	err = preloadCache(cursedWordCache)
+	if err != nil {
+		return fmt.Errorf("failed to build word cache: %w", err)
+	}
+	cacheListener.Register(cache.CursedWordsTable, cursedWordCache)
+	cursedAllowCache := cache.NewAllowlistCache(pool, cacheOpts)
+	err = preloadCache(cursedAllowCache)
+	if err != nil {
+		return fmt.Errorf("failed to build allowlist cache: %w", err)
+	}
+	cacheListener.Register(cache.CursedAllowTable, cursedAllowCache)
+	optOutCache := cache.NewOptOutCache(pool, cacheOpts)
+	err = preloadCache(optOutCache)
+	if err != nil {
+		return fmt.Errorf("failed to build opt out cache: %w", err)
+	}
+	cacheListener.Register(cache.OptOutsTable, optOutCache)
+	if err = achievements.ValidateRules(achievements.Rules); err != nil {
+		return fmt.Errorf("invalid achievement rules: %w", err)
+	}
+	app = App{
+		Quotes:               qEngine,
+		DNotationParser:      dNotationParser,
+		ThresholdRoller:      thRoller,
+		ConnPool:             pool,
+		Stats:                statsSvc,
+		CursedChannelCache:   cursedChannelCache,
+		CursedWordCache:      cursedWordCache,
+		CursedAllowCache:     cursedAllowCache,
+		CursedMatchers:       cursed.NewMatchers(cursedWordCache, cursedAllowCache),
+		Cursed:               cursed.New(pool),
+		Infractions:          infractions.New(pool),
+		CursedIncidents:      cursed.NewIncidents(),
+		ContainmentCooldowns: cursed.NewCooldowns(),
+		Protection:           protection.NewDetector(),
+		AuditMessages:        audit.NewMessageCache(cache.Options{}),
+		OptOutCache:          optOutCache,
+		CacheListener:        cacheListener,
+		Starboard:            starboard.New(pool),
+		Privacy:              privacy.New(pool),
+		Achievements:         achievements.NewEngine(achievements.New(pool), achievements.Rules),
+	}
+	return nil
+}
+
+func newStatsStore(pool model.DbPool) (stats.StatsStore, error) {
+	switch GlobalConfig.Db.StatsStore {
+	case "", PostgresStatsStore:
+		pgStats := stats.New(pool)
+		return &pgStats, nil
+	case MemoryStatsStore:
+		return stats.NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("invalid stats store %s", GlobalConfig.Db.StatsStore)
+	}
+}
+
 func preloadCache(c cache.PreloadableCache) error {
 	ctx := context.WithValue(context.Background(), "setup", "channelCache")
 	guilds := make([]uint64, len(GlobalConfig.Discord.ListenConfigs))
@@ -0,0 +1,9 @@
+// Package docs embeds the reference table schemas, so stats imports can be validated without the source tree
+package docs
+
+import "embed"
+
+// Schemas is the CREATE TABLE statement for each table, in schemas/<table>.sql
+//
+//go:embed schemas/*.sql
+var Schemas embed.FS
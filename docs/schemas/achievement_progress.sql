@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS achievement_progress (
+    id SERIAL PRIMARY KEY,
+    guild_id NUMERIC NOT NULL,
+    user_id NUMERIC NOT NULL,
+    progress VARCHAR(64) NOT NULL,
+    value INTEGER NOT NULL DEFAULT 0,
+    day VARCHAR(16) NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX achievement_progress_guild_user_progress ON achievement_progress(guild_id, user_id, progress);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS achievements (
+    id SERIAL PRIMARY KEY,
+    guild_id NUMERIC NOT NULL,
+    user_id NUMERIC NOT NULL,
+    achievement VARCHAR(64) NOT NULL,
+    awarded_on VARCHAR(16) NOT NULL
+);
+
+CREATE UNIQUE INDEX achievements_guild_user_achievement ON achievements(guild_id, user_id, achievement);
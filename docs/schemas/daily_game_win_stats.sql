@@ -2,7 +2,7 @@ CREATE TABLE IF NOT EXiSTS daily_game_win_stats (
                                                     id SERIAL PRIMARY KEY,
                                                     guild_id NUMERIC NOT NULL,
                                                     user_id NUMERIC NOT NULL,
-                                                    report_month VARCHAR(7) NOT NULL,
+                                                    period VARCHAR(16) NOT NULL,
                                                     play_count INTEGER NOT NULL DEFAULT 0,
                                                     guess_count INTEGER NOT NULL DEFAULT 0,
                                                     win_count INTEGER NOT NULL DEFAULT 0,
@@ -11,4 +11,5 @@ CREATE TABLE IF NOT EXiSTS daily_game_win_stats (
 );
 
 CREATE INDEX win_stats_guild_users ON daily_game_win_stats(guild_id, user_id);
-CREATE INDEX win_stats_guild_month ON daily_game_win_stats(guild_id, report_month);
+CREATE INDEX win_stats_guild_period ON daily_game_win_stats(guild_id, period);
+CREATE UNIQUE INDEX win_stats_guild_user_period ON daily_game_win_stats(guild_id, user_id, period);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS reaction_counters (
+    id SERIAL PRIMARY KEY,
+    guild_id NUMERIC NOT NULL,
+    period VARCHAR(16) NOT NULL,
+    channel_id NUMERIC NOT NULL,
+    message_id NUMERIC NOT NULL,
+    author_id NUMERIC NOT NULL,
+    emoji VARCHAR(128) NOT NULL,
+    value INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX reaction_counters_guild_period_message_emoji ON reaction_counters(guild_id, period, message_id, emoji);
+CREATE INDEX reaction_counters_period ON reaction_counters(period);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS stat_counters (
+    id SERIAL PRIMARY KEY,
+    metric VARCHAR(64) NOT NULL,
+    guild_id NUMERIC NOT NULL,
+    user_id NUMERIC NOT NULL,
+    period VARCHAR(16) NOT NULL, -- report period key, or YYYY-MM-DD for per day chart counts
+    value INTEGER NOT NULL DEFAULT 0,
+    channel_id NUMERIC NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX stat_counters_metric_guild_user_period_channel ON stat_counters(metric, guild_id, user_id, period, channel_id);
+CREATE INDEX stat_counters_guild_period ON stat_counters(guild_id, period);
+CREATE INDEX stat_counters_guild_period_channel ON stat_counters(guild_id, period, channel_id);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS stat_removals (
+    id SERIAL PRIMARY KEY,
+    guild_id NUMERIC NOT NULL,
+    user_id NUMERIC NOT NULL,
+    removed_at NUMERIC NOT NULL -- unix seconds
+);
+
+CREATE UNIQUE INDEX stat_removals_guild_user ON stat_removals(guild_id, user_id);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS swear_jar (
+    id SERIAL PRIMARY KEY,
+    guild_id NUMERIC NOT NULL,
+    user_id NUMERIC NOT NULL,
+    total INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX swear_jar_guild_user ON swear_jar(guild_id, user_id);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS yearly_rollups (
+    id SERIAL PRIMARY KEY,
+    guild_id NUMERIC NOT NULL,
+    user_id NUMERIC NOT NULL,
+    period VARCHAR(16) NOT NULL,
+    stat VARCHAR(64) NOT NULL,
+    value INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX yearly_rollups_guild_user_period_stat ON yearly_rollups(guild_id, user_id, period, stat);
+CREATE INDEX yearly_rollups_guild_period ON yearly_rollups(guild_id, period);
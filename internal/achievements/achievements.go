@@ -0,0 +1,147 @@
+// Package achievements awards members milestones for their activity, checked against declarative rules
+package achievements
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+// Store persists earned achievements & each user's progress towards them
+type Store interface {
+	// GetProgress gets the user's progress for the key, or zero progress if there's none recorded
+	GetProgress(ctx context.Context, guildId uint64, userId uint64, key string) (model.AchievementProgress, error)
+	// SaveProgress saves the progress in a single batch. Progress loaded before its user was removed is out of date, so
+	// it's skipped & returned.
+	SaveProgress(ctx context.Context, progress []LoadedProgress) ([]LoadedProgress, error)
+	// Award records the achievement, returning false if the user already had it
+	Award(ctx context.Context, award model.Achievement) (bool, error)
+	GetAwards(ctx context.Context, guildId uint64, userId uint64) ([]*model.Achievement, error)
+	// RemoveUser deletes the user's achievements & progress in the guild
+	RemoveUser(ctx context.Context, guildId uint64, userId uint64) error
+}
+
+// LoadedProgress is progress with the time it was read from the store
+type LoadedProgress struct {
+	model.AchievementProgress
+	LoadedAt time.Time
+}
+
+// Achievements is the Postgres backed Store
+type Achievements struct {
+	pool model.DbPool
+}
+
+var _ Store = (*Achievements)(nil)
+var _ Store = (*MemoryStore)(nil)
+
+func New(pool model.DbPool) *Achievements {
+	return &Achievements{pool: pool}
+}
+
+func (a *Achievements) GetProgress(ctx context.Context, guildId uint64, userId uint64, key string) (model.AchievementProgress, error) {
+	progress := model.AchievementProgress{GuildId: guildId, UserId: userId, Progress: key}
+	err := pgxscan.Get(ctx, a.pool, &progress, `
+SELECT guild_id, user_id, progress, value, day
+FROM achievement_progress
+WHERE guild_id = $1 AND user_id = $2 AND progress = $3`, guildId, userId, key)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return progress, fmt.Errorf("failed to get %s progress: %w", key, err)
+	}
+	return progress, nil
+}
+
+// progressUpsertQuery saves progress unless its user was removed since it was loaded
+const progressUpsertQuery = `
+INSERT INTO achievement_progress (guild_id, user_id, progress, value, day)
+SELECT $1::NUMERIC, $2::NUMERIC, $3::VARCHAR, $4::INTEGER, $5::VARCHAR
+WHERE NOT EXISTS (SELECT 1 FROM stat_removals WHERE guild_id = $1 AND user_id = $2 AND removed_at >= $6)
+ON CONFLICT (guild_id, user_id, progress) DO UPDATE
+SET value = EXCLUDED.value, day = EXCLUDED.day`
+
+func (a *Achievements) SaveProgress(ctx context.Context, progress []LoadedProgress) ([]LoadedProgress, error) {
+	if len(progress) == 0 {
+		return nil, nil
+	}
+	results := a.pool.SendBatch(ctx, buildProgressBatch(progress))
+	defer results.Close()
+	var skipped []LoadedProgress
+	for _, p := range progress {
+		tag, err := results.Exec()
+		if err != nil {
+			return nil, fmt.Errorf("failed to save %s progress: %w", p.Progress, err)
+		}
+		if tag.RowsAffected() == 0 {
+			skipped = append(skipped, p)
+		}
+	}
+	if err := results.Close(); err != nil {
+		return nil, fmt.Errorf("failed to save progress: %w", err)
+	}
+	return skipped, nil
+}
+
+// buildProgressBatch creates the batch of upserts for the progress, in the same order
+func buildProgressBatch(progress []LoadedProgress) *pgx.Batch {
+	batch := &pgx.Batch{}
+	for _, p := range progress {
+		batch.Queue(progressUpsertQuery, p.GuildId, p.UserId, p.Progress, p.Value, p.Day, p.LoadedAt.Unix())
+	}
+	return batch
+}
+
+func (a *Achievements) Award(ctx context.Context, award model.Achievement) (bool, error) {
+	tag, err := a.pool.Exec(ctx, `
+INSERT INTO achievements (guild_id, user_id, achievement, awarded_on)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (guild_id, user_id, achievement) DO NOTHING`,
+		award.GuildId, award.UserId, award.Achievement, award.AwardedOn)
+	if err != nil {
+		return false, fmt.Errorf("failed to award %s: %w", award.Achievement, err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (a *Achievements) GetAwards(ctx context.Context, guildId uint64, userId uint64) ([]*model.Achievement, error) {
+	var awards []*model.Achievement
+	err := pgxscan.Select(ctx, a.pool, &awards, `
+SELECT guild_id, user_id, achievement, awarded_on
+FROM achievements
+WHERE guild_id = $1 AND user_id = $2
+ORDER BY awarded_on, achievement`, guildId, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get achievements: %w", err)
+	}
+	return awards, nil
+}
+
+func (a *Achievements) RemoveUser(ctx context.Context, guildId uint64, userId uint64) error {
+	tx, err := a.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start achievement removal: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	for _, table := range []string{"achievements", "achievement_progress"} {
+		_, err = tx.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE guild_id = $1 AND user_id = $2", table), guildId, userId)
+		if err != nil {
+			return fmt.Errorf("failed to remove user from %s: %w", table, err)
+		}
+	}
+	// recorded so progress other processes loaded before the removal isn't saved after it
+	_, err = tx.Exec(ctx, `
+INSERT INTO stat_removals (guild_id, user_id, removed_at) VALUES ($1, $2, $3)
+ON CONFLICT (guild_id, user_id) DO UPDATE SET removed_at = EXCLUDED.removed_at`, guildId, userId, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to record achievement removal: %w", err)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit achievement removal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package achievements
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+func TestAchievements_GetProgress(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`SELECT guild_id, user_id, progress, value, day
+FROM achievement_progress
+WHERE guild_id = \$1 AND user_id = \$2 AND progress = \$3`).
+		WithArgs(uint64(1), uint64(2), "count:message").
+		WillReturnRows(pgxmock.NewRows([]string{"guild_id", "user_id", "progress", "value", "day"}).
+			AddRow(uint64(1), uint64(2), "count:message", 5, ""))
+	got, err := New(mockDb).GetProgress(context.Background(), 1, 2, "count:message")
+	require.NoError(t, err)
+	assert.Equal(t, model.AchievementProgress{GuildId: 1, UserId: 2, Progress: "count:message", Value: 5}, got)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestAchievements_GetProgressMissing(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`FROM achievement_progress`).
+		WithArgs(uint64(1), uint64(2), "count:message").
+		WillReturnError(pgx.ErrNoRows)
+	got, err := New(mockDb).GetProgress(context.Background(), 1, 2, "count:message")
+	require.NoError(t, err)
+	assert.Equal(t, model.AchievementProgress{GuildId: 1, UserId: 2, Progress: "count:message"}, got)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func Test_buildProgressBatch(t *testing.T) {
+	loadedAt := time.Unix(1704067200, 0)
+	batch := buildProgressBatch([]LoadedProgress{
+		{AchievementProgress: model.AchievementProgress{GuildId: 1, UserId: 2, Progress: "streak:game", Value: 3, Day: "2024-01-02"}, LoadedAt: loadedAt},
+		{AchievementProgress: model.AchievementProgress{GuildId: 1, UserId: 0, Progress: "first:message", Day: "2024-01-02"}, LoadedAt: loadedAt},
+	})
+	if assert.Equal(t, 2, batch.Len()) {
+		assert.Equal(t, progressUpsertQuery, batch.QueuedQueries[0].SQL)
+		assert.Equal(t, []any{uint64(1), uint64(2), "streak:game", 3, "2024-01-02", int64(1704067200)}, batch.QueuedQueries[0].Arguments)
+		assert.Equal(t, []any{uint64(1), uint64(0), "first:message", 0, "2024-01-02", int64(1704067200)}, batch.QueuedQueries[1].Arguments)
+	}
+}
+
+func TestAchievements_SaveProgressEmpty(t *testing.T) {
+	skipped, err := New(nil).SaveProgress(context.Background(), nil) // nil pool would panic if a batch was sent
+	assert.NoError(t, err)
+	assert.Empty(t, skipped)
+}
+
+func TestAchievements_Award(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected int64
+		want     bool
+	}{
+		{"new award", 1, true},
+		{"already awarded", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb, err := pgxmock.NewPool()
+			require.Nil(t, err, "failed to build pool")
+			defer mockDb.Close()
+			mockDb.ExpectExec(`INSERT INTO achievements \(guild_id, user_id, achievement, awarded_on\)
+VALUES \(\$1, \$2, \$3, \$4\)
+ON CONFLICT \(guild_id, user_id, achievement\) DO NOTHING`).
+				WithArgs(uint64(1), uint64(2), "messages_100", "2024-01-02").
+				WillReturnResult(pgxmock.NewResult("INSERT", tt.affected))
+			got, err := New(mockDb).Award(context.Background(), model.Achievement{GuildId: 1, UserId: 2, Achievement: "messages_100", AwardedOn: "2024-01-02"})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+		})
+	}
+}
+
+func TestAchievements_GetAwards(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`FROM achievements
+WHERE guild_id = \$1 AND user_id = \$2
+ORDER BY awarded_on, achievement`).
+		WithArgs(uint64(1), uint64(2)).
+		WillReturnRows(pgxmock.NewRows([]string{"guild_id", "user_id", "achievement", "awarded_on"}).
+			AddRow(uint64(1), uint64(2), "first_message", "2024-01-01").
+			AddRow(uint64(1), uint64(2), "messages_100", "2024-01-02"))
+	got, err := New(mockDb).GetAwards(context.Background(), 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []*model.Achievement{
+		{GuildId: 1, UserId: 2, Achievement: "first_message", AwardedOn: "2024-01-01"},
+		{GuildId: 1, UserId: 2, Achievement: "messages_100", AwardedOn: "2024-01-02"},
+	}, got)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestAchievements_RemoveUser(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectBegin()
+	mockDb.ExpectExec(`DELETE FROM achievements WHERE guild_id = \$1 AND user_id = \$2`).
+		WithArgs(uint64(1), uint64(2)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 3))
+	mockDb.ExpectExec(`DELETE FROM achievement_progress WHERE guild_id = \$1 AND user_id = \$2`).
+		WithArgs(uint64(1), uint64(2)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 4))
+	mockDb.ExpectExec(`INSERT INTO stat_removals`).
+		WithArgs(uint64(1), uint64(2), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockDb.ExpectCommit()
+	mockDb.ExpectRollback()
+	assert.NoError(t, New(mockDb).RemoveUser(context.Background(), 1, 2))
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestAchievements_RemoveUserError(t *testing.T) {
+	expectedErr := errors.New("underlying db err")
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectBegin()
+	mockDb.ExpectExec(`DELETE FROM achievements`).
+		WithArgs(uint64(1), uint64(2)).
+		WillReturnError(expectedErr)
+	mockDb.ExpectRollback()
+	err = New(mockDb).RemoveUser(context.Background(), 1, 2)
+	assert.ErrorIs(t, err, expectedErr, "expected error not wrapped")
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
@@ -0,0 +1,335 @@
+package achievements
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/dmtaylor/costanza/internal/model"
+	"github.com/dmtaylor/costanza/internal/stats"
+)
+
+// guildUserId is the user progress is recorded against for guild wide rules, such as the first message of the day
+const guildUserId = 0
+
+const flushTimeout = time.Second * 10
+
+// Award is an achievement a user has earned
+type Award struct {
+	Rule
+	AwardedOn string // Day key of the day it was earned
+}
+
+// Engine checks events against the rules, keeping each user's progress in memory & awarding achievements. Changed
+// progress is saved to the store in batches.
+type Engine struct {
+	store Store
+	rules []Rule
+	byId  map[string]Rule
+
+	lock      sync.Mutex
+	progress  map[progressKey]*cachedProgress
+	flushing  sync.Mutex // serializes flushes & removals, so removed progress isn't saved after the removal
+	buffered  bool
+	done      chan struct{}
+	stopped   sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// cachedProgress is progress kept by the Engine between flushes
+type cachedProgress struct {
+	LoadedProgress
+	dirty   bool // changed since it was last saved
+	touched bool // used since the last flush, progress that isn't is dropped
+}
+
+func NewEngine(store Store, rules []Rule) *Engine {
+	byId := make(map[string]Rule, len(rules))
+	for _, rule := range rules {
+		byId[rule.Id] = rule
+	}
+	return &Engine{
+		store:    store,
+		rules:    rules,
+		byId:     byId,
+		progress: make(map[progressKey]*cachedProgress),
+		done:     make(chan struct{}),
+	}
+}
+
+// StartWriteBuffer keeps changed progress in memory, saving it to the store every flushInterval. Without it progress
+// is saved after every event. This must be called before the engine is shared across goroutines. Call Close to save
+// pending progress.
+func (e *Engine) StartWriteBuffer(flushInterval time.Duration) {
+	if e.buffered {
+		return
+	}
+	e.buffered = true
+	e.stopped.Add(1)
+	go func() {
+		defer e.stopped.Done()
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+				if err := e.Flush(ctx); err != nil {
+					slog.ErrorContext(ctx, "failed to flush achievement progress: "+err.Error())
+				}
+				cancel()
+			case <-e.done:
+				return
+			}
+		}
+	}()
+}
+
+// Close saves any pending progress & stops the write buffer. No-op if buffering isn't enabled
+func (e *Engine) Close(ctx context.Context) error {
+	if !e.buffered {
+		return nil
+	}
+	e.closeOnce.Do(func() {
+		close(e.done)
+	})
+	e.stopped.Wait()
+	return e.Flush(ctx)
+}
+
+// step is a change in progress value, used to check if a threshold was passed
+type step struct {
+	from int
+	to   int
+}
+
+func (s step) reaches(threshold int) bool {
+	return s.from < threshold && s.to >= threshold
+}
+
+// Evaluate records the progress from the event, returning the rules newly earned by it. The store is only read for
+// progress that isn't in memory, & written when a threshold is reached or progress is flushed.
+func (e *Engine) Evaluate(ctx context.Context, event Event) ([]Rule, error) {
+	steps := make(map[string][]step)
+	var earned []Rule
+	for _, rule := range e.rules {
+		key := rule.progressKey()
+		ruleSteps, ok := steps[key]
+		if !ok {
+			outcomes := rule.outcomes(event)
+			if len(outcomes) == 0 {
+				continue
+			}
+			var err error
+			ruleSteps, err = e.updateProgress(ctx, rule, event, key, outcomes)
+			if err != nil {
+				return earned, err
+			}
+			steps[key] = ruleSteps
+		}
+		for _, s := range ruleSteps {
+			if !s.reaches(rule.Threshold) {
+				continue
+			}
+			added, err := e.store.Award(ctx, model.Achievement{
+				GuildId:     event.GuildId,
+				UserId:      event.UserId,
+				Achievement: rule.Id,
+				AwardedOn:   stats.DayKey(event.Time),
+			})
+			if err != nil {
+				return earned, fmt.Errorf("failed to award achievement: %w", err)
+			}
+			if added {
+				earned = append(earned, rule)
+			}
+			break
+		}
+	}
+	if !e.buffered && len(steps) > 0 {
+		if err := e.Flush(ctx); err != nil {
+			return earned, err
+		}
+	}
+	return earned, nil
+}
+
+// updateProgress applies the event's outcomes to the progress for the key. Progress is loaded before taking the lock,
+// so events aren't serialized on reading the store.
+func (e *Engine) updateProgress(ctx context.Context, rule Rule, event Event, key string, outcomes []bool) ([]step, error) {
+	entry, err := e.load(ctx, progressKey{event.GuildId, event.UserId, key})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get achievement progress: %w", err)
+	}
+	matched := 0
+	for _, outcome := range outcomes {
+		if outcome {
+			matched++
+		}
+	}
+	var guildEntry *cachedProgress
+	if rule.Kind == FirstOfDayRule && matched > 0 {
+		guildEntry, err = e.load(ctx, progressKey{event.GuildId, guildUserId, key})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get guild achievement progress: %w", err)
+		}
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	entry = e.keep(entry)
+	today := stats.DayKey(event.Time)
+	progress := entry.AchievementProgress
+	updated := progress
+	var steps []step
+	switch rule.Kind {
+	case CountRule:
+		updated.Value += matched
+	case StreakRule:
+		if progress.Day == today {
+			break
+		}
+		updated.Day = today
+		switch {
+		case matched == 0:
+			updated.Value = 0
+		case progress.Day == stats.DayKey(event.Time.AddDate(0, 0, -1)):
+			updated.Value++
+		default:
+			updated.Value = 1
+		}
+	case RunRule:
+		for _, outcome := range outcomes {
+			from := updated.Value
+			if outcome {
+				updated.Value++
+			} else {
+				updated.Value = 0
+			}
+			steps = append(steps, step{from: from, to: updated.Value})
+		}
+	case FirstOfDayRule:
+		if matched == 0 {
+			break
+		}
+		guildEntry = e.keep(guildEntry)
+		if guildEntry.Day == today {
+			break
+		}
+		guildEntry.Day = today
+		guildEntry.dirty = true
+		updated.Value++
+		updated.Day = today
+	}
+	if steps == nil {
+		steps = []step{{from: progress.Value, to: updated.Value}}
+	}
+	if updated != progress {
+		entry.AchievementProgress = updated
+		entry.dirty = true
+	}
+	return steps, nil
+}
+
+// load gets the progress from memory, or reads it from the store without holding the lock. Progress read from the
+// store isn't kept until it's passed to keep.
+func (e *Engine) load(ctx context.Context, key progressKey) (*cachedProgress, error) {
+	e.lock.Lock()
+	entry, ok := e.progress[key]
+	e.lock.Unlock()
+	if ok {
+		return entry, nil
+	}
+	loadedAt := time.Now()
+	progress, err := e.store.GetProgress(ctx, key.guildId, key.userId, key.key)
+	if err != nil {
+		return nil, err
+	}
+	return &cachedProgress{LoadedProgress: LoadedProgress{AchievementProgress: progress, LoadedAt: loadedAt}}, nil
+}
+
+// keep gets the progress kept in memory for the loaded entry, keeping the entry if there isn't any, e.g. it was read
+// from the store or dropped by a flush since it was loaded. Callers must hold the lock.
+func (e *Engine) keep(entry *cachedProgress) *cachedProgress {
+	key := progressKey{entry.GuildId, entry.UserId, entry.Progress}
+	if kept, ok := e.progress[key]; ok {
+		entry = kept
+	} else {
+		e.progress[key] = entry
+	}
+	entry.touched = true
+	return entry
+}
+
+// Flush saves changed progress to the store in a single batch, & drops progress that hasn't been used since the last
+// flush. Progress loaded before its user was removed is dropped rather than saved, so it's read again.
+func (e *Engine) Flush(ctx context.Context) error {
+	e.flushing.Lock()
+	defer e.flushing.Unlock()
+	e.lock.Lock()
+	var pending []LoadedProgress
+	for key, entry := range e.progress {
+		switch {
+		case entry.dirty:
+			pending = append(pending, entry.LoadedProgress)
+			entry.dirty = false
+		case !entry.touched:
+			delete(e.progress, key)
+		}
+		entry.touched = false
+	}
+	e.lock.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	skipped, err := e.store.SaveProgress(ctx, pending)
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if err != nil {
+		for _, p := range pending {
+			if entry, ok := e.progress[progressKey{p.GuildId, p.UserId, p.Progress}]; ok {
+				entry.dirty = true
+			}
+		}
+		return fmt.Errorf("failed to save achievement progress: %w", err)
+	}
+	for _, p := range skipped {
+		delete(e.progress, progressKey{p.GuildId, p.UserId, p.Progress})
+	}
+	return nil
+}
+
+// Awards gets the achievements the user has earned in the guild, oldest first. Awards for rules that no longer exist
+// are skipped.
+func (e *Engine) Awards(ctx context.Context, guildId uint64, userId uint64) ([]Award, error) {
+	achievements, err := e.store.GetAwards(ctx, guildId, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get awards: %w", err)
+	}
+	awards := make([]Award, 0, len(achievements))
+	for _, achievement := range achievements {
+		rule, ok := e.byId[achievement.Achievement]
+		if !ok {
+			continue
+		}
+		awards = append(awards, Award{Rule: rule, AwardedOn: achievement.AwardedOn})
+	}
+	return awards, nil
+}
+
+// RemoveUser deletes the user's achievements & progress in the guild, including progress that hasn't been saved
+func (e *Engine) RemoveUser(ctx context.Context, guildId uint64, userId uint64) error {
+	e.flushing.Lock()
+	defer e.flushing.Unlock()
+	e.lock.Lock()
+	for key := range e.progress {
+		if key.guildId == guildId && key.userId == userId {
+			delete(e.progress, key)
+		}
+	}
+	e.lock.Unlock()
+	return e.store.RemoveUser(ctx, guildId, userId)
+}
@@ -0,0 +1,208 @@
+package achievements
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/internal/parser"
+)
+
+func ruleIds(rules []Rule) []string {
+	var ids []string
+	for _, rule := range rules {
+		ids = append(ids, rule.Id)
+	}
+	return ids
+}
+
+func day(d int) time.Time {
+	return time.Date(2024, time.January, d, 12, 0, 0, 0, time.UTC)
+}
+
+func TestValidateRules(t *testing.T) {
+	assert.NoError(t, ValidateRules(Rules))
+	tests := []struct {
+		name  string
+		rules []Rule
+	}{
+		{"missing id", []Rule{{Kind: CountRule, Event: MessageEvent, Threshold: 1}}},
+		{"duplicate id", []Rule{
+			{Id: "a", Kind: CountRule, Event: MessageEvent, Threshold: 1},
+			{Id: "a", Kind: CountRule, Event: MessageEvent, Threshold: 2},
+		}},
+		{"zero threshold", []Rule{{Id: "a", Kind: CountRule, Event: MessageEvent}}},
+		{"bad kind", []Rule{{Id: "a", Kind: "total", Event: MessageEvent, Threshold: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, ValidateRules(tt.rules))
+		})
+	}
+}
+
+func TestEngine_Count(t *testing.T) {
+	ctx := context.Background()
+	rules := []Rule{
+		{Id: "messages_2", Kind: CountRule, Event: MessageEvent, Threshold: 2},
+		{Id: "messages_3", Kind: CountRule, Event: MessageEvent, Threshold: 3},
+	}
+	engine := NewEngine(NewMemoryStore(), rules)
+	var got [][]string
+	for i := 0; i < 4; i++ {
+		earned, err := engine.Evaluate(ctx, Event{Type: MessageEvent, GuildId: 1, UserId: 10, Time: day(1)})
+		require.NoError(t, err)
+		got = append(got, ruleIds(earned))
+	}
+	assert.Equal(t, [][]string{nil, {"messages_2"}, {"messages_3"}, nil}, got)
+
+	// other users & event types don't count
+	earned, err := engine.Evaluate(ctx, Event{Type: MessageEvent, GuildId: 1, UserId: 11, Time: day(1)})
+	require.NoError(t, err)
+	assert.Empty(t, earned)
+	earned, err = engine.Evaluate(ctx, Event{Type: RollEvent, GuildId: 1, UserId: 11, Time: day(1)})
+	require.NoError(t, err)
+	assert.Empty(t, earned)
+
+	awards, err := engine.Awards(ctx, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []Award{{Rule: rules[0], AwardedOn: "2024-01-01"}, {Rule: rules[1], AwardedOn: "2024-01-01"}}, awards)
+}
+
+func TestEngine_Streak(t *testing.T) {
+	ctx := context.Background()
+	rule := Rule{Id: "wordle_streak_3", Kind: StreakRule, Event: GameEvent, Game: "Wordle", WinsOnly: true, Threshold: 3}
+	engine := NewEngine(NewMemoryStore(), []Rule{rule})
+	plays := []struct {
+		day  int
+		game string
+		win  bool
+		want []string
+	}{
+		{1, "Wordle", true, nil},
+		{2, "Wordle", true, nil},
+		{2, "Wordle", true, nil}, // same day doesn't extend the streak
+		{3, "Framed", true, nil}, // other games don't count
+		{4, "Wordle", true, nil}, // missed a day
+		{5, "Wordle", true, nil},
+		{6, "Wordle", false, nil}, // lost
+		{7, "Wordle", true, nil},
+		{8, "Wordle", true, nil},
+		{9, "Wordle", true, []string{"wordle_streak_3"}},
+	}
+	for _, play := range plays {
+		earned, err := engine.Evaluate(ctx, Event{Type: GameEvent, GuildId: 1, UserId: 10, Time: day(play.day), Game: play.game, Win: play.win})
+		require.NoError(t, err)
+		assert.Equal(t, play.want, ruleIds(earned), "day %d", play.day)
+	}
+}
+
+func TestEngine_Run(t *testing.T) {
+	ctx := context.Background()
+	rule := Rule{Id: "nat20_run_3", Kind: RunRule, Event: RollEvent, Die: 20, Face: 20, Threshold: 3}
+	engine := NewEngine(NewMemoryStore(), []Rule{rule})
+	rolls := []struct {
+		name string
+		dice []parser.DieRoll
+		want []string
+	}{
+		{"two nat 20s", []parser.DieRoll{{Sides: 20, Value: 20}, {Sides: 20, Value: 20}}, nil},
+		{"other dice don't break the run", []parser.DieRoll{{Sides: 6, Value: 1}}, nil},
+		{"run broken", []parser.DieRoll{{Sides: 20, Value: 3}, {Sides: 20, Value: 20}}, nil},
+		{"run across rolls", []parser.DieRoll{{Sides: 20, Value: 20}, {Sides: 20, Value: 20}, {Sides: 20, Value: 20}}, []string{"nat20_run_3"}},
+		{"already earned", []parser.DieRoll{{Sides: 20, Value: 20}, {Sides: 20, Value: 20}, {Sides: 20, Value: 20}}, nil},
+	}
+	for _, roll := range rolls {
+		earned, err := engine.Evaluate(ctx, Event{Type: RollEvent, GuildId: 1, UserId: 10, Time: day(1), Dice: roll.dice})
+		require.NoError(t, err)
+		assert.Equal(t, roll.want, ruleIds(earned), roll.name)
+	}
+}
+
+func TestEngine_FirstOfDay(t *testing.T) {
+	ctx := context.Background()
+	rules := []Rule{
+		{Id: "first_message", Kind: FirstOfDayRule, Event: MessageEvent, Threshold: 1},
+		{Id: "first_message_2", Kind: FirstOfDayRule, Event: MessageEvent, Threshold: 2},
+	}
+	engine := NewEngine(NewMemoryStore(), rules)
+	messages := []struct {
+		day    int
+		userId uint64
+		want   []string
+	}{
+		{1, 10, []string{"first_message"}},
+		{1, 10, nil},
+		{1, 11, nil},
+		{2, 11, []string{"first_message"}},
+		{2, 10, nil},
+		{3, 10, []string{"first_message_2"}},
+	}
+	for _, message := range messages {
+		earned, err := engine.Evaluate(ctx, Event{Type: MessageEvent, GuildId: 1, UserId: message.userId, Time: day(message.day)})
+		require.NoError(t, err)
+		assert.Equal(t, message.want, ruleIds(earned), "day %d user %d", message.day, message.userId)
+	}
+	// guilds are separate
+	earned, err := engine.Evaluate(ctx, Event{Type: MessageEvent, GuildId: 2, UserId: 11, Time: day(3)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first_message"}, ruleIds(earned))
+}
+
+func TestEngine_RemoveUser(t *testing.T) {
+	ctx := context.Background()
+	rule := Rule{Id: "messages_1", Kind: CountRule, Event: MessageEvent, Threshold: 1}
+	engine := NewEngine(NewMemoryStore(), []Rule{rule})
+	earned, err := engine.Evaluate(ctx, Event{Type: MessageEvent, GuildId: 1, UserId: 10, Time: day(1)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"messages_1"}, ruleIds(earned))
+	require.NoError(t, engine.RemoveUser(ctx, 1, 10))
+	awards, err := engine.Awards(ctx, 1, 10)
+	require.NoError(t, err)
+	assert.Empty(t, awards)
+	// progress starts over
+	earned, err = engine.Evaluate(ctx, Event{Type: MessageEvent, GuildId: 1, UserId: 10, Time: day(2)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"messages_1"}, ruleIds(earned))
+}
+
+func TestEngine_WriteBuffer(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	rule := Rule{Id: "messages_2", Kind: CountRule, Event: MessageEvent, Threshold: 2}
+	engine := NewEngine(store, []Rule{rule})
+	engine.StartWriteBuffer(time.Hour)
+	defer engine.Close(ctx)
+	evaluate := func() []string {
+		earned, err := engine.Evaluate(ctx, Event{Type: MessageEvent, GuildId: 1, UserId: 10, Time: day(1)})
+		require.NoError(t, err)
+		return ruleIds(earned)
+	}
+	savedValue := func() int {
+		progress, err := store.GetProgress(ctx, 1, 10, rule.progressKey())
+		require.NoError(t, err)
+		return progress.Value
+	}
+
+	assert.Empty(t, evaluate())
+	assert.Equal(t, []string{"messages_2"}, evaluate(), "awards aren't buffered")
+	assert.Zero(t, savedValue(), "progress saved before flush")
+	require.NoError(t, engine.Flush(ctx))
+	assert.Equal(t, 2, savedValue())
+
+	// progress loaded before the user was removed elsewhere, e.g. from the CLI, isn't saved
+	evaluate()
+	require.NoError(t, store.RemoveUser(ctx, 1, 10))
+	require.NoError(t, engine.Flush(ctx))
+	assert.Zero(t, savedValue(), "removed user's progress saved")
+	evaluate()
+	require.NoError(t, engine.Flush(ctx))
+	assert.Equal(t, 1, savedValue(), "progress not read again after removal")
+
+	// unused progress is dropped from memory
+	require.NoError(t, engine.Flush(ctx))
+	assert.Empty(t, engine.progress)
+}
@@ -0,0 +1,111 @@
+package achievements
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+type progressKey struct {
+	guildId uint64
+	userId  uint64
+	key     string
+}
+
+type userKey struct {
+	guildId uint64
+	userId  uint64
+}
+
+type awardKey struct {
+	guildId     uint64
+	userId      uint64
+	achievement string
+}
+
+// MemoryStore is an in-process Store with the same semantics as the Postgres backed Achievements, meant for tests
+type MemoryStore struct {
+	lock     sync.Mutex
+	progress map[progressKey]model.AchievementProgress
+	awards   map[awardKey]model.Achievement
+	removals map[userKey]time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		progress: make(map[progressKey]model.AchievementProgress),
+		awards:   make(map[awardKey]model.Achievement),
+		removals: make(map[userKey]time.Time),
+	}
+}
+
+func (m *MemoryStore) GetProgress(_ context.Context, guildId uint64, userId uint64, key string) (model.AchievementProgress, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	progress, ok := m.progress[progressKey{guildId, userId, key}]
+	if !ok {
+		return model.AchievementProgress{GuildId: guildId, UserId: userId, Progress: key}, nil
+	}
+	return progress, nil
+}
+
+func (m *MemoryStore) SaveProgress(_ context.Context, progress []LoadedProgress) ([]LoadedProgress, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var skipped []LoadedProgress
+	for _, p := range progress {
+		if removedAt, ok := m.removals[userKey{p.GuildId, p.UserId}]; ok && !removedAt.Before(p.LoadedAt) {
+			skipped = append(skipped, p)
+			continue
+		}
+		m.progress[progressKey{p.GuildId, p.UserId, p.Progress}] = p.AchievementProgress
+	}
+	return skipped, nil
+}
+
+func (m *MemoryStore) Award(_ context.Context, award model.Achievement) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	key := awardKey{award.GuildId, award.UserId, award.Achievement}
+	if _, ok := m.awards[key]; ok {
+		return false, nil
+	}
+	m.awards[key] = award
+	return true, nil
+}
+
+func (m *MemoryStore) GetAwards(_ context.Context, guildId uint64, userId uint64) ([]*model.Achievement, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var awards []*model.Achievement
+	for key, award := range m.awards {
+		if key.guildId == guildId && key.userId == userId {
+			awards = append(awards, &award)
+		}
+	}
+	slices.SortFunc(awards, func(a, b *model.Achievement) int {
+		return cmp.Or(cmp.Compare(a.AwardedOn, b.AwardedOn), cmp.Compare(a.Achievement, b.Achievement))
+	})
+	return awards, nil
+}
+
+func (m *MemoryStore) RemoveUser(_ context.Context, guildId uint64, userId uint64) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key := range m.awards {
+		if key.guildId == guildId && key.userId == userId {
+			delete(m.awards, key)
+		}
+	}
+	for key := range m.progress {
+		if key.guildId == guildId && key.userId == userId {
+			delete(m.progress, key)
+		}
+	}
+	m.removals[userKey{guildId, userId}] = time.Now()
+	return nil
+}
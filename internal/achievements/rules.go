@@ -0,0 +1,128 @@
+package achievements
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dmtaylor/costanza/internal/parser"
+)
+
+// EventType is the kind of activity a rule is checked against
+type EventType string
+
+const (
+	MessageEvent EventType = "message"
+	GameEvent    EventType = "game"
+	RollEvent    EventType = "roll"
+)
+
+// Event is activity by a member, passed to the Engine from the listen handlers
+type Event struct {
+	Type    EventType
+	GuildId uint64
+	UserId  uint64
+	Time    time.Time        // In the guild's timezone, for day based rules
+	Game    string           // Game played, for game events
+	Win     bool             // Whether the game was won, for game events
+	Dice    []parser.DieRoll // Dice rolled in order, for roll events
+}
+
+// RuleKind is how progress towards a rule is tracked
+type RuleKind string
+
+const (
+	CountRule      RuleKind = "count"  // Matching events ever
+	StreakRule     RuleKind = "streak" // Consecutive days with a matching event
+	RunRule        RuleKind = "run"    // Consecutive matching events, reset by one of the same type that doesn't match
+	FirstOfDayRule RuleKind = "first"  // Days the user had the guild's first matching event
+)
+
+// Rule declares an achievement & the progress needed to earn it. Rules with the same kind & matching fields share
+// their progress.
+type Rule struct {
+	Id          string // Stored with awards, so mustn't change
+	Name        string
+	Description string
+	Kind        RuleKind
+	Event       EventType
+	Game        string // Only this game's events match, any game if empty
+	WinsOnly    bool   // Only won games match
+	Die         int    // Only dice with this many sides are checked for roll events, all dice if 0
+	Face        int    // Only dice rolling this value match, any value if 0
+	Threshold   int    // Progress needed to earn the achievement
+}
+
+// Rules are the achievements that can be earned
+var Rules = []Rule{
+	{Id: "messages_100", Name: "Regular", Description: "Send 100 messages", Kind: CountRule, Event: MessageEvent, Threshold: 100},
+	{Id: "messages_1000", Name: "Chatterbox", Description: "Send 1000 messages", Kind: CountRule, Event: MessageEvent, Threshold: 1000},
+	{Id: "messages_10000", Name: "Motormouth", Description: "Send 10000 messages", Kind: CountRule, Event: MessageEvent, Threshold: 10000},
+	{Id: "first_message", Name: "Early bird", Description: "Send the first message of the day", Kind: FirstOfDayRule, Event: MessageEvent, Threshold: 1},
+	{Id: "first_message_30", Name: "Rooster", Description: "Send the first message of the day 30 times", Kind: FirstOfDayRule, Event: MessageEvent, Threshold: 30},
+	{Id: "games_100", Name: "Puzzler", Description: "Play 100 daily games", Kind: CountRule, Event: GameEvent, Threshold: 100},
+	{Id: "wordle_streak_30", Name: "Wordsmith", Description: "Win Wordle 30 days in a row", Kind: StreakRule, Event: GameEvent, Game: "Wordle", WinsOnly: true, Threshold: 30},
+	{Id: "nat20_run_3", Name: "Blessed by the dice", Description: "Roll a natural 20 three times in a row", Kind: RunRule, Event: RollEvent, Die: 20, Face: 20, Threshold: 3},
+	{Id: "nat1_run_3", Name: "Serenity now", Description: "Roll a natural 1 three times in a row", Kind: RunRule, Event: RollEvent, Die: 20, Face: 1, Threshold: 3},
+}
+
+// maxKeyLength is the length of the achievement & progress columns
+const maxKeyLength = 64
+
+// progressKey identifies the progress shared by rules with the same kind & matching fields, e.g. run:roll:d20=20
+func (r Rule) progressKey() string {
+	parts := []string{string(r.Kind), string(r.Event)}
+	if r.Game != "" {
+		parts = append(parts, r.Game)
+	}
+	if r.WinsOnly {
+		parts = append(parts, "wins")
+	}
+	if r.Die != 0 || r.Face != 0 {
+		parts = append(parts, "d"+strconv.Itoa(r.Die)+"="+strconv.Itoa(r.Face))
+	}
+	return strings.Join(parts, ":")
+}
+
+// outcomes gets whether each part of the event matches the rule: every checked die for roll events, or the event
+// itself otherwise. Events the rule doesn't apply to have no outcomes.
+func (r Rule) outcomes(event Event) []bool {
+	if event.Type != r.Event || (r.Game != "" && event.Game != r.Game) {
+		return nil
+	}
+	if event.Type != RollEvent {
+		return []bool{!r.WinsOnly || event.Win}
+	}
+	var outcomes []bool
+	for _, die := range event.Dice {
+		if r.Die == 0 || die.Sides == r.Die {
+			outcomes = append(outcomes, r.Face == 0 || die.Value == r.Face)
+		}
+	}
+	return outcomes
+}
+
+// ValidateRules checks the rules can be stored & earned
+func ValidateRules(rules []Rule) error {
+	ids := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		switch {
+		case rule.Id == "" || len(rule.Id) > maxKeyLength:
+			return fmt.Errorf("invalid achievement id %q", rule.Id)
+		case ids[rule.Id]:
+			return fmt.Errorf("duplicate achievement id %s", rule.Id)
+		case rule.Threshold < 1:
+			return fmt.Errorf("achievement %s has invalid threshold %d", rule.Id, rule.Threshold)
+		case len(rule.progressKey()) > maxKeyLength:
+			return fmt.Errorf("achievement %s progress key is too long", rule.Id)
+		}
+		switch rule.Kind {
+		case CountRule, StreakRule, RunRule, FirstOfDayRule:
+		default:
+			return fmt.Errorf("achievement %s has invalid kind %s", rule.Id, rule.Kind)
+		}
+		ids[rule.Id] = true
+	}
+	return nil
+}
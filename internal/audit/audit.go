@@ -0,0 +1,225 @@
+// Package audit builds the embeds mirrored into each guild's audit log channel, like message edits & deletes, members
+// joining & leaving, role changes & the bot's own moderation actions
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/dmtaylor/costanza/internal/cache"
+)
+
+// Embed colors for each kind of entry
+const (
+	EditColor       = 0xf1c40f
+	DeleteColor     = 0xe74c3c
+	JoinColor       = 0x2ecc71
+	LeaveColor      = 0x95a5a6
+	RoleColor       = 0x3498db
+	ModerationColor = 0xe67e22
+)
+
+// Message content is kept for an hour by default, so deletes after that show it wasn't cached
+const (
+	DefaultMessageTTL       = time.Hour
+	DefaultMessageCacheSize = 10000
+)
+
+const maxDescription = 4096
+const maxField = 1024
+
+// errNotCached is returned when getting a message that isn't in the cache, as messages can't be loaded once deleted
+var errNotCached = errors.New("message not cached")
+
+// Message is what's kept of a post, so edits & deletes can show what it said
+type Message struct {
+	Id          string
+	GuildId     string
+	ChannelId   string
+	AuthorId    string
+	AuthorName  string
+	AuthorIcon  string
+	Content     string
+	Attachments []string // attachment links
+}
+
+// NewMessage keeps the parts of the message shown in the audit log
+func NewMessage(m *discordgo.Message) Message {
+	message := Message{Id: m.ID, GuildId: m.GuildID, ChannelId: m.ChannelID, Content: m.Content}
+	if m.Author != nil {
+		message.AuthorId = m.Author.ID
+		message.AuthorName = displayName(m.Author)
+		message.AuthorIcon = m.Author.AvatarURL("")
+	}
+	for _, attachment := range m.Attachments {
+		message.Attachments = append(message.Attachments, fmt.Sprintf("[%s](%s)", attachment.Filename, attachment.URL))
+	}
+	return message
+}
+
+// MessageCache keeps recent posts by id. Posts can't be loaded once they're gone, so only Peek & Set are useful.
+type MessageCache = cache.Cache[string, Message]
+
+func NewMessageCache(opts cache.Options) *MessageCache {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultMessageTTL
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultMessageCacheSize
+	}
+	return cache.New(func(_ context.Context, _ string) (Message, error) {
+		return Message{}, errNotCached
+	}, opts)
+}
+
+// EditEmbed builds the entry for an edited post. before is nil if the post wasn't cached.
+func EditEmbed(before *Message, after Message, t time.Time) *discordgo.MessageEmbed {
+	embed := messageEmbed("Message edited", EditColor, after, t)
+	previous := "*Not cached*"
+	if before != nil {
+		previous = orEmpty(before.Content)
+	}
+	embed.Fields = append(embed.Fields,
+		&discordgo.MessageEmbedField{Name: "Before", Value: truncate(previous, maxField)},
+		&discordgo.MessageEmbedField{Name: "After", Value: truncate(orEmpty(after.Content), maxField)},
+		&discordgo.MessageEmbedField{Name: "Source", Value: link(after)},
+	)
+	return embed
+}
+
+// DeleteEmbed builds the entry for a deleted post. deleted only has ids set if the post wasn't cached.
+func DeleteEmbed(deleted Message, cached bool, t time.Time) *discordgo.MessageEmbed {
+	embed := messageEmbed("Message deleted", DeleteColor, deleted, t)
+	if !cached {
+		embed.Description = "*Content not cached*"
+		return embed
+	}
+	embed.Description = truncate(orEmpty(deleted.Content), maxDescription)
+	if len(deleted.Attachments) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Attachments",
+			Value: truncate(strings.Join(deleted.Attachments, "\n"), maxField),
+		})
+	}
+	return embed
+}
+
+// JoinEmbed builds the entry for a member joining
+func JoinEmbed(member *discordgo.Member, t time.Time) *discordgo.MessageEmbed {
+	embed := memberEmbed("Member joined", JoinColor, member, t)
+	if created, err := discordgo.SnowflakeTimestamp(member.User.ID); err == nil {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Account created",
+			Value: fmt.Sprintf("<t:%d:R>", created.Unix()),
+		})
+	}
+	return embed
+}
+
+// LeaveEmbed builds the entry for a member leaving or being removed
+func LeaveEmbed(member *discordgo.Member, t time.Time) *discordgo.MessageEmbed {
+	return memberEmbed("Member left", LeaveColor, member, t)
+}
+
+// RoleEmbed builds the entry for a member's roles changing, or returns nil if they didn't
+func RoleEmbed(member *discordgo.Member, before []string, t time.Time) *discordgo.MessageEmbed {
+	added := roleMentions(member.Roles, before)
+	removed := roleMentions(before, member.Roles)
+	if len(added) == 0 && len(removed) == 0 {
+		return nil
+	}
+	embed := memberEmbed("Roles changed", RoleColor, member, t)
+	if len(added) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Added", Value: truncate(strings.Join(added, " "), maxField)})
+	}
+	if len(removed) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Removed", Value: truncate(strings.Join(removed, " "), maxField)})
+	}
+	return embed
+}
+
+// ModerationEmbed builds the entry for a moderation action the bot took, from its moderation log message
+func ModerationEmbed(content string, t time.Time) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       "Moderation action",
+		Description: truncate(content, maxDescription),
+		Color:       ModerationColor,
+		Timestamp:   t.Format(time.RFC3339),
+	}
+}
+
+func messageEmbed(title string, color int, message Message, t time.Time) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:     title,
+		Color:     color,
+		Timestamp: t.Format(time.RFC3339),
+		Fields:    []*discordgo.MessageEmbedField{{Name: "Channel", Value: "<#" + message.ChannelId + ">", Inline: true}},
+		Footer:    &discordgo.MessageEmbedFooter{Text: "Message ID: " + message.Id},
+	}
+	if message.AuthorId != "" {
+		embed.Author = &discordgo.MessageEmbedAuthor{Name: message.AuthorName, IconURL: message.AuthorIcon}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Author", Value: "<@" + message.AuthorId + ">", Inline: true})
+		embed.Footer.Text += " | User ID: " + message.AuthorId
+	}
+	return embed
+}
+
+func memberEmbed(title string, color int, member *discordgo.Member, t time.Time) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       title,
+		Description: fmt.Sprintf("<@%s> (%s)", member.User.ID, member.User.Username),
+		Color:       color,
+		Timestamp:   t.Format(time.RFC3339),
+		Author:      &discordgo.MessageEmbedAuthor{Name: displayName(member.User), IconURL: member.User.AvatarURL("")},
+		Footer:      &discordgo.MessageEmbedFooter{Text: "User ID: " + member.User.ID},
+	}
+}
+
+// roleMentions mentions the roles in roles that aren't in others
+func roleMentions(roles []string, others []string) []string {
+	var mentions []string
+	for _, role := range roles {
+		if !slices.Contains(others, role) {
+			mentions = append(mentions, "<@&"+role+">")
+		}
+	}
+	return mentions
+}
+
+func link(message Message) string {
+	return fmt.Sprintf("[Jump to message](https://discord.com/channels/%s/%s/%s)", message.GuildId, message.ChannelId, message.Id)
+}
+
+func displayName(user *discordgo.User) string {
+	if user.GlobalName != "" {
+		return user.GlobalName
+	}
+	return user.Username
+}
+
+// orEmpty marks empty content, e.g. posts with only attachments, as embed fields can't be empty
+func orEmpty(content string) string {
+	if content == "" {
+		return "*Empty*"
+	}
+	return content
+}
+
+// truncate shortens s to at most limit bytes on a rune boundary, marking it with an ellipsis
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	limit -= len("…")
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit] + "…"
+}
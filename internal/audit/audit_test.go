@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/internal/cache"
+)
+
+var testTime = time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+
+func testMessage(content string) Message {
+	return NewMessage(&discordgo.Message{
+		ID:          "500",
+		GuildID:     "100",
+		ChannelID:   "300",
+		Content:     content,
+		Author:      &discordgo.User{ID: "200", Username: "george", GlobalName: "George"},
+		Attachments: []*discordgo.MessageAttachment{{Filename: "cat.png", URL: "https://cdn/cat.png"}},
+	})
+}
+
+func testMember(roles ...string) *discordgo.Member {
+	return &discordgo.Member{GuildID: "100", User: &discordgo.User{ID: "200", Username: "george"}, Roles: roles}
+}
+
+func TestNewMessage(t *testing.T) {
+	got := testMessage("hello")
+	assert.Equal(t, "George", got.AuthorName)
+	assert.Equal(t, "200", got.AuthorId)
+	assert.Equal(t, []string{"[cat.png](https://cdn/cat.png)"}, got.Attachments)
+	assert.Equal(t, Message{Id: "1", ChannelId: "2"}, NewMessage(&discordgo.Message{ID: "1", ChannelID: "2"}))
+}
+
+func TestMessageCache(t *testing.T) {
+	ctx := context.Background()
+	messages := NewMessageCache(cache.Options{})
+	_, err := messages.Get(ctx, "500")
+	assert.ErrorIs(t, err, errNotCached)
+	messages.Set(ctx, "500", testMessage("hello"))
+	got, ok := messages.Peek("500")
+	require.True(t, ok)
+	assert.Equal(t, "hello", got.Content)
+}
+
+func TestEditEmbed(t *testing.T) {
+	before := testMessage("helo")
+	got := EditEmbed(&before, testMessage("hello"), testTime)
+	assert.Equal(t, "Message edited", got.Title)
+	assert.Equal(t, EditColor, got.Color)
+	assert.Equal(t, "2024-03-15T12:00:00Z", got.Timestamp)
+	assert.Equal(t, "George", got.Author.Name)
+	assert.Equal(t, "Message ID: 500 | User ID: 200", got.Footer.Text)
+	require.Len(t, got.Fields, 5)
+	assert.Equal(t, "<#300>", got.Fields[0].Value)
+	assert.Equal(t, "<@200>", got.Fields[1].Value)
+	assert.Equal(t, "helo", got.Fields[2].Value)
+	assert.Equal(t, "hello", got.Fields[3].Value)
+	assert.Equal(t, "[Jump to message](https://discord.com/channels/100/300/500)", got.Fields[4].Value)
+
+	got = EditEmbed(nil, testMessage(""), testTime)
+	assert.Equal(t, "*Not cached*", got.Fields[2].Value)
+	assert.Equal(t, "*Empty*", got.Fields[3].Value)
+}
+
+func TestDeleteEmbed(t *testing.T) {
+	got := DeleteEmbed(testMessage(strings.Repeat("a", 5000)), true, testTime)
+	assert.Equal(t, "Message deleted", got.Title)
+	assert.Len(t, got.Description, maxDescription)
+	assert.True(t, strings.HasSuffix(got.Description, "…"))
+	require.Len(t, got.Fields, 3)
+	assert.Equal(t, "Attachments", got.Fields[2].Name)
+
+	got = DeleteEmbed(Message{Id: "500", GuildId: "100", ChannelId: "300"}, false, testTime)
+	assert.Equal(t, "*Content not cached*", got.Description)
+	assert.Nil(t, got.Author)
+	assert.Len(t, got.Fields, 1)
+	assert.Equal(t, "Message ID: 500", got.Footer.Text)
+}
+
+func TestMemberEmbeds(t *testing.T) {
+	joined := JoinEmbed(testMember(), testTime)
+	assert.Equal(t, "Member joined", joined.Title)
+	assert.Equal(t, "<@200> (george)", joined.Description)
+	require.Len(t, joined.Fields, 1)
+	assert.Equal(t, "<t:1420070400:R>", joined.Fields[0].Value)
+
+	left := LeaveEmbed(testMember(), testTime)
+	assert.Equal(t, "Member left", left.Title)
+	assert.Equal(t, LeaveColor, left.Color)
+	assert.Equal(t, "User ID: 200", left.Footer.Text)
+}
+
+func TestRoleEmbed(t *testing.T) {
+	got := RoleEmbed(testMember("10", "12"), []string{"10", "11"}, testTime)
+	require.NotNil(t, got)
+	assert.Equal(t, "Roles changed", got.Title)
+	assert.Equal(t, []*discordgo.MessageEmbedField{{Name: "Added", Value: "<@&12>"}, {Name: "Removed", Value: "<@&11>"}}, got.Fields)
+
+	got = RoleEmbed(testMember("10", "11"), []string{"10"}, testTime)
+	assert.Equal(t, []*discordgo.MessageEmbedField{{Name: "Added", Value: "<@&11>"}}, got.Fields)
+	assert.Nil(t, RoleEmbed(testMember("10"), []string{"10"}, testTime), "embed for unchanged roles")
+}
+
+func TestModerationEmbed(t *testing.T) {
+	got := ModerationEmbed("🤬 <@200> posted `heck`", testTime)
+	assert.Equal(t, "Moderation action", got.Title)
+	assert.Equal(t, "🤬 <@200> posted `heck`", got.Description)
+	assert.Equal(t, ModerationColor, got.Color)
+}
@@ -1,13 +1,175 @@
+// Package cache keeps values loaded from the db in memory, like each guild's cursed channels & words
 package cache
 
 import (
+	"container/list"
 	"context"
+	"fmt"
+	"log/slog"
+	"sync"
 	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"golang.org/x/sync/singleflight"
 )
 
-const maxSize = 20
-const defaultEntryDuration = time.Minute * 15
+// Defaults for caches that don't set their own options
+const (
+	DefaultTTL     = 15 * time.Minute
+	DefaultMaxSize = 100
+)
 
 type PreloadableCache interface {
 	PreloadCache(ctx context.Context, guildIds []uint64) error
 }
+
+// Loader gets the value for a key on a cache miss
+type Loader[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// Options configures a Cache. Zero values use the defaults.
+type Options struct {
+	TTL     time.Duration // How long entries are kept before they're loaded again
+	MaxSize int           // Entries kept before the least recently used is evicted
+}
+
+// Cache keeps values from a Loader in memory. Entries expire after the TTL, the least recently used entry is evicted
+// once the cache is full, & concurrent misses for a key share a single load.
+type Cache[K comparable, V any] struct {
+	load    Loader[K, V]
+	ttl     time.Duration
+	maxSize int
+	loads   singleflight.Group
+
+	lock    sync.Mutex
+	entries map[K]*list.Element
+	recent  *list.List // entries, most recently used first
+	version uint64     // bumped on every invalidation, so loads started before one aren't stored
+}
+
+type cacheEntry[K comparable, V any] struct {
+	key    K
+	value  V
+	expiry time.Time
+}
+
+func New[K comparable, V any](load Loader[K, V], opts Options) *Cache[K, V] {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultTTL
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultMaxSize
+	}
+	return &Cache[K, V]{
+		load:    load,
+		ttl:     opts.TTL,
+		maxSize: opts.MaxSize,
+		entries: make(map[K]*list.Element),
+		recent:  list.New(),
+	}
+}
+
+// Get gets the key's value, loading it if it isn't cached or has expired
+func (c *Cache[K, V]) Get(ctx context.Context, key K) (V, error) {
+	c.lock.Lock()
+	if element, ok := c.entries[key]; ok {
+		e := element.Value.(*cacheEntry[K, V])
+		if time.Now().Before(e.expiry) {
+			c.recent.MoveToFront(element)
+			c.lock.Unlock()
+			return e.value, nil
+		}
+	}
+	version := c.version
+	c.lock.Unlock()
+
+	// loads are shared per version, so misses after an invalidation don't wait on a load that may be stale
+	value, err, _ := c.loads.Do(fmt.Sprintf("%d:%v", version, key), func() (any, error) {
+		slog.DebugContext(ctx, "loading cache entry", "key", fmt.Sprint(key))
+		value, err := c.load(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		if c.version == version {
+			c.set(key, value)
+		}
+		return value, nil
+	})
+	if err != nil {
+		var zero V
+		return zero, fmt.Errorf("failed to load cache entry: %w", err)
+	}
+	return value.(V), nil
+}
+
+// Peek gets the key's value if it's cached & hasn't expired, without loading it
+func (c *Cache[K, V]) Peek(key K) (V, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if element, ok := c.entries[key]; ok {
+		e := element.Value.(*cacheEntry[K, V])
+		if time.Now().Before(e.expiry) {
+			c.recent.MoveToFront(element)
+			return e.value, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+func (c *Cache[K, V]) Set(_ context.Context, key K, value V) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.set(key, value)
+}
+
+// set stores the value, evicting the least recently used entry if the cache is full. Must hold the lock.
+func (c *Cache[K, V]) set(key K, value V) {
+	e := &cacheEntry[K, V]{key: key, value: value, expiry: time.Now().Add(c.ttl)}
+	if element, ok := c.entries[key]; ok {
+		element.Value = e
+		c.recent.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.recent.PushFront(e)
+	for c.recent.Len() > c.maxSize {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry[K, V]).key)
+	}
+}
+
+// Invalidate drops the key so the next Get reloads it
+func (c *Cache[K, V]) Invalidate(_ context.Context, key K) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.version++
+	if element, ok := c.entries[key]; ok {
+		c.recent.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+// Clear drops every key
+func (c *Cache[K, V]) Clear(_ context.Context) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.version++
+	c.entries = make(map[K]*list.Element)
+	c.recent.Init()
+}
+
+// PreloadCache loads the keys into the cache, skipping keys that fail to load
+func (c *Cache[K, V]) PreloadCache(ctx context.Context, keys []K) error {
+	var err *multierror.Error
+	for _, key := range keys {
+		value, e := c.load(ctx, key)
+		if e != nil {
+			err = multierror.Append(err, e)
+			continue
+		}
+		c.Set(ctx, key, value)
+	}
+	return err.ErrorOrNil()
+}
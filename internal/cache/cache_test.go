@@ -0,0 +1,195 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingLoader loads the key doubled, counting the loads
+type countingLoader struct {
+	calls atomic.Int32
+}
+
+func (l *countingLoader) load(_ context.Context, key int) (int, error) {
+	l.calls.Add(1)
+	return key * 2, nil
+}
+
+func TestNew(t *testing.T) {
+	loader := &countingLoader{}
+	cache := New(loader.load, Options{})
+	assert.Equal(t, DefaultTTL, cache.ttl)
+	assert.Equal(t, DefaultMaxSize, cache.maxSize)
+	cache = New(loader.load, Options{TTL: time.Minute, MaxSize: 5})
+	assert.Equal(t, time.Minute, cache.ttl)
+	assert.Equal(t, 5, cache.maxSize)
+}
+
+func TestCache_Get(t *testing.T) {
+	ctx := context.Background()
+	loader := &countingLoader{}
+	cache := New(loader.load, Options{})
+
+	got, err := cache.Get(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 10, got)
+	got, err = cache.Get(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 10, got)
+	assert.Equal(t, int32(1), loader.calls.Load(), "cache hit loaded")
+}
+
+func TestCache_GetExpiredEntry(t *testing.T) {
+	ctx := context.Background()
+	loader := &countingLoader{}
+	cache := New(loader.load, Options{})
+	cache.Set(ctx, 5, 7)
+	cache.entries[5].Value.(*cacheEntry[int, int]).expiry = time.Now().Add(-time.Minute)
+
+	got, err := cache.Get(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 10, got, "expired entry used")
+	assert.Equal(t, int32(1), loader.calls.Load())
+	assert.WithinDuration(t, time.Now().Add(DefaultTTL), cache.entries[5].Value.(*cacheEntry[int, int]).expiry, time.Second, "expiry way off")
+}
+
+func TestCache_Peek(t *testing.T) {
+	ctx := context.Background()
+	loader := &countingLoader{}
+	cache := New(loader.load, Options{})
+	_, ok := cache.Peek(5)
+	assert.False(t, ok)
+	cache.Set(ctx, 5, 7)
+	got, ok := cache.Peek(5)
+	assert.True(t, ok)
+	assert.Equal(t, 7, got)
+	cache.entries[5].Value.(*cacheEntry[int, int]).expiry = time.Now().Add(-time.Minute)
+	_, ok = cache.Peek(5)
+	assert.False(t, ok, "expired entry found")
+	assert.Equal(t, int32(0), loader.calls.Load(), "peek loaded")
+}
+
+func TestCache_GetError(t *testing.T) {
+	ctx := context.Background()
+	fail := errors.New("db down")
+	cache := New(func(_ context.Context, _ int) (int, error) { return 0, fail }, Options{})
+	_, err := cache.Get(ctx, 5)
+	assert.ErrorIs(t, err, fail)
+	assert.Empty(t, cache.entries, "failed load cached")
+}
+
+func TestCache_Eviction(t *testing.T) {
+	ctx := context.Background()
+	loader := &countingLoader{}
+	cache := New(loader.load, Options{MaxSize: 2})
+	for _, key := range []int{1, 2, 1, 3} {
+		_, err := cache.Get(ctx, key)
+		require.NoError(t, err)
+	}
+	assert.Len(t, cache.entries, 2)
+	assert.Contains(t, cache.entries, 1, "recently used entry evicted")
+	assert.Contains(t, cache.entries, 3)
+	assert.NotContains(t, cache.entries, 2, "least recently used entry kept")
+
+	cache.Set(ctx, 3, 9)
+	assert.Len(t, cache.entries, 2, "replaced entry counted twice")
+	assert.Equal(t, 2, cache.recent.Len())
+}
+
+func TestCache_ConcurrentGet(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	var calls atomic.Int32
+	cache := New(func(_ context.Context, key int) (int, error) {
+		calls.Add(1)
+		<-release
+		return key * 2, nil
+	}, Options{})
+
+	const getters = 10
+	results := make([]int, getters)
+	var wg sync.WaitGroup
+	wg.Add(getters)
+	for i := range getters {
+		go func() {
+			defer wg.Done()
+			results[i], _ = cache.Get(ctx, 5)
+		}()
+	}
+	// let the getters pile up on the first load
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load(), "concurrent misses not shared")
+	for _, result := range results {
+		assert.Equal(t, 10, result)
+	}
+}
+
+func TestCache_InvalidateDuringLoad(t *testing.T) {
+	ctx := context.Background()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	cache := New(func(_ context.Context, key int) (int, error) {
+		close(started)
+		<-release
+		return key, nil
+	}, Options{})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = cache.Get(ctx, 5)
+	}()
+	<-started
+	cache.Invalidate(ctx, 5)
+	close(release)
+	<-done
+	assert.Empty(t, cache.entries, "load started before invalidation cached")
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	cache := New((&countingLoader{}).load, Options{})
+	cache.Set(ctx, 5, 10)
+	cache.Set(ctx, 6, 15)
+	cache.Invalidate(ctx, 5)
+	assert.Len(t, cache.entries, 1, "other keys removed")
+	assert.NotContains(t, cache.entries, 5)
+	assert.Equal(t, 1, cache.recent.Len())
+}
+
+func TestCache_Clear(t *testing.T) {
+	ctx := context.Background()
+	cache := New((&countingLoader{}).load, Options{})
+	cache.Set(ctx, 5, 10)
+	cache.Set(ctx, 6, 15)
+	cache.Clear(ctx)
+	assert.Empty(t, cache.entries, "cache not empty")
+	assert.Equal(t, 0, cache.recent.Len())
+}
+
+func TestCache_PreloadCache(t *testing.T) {
+	ctx := context.Background()
+	fail := errors.New("db down")
+	cache := New(func(_ context.Context, key uint64) (uint64, error) {
+		if key == 2 {
+			return 0, fail
+		}
+		return key * 2, nil
+	}, Options{})
+	err := cache.PreloadCache(ctx, []uint64{1, 2, 3})
+	assert.ErrorIs(t, err, fail)
+	assert.Len(t, cache.entries, 2, "loaded keys not kept")
+	got, err := cache.Get(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(6), got)
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+// ChannelCache caches a list of ids for each guild
+type ChannelCache interface {
+	Get(ctx context.Context, key uint64) ([]uint64, error)
+	Set(ctx context.Context, key uint64, value []uint64)
+	Clear(ctx context.Context)
+	// Invalidate drops the key so the next Get reloads it
+	Invalidate(ctx context.Context, key uint64)
+}
+
+// StringListCache caches a list of strings for each guild
+type StringListCache interface {
+	Get(ctx context.Context, key uint64) ([]string, error)
+	Set(ctx context.Context, key uint64, value []string)
+	Clear(ctx context.Context)
+	// Invalidate drops the key so the next Get reloads it
+	Invalidate(ctx context.Context, key uint64)
+}
+
+var _ ChannelCache = (*Cache[uint64, []uint64])(nil)
+var _ StringListCache = (*Cache[uint64, []string])(nil)
+var _ PreloadableCache = (*Cache[uint64, []uint64])(nil)
+
+// Tables the guild lists are cached from, as named in their invalidation notifications
+const (
+	CursedChannelsTable = "cursed_channels"
+	CursedWordsTable    = "cursed_word_list"
+	CursedAllowTable    = "cursed_word_allowlist"
+	OptOutsTable        = "privacy_opt_outs"
+)
+
+// queries get the list cached for a guild
+const (
+	cursedChannelsQuery  = "SELECT channel_id FROM cursed_channels WHERE guild_id = $1"
+	optOutsQuery         = "SELECT user_id FROM privacy_opt_outs WHERE guild_id = $1"
+	cursedWordsQuery     = "SELECT word FROM cursed_word_list WHERE guild_id = $1"
+	cursedAllowlistQuery = "SELECT word FROM cursed_word_allowlist WHERE guild_id = $1"
+)
+
+// QueryLoader loads the single column results of the query for a guild. The query's only argument is the guild id.
+func QueryLoader[V any](pool model.DbPool, query string) Loader[uint64, []V] {
+	return func(ctx context.Context, guildId uint64) ([]V, error) {
+		var results []V
+		err := pgxscan.Select(ctx, pool, &results, query, guildId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query cached values: %w", err)
+		}
+		return results, nil
+	}
+}
+
+// NewCursedChannelCache caches the cursed channels for each guild
+func NewCursedChannelCache(pool model.DbPool, opts Options) *Cache[uint64, []uint64] {
+	return New(QueryLoader[uint64](pool, cursedChannelsQuery), opts)
+}
+
+// NewOptOutCache caches the users who opted out of stat tracking for each guild
+func NewOptOutCache(pool model.DbPool, opts Options) *Cache[uint64, []uint64] {
+	return New(QueryLoader[uint64](pool, optOutsQuery), opts)
+}
+
+// NewCursedWordCache caches the cursed words for each guild
+func NewCursedWordCache(pool model.DbPool, opts Options) *Cache[uint64, []string] {
+	return New(QueryLoader[string](pool, cursedWordsQuery), opts)
+}
+
+// NewAllowlistCache caches the words exempt from cursed word matching for each guild
+func NewAllowlistCache(pool model.DbPool, opts Options) *Cache[uint64, []string] {
+	return New(QueryLoader[string](pool, cursedAllowlistQuery), opts)
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCursedChannelCache(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`SELECT channel_id FROM cursed_channels WHERE guild_id = \$1`).
+		WithArgs(uint64(90)).
+		WillReturnRows(pgxmock.NewRows([]string{"channel_id"}).AddRow(uint64(5)).AddRow(uint64(10)))
+	cache := NewCursedChannelCache(mockDb, Options{})
+	for range 2 {
+		got, err := cache.Get(context.Background(), 90)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{5, 10}, got)
+	}
+	assert.NoError(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestNewOptOutCache(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`SELECT user_id FROM privacy_opt_outs WHERE guild_id = \$1`).
+		WithArgs(uint64(90)).
+		WillReturnRows(pgxmock.NewRows([]string{"user_id"}).AddRow(uint64(200)))
+	got, err := NewOptOutCache(mockDb, Options{}).Get(context.Background(), 90)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{200}, got)
+	assert.NoError(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestNewCursedWordCache(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`SELECT word FROM cursed_word_list WHERE guild_id = \$1`).
+		WithArgs(uint64(90)).
+		WillReturnRows(pgxmock.NewRows([]string{"word"}).AddRow("heck").AddRow("darn"))
+	got, err := NewCursedWordCache(mockDb, Options{}).Get(context.Background(), 90)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"heck", "darn"}, got)
+	assert.NoError(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+//goland:noinspection Annotator
+func TestNewAllowlistCache_PreloadCache(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`SELECT word FROM cursed_word_allowlist WHERE guild_id = \$1`).
+		WithArgs(uint64(1)).
+		WillReturnRows(pgxmock.NewRows([]string{"word"}).AddRow("class"))
+	mockDb.ExpectQuery(`SELECT word FROM cursed_word_allowlist WHERE guild_id = \$1`).
+		WithArgs(uint64(2)).
+		WillReturnRows(pgxmock.NewRows([]string{"word"}).AddRow("scunthorpe"))
+	cache := NewAllowlistCache(mockDb, Options{})
+	require.NoError(t, cache.PreloadCache(context.Background(), []uint64{1, 2}))
+	assert.NoError(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+	got, err := cache.Get(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"scunthorpe"}, got, "preloaded value not used")
+}
@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+// InvalidationChannel is the channel the cache invalidation triggers notify on. Payloads are "table:guild_id", or just
+// the table when it's truncated.
+const InvalidationChannel = "cache_invalidation"
+
+// listenRetry is the wait before reconnecting a dropped listener
+const listenRetry = 5 * time.Second
+
+// Invalidator is a cache of per guild values that can be dropped when they change
+type Invalidator interface {
+	Invalidate(ctx context.Context, key uint64)
+	Clear(ctx context.Context)
+}
+
+// Listener invalidates caches as the tables they're loaded from change, so changes made by any shard, or directly in
+// the db, take effect straight away everywhere.
+type Listener struct {
+	pool model.DbPool
+
+	lock   sync.RWMutex
+	caches map[string][]Invalidator
+}
+
+func NewListener(pool model.DbPool) *Listener {
+	return &Listener{pool: pool, caches: make(map[string][]Invalidator)}
+}
+
+// Register invalidates the cache when the table changes
+func (l *Listener) Register(table string, cache Invalidator) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.caches[table] = append(l.caches[table], cache)
+}
+
+// Listen handles invalidation notifications until the context is done, reconnecting if the connection drops. Every
+// cache is cleared after a drop, as notifications sent while disconnected are lost.
+func (l *Listener) Listen(ctx context.Context) {
+	for {
+		err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.WarnContext(ctx, "cache invalidation listener disconnected: "+err.Error())
+		l.clearAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetry):
+		}
+	}
+}
+
+func (l *Listener) listen(ctx context.Context) error {
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire listener connection: %w", err)
+	}
+	defer func() {
+		// stop listening before the connection goes back to the pool
+		unlistenCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		conn.Exec(unlistenCtx, "UNLISTEN *")
+		conn.Release()
+	}()
+	if _, err = conn.Exec(ctx, "LISTEN "+InvalidationChannel); err != nil {
+		return fmt.Errorf("failed to listen for cache invalidations: %w", err)
+	}
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("failed waiting for cache invalidation: %w", err)
+		}
+		l.handle(ctx, notification.Payload)
+	}
+}
+
+// handle invalidates the guild in the caches for the table in the payload, or clears them if there's no valid guild
+func (l *Listener) handle(ctx context.Context, payload string) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	table, guild, found := strings.Cut(payload, ":")
+	guildId, err := strconv.ParseUint(guild, 10, 64)
+	if found && err != nil {
+		slog.WarnContext(ctx, "bad cache invalidation payload "+payload+": "+err.Error())
+	}
+	for _, cache := range l.caches[table] {
+		if found && err == nil {
+			cache.Invalidate(ctx, guildId)
+		} else {
+			cache.Clear(ctx)
+		}
+	}
+}
+
+func (l *Listener) clearAll(ctx context.Context) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	for _, caches := range l.caches {
+		for _, cache := range caches {
+			cache.Clear(ctx)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testInvalidator records invalidations
+type testInvalidator struct {
+	keys    []uint64
+	cleared int
+}
+
+func (c *testInvalidator) Invalidate(_ context.Context, key uint64) { c.keys = append(c.keys, key) }
+
+func (c *testInvalidator) Clear(_ context.Context) { c.cleared++ }
+
+func TestListener_handle(t *testing.T) {
+	ctx := context.Background()
+	listener := NewListener(nil)
+	channels, words, allowed := &testInvalidator{}, &testInvalidator{}, &testInvalidator{}
+	listener.Register(CursedChannelsTable, channels)
+	listener.Register(CursedWordsTable, words)
+	listener.Register(CursedWordsTable, allowed)
+
+	listener.handle(ctx, "cursed_channels:12345")
+	listener.handle(ctx, "cursed_word_list:67890")
+	listener.handle(ctx, "cursed_word_list")
+	listener.handle(ctx, "cursed_channels:bad")
+	listener.handle(ctx, "quotes:12345")
+
+	assert.Equal(t, []uint64{12345}, channels.keys)
+	assert.Equal(t, 1, channels.cleared, "bad guild id not cleared")
+	assert.Equal(t, []uint64{67890}, words.keys)
+	assert.Equal(t, 1, words.cleared, "truncate not cleared")
+	assert.Equal(t, []uint64{67890}, allowed.keys, "every cache for the table not invalidated")
+
+	listener.clearAll(ctx)
+	assert.Equal(t, []int{2, 2, 2}, []int{channels.cleared, words.cleared, allowed.cleared})
+}
@@ -0,0 +1,267 @@
+// Package chart renders simple bar & line charts as PNG images, without any external services
+package chart
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Kind is the way values are drawn
+type Kind int
+
+const (
+	Bar Kind = iota
+	Line
+)
+
+// Image dimensions & layout in pixels
+const (
+	Width        = 800
+	Height       = 400
+	marginLeft   = 60
+	marginRight  = 20
+	marginTop    = 40
+	marginBottom = 40
+	yTicks       = 5
+	labelGap     = 8
+)
+
+var ErrNoData = errors.New("no data to chart")
+
+// Colours chosen to be readable on Discord's dark theme
+var (
+	background = color.RGBA{R: 0x2b, G: 0x2d, B: 0x31, A: 0xff}
+	foreground = color.RGBA{R: 0xdb, G: 0xde, B: 0xe1, A: 0xff}
+	gridColour = color.RGBA{R: 0x4e, G: 0x50, B: 0x58, A: 0xff}
+	palette    = []color.RGBA{
+		{R: 0x58, G: 0x65, B: 0xf2, A: 0xff},
+		{R: 0xf1, G: 0xc4, B: 0x0f, A: 0xff},
+		{R: 0x57, G: 0xf2, B: 0x87, A: 0xff},
+		{R: 0xed, G: 0x42, B: 0x45, A: 0xff},
+		{R: 0xeb, G: 0x45, B: 0x9e, A: 0xff},
+	}
+)
+
+var face = basicfont.Face7x13
+
+// Series is a named set of values, one for each of the chart's labels
+type Series struct {
+	Name   string
+	Values []float64
+}
+
+// Chart describes a chart to render. Every series must have one value for each label.
+type Chart struct {
+	Title   string
+	Kind    Kind
+	Labels  []string // X axis labels
+	Series  []Series
+	Percent bool // Y axis values are percentages
+}
+
+// RenderPNG draws the chart & encodes it as a PNG
+func (c Chart) RenderPNG(w io.Writer) error {
+	img, err := c.Render()
+	if err != nil {
+		return err
+	}
+	if err = png.Encode(w, img); err != nil {
+		return fmt.Errorf("failed to encode chart: %w", err)
+	}
+	return nil
+}
+
+// Render draws the chart
+func (c Chart) Render() (*image.RGBA, error) {
+	if len(c.Labels) == 0 || len(c.Series) == 0 {
+		return nil, ErrNoData
+	}
+	for _, series := range c.Series {
+		if len(series.Values) != len(c.Labels) {
+			return nil, fmt.Errorf("series %s has %d values for %d labels", series.Name, len(series.Values), len(c.Labels))
+		}
+	}
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	plot := image.Rect(marginLeft, marginTop, Width-marginRight, Height-marginBottom)
+
+	drawText(img, c.Title, (Width-textWidth(c.Title))/2, marginTop/2+4, foreground)
+	yMax := niceMax(c.maxValue())
+	for i := 0; i <= yTicks; i++ {
+		value := yMax * float64(i) / yTicks
+		y := plot.Max.Y - int(float64(plot.Dy())*float64(i)/yTicks)
+		hLine(img, plot.Min.X, plot.Max.X, y, gridColour)
+		label := c.formatValue(value)
+		drawText(img, label, plot.Min.X-labelGap-textWidth(label), y+4, foreground)
+	}
+
+	slot := float64(plot.Dx()) / float64(len(c.Labels))
+	// skip labels so they don't overlap
+	labelEvery := 1
+	for float64(labelEvery)*slot < float64(c.maxLabelWidth()+labelGap) {
+		labelEvery++
+	}
+	for i, label := range c.Labels {
+		if i%labelEvery != 0 {
+			continue
+		}
+		center := plot.Min.X + int(slot*(float64(i)+0.5))
+		drawText(img, label, center-textWidth(label)/2, plot.Max.Y+labelGap+face.Ascent, foreground)
+	}
+
+	scale := func(value float64) int {
+		return plot.Max.Y - int(math.Round(float64(plot.Dy())*value/yMax))
+	}
+	switch c.Kind {
+	case Line:
+		for s, series := range c.Series {
+			colour := palette[s%len(palette)]
+			prevX, prevY := 0, 0
+			for i, value := range series.Values {
+				x, y := plot.Min.X+int(slot*(float64(i)+0.5)), scale(value)
+				if i > 0 {
+					drawLine(img, prevX, prevY, x, y, colour)
+				}
+				fillRect(img, image.Rect(x-2, y-2, x+3, y+3), colour)
+				prevX, prevY = x, y
+			}
+		}
+	default:
+		// bars for each label are grouped, leaving a gap between groups
+		barWidth := math.Max(slot*0.8/float64(len(c.Series)), 1)
+		for s, series := range c.Series {
+			colour := palette[s%len(palette)]
+			for i, value := range series.Values {
+				left := plot.Min.X + int(slot*(float64(i)+0.1)+barWidth*float64(s))
+				fillRect(img, image.Rect(left, scale(value), left+int(math.Ceil(barWidth)), plot.Max.Y), colour)
+			}
+		}
+	}
+	hLine(img, plot.Min.X, plot.Max.X, plot.Max.Y, foreground)
+	c.drawLegend(img, plot)
+	return img, nil
+}
+
+// drawLegend lists the series names in the top right of the plot when there's more than one
+func (c Chart) drawLegend(img *image.RGBA, plot image.Rectangle) {
+	if len(c.Series) < 2 {
+		return
+	}
+	y := plot.Min.Y + labelGap
+	for s, series := range c.Series {
+		x := plot.Max.X - labelGap - textWidth(series.Name)
+		fillRect(img, image.Rect(x-14, y-9, x-4, y+1), palette[s%len(palette)])
+		drawText(img, series.Name, x, y, foreground)
+		y += face.Height + 4
+	}
+}
+
+func (c Chart) maxValue() float64 {
+	maxValue := 0.0
+	for _, series := range c.Series {
+		for _, value := range series.Values {
+			maxValue = math.Max(maxValue, value)
+		}
+	}
+	return maxValue
+}
+
+func (c Chart) maxLabelWidth() int {
+	width := 0
+	for _, label := range c.Labels {
+		width = max(width, textWidth(label))
+	}
+	return width
+}
+
+func (c Chart) formatValue(value float64) string {
+	if c.Percent {
+		return strconv.FormatFloat(value, 'f', 0, 64) + "%"
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// niceMax rounds the axis maximum up so each tick is a round number
+func niceMax(value float64) float64 {
+	if value <= 0 {
+		return yTicks
+	}
+	step := value / yTicks
+	magnitude := math.Pow(10, math.Floor(math.Log10(step)))
+	for _, multiple := range []float64{1, 2, 2.5, 3, 4, 5, 6, 8, 10} {
+		nice := math.Max(multiple*magnitude, 1)
+		// integer counts shouldn't have fractional ticks
+		if nice >= step && nice == math.Trunc(nice) {
+			return nice * yTicks
+		}
+	}
+	return 10 * magnitude * yTicks
+}
+
+func textWidth(text string) int {
+	return font.MeasureString(face, text).Round()
+}
+
+func drawText(img *image.RGBA, text string, x, y int, colour color.Color) {
+	drawer := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(colour),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	drawer.DrawString(text)
+}
+
+func fillRect(img *image.RGBA, rect image.Rectangle, colour color.Color) {
+	draw.Draw(img, rect, image.NewUniform(colour), image.Point{}, draw.Src)
+}
+
+func hLine(img *image.RGBA, x1, x2, y int, colour color.Color) {
+	fillRect(img, image.Rect(x1, y, x2, y+1), colour)
+}
+
+// drawLine draws a 2px wide line between the points using Bresenham's algorithm
+func drawLine(img *image.RGBA, x1, y1, x2, y2 int, colour color.Color) {
+	dx, dy := abs(x2-x1), -abs(y2-y1)
+	sx, sy := 1, 1
+	if x1 > x2 {
+		sx = -1
+	}
+	if y1 > y2 {
+		sy = -1
+	}
+	errTerm := dx + dy
+	for {
+		fillRect(img, image.Rect(x1, y1, x1+2, y1+2), colour)
+		if x1 == x2 && y1 == y2 {
+			return
+		}
+		e2 := 2 * errTerm
+		if e2 >= dy {
+			errTerm += dy
+			x1 += sx
+		}
+		if e2 <= dx {
+			errTerm += dx
+			y1 += sy
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
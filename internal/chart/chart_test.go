@@ -0,0 +1,71 @@
+package chart
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChart_RenderPNG(t *testing.T) {
+	tests := []struct {
+		name  string
+		chart Chart
+	}{
+		{
+			"bar",
+			Chart{Title: "Messages per day", Kind: Bar, Labels: []string{"Mar 1", "Mar 2", "Mar 3"}, Series: []Series{{Name: "messages", Values: []float64{3, 0, 12}}}},
+		},
+		{
+			"line_multiple_series",
+			Chart{
+				Title:   "Win rate",
+				Kind:    Line,
+				Labels:  []string{"Jan 2024", "Feb 2024"},
+				Series:  []Series{{Name: "<@1>", Values: []float64{50, 75}}, {Name: "<@2>", Values: []float64{100, 0}}},
+				Percent: true,
+			},
+		},
+		{
+			"all_zero",
+			Chart{Title: "Quiet", Kind: Bar, Labels: []string{"Mon"}, Series: []Series{{Name: "messages", Values: []float64{0}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, tt.chart.RenderPNG(&buf))
+			img, err := png.Decode(&buf)
+			require.NoError(t, err)
+			assert.Equal(t, Width, img.Bounds().Dx())
+			assert.Equal(t, Height, img.Bounds().Dy())
+		})
+	}
+}
+
+func TestChart_RenderErrors(t *testing.T) {
+	_, err := Chart{Title: "empty"}.Render()
+	assert.ErrorIs(t, err, ErrNoData)
+
+	_, err = Chart{Labels: []string{"a", "b"}, Series: []Series{{Name: "short", Values: []float64{1}}}}.Render()
+	assert.Error(t, err)
+}
+
+func Test_niceMax(t *testing.T) {
+	tests := []struct {
+		value float64
+		want  float64
+	}{
+		{0, 5},
+		{3, 5},
+		{12, 15},
+		{47, 50},
+		{100, 100},
+		{1234, 1250},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, niceMax(tt.value), "niceMax(%v)", tt.value)
+	}
+}
@@ -0,0 +1,118 @@
+package cursed
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Action is what's done to posts with a cursed word in the action's tier. Each guild configures its own actions.
+type Action struct {
+	Tier            string   `mapstructure:"tier"`             // Name shown in the moderation log
+	Words           []string `mapstructure:"words"`            // Cursed words in the tier, as in the word list. Matches words not in any other tier if empty
+	React           string   `mapstructure:"react"`            // Emoji to react with, unicode or name:id for custom emoji
+	Reply           bool     `mapstructure:"reply"`            // Reply with a quote
+	Delete          bool     `mapstructure:"delete"`           // Delete the post
+	TimeoutAfter    int      `mapstructure:"timeout_after"`    // Posts in the tier within timeout_window before the member is timed out, disabled if 0
+	TimeoutWindow   string   `mapstructure:"timeout_window"`   // Duration posts are counted over for timeouts, e.g. "1h"
+	TimeoutDuration string   `mapstructure:"timeout_duration"` // Duration of timeouts, e.g. "10m"
+	window          time.Duration
+	duration        time.Duration
+}
+
+// Load parses the timeout durations from the raw config values
+func (a *Action) Load() error {
+	if a.Tier == "" {
+		return fmt.Errorf("cursed action missing tier")
+	}
+	if a.TimeoutAfter < 0 {
+		return fmt.Errorf("invalid timeout_after %d for cursed tier %s", a.TimeoutAfter, a.Tier)
+	}
+	if a.TimeoutAfter == 0 {
+		return nil
+	}
+	var err error
+	if a.window, err = time.ParseDuration(a.TimeoutWindow); err != nil || a.window <= 0 {
+		return fmt.Errorf("invalid timeout_window %q for cursed tier %s", a.TimeoutWindow, a.Tier)
+	}
+	if a.duration, err = time.ParseDuration(a.TimeoutDuration); err != nil || a.duration <= 0 {
+		return fmt.Errorf("invalid timeout_duration %q for cursed tier %s", a.TimeoutDuration, a.Tier)
+	}
+	return nil
+}
+
+// Window gets the duration posts are counted over for timeouts
+func (a *Action) Window() time.Duration {
+	return a.window
+}
+
+// Duration gets how long members are timed out for
+func (a *Action) Duration() time.Duration {
+	return a.duration
+}
+
+// ActionsFor gets the actions for tiers with any of the cursed words found. Words not in a tier's word list fall back
+// to the actions without a word list.
+func ActionsFor(actions []Action, words []string) []*Action {
+	var found []*Action
+	fallback := false
+	for _, word := range words {
+		inTier := false
+		for i := range actions {
+			if slices.ContainsFunc(actions[i].Words, func(w string) bool { return strings.EqualFold(strings.TrimSpace(w), word) }) {
+				inTier = true
+				if !slices.Contains(found, &actions[i]) {
+					found = append(found, &actions[i])
+				}
+			}
+		}
+		fallback = fallback || !inTier
+	}
+	if fallback {
+		for i := range actions {
+			if len(actions[i].Words) == 0 && !slices.Contains(found, &actions[i]) {
+				found = append(found, &actions[i])
+			}
+		}
+	}
+	return found
+}
+
+type incidentKey struct {
+	guildId uint64
+	userId  uint64
+	tier    string
+}
+
+// Incidents counts each member's recent cursed posts in each tier, to time them out after too many. Counts are kept
+// in memory, so they start over when the bot restarts.
+type Incidents struct {
+	lock  sync.Mutex
+	times map[incidentKey][]time.Time
+}
+
+func NewIncidents() *Incidents {
+	return &Incidents{times: make(map[incidentKey][]time.Time)}
+}
+
+// Record adds a post in the action's tier at t, returning true if the member reached the action's timeout limit.
+// The count starts over once the limit is reached.
+func (i *Incidents) Record(guildId, userId uint64, action *Action, t time.Time) bool {
+	if action.TimeoutAfter == 0 {
+		return false
+	}
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	key := incidentKey{guildId: guildId, userId: userId, tier: action.Tier}
+	cutoff := t.Add(-action.window)
+	times := slices.DeleteFunc(i.times[key], func(incident time.Time) bool { return !incident.After(cutoff) })
+	times = append(times, t)
+	if len(times) >= action.TimeoutAfter {
+		delete(i.times, key)
+		return true
+	}
+	i.times[key] = times
+	return false
+}
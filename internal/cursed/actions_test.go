@@ -0,0 +1,90 @@
+package cursed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAction_Load(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  Action
+		wantErr bool
+	}{
+		{"react only", Action{Tier: "mild", React: "🧼"}, false},
+		{"timeout", Action{Tier: "severe", TimeoutAfter: 3, TimeoutWindow: "1h", TimeoutDuration: "10m"}, false},
+		{"missing tier", Action{React: "🧼"}, true},
+		{"negative limit", Action{Tier: "severe", TimeoutAfter: -1}, true},
+		{"missing window", Action{Tier: "severe", TimeoutAfter: 3, TimeoutDuration: "10m"}, true},
+		{"invalid duration", Action{Tier: "severe", TimeoutAfter: 3, TimeoutWindow: "1h", TimeoutDuration: "forever"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.action.Load()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+	action := Action{Tier: "severe", TimeoutAfter: 3, TimeoutWindow: "1h", TimeoutDuration: "10m"}
+	require.NoError(t, action.Load())
+	assert.Equal(t, time.Hour, action.Window())
+	assert.Equal(t, 10*time.Minute, action.Duration())
+}
+
+func TestActionsFor(t *testing.T) {
+	actions := []Action{
+		{Tier: "mild", React: "🧼"},
+		{Tier: "severe", Words: []string{"Heck", "/d+a+r+n+/"}, Delete: true},
+		{Tier: "reply", Words: []string{"heck"}, Reply: true},
+	}
+	tiers := func(found []*Action) []string {
+		var names []string
+		for _, action := range found {
+			names = append(names, action.Tier)
+		}
+		return names
+	}
+	tests := []struct {
+		name  string
+		words []string
+		want  []string
+	}{
+		{"no words", nil, nil},
+		{"untiered word", []string{"gosh"}, []string{"mild"}},
+		{"tiered word", []string{"heck"}, []string{"severe", "reply"}},
+		{"regex entry", []string{"/d+a+r+n+/"}, []string{"severe"}},
+		{"both", []string{"heck", "gosh", "heck"}, []string{"severe", "reply", "mild"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tiers(ActionsFor(actions, tt.words)))
+		})
+	}
+}
+
+func TestIncidents_Record(t *testing.T) {
+	action := &Action{Tier: "severe", TimeoutAfter: 3, TimeoutWindow: "1h", TimeoutDuration: "10m"}
+	require.NoError(t, action.Load())
+	incidents := NewIncidents()
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.False(t, incidents.Record(1, 2, action, start))
+	assert.False(t, incidents.Record(1, 2, action, start.Add(10*time.Minute)))
+	assert.False(t, incidents.Record(1, 3, action, start.Add(20*time.Minute)), "other members counted together")
+	assert.False(t, incidents.Record(2, 2, action, start.Add(20*time.Minute)), "other guilds counted together")
+	assert.True(t, incidents.Record(1, 2, action, start.Add(30*time.Minute)))
+	assert.False(t, incidents.Record(1, 2, action, start.Add(40*time.Minute)), "count not reset after timeout")
+
+	// the first post falls out of the window
+	assert.False(t, incidents.Record(1, 3, action, start.Add(70*time.Minute)))
+	assert.False(t, incidents.Record(1, 3, action, start.Add(80*time.Minute)))
+	assert.True(t, incidents.Record(1, 3, action, start.Add(90*time.Minute)))
+
+	assert.False(t, incidents.Record(1, 2, &Action{Tier: "mild"}, start), "tier without timeouts recorded")
+}
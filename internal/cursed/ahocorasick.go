@@ -0,0 +1,92 @@
+package cursed
+
+// automaton is an Aho-Corasick automaton, finding every occurrence of a set of patterns in a single pass over the
+// text however many patterns there are
+type automaton struct {
+	nodes []acNode
+}
+
+type acNode struct {
+	next   map[byte]int
+	fail   int   // longest proper suffix of this node that's also in the trie
+	output int   // nearest node on the fail chain that ends a pattern, or -1
+	ends   []int // patterns ending at this node
+}
+
+// acMatch is an occurrence of a pattern, with the byte offsets it ends at
+type acMatch struct {
+	pattern int
+	end     int
+}
+
+func newAutomaton(patterns []string) *automaton {
+	a := &automaton{nodes: []acNode{{next: make(map[byte]int), output: -1}}}
+	for i, pattern := range patterns {
+		node := 0
+		for j := 0; j < len(pattern); j++ {
+			child, ok := a.nodes[node].next[pattern[j]]
+			if !ok {
+				child = len(a.nodes)
+				a.nodes = append(a.nodes, acNode{next: make(map[byte]int), output: -1})
+				a.nodes[node].next[pattern[j]] = child
+			}
+			node = child
+		}
+		a.nodes[node].ends = append(a.nodes[node].ends, i)
+	}
+
+	// breadth first, so each node's fail link is set before its children need it
+	queue := make([]int, 0, len(a.nodes))
+	for _, child := range a.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for c, child := range a.nodes[node].next {
+			fail := a.nodes[node].fail
+			for {
+				if next, ok := a.nodes[fail].next[c]; ok {
+					fail = next
+					break
+				}
+				if fail == 0 {
+					break
+				}
+				fail = a.nodes[fail].fail
+			}
+			a.nodes[child].fail = fail
+			if len(a.nodes[fail].ends) > 0 {
+				a.nodes[child].output = fail
+			} else {
+				a.nodes[child].output = a.nodes[fail].output
+			}
+			queue = append(queue, child)
+		}
+	}
+	return a
+}
+
+// find gets every occurrence of the patterns in the text, including overlapping ones
+func (a *automaton) find(text string) []acMatch {
+	var matches []acMatch
+	node := 0
+	for i := 0; i < len(text); i++ {
+		for {
+			if next, ok := a.nodes[node].next[text[i]]; ok {
+				node = next
+				break
+			}
+			if node == 0 {
+				break
+			}
+			node = a.nodes[node].fail
+		}
+		for out := node; out > 0; out = a.nodes[out].output {
+			for _, pattern := range a.nodes[out].ends {
+				matches = append(matches, acMatch{pattern: pattern, end: i + 1})
+			}
+		}
+	}
+	return matches
+}
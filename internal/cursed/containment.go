@@ -0,0 +1,49 @@
+package cursed
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// ContainReplyMode replies to contained members' posts outside the cursed channels, pointing them back
+	ContainReplyMode = "reply"
+	// ContainMoveMode reposts contained members' posts outside the cursed channels into a cursed channel
+	ContainMoveMode = "move"
+)
+
+// DefaultContainmentCooldown is the time between reminders to each contained member for guilds that don't set one
+const DefaultContainmentCooldown = 10 * time.Minute
+
+// IsContainMode checks if the mode is a valid containment mode
+func IsContainMode(mode string) bool {
+	return mode == ContainReplyMode || mode == ContainMoveMode
+}
+
+type cooldownKey struct {
+	guildId uint64
+	userId  uint64
+}
+
+// Cooldowns limits how often each member in a guild is reminded. Times are kept in memory, so cooldowns start over when
+// the bot restarts.
+type Cooldowns struct {
+	lock sync.Mutex
+	last map[cooldownKey]time.Time
+}
+
+func NewCooldowns() *Cooldowns {
+	return &Cooldowns{last: make(map[cooldownKey]time.Time)}
+}
+
+// Ready checks if the member's cooldown has passed at t, starting a new cooldown if it has
+func (c *Cooldowns) Ready(guildId, userId uint64, cooldown time.Duration, t time.Time) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	key := cooldownKey{guildId: guildId, userId: userId}
+	if last, ok := c.last[key]; ok && t.Before(last.Add(cooldown)) {
+		return false
+	}
+	c.last[key] = t
+	return true
+}
@@ -0,0 +1,27 @@
+package cursed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCooldowns_Ready(t *testing.T) {
+	cooldowns := NewCooldowns()
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.True(t, cooldowns.Ready(1, 2, 10*time.Minute, start))
+	assert.False(t, cooldowns.Ready(1, 2, 10*time.Minute, start.Add(5*time.Minute)))
+	assert.True(t, cooldowns.Ready(1, 3, 10*time.Minute, start.Add(5*time.Minute)), "other members share a cooldown")
+	assert.True(t, cooldowns.Ready(2, 2, 10*time.Minute, start.Add(5*time.Minute)), "other guilds share a cooldown")
+	assert.False(t, cooldowns.Ready(1, 2, 10*time.Minute, start.Add(9*time.Minute)), "cooldown extended by early post")
+	assert.True(t, cooldowns.Ready(1, 2, 10*time.Minute, start.Add(10*time.Minute)))
+	assert.False(t, cooldowns.Ready(1, 2, 10*time.Minute, start.Add(15*time.Minute)))
+}
+
+func TestIsContainMode(t *testing.T) {
+	assert.True(t, IsContainMode(ContainReplyMode))
+	assert.True(t, IsContainMode(ContainMoveMode))
+	assert.False(t, IsContainMode("ban"))
+}
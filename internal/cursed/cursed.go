@@ -0,0 +1,135 @@
+// Package cursed manages each guild's cursed words & cursed channels, matches cursed words in posts, & keeps the
+// actions & swear jar for cursed posts
+package cursed
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+// Store persists the cursed words & channels for each guild. Lookups while listening go through the cursed caches.
+type Store interface {
+	// AddWord curses the word in the guild, returning false if it already was
+	AddWord(ctx context.Context, guildId uint64, word string) (bool, error)
+	// RemoveWord uncurses the word in the guild, returning false if it wasn't cursed
+	RemoveWord(ctx context.Context, guildId uint64, word string) (bool, error)
+	Words(ctx context.Context, guildId uint64) ([]string, error)
+	// AddChannel curses the channel in the guild, returning false if it already was
+	AddChannel(ctx context.Context, guildId, channelId uint64) (bool, error)
+	// RemoveChannel uncurses the channel in the guild, returning false if it wasn't cursed
+	RemoveChannel(ctx context.Context, guildId, channelId uint64) (bool, error)
+	Channels(ctx context.Context, guildId uint64) ([]uint64, error)
+	// AddAllowed exempts the word from cursed word matching in the guild, returning false if it already was
+	AddAllowed(ctx context.Context, guildId uint64, word string) (bool, error)
+	// RemoveAllowed removes the word's exemption in the guild, returning false if it wasn't exempt
+	RemoveAllowed(ctx context.Context, guildId uint64, word string) (bool, error)
+	Allowed(ctx context.Context, guildId uint64) ([]string, error)
+	// AddToSwearJar adds cursed words to the user's swear jar, returning their new total
+	AddToSwearJar(ctx context.Context, guildId, userId uint64, count int) (int, error)
+	// SwearJar gets the user's swear jar total, 0 if they've never cursed
+	SwearJar(ctx context.Context, guildId, userId uint64) (int, error)
+	// SwearJarLeaders gets the largest swear jar totals in the guild
+	SwearJarLeaders(ctx context.Context, guildId uint64, limit int) ([]model.SwearJarTotal, error)
+	// RemoveUser deletes the user's swear jar in the guild
+	RemoveUser(ctx context.Context, guildId, userId uint64) error
+}
+
+// Cursed is the Postgres backed Store
+type Cursed struct {
+	pool model.DbPool
+}
+
+var _ Store = (*Cursed)(nil)
+
+func New(pool model.DbPool) *Cursed {
+	return &Cursed{pool: pool}
+}
+
+func (c *Cursed) AddWord(ctx context.Context, guildId uint64, word string) (bool, error) {
+	// the table has no unique index, so existing entries are checked for instead of conflicting
+	tag, err := c.pool.Exec(ctx, `
+INSERT INTO cursed_word_list (guild_id, word)
+SELECT $1, $2
+WHERE NOT EXISTS (SELECT 1 FROM cursed_word_list WHERE guild_id = $1 AND word = $2)`, guildId, word)
+	if err != nil {
+		return false, fmt.Errorf("failed to add cursed word: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (c *Cursed) RemoveWord(ctx context.Context, guildId uint64, word string) (bool, error) {
+	tag, err := c.pool.Exec(ctx, "DELETE FROM cursed_word_list WHERE guild_id = $1 AND word = $2", guildId, word)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove cursed word: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (c *Cursed) Words(ctx context.Context, guildId uint64) ([]string, error) {
+	var words []string
+	err := pgxscan.Select(ctx, c.pool, &words, "SELECT DISTINCT word FROM cursed_word_list WHERE guild_id = $1 ORDER BY word", guildId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cursed words: %w", err)
+	}
+	return words, nil
+}
+
+func (c *Cursed) AddChannel(ctx context.Context, guildId, channelId uint64) (bool, error) {
+	tag, err := c.pool.Exec(ctx, `
+INSERT INTO cursed_channels (guild_id, channel_id)
+SELECT $1, $2
+WHERE NOT EXISTS (SELECT 1 FROM cursed_channels WHERE guild_id = $1 AND channel_id = $2)`, guildId, channelId)
+	if err != nil {
+		return false, fmt.Errorf("failed to add cursed channel: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (c *Cursed) RemoveChannel(ctx context.Context, guildId, channelId uint64) (bool, error) {
+	tag, err := c.pool.Exec(ctx, "DELETE FROM cursed_channels WHERE guild_id = $1 AND channel_id = $2", guildId, channelId)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove cursed channel: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (c *Cursed) Channels(ctx context.Context, guildId uint64) ([]uint64, error) {
+	var channels []uint64
+	err := pgxscan.Select(ctx, c.pool, &channels, "SELECT DISTINCT channel_id FROM cursed_channels WHERE guild_id = $1 ORDER BY channel_id", guildId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cursed channels: %w", err)
+	}
+	return channels, nil
+}
+
+func (c *Cursed) AddAllowed(ctx context.Context, guildId uint64, word string) (bool, error) {
+	tag, err := c.pool.Exec(ctx, `
+INSERT INTO cursed_word_allowlist (guild_id, word)
+SELECT $1, $2
+WHERE NOT EXISTS (SELECT 1 FROM cursed_word_allowlist WHERE guild_id = $1 AND word = $2)`, guildId, word)
+	if err != nil {
+		return false, fmt.Errorf("failed to add allowed word: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (c *Cursed) RemoveAllowed(ctx context.Context, guildId uint64, word string) (bool, error) {
+	tag, err := c.pool.Exec(ctx, "DELETE FROM cursed_word_allowlist WHERE guild_id = $1 AND word = $2", guildId, word)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove allowed word: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (c *Cursed) Allowed(ctx context.Context, guildId uint64) ([]string, error) {
+	var words []string
+	err := pgxscan.Select(ctx, c.pool, &words, "SELECT DISTINCT word FROM cursed_word_allowlist WHERE guild_id = $1 ORDER BY word", guildId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get allowed words: %w", err)
+	}
+	return words, nil
+}
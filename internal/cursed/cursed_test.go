@@ -0,0 +1,144 @@
+package cursed
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursed_AddWord(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected int64
+		want     bool
+	}{
+		{"new word", 1, true},
+		{"already cursed", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb, err := pgxmock.NewPool()
+			require.Nil(t, err, "failed to build pool")
+			defer mockDb.Close()
+			mockDb.ExpectExec(`INSERT INTO cursed_word_list \(guild_id, word\)
+SELECT \$1, \$2
+WHERE NOT EXISTS \(SELECT 1 FROM cursed_word_list WHERE guild_id = \$1 AND word = \$2\)`).
+				WithArgs(uint64(1), "heck").
+				WillReturnResult(pgxmock.NewResult("INSERT", tt.affected))
+			got, err := New(mockDb).AddWord(context.Background(), 1, "heck")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+		})
+	}
+}
+
+func TestCursed_RemoveWord(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectExec(`DELETE FROM cursed_word_list WHERE guild_id = \$1 AND word = \$2`).
+		WithArgs(uint64(1), "heck").
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+	got, err := New(mockDb).RemoveWord(context.Background(), 1, "heck")
+	require.NoError(t, err)
+	assert.True(t, got)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestCursed_Words(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`SELECT DISTINCT word FROM cursed_word_list WHERE guild_id = \$1 ORDER BY word`).
+		WithArgs(uint64(1)).
+		WillReturnRows(pgxmock.NewRows([]string{"word"}).AddRow("darn").AddRow("heck"))
+	got, err := New(mockDb).Words(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"darn", "heck"}, got)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestCursed_AddChannel(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectExec(`INSERT INTO cursed_channels \(guild_id, channel_id\)
+SELECT \$1, \$2
+WHERE NOT EXISTS \(SELECT 1 FROM cursed_channels WHERE guild_id = \$1 AND channel_id = \$2\)`).
+		WithArgs(uint64(1), uint64(2)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	got, err := New(mockDb).AddChannel(context.Background(), 1, 2)
+	require.NoError(t, err)
+	assert.True(t, got)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestCursed_RemoveChannel(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectExec(`DELETE FROM cursed_channels WHERE guild_id = \$1 AND channel_id = \$2`).
+		WithArgs(uint64(1), uint64(2)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	got, err := New(mockDb).RemoveChannel(context.Background(), 1, 2)
+	require.NoError(t, err)
+	assert.False(t, got)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestCursed_ChannelsError(t *testing.T) {
+	expectedErr := errors.New("underlying db err")
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`FROM cursed_channels`).WithArgs(uint64(1)).WillReturnError(expectedErr)
+	_, err = New(mockDb).Channels(context.Background(), 1)
+	assert.ErrorIs(t, err, expectedErr, "expected error not wrapped")
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestCursed_AddAllowed(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectExec(`INSERT INTO cursed_word_allowlist \(guild_id, word\)
+SELECT \$1, \$2
+WHERE NOT EXISTS \(SELECT 1 FROM cursed_word_allowlist WHERE guild_id = \$1 AND word = \$2\)`).
+		WithArgs(uint64(1), "class").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	got, err := New(mockDb).AddAllowed(context.Background(), 1, "class")
+	require.NoError(t, err)
+	assert.True(t, got)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestCursed_RemoveAllowed(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectExec(`DELETE FROM cursed_word_allowlist WHERE guild_id = \$1 AND word = \$2`).
+		WithArgs(uint64(1), "class").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	got, err := New(mockDb).RemoveAllowed(context.Background(), 1, "class")
+	require.NoError(t, err)
+	assert.True(t, got)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestCursed_Allowed(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`SELECT DISTINCT word FROM cursed_word_allowlist WHERE guild_id = \$1 ORDER BY word`).
+		WithArgs(uint64(1)).
+		WillReturnRows(pgxmock.NewRows([]string{"word"}).AddRow("class"))
+	got, err := New(mockDb).Allowed(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"class"}, got)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
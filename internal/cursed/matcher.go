@@ -0,0 +1,174 @@
+package cursed
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// wordRunes matches the letters an inner wildcard can stand for
+const wordRunes = `[\p{L}\p{N}]*`
+
+// entry is a parsed cursed word. Entries are one of:
+//   - a word, matched as a whole word, e.g. "heck"
+//   - a word with * wildcards, which match any letters at that point. Leading or trailing wildcards let the match be
+//     part of a longer word, e.g. "heck*" matches "heckin"
+//   - a regular expression between slashes, matched anywhere, e.g. "/h+e+c+k+/"
+//
+// Words are normalized the same as posts, so "h3ck" & "heck" are the same entry.
+type entry struct {
+	word       string         // as in the word list
+	literal    string         // normalized word, for entries without inner wildcards
+	re         *regexp.Regexp // for regular expressions & inner wildcards
+	leftBound  bool           // match must start a word
+	rightBound bool           // match must end a word
+}
+
+var errEmptyEntry = errors.New("empty cursed word")
+
+// IsRegexEntry checks if the cursed word is a regular expression
+func IsRegexEntry(word string) bool {
+	return len(word) > 2 && strings.HasPrefix(word, "/") && strings.HasSuffix(word, "/")
+}
+
+// ValidateEntry checks the cursed word can be matched
+func ValidateEntry(word string) error {
+	_, err := parseEntry(word)
+	return err
+}
+
+func parseEntry(word string) (entry, error) {
+	word = strings.TrimSpace(word)
+	if IsRegexEntry(word) {
+		re, err := regexp.Compile("(?i)" + word[1:len(word)-1])
+		if err != nil {
+			return entry{}, fmt.Errorf("invalid cursed word regex %s: %w", word, err)
+		}
+		return entry{word: word, re: re}, nil
+	}
+	e := entry{word: word, leftBound: !strings.HasPrefix(word, "*"), rightBound: !strings.HasSuffix(word, "*")}
+	core := Normalize(strings.Trim(word, "*"))
+	if core == "" {
+		return entry{}, errEmptyEntry
+	}
+	if !strings.Contains(core, "*") {
+		e.literal = core
+		return e, nil
+	}
+	parts := strings.Split(core, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	e.re = regexp.MustCompile(strings.Join(parts, wordRunes))
+	return e, nil
+}
+
+// Matcher counts the cursed words in posts. Plain & edge wildcard words are matched together by an Aho-Corasick
+// automaton, so adding words doesn't slow matching much. Regular expressions & inner wildcards are matched one by one.
+type Matcher struct {
+	entries   []entry    // in word list order
+	automaton *automaton // patterns are the entries' literals, empty for regular expressions so they never match
+	allow     map[string]bool
+}
+
+// NewMatcher builds a matcher for the cursed words. Matches inside words on the allowlist aren't counted. Invalid
+// words are left out, & returned as an error along with the matcher for the rest.
+func NewMatcher(words []string, allow []string) (*Matcher, error) {
+	m := &Matcher{allow: make(map[string]bool, len(allow))}
+	for _, word := range allow {
+		m.allow[Normalize(strings.TrimSpace(word))] = true
+	}
+	var errs *multierror.Error
+	var literals []string
+	for _, word := range words {
+		e, err := parseEntry(word)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		m.entries = append(m.entries, e)
+		literals = append(literals, e.literal)
+	}
+	m.automaton = newAutomaton(literals)
+	return m, errs.ErrorOrNil()
+}
+
+// Count gets the number of cursed words in the text. Overlapping matches starting at the same place count once.
+func (m *Matcher) Count(text string) int {
+	return len(m.Find(text))
+}
+
+// Find gets the cursed word, as in the word list, for each match in the text in order. Where overlapping matches start
+// at the same place, only the first word in the list is given.
+func (m *Matcher) Find(text string) []string {
+	normalized := Normalize(text)
+	starts := make(map[int]int) // match start to index of the entry matched
+	found := func(start, index int) {
+		if prev, ok := starts[start]; !ok || index < prev {
+			starts[start] = index
+		}
+	}
+	for _, match := range m.automaton.find(normalized) {
+		e := m.entries[match.pattern]
+		start := match.end - len(e.literal)
+		if m.counts(normalized, e, start, match.end) {
+			found(start, match.pattern)
+		}
+	}
+	for i, e := range m.entries {
+		if e.re == nil {
+			continue
+		}
+		for _, loc := range e.re.FindAllStringIndex(normalized, -1) {
+			if loc[0] < loc[1] && m.counts(normalized, e, loc[0], loc[1]) {
+				found(loc[0], i)
+			}
+		}
+	}
+	positions := make([]int, 0, len(starts))
+	for start := range starts {
+		positions = append(positions, start)
+	}
+	slices.Sort(positions)
+	words := make([]string, len(positions))
+	for i, start := range positions {
+		words[i] = m.entries[starts[start]].word
+	}
+	return words
+}
+
+// counts checks the match is on the word boundaries the entry needs & isn't in an allowed word
+func (m *Matcher) counts(text string, e entry, start, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(text[:start])
+	after, _ := utf8.DecodeRuneInString(text[end:])
+	if e.leftBound && start > 0 && isWordRune(before) {
+		return false
+	}
+	if e.rightBound && end < len(text) && isWordRune(after) {
+		return false
+	}
+	if len(m.allow) == 0 {
+		return true
+	}
+	// widen the match to the whole words it's in
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(text[:start])
+		if !isWordRune(r) {
+			break
+		}
+		start -= size
+	}
+	for end < len(text) {
+		r, size := utf8.DecodeRuneInString(text[end:])
+		if !isWordRune(r) {
+			break
+		}
+		end += size
+	}
+	return !m.allow[text[start:end]]
+}
@@ -0,0 +1,121 @@
+package cursed
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"lower cased", "HeCk", "heck"},
+		{"leetspeak", "a$$ h3ck @nd 5h00t", "ass heck and shoot"},
+		{"exclamation inside word", "sh!t! wow!", "shit! wow!"},
+		{"accents", "héçk", "heck"},
+		{"cyrillic look-alikes", "hеск", "heck"},
+		{"fullwidth", "ｈｅｃｋ", "heck"},
+		{"styled letters", "𝐡𝐞𝐜𝐤", "heck"},
+		{"zero width characters", "he\u200bck", "heck"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Normalize(tt.text))
+		})
+	}
+}
+
+func TestAutomaton_find(t *testing.T) {
+	a := newAutomaton([]string{"he", "she", "his", "hers"})
+	assert.Equal(t, []acMatch{{pattern: 1, end: 4}, {pattern: 0, end: 4}, {pattern: 3, end: 6}}, a.find("ushers"))
+	assert.Empty(t, newAutomaton(nil).find("ushers"))
+}
+
+func TestMatcher_Count(t *testing.T) {
+	matcher, err := NewMatcher([]string{"ass", "heck*", "*darn", "sh*t", "/fr+ick/"}, []string{"hecking"})
+	require.NoError(t, err)
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"whole word", "what an ass", 1},
+		{"inside words", "the class assistant", 0},
+		{"leetspeak", "what an a$$", 1},
+		{"homoglyphs", "what an аss", 1},
+		{"repeated", "ass, ASS! ass.", 3},
+		{"trailing wildcard", "heck heckin heckity", 3},
+		{"allowlist", "hecking", 0},
+		{"leading wildcard", "goshdarn it", 1},
+		{"inner wildcard", "shoot shot sht shelf", 3},
+		{"inner wildcard whole word", "shtick", 0},
+		{"regex", "frrrrick frickin", 2},
+		{"nothing", "hello there", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, matcher.Count(tt.text))
+		})
+	}
+}
+
+func TestMatcher_Find(t *testing.T) {
+	matcher, err := NewMatcher([]string{"/fr+ick/", "Heck*", "h3ck", "sh*t"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Heck*", "sh*t", "/fr+ick/"}, matcher.Find("HECK, shoot, frrick"))
+	assert.Empty(t, matcher.Find("hello there"))
+}
+
+func TestNewMatcher_invalid(t *testing.T) {
+	matcher, err := NewMatcher([]string{"/(/", "*", "heck"}, nil)
+	assert.Error(t, err)
+	require.NotNil(t, matcher)
+	assert.Equal(t, 1, matcher.Count("heck"), "valid words not matched")
+}
+
+func TestValidateEntry(t *testing.T) {
+	assert.NoError(t, ValidateEntry("heck"))
+	assert.NoError(t, ValidateEntry("/h+eck/"))
+	assert.Error(t, ValidateEntry("/h(eck/"))
+	assert.Error(t, ValidateEntry("**"))
+}
+
+// testStringLists is a fixed string list cache
+type testStringLists map[uint64][]string
+
+func (c testStringLists) Get(_ context.Context, key uint64) ([]string, error) { return c[key], nil }
+
+func (c testStringLists) Set(_ context.Context, key uint64, value []string) { c[key] = value }
+
+func (c testStringLists) Clear(_ context.Context) {}
+
+func (c testStringLists) Invalidate(_ context.Context, _ uint64) {}
+
+func TestMatchers_Get(t *testing.T) {
+	ctx := context.Background()
+	words := testStringLists{1: {"heck"}, 2: {"darn"}}
+	allow := testStringLists{}
+	matchers := NewMatchers(words, allow)
+
+	first, err := matchers.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, first.Count("heck darn"))
+	again, err := matchers.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.Same(t, first, again, "unchanged matcher rebuilt")
+	other, err := matchers.Get(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, other.Count("darn heck"), "guild lists mixed")
+
+	words.Set(ctx, 1, []string{"heck*"})
+	allow.Set(ctx, 1, []string{"hecking"})
+	updated, err := matchers.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.NotSame(t, first, updated, "matcher not rebuilt after change")
+	assert.Equal(t, 1, updated.Count("heckin hecking"))
+}
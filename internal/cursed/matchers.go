@@ -0,0 +1,60 @@
+package cursed
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/dmtaylor/costanza/internal/cache"
+)
+
+// Matchers keeps a compiled Matcher for each guild, built from the guild's cached cursed words & allowlist. A
+// guild's matcher is rebuilt when either list changes, so invalidating the caches also updates the matcher.
+type Matchers struct {
+	words cache.StringListCache
+	allow cache.StringListCache
+
+	lock  sync.Mutex
+	built map[uint64]builtMatcher
+}
+
+type builtMatcher struct {
+	words   []string
+	allow   []string
+	matcher *Matcher
+}
+
+func NewMatchers(words cache.StringListCache, allow cache.StringListCache) *Matchers {
+	return &Matchers{
+		words: words,
+		allow: allow,
+		built: make(map[uint64]builtMatcher),
+	}
+}
+
+// Get gets the guild's matcher, building it if the guild's lists changed since it was last built
+func (m *Matchers) Get(ctx context.Context, guildId uint64) (*Matcher, error) {
+	words, err := m.words.Get(ctx, guildId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cursed words: %w", err)
+	}
+	allow, err := m.allow.Get(ctx, guildId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get allowed words: %w", err)
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if built, ok := m.built[guildId]; ok && slices.Equal(built.words, words) && slices.Equal(built.allow, allow) {
+		return built.matcher, nil
+	}
+	matcher, err := NewMatcher(words, allow)
+	if err != nil {
+		// still match the valid words, as an invalid word added outside of /cursed shouldn't stop matching
+		slog.WarnContext(ctx, "skipped invalid cursed words: "+err.Error(), "guildId", strconv.FormatUint(guildId, 10))
+	}
+	m.built[guildId] = builtMatcher{words: words, allow: allow, matcher: matcher}
+	return matcher, nil
+}
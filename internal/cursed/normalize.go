@@ -0,0 +1,58 @@
+package cursed
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// homoglyphs maps letters that look like latin letters to them. Fullwidth & styled letters (e.g. 𝐚) are handled by
+// compatibility decomposition first, so only look-alikes from other scripts are needed.
+var homoglyphs = map[rune]rune{
+	// cyrillic
+	'а': 'a', 'в': 'b', 'с': 'c', 'ԁ': 'd', 'е': 'e', 'ё': 'e', 'һ': 'h', 'і': 'i', 'ї': 'i', 'ј': 'j', 'к': 'k',
+	'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'ԛ': 'q', 'ѕ': 's', 'т': 't', 'у': 'y', 'ԝ': 'w', 'х': 'x', 'ү': 'y',
+	// greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u',
+	'χ': 'x', 'ω': 'w',
+	// latin variants
+	'ı': 'i', 'ł': 'l', 'ø': 'o', 'ß': 's', 'ɡ': 'g', 'ɑ': 'a',
+}
+
+// leetspeak maps symbols & digits used in place of letters to them
+var leetspeak = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b', '9': 'g',
+	'@': 'a', '$': 's', '!': 'i', '+': 't', '€': 'e', '£': 'l', '¢': 'c',
+}
+
+// Normalize folds text for matching cursed words: it's lower cased, accents are stripped, styled & look-alike
+// letters are replaced with plain latin ones, leetspeak is decoded & invisible characters are dropped. Leetspeak
+// symbols become letters, so they're part of words when checking word boundaries.
+func Normalize(text string) string {
+	var runes []rune
+	for _, r := range norm.NFKD.String(text) {
+		// drop accents left by decomposition, & zero width characters used to split words
+		if !unicode.Is(unicode.Mn, r) && !unicode.Is(unicode.Cf, r) {
+			runes = append(runes, r)
+		}
+	}
+	var b strings.Builder
+	b.Grow(len(text))
+	for i, r := range runes {
+		r = unicode.ToLower(r)
+		if mapped, ok := homoglyphs[r]; ok {
+			r = mapped
+		} else if mapped, ok := leetspeak[r]; ok && (r != '!' || (i+1 < len(runes) && unicode.IsLetter(runes[i+1]))) {
+			// ! is usually punctuation, so it's only a letter inside a word
+			r = mapped
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// isWordRune checks if the rune is part of a word in normalized text
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,60 @@
+package cursed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+// DefaultSwearJarFine is the swear jar dollars per cursed word for guilds that don't set one
+const DefaultSwearJarFine = 0.25
+
+func (c *Cursed) AddToSwearJar(ctx context.Context, guildId, userId uint64, count int) (int, error) {
+	var total int
+	err := c.pool.QueryRow(ctx, `
+INSERT INTO swear_jar (guild_id, user_id, total) VALUES ($1, $2, $3)
+ON CONFLICT (guild_id, user_id) DO UPDATE SET total = swear_jar.total + EXCLUDED.total
+RETURNING total`, guildId, userId, count).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add to swear jar: %w", err)
+	}
+	return total, nil
+}
+
+func (c *Cursed) SwearJar(ctx context.Context, guildId, userId uint64) (int, error) {
+	var total int
+	err := c.pool.QueryRow(ctx, "SELECT total FROM swear_jar WHERE guild_id = $1 AND user_id = $2", guildId, userId).Scan(&total)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get swear jar: %w", err)
+	}
+	return total, nil
+}
+
+func (c *Cursed) SwearJarLeaders(ctx context.Context, guildId uint64, limit int) ([]model.SwearJarTotal, error) {
+	var totals []model.SwearJarTotal
+	err := pgxscan.Select(ctx, c.pool, &totals, `
+SELECT guild_id, user_id, total FROM swear_jar
+WHERE guild_id = $1 AND total > 0
+ORDER BY total DESC, user_id
+LIMIT $2`, guildId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get swear jar leaders: %w", err)
+	}
+	return totals, nil
+}
+
+func (c *Cursed) RemoveUser(ctx context.Context, guildId, userId uint64) error {
+	_, err := c.pool.Exec(ctx, "DELETE FROM swear_jar WHERE guild_id = $1 AND user_id = $2", guildId, userId)
+	if err != nil {
+		return fmt.Errorf("failed to remove user from swear jar: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package cursed
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+func TestCursed_AddToSwearJar(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`INSERT INTO swear_jar \(guild_id, user_id, total\) VALUES \(\$1, \$2, \$3\)
+ON CONFLICT \(guild_id, user_id\) DO UPDATE SET total = swear_jar.total \+ EXCLUDED.total
+RETURNING total`).
+		WithArgs(uint64(1), uint64(2), 3).
+		WillReturnRows(pgxmock.NewRows([]string{"total"}).AddRow(7))
+	got, err := New(mockDb).AddToSwearJar(context.Background(), 1, 2, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 7, got)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestCursed_SwearJar(t *testing.T) {
+	tests := []struct {
+		name string
+		rows *pgxmock.Rows
+		err  error
+		want int
+	}{
+		{"has total", pgxmock.NewRows([]string{"total"}).AddRow(4), nil, 4},
+		{"never cursed", nil, pgx.ErrNoRows, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb, err := pgxmock.NewPool()
+			require.Nil(t, err, "failed to build pool")
+			defer mockDb.Close()
+			query := mockDb.ExpectQuery(`SELECT total FROM swear_jar WHERE guild_id = \$1 AND user_id = \$2`).
+				WithArgs(uint64(1), uint64(2))
+			if tt.err != nil {
+				query.WillReturnError(tt.err)
+			} else {
+				query.WillReturnRows(tt.rows)
+			}
+			got, err := New(mockDb).SwearJar(context.Background(), 1, 2)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+		})
+	}
+}
+
+func TestCursed_SwearJarLeaders(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`SELECT guild_id, user_id, total FROM swear_jar
+WHERE guild_id = \$1 AND total > 0
+ORDER BY total DESC, user_id
+LIMIT \$2`).
+		WithArgs(uint64(1), 5).
+		WillReturnRows(pgxmock.NewRows([]string{"guild_id", "user_id", "total"}).AddRow(uint64(1), uint64(3), 9).AddRow(uint64(1), uint64(2), 4))
+	got, err := New(mockDb).SwearJarLeaders(context.Background(), 1, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []model.SwearJarTotal{{GuildId: 1, UserId: 3, Total: 9}, {GuildId: 1, UserId: 2, Total: 4}}, got)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestCursed_RemoveUser(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectExec(`DELETE FROM swear_jar WHERE guild_id = \$1 AND user_id = \$2`).
+		WithArgs(uint64(1), uint64(2)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	require.NoError(t, New(mockDb).RemoveUser(context.Background(), 1, 2))
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
@@ -0,0 +1,195 @@
+// Package export copies guild stats between databases, or out to members, as csv, json or parquet files
+package export
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/dmtaylor/costanza/internal/model"
+	"github.com/dmtaylor/costanza/internal/stats"
+)
+
+const guildColumn = "guild_id"
+const periodColumn = "period"
+
+// Table is the exported rows of a single table, in text form so every format can hold them
+type Table struct {
+	Name    string
+	Columns []string
+	Rows    [][]pgtype.Text
+}
+
+// PeriodRange limits exported rows to periods starting between From & To inclusive. Zero times leave the range open.
+// Tables without a period are always exported in full.
+type PeriodRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// Contains checks if the period key starts in the range
+func (r PeriodRange) Contains(key string) (bool, error) {
+	start, err := stats.PeriodStart(key)
+	if err != nil {
+		return false, err
+	}
+	return (r.From.IsZero() || !start.Before(r.From)) && (r.To.IsZero() || !start.After(r.To)), nil
+}
+
+// Export gets the guild's rows from the table
+func Export(ctx context.Context, pool model.DbPool, schema Schema, guildId uint64, periods PeriodRange) (Table, error) {
+	table := Table{Name: schema.Table, Columns: schema.DataColumns()}
+	selects := make([]string, len(table.Columns))
+	for i, name := range table.Columns {
+		selects[i] = name + "::text"
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1", strings.Join(selects, ", "), schema.Table, guildColumn)
+	for _, column := range schema.Columns {
+		if column.Type == SerialColumn {
+			query += " ORDER BY " + column.Name
+			break
+		}
+	}
+	rows, err := pool.Query(ctx, query, guildId)
+	if err != nil {
+		return Table{}, fmt.Errorf("failed to export %s: %w", schema.Table, err)
+	}
+	defer rows.Close()
+	period := slices.Index(table.Columns, periodColumn)
+	for rows.Next() {
+		row := make([]pgtype.Text, len(table.Columns))
+		dest := make([]any, len(row))
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return Table{}, fmt.Errorf("failed to scan %s: %w", schema.Table, err)
+		}
+		if period >= 0 {
+			ok, err := periods.Contains(row[period].String)
+			if err != nil {
+				return Table{}, fmt.Errorf("bad %s row: %w", schema.Table, err)
+			}
+			if !ok {
+				continue
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	if err = rows.Err(); err != nil {
+		return Table{}, fmt.Errorf("failed to read %s: %w", schema.Table, err)
+	}
+	return table, nil
+}
+
+// Import validates the tables against their schemas, then writes them in a single transaction. Importing the same
+// rows again doesn't change anything: rows are upserted on the table's unique index, tables with a period but no unique
+// index have the guild's rows for each imported period replaced, & other tables only get the rows they don't have yet.
+func Import(ctx context.Context, pool model.DbPool, schemas map[string]Schema, guildId uint64, tables []Table) error {
+	if err := ValidateGuild(schemas, guildId, tables); err != nil {
+		return err
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start import: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	for _, table := range tables {
+		if err = importTable(ctx, tx, schemas[table.Name], guildId, table); err != nil {
+			return err
+		}
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit import: %w", err)
+	}
+	return nil
+}
+
+// ValidateGuild checks each table against its schema & that every row is for the guild
+func ValidateGuild(schemas map[string]Schema, guildId uint64, tables []Table) error {
+	for _, table := range tables {
+		schema, ok := schemas[table.Name]
+		if !ok {
+			return fmt.Errorf("no schema for table %s", table.Name)
+		}
+		if err := schema.Validate(table); err != nil {
+			return err
+		}
+		guild := slices.Index(table.Columns, guildColumn)
+		if guild < 0 {
+			return fmt.Errorf("%s has no guild data", table.Name)
+		}
+		for i, row := range table.Rows {
+			if id, _ := strconv.ParseUint(row[guild].String, 10, 64); id != guildId {
+				return fmt.Errorf("%s row %d is for guild %s, expected %d", table.Name, i+1, row[guild].String, guildId)
+			}
+		}
+	}
+	return nil
+}
+
+func importTable(ctx context.Context, tx pgx.Tx, schema Schema, guildId uint64, table Table) error {
+	columns := make([]Column, len(table.Columns))
+	params := make([]string, len(table.Columns))
+	for i, name := range table.Columns {
+		columns[i], _ = schema.Column(name)
+		params[i] = fmt.Sprintf("$%d::%s", i+1, columns[i].sqlType())
+	}
+	names := strings.Join(table.Columns, ", ")
+	var query string
+	period := slices.Index(table.Columns, periodColumn)
+	switch {
+	case len(schema.UniqueKey) > 0:
+		var updates []string
+		for _, name := range table.Columns {
+			if !slices.Contains(schema.UniqueKey, name) {
+				updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", name, name))
+			}
+		}
+		action := "DO NOTHING"
+		if len(updates) > 0 {
+			action = "DO UPDATE SET " + strings.Join(updates, ", ")
+		}
+		query = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) %s",
+			schema.Table, names, strings.Join(params, ", "), strings.Join(schema.UniqueKey, ", "), action)
+	case period >= 0:
+		var keys []string
+		for _, row := range table.Rows {
+			if !slices.Contains(keys, row[period].String) {
+				keys = append(keys, row[period].String)
+			}
+		}
+		_, err := tx.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s = $1 AND %s = ANY($2)", schema.Table, guildColumn, periodColumn),
+			guildId, keys)
+		if err != nil {
+			return fmt.Errorf("failed to replace %s periods: %w", schema.Table, err)
+		}
+		query = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", schema.Table, names, strings.Join(params, ", "))
+	default:
+		matches := make([]string, len(table.Columns))
+		for i, name := range table.Columns {
+			matches[i] = fmt.Sprintf("%s IS NOT DISTINCT FROM %s", name, params[i])
+		}
+		query = fmt.Sprintf("INSERT INTO %s (%s) SELECT %s WHERE NOT EXISTS (SELECT 1 FROM %s WHERE %s)",
+			schema.Table, names, strings.Join(params, ", "), schema.Table, strings.Join(matches, " AND "))
+	}
+	for i, row := range table.Rows {
+		args := make([]any, len(row))
+		for j, value := range row {
+			var err error
+			if args[j], err = columns[j].value(value); err != nil {
+				return fmt.Errorf("%s row %d: %w", table.Name, i+1, err)
+			}
+		}
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to import %s row %d: %w", table.Name, i+1, err)
+		}
+	}
+	return nil
+}
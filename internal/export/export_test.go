@@ -0,0 +1,189 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/docs"
+)
+
+func loadSchemas(t *testing.T) map[string]Schema {
+	t.Helper()
+	schemas, err := LoadSchemas(docs.Schemas, "schemas")
+	require.NoError(t, err)
+	return schemas
+}
+
+func TestPeriodRange_Contains(t *testing.T) {
+	r := PeriodRange{From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)}
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"2024", true},
+		{"2024-Q1", true},
+		{"2024-Q2", false},
+		{"2024-03", true},
+		{"2024-W01", true},
+		{"2023-W52", false},
+		{"2024-03-31", true},
+		{"2023-12-31", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := r.Contains(tt.key)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	got, err := PeriodRange{}.Contains("1999-01")
+	require.NoError(t, err)
+	assert.True(t, got, "open range should contain everything")
+	_, err = r.Contains("someday")
+	assert.Error(t, err)
+}
+
+func TestExport(t *testing.T) {
+	schemas := loadSchemas(t)
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`SELECT metric::text, guild_id::text, user_id::text, period::text, value::text, channel_id::text FROM stat_counters WHERE guild_id = \$1 ORDER BY id`).
+		WithArgs(uint64(100)).
+		WillReturnRows(pgxmock.NewRows([]string{"metric", "guild_id", "user_id", "period", "value", "channel_id"}).
+			AddRow("messages", "100", "200", "2024-03", "12", "0").
+			AddRow("messages", "100", "200", "2023-12", "4", "0").
+			AddRow("messages", "100", "201", "2024-03-15", "2", "300"))
+	got, err := Export(context.Background(), mockDb, schemas["stat_counters"], 100, PeriodRange{From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	assert.Equal(t, Table{
+		Name:    "stat_counters",
+		Columns: []string{"metric", "guild_id", "user_id", "period", "value", "channel_id"},
+		Rows: [][]pgtype.Text{
+			{text("messages"), text("100"), text("200"), text("2024-03"), text("12"), text("0")},
+			{text("messages"), text("100"), text("201"), text("2024-03-15"), text("2"), text("300")},
+		},
+	}, got)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestExportError(t *testing.T) {
+	expectedErr := errors.New("underlying db err")
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`FROM cursed_word_list`).WithArgs(uint64(100)).WillReturnError(expectedErr)
+	_, err = Export(context.Background(), mockDb, loadSchemas(t)["cursed_word_list"], 100, PeriodRange{})
+	assert.ErrorIs(t, err, expectedErr, "expected error not wrapped")
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestImport(t *testing.T) {
+	schemas := loadSchemas(t)
+	tables := []Table{
+		{
+			Name:    "stat_counters",
+			Columns: []string{"metric", "guild_id", "user_id", "period", "value", "channel_id"},
+			Rows:    [][]pgtype.Text{{text("messages"), text("100"), text("200"), text("2024-03"), text("12"), text("0")}},
+		},
+		{
+			Name:    "daily_game_times",
+			Columns: []string{"guild_id", "user_id", "period", "game", "solve_seconds"},
+			Rows: [][]pgtype.Text{
+				{text("100"), text("200"), text("2024-03"), text("Mini"), text("31")},
+				{text("100"), text("201"), text("2024-03"), text("Mini"), text("45")},
+			},
+		},
+		{
+			Name:    "cursed_word_list",
+			Columns: []string{"guild_id", "word"},
+			Rows:    [][]pgtype.Text{{text("100"), text("heck")}, {text("100"), {}}},
+		},
+	}
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectBegin()
+	mockDb.ExpectExec(`INSERT INTO stat_counters \(metric, guild_id, user_id, period, value, channel_id\) `+
+		`VALUES \(\$1::text, \$2::numeric, \$3::numeric, \$4::text, \$5::integer, \$6::numeric\) `+
+		`ON CONFLICT \(metric, guild_id, user_id, period, channel_id\) DO UPDATE SET value = EXCLUDED\.value`).
+		WithArgs("messages", uint64(100), uint64(200), "2024-03", int32(12), uint64(0)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockDb.ExpectExec(`DELETE FROM daily_game_times WHERE guild_id = \$1 AND period = ANY\(\$2\)`).
+		WithArgs(uint64(100), []string{"2024-03"}).
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+	for _, args := range [][]any{{uint64(200), int32(31)}, {uint64(201), int32(45)}} {
+		mockDb.ExpectExec(`INSERT INTO daily_game_times \(guild_id, user_id, period, game, solve_seconds\) `+
+			`VALUES \(\$1::numeric, \$2::numeric, \$3::text, \$4::text, \$5::integer\)$`).
+			WithArgs(uint64(100), args[0], "2024-03", "Mini", args[1]).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	}
+	for _, word := range []any{"heck", nil} {
+		mockDb.ExpectExec(`INSERT INTO cursed_word_list \(guild_id, word\) SELECT \$1::numeric, \$2::text `+
+			`WHERE NOT EXISTS \(SELECT 1 FROM cursed_word_list WHERE guild_id IS NOT DISTINCT FROM \$1::numeric AND word IS NOT DISTINCT FROM \$2::text\)`).
+			WithArgs(uint64(100), word).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	}
+	mockDb.ExpectCommit()
+
+	err = Import(context.Background(), mockDb, schemas, 100, tables)
+	assert.NoError(t, err)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestImportRollback(t *testing.T) {
+	expectedErr := errors.New("underlying db err")
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectBegin()
+	mockDb.ExpectExec(`INSERT INTO starboard_posts`).
+		WithArgs(uint64(100), uint64(300), uint64(500), uint64(600), int32(4)).
+		WillReturnError(expectedErr)
+	mockDb.ExpectRollback()
+	err = Import(context.Background(), mockDb, loadSchemas(t), 100, []Table{{
+		Name:    "starboard_posts",
+		Columns: []string{"guild_id", "channel_id", "message_id", "starboard_message_id", "count"},
+		Rows:    [][]pgtype.Text{{text("100"), text("300"), text("500"), text("600"), text("4")}},
+	}})
+	assert.ErrorIs(t, err, expectedErr, "expected error not wrapped")
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestImportValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		table   Table
+		wantErr string
+	}{
+		{"unknown_table", Table{Name: "bread"}, "no schema for table bread"},
+		{"no_guild", Table{Name: "quotes", Columns: []string{"data", "type"}}, "quotes has no guild data"},
+		{
+			"other_guild",
+			Table{Name: "cursed_channels", Columns: []string{"guild_id", "channel_id"}, Rows: [][]pgtype.Text{{text("101"), text("300")}}},
+			"cursed_channels row 1 is for guild 101, expected 100",
+		},
+		{
+			"invalid",
+			Table{Name: "cursed_channels", Columns: []string{"guild_id", "channel_id"}, Rows: [][]pgtype.Text{{text("100"), text("general")}}},
+			"channel_id isn't an id",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb, err := pgxmock.NewPool()
+			require.Nil(t, err, "failed to build pool")
+			defer mockDb.Close()
+			err = Import(context.Background(), mockDb, loadSchemas(t), 100, []Table{tt.table})
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.Nil(t, mockDb.ExpectationsWereMet(), "nothing should be written")
+		})
+	}
+}
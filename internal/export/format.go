@@ -0,0 +1,201 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Format is the file format tables are exported as, also used as the file extension
+type Format string
+
+const (
+	CSVFormat     Format = "csv"
+	JSONFormat    Format = "json"
+	ParquetFormat Format = "parquet"
+)
+
+// Formats is every supported export format
+var Formats = []Format{CSVFormat, JSONFormat, ParquetFormat}
+
+// InvalidFormatError returned when parsing an unsupported format name
+type InvalidFormatError string
+
+func (i InvalidFormatError) Error() string {
+	return "invalid export format " + string(i)
+}
+
+// ParseFormat gets the format with the given name
+func ParseFormat(name string) (Format, error) {
+	if slices.Contains(Formats, Format(name)) {
+		return Format(name), nil
+	}
+	return "", InvalidFormatError(name)
+}
+
+// Write writes the table to w in the format
+func Write(w io.Writer, format Format, schema Schema, table Table) error {
+	switch format {
+	case CSVFormat:
+		return writeCSV(w, table)
+	case JSONFormat:
+		return writeJSON(w, schema, table)
+	case ParquetFormat:
+		return writeParquet(w, schema, table)
+	default:
+		return InvalidFormatError(format)
+	}
+}
+
+// Read reads a table written in the format. The table isn't validated against the schema, which is only used to
+// restore the column types.
+func Read(r io.Reader, format Format, schema Schema) (Table, error) {
+	switch format {
+	case CSVFormat:
+		return readCSV(r, schema)
+	case JSONFormat:
+		return readJSON(r, schema)
+	case ParquetFormat:
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return Table{}, fmt.Errorf("failed to read parquet file: %w", err)
+		}
+		return readParquet(data, schema)
+	default:
+		return Table{}, InvalidFormatError(format)
+	}
+}
+
+// writeCSV writes a header of the column names, then each row. Nulls are written as empty values.
+func writeCSV(w io.Writer, table Table) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(table.Columns); err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+	record := make([]string, len(table.Columns))
+	for _, row := range table.Rows {
+		for i, value := range row {
+			record[i] = value.String
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write csv row: %w", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// readCSV reads the rows under the header. Empty values in nullable columns are read as null.
+func readCSV(r io.Reader, schema Schema) (Table, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // row lengths are checked by schema validation
+	header, err := reader.Read()
+	if err != nil {
+		return Table{}, fmt.Errorf("failed to read csv header: %w", err)
+	}
+	table := Table{Name: schema.Table, Columns: header}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return table, nil
+		}
+		if err != nil {
+			return Table{}, fmt.Errorf("failed to read csv: %w", err)
+		}
+		row := make([]pgtype.Text, len(record))
+		for i, value := range record {
+			row[i] = pgtype.Text{String: value, Valid: true}
+			if i < len(header) && value == "" {
+				if column, ok := schema.Column(header[i]); ok && !column.NotNull {
+					row[i].Valid = false
+				}
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+}
+
+// writeJSON writes an array with an object for each row, one row per line. Integers are written as numbers & ids as
+// strings, as they're too large for some JSON parsers.
+func writeJSON(w io.Writer, schema Schema, table Table) error {
+	keys := make([][]byte, len(table.Columns))
+	numbers := make([]bool, len(table.Columns))
+	for i, name := range table.Columns {
+		keys[i], _ = json.Marshal(name)
+		column, _ := schema.Column(name)
+		numbers[i] = column.Type == IntegerColumn
+	}
+	var buf, scratch bytes.Buffer
+	encoder := json.NewEncoder(&scratch)
+	encoder.SetEscapeHTML(false) // keep custom emoji like <:name:id> readable
+	buf.WriteString("[")
+	for i, row := range table.Rows {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n{")
+		for j, value := range row {
+			if j > 0 {
+				buf.WriteString(",")
+			}
+			buf.Write(keys[j])
+			buf.WriteString(":")
+			switch {
+			case !value.Valid:
+				buf.WriteString("null")
+			case numbers[j]:
+				buf.WriteString(value.String)
+			default:
+				scratch.Reset()
+				if err := encoder.Encode(value.String); err != nil {
+					return fmt.Errorf("failed to encode %s: %w", table.Columns[j], err)
+				}
+				buf.Write(bytes.TrimSuffix(scratch.Bytes(), []byte("\n")))
+			}
+		}
+		buf.WriteString("}")
+	}
+	buf.WriteString("\n]\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// readJSON reads an array of row objects, which must each have every column in the schema
+func readJSON(r io.Reader, schema Schema) (Table, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	var objects []map[string]any
+	if err := decoder.Decode(&objects); err != nil {
+		return Table{}, fmt.Errorf("failed to read json: %w", err)
+	}
+	table := Table{Name: schema.Table, Columns: schema.DataColumns()}
+	for i, object := range objects {
+		if len(object) != len(table.Columns) {
+			return Table{}, fmt.Errorf("%s row %d has %d values, expected %d", schema.Table, i+1, len(object), len(table.Columns))
+		}
+		row := make([]pgtype.Text, len(table.Columns))
+		for j, name := range table.Columns {
+			value, ok := object[name]
+			if !ok {
+				return Table{}, fmt.Errorf("%s row %d is missing %s", schema.Table, i+1, name)
+			}
+			switch v := value.(type) {
+			case nil:
+			case string:
+				row[j] = pgtype.Text{String: v, Valid: true}
+			case json.Number:
+				row[j] = pgtype.Text{String: v.String(), Valid: true}
+			default:
+				return Table{}, fmt.Errorf("%s row %d has invalid %s %v", schema.Table, i+1, name, value)
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table, nil
+}
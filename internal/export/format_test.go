@@ -0,0 +1,135 @@
+package export
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSchema(t *testing.T) Schema {
+	t.Helper()
+	schema, err := ParseSchema(`CREATE TABLE reaction_counters (
+    id SERIAL PRIMARY KEY,
+    guild_id NUMERIC NOT NULL,
+    period VARCHAR(16) NOT NULL,
+    emoji VARCHAR(128) NOT NULL,
+    note TEXT,
+    value INTEGER NOT NULL DEFAULT 0
+);`)
+	require.NoError(t, err)
+	return schema
+}
+
+func testTable() Table {
+	return Table{
+		Name:    "reaction_counters",
+		Columns: []string{"guild_id", "period", "emoji", "note", "value"},
+		Rows: [][]pgtype.Text{
+			{text("18446744073709551615"), text("2024-03"), text("🔥"), text("with \"quotes\", commas\nand lines"), text("12")},
+			{text("1234567890123456789"), text("2024-W05"), text("<:costanza:1234>"), {}, text("-3")},
+			{text("1"), text("2024"), text("😂"), {}, text("0")},
+		},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	got, err := ParseFormat("parquet")
+	assert.NoError(t, err)
+	assert.Equal(t, ParquetFormat, got)
+
+	_, err = ParseFormat("xlsx")
+	assert.ErrorIs(t, err, InvalidFormatError("xlsx"))
+}
+
+func TestWriteRead(t *testing.T) {
+	schema := testSchema(t)
+	for _, format := range Formats {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Write(&buf, format, schema, testTable()))
+			got, err := Read(&buf, format, schema)
+			require.NoError(t, err)
+			assert.Equal(t, testTable(), got)
+			assert.NoError(t, schema.Validate(got))
+		})
+		t.Run(string(format)+"_empty", func(t *testing.T) {
+			empty := Table{Name: "reaction_counters", Columns: testTable().Columns}
+			var buf bytes.Buffer
+			require.NoError(t, Write(&buf, format, schema, empty))
+			got, err := Read(&buf, format, schema)
+			require.NoError(t, err)
+			assert.Equal(t, empty.Columns, got.Columns)
+			assert.Empty(t, got.Rows)
+		})
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	table := testTable()
+	table.Rows = table.Rows[1:2]
+	require.NoError(t, Write(&buf, JSONFormat, testSchema(t), table))
+	assert.Equal(t, `[
+{"guild_id":"1234567890123456789","period":"2024-W05","emoji":"<:costanza:1234>","note":null,"value":-3}
+]
+`, buf.String())
+}
+
+func TestReadJSONErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr string
+	}{
+		{"not_array", `{"guild_id": "1"}`, "failed to read json"},
+		{"missing_column", `[{"guild_id": "1", "period": "2024", "emoji": "🔥", "note": null, "count": 1}]`, "row 1 is missing value"},
+		{"extra_column", `[{"guild_id": "1", "period": "2024", "emoji": "🔥", "note": null, "value": 1, "id": 2}]`, "row 1 has 6 values"},
+		{"bad_value", `[{"guild_id": "1", "period": "2024", "emoji": "🔥", "note": null, "value": true}]`, "invalid value true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.json), JSONFormat, testSchema(t))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	got, err := Read(strings.NewReader("emoji,note,value\n🔥,,3\n,x\n"), CSVFormat, testSchema(t))
+	require.NoError(t, err)
+	assert.Equal(t, Table{
+		Name:    "reaction_counters",
+		Columns: []string{"emoji", "note", "value"},
+		Rows: [][]pgtype.Text{
+			{text("🔥"), {}, text("3")},
+			{text(""), text("x")},
+		},
+	}, got, "empty values are only null for nullable columns, & short rows are left to validation")
+}
+
+func TestReadParquetErrors(t *testing.T) {
+	_, err := Read(strings.NewReader("guild_id,period\n"), ParquetFormat, testSchema(t))
+	assert.ErrorContains(t, err, "not a parquet file")
+
+	var nested bytes.Buffer
+	require.NoError(t, parquet.Write(&nested, []struct{ Emoji []string }{{Emoji: []string{"🔥"}}}))
+	_, err = Read(&nested, ParquetFormat, testSchema(t))
+	assert.ErrorIs(t, err, ErrUnsupportedParquet)
+}
+
+// Checks files from other tools can be read. The file was written by Apache Arrow's parquet writer with its dictionary
+// encoding & snappy compression, & its columns in the schema's order.
+func TestReadParquetArrow(t *testing.T) {
+	file, err := os.Open("testdata/reaction_counters.arrow.parquet")
+	require.NoError(t, err)
+	defer file.Close()
+	got, err := Read(file, ParquetFormat, testSchema(t))
+	require.NoError(t, err)
+	assert.Equal(t, testTable(), got)
+}
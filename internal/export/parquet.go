@@ -0,0 +1,217 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/encoding/thrift"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// parquetBatchSize rows read or written at a time
+const parquetBatchSize = 1000
+
+// ErrUnsupportedParquet returned when reading parquet files with columns that can't be imported, e.g. nested columns
+var ErrUnsupportedParquet = errors.New("unsupported parquet file")
+
+// parquetNode gets the parquet type for the column. Ids are stored as unsigned 64 bit integers, as they're too large
+// for signed ones.
+func parquetNode(column Column) parquet.Node {
+	var node parquet.Node
+	switch column.Type {
+	case NumericColumn:
+		node = parquet.Uint(64)
+	case IntegerColumn, SerialColumn:
+		node = parquet.Int(32)
+	default:
+		node = parquet.String()
+	}
+	if !column.NotNull {
+		node = parquet.Optional(node)
+	}
+	return node
+}
+
+// parquetValue converts the text value to the column's parquet type
+func parquetValue(column Column, value pgtype.Text) (parquet.Value, error) {
+	if !value.Valid {
+		return parquet.NullValue(), nil
+	}
+	switch column.Type {
+	case NumericColumn:
+		v, err := strconv.ParseUint(value.String, 10, 64)
+		if err != nil {
+			return parquet.Value{}, fmt.Errorf("invalid %s %q: %w", column.Name, value.String, err)
+		}
+		return parquet.Int64Value(int64(v)), nil
+	case IntegerColumn, SerialColumn:
+		v, err := strconv.ParseInt(value.String, 10, 32)
+		if err != nil {
+			return parquet.Value{}, fmt.Errorf("invalid %s %q: %w", column.Name, value.String, err)
+		}
+		return parquet.Int32Value(int32(v)), nil
+	default:
+		return parquet.ByteArrayValue([]byte(value.String)), nil
+	}
+}
+
+// writeParquet writes the table as a snappy compressed parquet file with a column for each table column. Parquet
+// sorts the columns by name.
+func writeParquet(w io.Writer, schema Schema, table Table) error {
+	group := make(parquet.Group, len(table.Columns))
+	columns := make([]Column, len(table.Columns))
+	for i, name := range table.Columns {
+		column, ok := schema.Column(name)
+		if !ok {
+			return fmt.Errorf("no column %s in %s", name, schema.Table)
+		}
+		columns[i] = column
+		group[name] = parquetNode(column)
+	}
+	fileSchema := parquet.NewSchema(schema.Table, group)
+	leaves := make([]parquet.LeafColumn, len(columns))
+	for i, column := range columns {
+		leaves[i], _ = fileSchema.Lookup(column.Name)
+	}
+
+	writer := parquet.NewWriter(w, fileSchema, parquet.Compression(&parquet.Snappy), parquet.CreatedBy("costanza", "", ""))
+	rows := make([]parquet.Row, 0, parquetBatchSize)
+	for i, tableRow := range table.Rows {
+		if len(tableRow) != len(columns) {
+			return fmt.Errorf("%s row %d has %d values, expected %d", schema.Table, i+1, len(tableRow), len(columns))
+		}
+		row := make(parquet.Row, len(columns))
+		for j, value := range tableRow {
+			v, err := parquetValue(columns[j], value)
+			if err != nil {
+				return fmt.Errorf("%s row %d: %w", schema.Table, i+1, err)
+			}
+			definition := 0
+			if value.Valid {
+				definition = leaves[j].MaxDefinitionLevel
+			}
+			row[leaves[j].ColumnIndex] = v.Level(0, definition, leaves[j].ColumnIndex)
+		}
+		rows = append(rows, row)
+		if len(rows) == parquetBatchSize {
+			if _, err := writer.WriteRows(rows); err != nil {
+				return fmt.Errorf("failed to write parquet rows: %w", err)
+			}
+			rows = rows[:0]
+		}
+	}
+	if _, err := writer.WriteRows(rows); err != nil {
+		return fmt.Errorf("failed to write parquet rows: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to write parquet file: %w", err)
+	}
+	return nil
+}
+
+// readParquet reads a parquet file with a column for each table column, such as the ones written by writeParquet.
+// Columns are put back in the schema's order, followed by any that aren't in the schema.
+func readParquet(data []byte, schema Schema) (Table, error) {
+	data = clearRootRepetition(data)
+	file, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return Table{}, fmt.Errorf("not a parquet file: %w", err)
+	}
+	fileSchema := file.Schema()
+	table := Table{Name: schema.Table}
+	unsigned := make(map[int]bool)
+	for _, path := range fileSchema.Columns() {
+		leaf, _ := fileSchema.Lookup(path...)
+		if len(path) != 1 || leaf.MaxRepetitionLevel > 0 {
+			return Table{}, fmt.Errorf("%w: nested column %v", ErrUnsupportedParquet, path)
+		}
+		if logical := leaf.Node.Type().LogicalType(); logical != nil && logical.Integer != nil {
+			unsigned[leaf.ColumnIndex] = !logical.Integer.IsSigned
+		}
+		table.Columns = append(table.Columns, path[0])
+	}
+	order := schema.DataColumns()
+	position := func(name string) int {
+		if i := slices.Index(order, name); i >= 0 {
+			return i
+		}
+		return len(order)
+	}
+	slices.SortStableFunc(table.Columns, func(a, b string) int { return position(a) - position(b) })
+	indexes := make(map[int]int, len(table.Columns)) // file column index to table column index
+	for i, name := range table.Columns {
+		leaf, _ := fileSchema.Lookup(name)
+		indexes[leaf.ColumnIndex] = i
+	}
+
+	reader := parquet.NewReader(file)
+	defer reader.Close()
+	rows := make([]parquet.Row, parquetBatchSize)
+	for {
+		n, err := reader.ReadRows(rows)
+		for _, row := range rows[:n] {
+			tableRow := make([]pgtype.Text, len(table.Columns))
+			for _, value := range row {
+				tableRow[indexes[value.Column()]] = parquetText(value, unsigned[value.Column()])
+			}
+			table.Rows = append(table.Rows, tableRow)
+		}
+		if errors.Is(err, io.EOF) {
+			return table, nil
+		}
+		if err != nil {
+			return Table{}, fmt.Errorf("failed to read parquet rows: %w", err)
+		}
+	}
+}
+
+// clearRootRepetition removes the repetition from the root of the file's schema, which Arrow's Go writer marks
+// repeated, so the parquet library doesn't expect repetition levels for every column. Files that can't be parsed are
+// returned unchanged, for OpenFile to report the error.
+func clearRootRepetition(data []byte) []byte {
+	if len(data) < 12 {
+		return data
+	}
+	footerLength := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if footerLength > len(data)-12 {
+		return data
+	}
+	footerStart := len(data) - 8 - footerLength
+	protocol := &thrift.CompactProtocol{}
+	var metadata format.FileMetaData
+	if err := thrift.Unmarshal(protocol, data[footerStart:len(data)-8], &metadata); err != nil {
+		return data
+	}
+	if len(metadata.Schema) == 0 || metadata.Schema[0].RepetitionType == nil {
+		return data
+	}
+	metadata.Schema[0].RepetitionType = nil
+	footer, err := thrift.Marshal(protocol, &metadata)
+	if err != nil {
+		return data
+	}
+	fixed := append(data[:footerStart:footerStart], footer...)
+	fixed = binary.LittleEndian.AppendUint32(fixed, uint32(len(footer)))
+	return append(fixed, data[len(data)-4:]...)
+}
+
+// parquetText formats the value as text, the same as Postgres would
+func parquetText(value parquet.Value, unsigned bool) pgtype.Text {
+	switch {
+	case value.IsNull():
+		return pgtype.Text{}
+	case unsigned && value.Kind() == parquet.Int64:
+		return pgtype.Text{String: strconv.FormatUint(value.Uint64(), 10), Valid: true}
+	case unsigned && value.Kind() == parquet.Int32:
+		return pgtype.Text{String: strconv.FormatUint(uint64(value.Uint32()), 10), Valid: true}
+	default:
+		return pgtype.Text{String: value.String(), Valid: true}
+	}
+}
@@ -0,0 +1,271 @@
+package export
+
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/dmtaylor/costanza/internal/stats"
+)
+
+// ColumnType is the Postgres type of a column, limited to the types used in the schemas
+type ColumnType int
+
+const (
+	SerialColumn ColumnType = iota
+	NumericColumn
+	IntegerColumn
+	VarcharColumn
+	TextColumn
+)
+
+// Column is a single column of a table schema
+type Column struct {
+	Name    string
+	Type    ColumnType
+	Length  int // Max characters for VARCHAR columns
+	NotNull bool
+}
+
+// Schema is a table definition parsed from docs/schemas
+type Schema struct {
+	Table     string
+	Columns   []Column
+	UniqueKey []string // Columns of the table's unique index, used to upsert imported rows
+}
+
+var createTableRegex = regexp.MustCompile(`(?is)CREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?(?:\w+\.)?(\w+)\s*\(`)
+var uniqueIndexRegex = regexp.MustCompile(`(?is)CREATE\s+UNIQUE\s+INDEX\s+\w+\s+ON\s+(?:\w+\.)?(\w+)\s*\(([^)]*)\)`)
+var columnTypeRegex = regexp.MustCompile(`(?i)^(SERIAL|NUMERIC|INTEGER|TEXT|VARCHAR\((\d+)\))$`)
+var commentRegex = regexp.MustCompile(`--[^\n]*`)
+
+// ParseSchema parses the CREATE TABLE & CREATE UNIQUE INDEX statements for a table
+func ParseSchema(sql string) (Schema, error) {
+	sql = commentRegex.ReplaceAllString(sql, "")
+	table := createTableRegex.FindStringSubmatchIndex(sql)
+	if table == nil {
+		return Schema{}, fmt.Errorf("no CREATE TABLE statement found")
+	}
+	schema := Schema{Table: strings.ToLower(sql[table[2]:table[3]])}
+	definitions, ok := splitColumns(sql[table[1]:])
+	if !ok {
+		return Schema{}, fmt.Errorf("unterminated CREATE TABLE statement for %s", schema.Table)
+	}
+	for _, definition := range definitions {
+		fields := strings.Fields(definition)
+		if len(fields) < 2 {
+			return Schema{}, fmt.Errorf("invalid column definition %q in %s", definition, schema.Table)
+		}
+		if slices.Contains([]string{"PRIMARY", "UNIQUE", "CONSTRAINT", "FOREIGN", "CHECK"}, strings.ToUpper(fields[0])) {
+			continue
+		}
+		column := Column{Name: strings.ToLower(fields[0])}
+		columnType := columnTypeRegex.FindStringSubmatch(fields[1])
+		if columnType == nil {
+			return Schema{}, fmt.Errorf("unsupported type %s for column %s.%s", fields[1], schema.Table, column.Name)
+		}
+		switch strings.ToUpper(columnType[1][:4]) {
+		case "SERI":
+			column.Type, column.NotNull = SerialColumn, true
+		case "NUME":
+			column.Type = NumericColumn
+		case "INTE":
+			column.Type = IntegerColumn
+		case "TEXT":
+			column.Type = TextColumn
+		default:
+			column.Type = VarcharColumn
+			column.Length, _ = strconv.Atoi(columnType[2])
+		}
+		modifiers := strings.ToUpper(strings.Join(fields[2:], " "))
+		if strings.Contains(modifiers, "NOT NULL") || strings.Contains(modifiers, "PRIMARY KEY") {
+			column.NotNull = true
+		}
+		schema.Columns = append(schema.Columns, column)
+	}
+	if index := uniqueIndexRegex.FindStringSubmatch(sql); index != nil && strings.EqualFold(index[1], schema.Table) {
+		for _, name := range strings.Split(index[2], ",") {
+			schema.UniqueKey = append(schema.UniqueKey, strings.ToLower(strings.TrimSpace(name)))
+		}
+	}
+	return schema, nil
+}
+
+// splitColumns splits the body of a CREATE TABLE statement, after its opening parenthesis, on commas outside of
+// parentheses. Returns false if the body isn't closed.
+func splitColumns(body string) ([]string, bool) {
+	var definitions []string
+	depth, start := 0, 0
+	for i, c := range body {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				if last := strings.TrimSpace(body[start:i]); last != "" {
+					definitions = append(definitions, last)
+				}
+				return definitions, true
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				definitions = append(definitions, strings.TrimSpace(body[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return nil, false
+}
+
+// LoadSchemas parses every .sql schema in the directory, keyed by table name
+func LoadSchemas(fsys fs.FS, dir string) (map[string]Schema, error) {
+	files, err := fs.Glob(fsys, dir+"/*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schemas: %w", err)
+	}
+	schemas := make(map[string]Schema, len(files))
+	for _, file := range files {
+		sql, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema %s: %w", file, err)
+		}
+		schema, err := ParseSchema(string(sql))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse schema %s: %w", file, err)
+		}
+		schemas[schema.Table] = schema
+	}
+	return schemas, nil
+}
+
+// GuildTables gets the schemas of tables with guild data, sorted by table name. Tables without a guild_id column
+// (e.g. quotes) are shared by every guild & aren't exported.
+func GuildTables(schemas map[string]Schema) []Schema {
+	var tables []Schema
+	for _, schema := range schemas {
+		if _, ok := schema.Column(guildColumn); ok {
+			tables = append(tables, schema)
+		}
+	}
+	slices.SortFunc(tables, func(a, b Schema) int {
+		return strings.Compare(a.Table, b.Table)
+	})
+	return tables
+}
+
+// Column gets the named column
+func (s Schema) Column(name string) (Column, bool) {
+	for _, column := range s.Columns {
+		if column.Name == name {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+// DataColumns gets the names of the exported columns. Serial ids are specific to a database, so they're left out.
+func (s Schema) DataColumns() []string {
+	var names []string
+	for _, column := range s.Columns {
+		if column.Type != SerialColumn {
+			names = append(names, column.Name)
+		}
+	}
+	return names
+}
+
+// Validate checks the table has exactly the schema's data columns & every value fits its column
+func (s Schema) Validate(table Table) error {
+	if table.Name != s.Table {
+		return fmt.Errorf("table %s doesn't match schema %s", table.Name, s.Table)
+	}
+	expected := s.DataColumns()
+	if len(table.Columns) != len(expected) {
+		return fmt.Errorf("%s has columns %v, expected %v", s.Table, table.Columns, expected)
+	}
+	columns := make([]Column, len(table.Columns))
+	for i, name := range table.Columns {
+		column, ok := s.Column(name)
+		if !ok || column.Type == SerialColumn || slices.Index(table.Columns, name) != i {
+			return fmt.Errorf("%s has columns %v, expected %v", s.Table, table.Columns, expected)
+		}
+		columns[i] = column
+	}
+	var err *multierror.Error
+	for i, row := range table.Rows {
+		if len(row) != len(columns) {
+			err = multierror.Append(err, fmt.Errorf("%s row %d has %d values, expected %d", s.Table, i+1, len(row), len(columns)))
+			continue
+		}
+		for j, value := range row {
+			if e := columns[j].validate(value); e != nil {
+				err = multierror.Append(err, fmt.Errorf("%s row %d: %w", s.Table, i+1, e))
+			}
+		}
+	}
+	return err.ErrorOrNil()
+}
+
+func (c Column) validate(value pgtype.Text) error {
+	if !value.Valid {
+		if c.NotNull {
+			return fmt.Errorf("%s can't be null", c.Name)
+		}
+		return nil
+	}
+	if _, err := c.value(value); err != nil {
+		return err
+	}
+	if c.Type == VarcharColumn && utf8.RuneCountInString(value.String) > c.Length {
+		return fmt.Errorf("%s is longer than %d characters", c.Name, c.Length)
+	}
+	if c.Name == periodColumn {
+		if _, err := stats.PeriodStart(value.String); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// value converts the text value into the Go type written to the column. NUMERIC columns hold discord ids.
+func (c Column) value(value pgtype.Text) (any, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	switch c.Type {
+	case NumericColumn:
+		v, err := strconv.ParseUint(value.String, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s isn't an id: %q", c.Name, value.String)
+		}
+		return v, nil
+	case IntegerColumn, SerialColumn:
+		v, err := strconv.ParseInt(value.String, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s isn't an integer: %q", c.Name, value.String)
+		}
+		return int32(v), nil
+	default:
+		return value.String, nil
+	}
+}
+
+// sqlType gets the type name used to cast query parameters for the column
+func (c Column) sqlType() string {
+	switch c.Type {
+	case NumericColumn:
+		return "numeric"
+	case IntegerColumn, SerialColumn:
+		return "integer"
+	default:
+		return "text"
+	}
+}
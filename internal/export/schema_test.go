@@ -0,0 +1,168 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/docs"
+)
+
+func text(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: true}
+}
+
+func TestParseSchema(t *testing.T) {
+	got, err := ParseSchema(`CREATE TABLE IF NOT EXiSTS reaction_counters (
+    id SERIAL PRIMARY KEY,
+    guild_id NUMERIC NOT NULL,
+    period VARCHAR(16) NOT NULL, -- report period key
+    emoji VARCHAR(128) NOT NULL,
+    note TEXT,
+    value INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX reaction_counters_period ON reaction_counters(period);
+CREATE UNIQUE INDEX reaction_counters_guild_period_emoji ON reaction_counters(guild_id, period, emoji);`)
+	require.NoError(t, err)
+	assert.Equal(t, Schema{
+		Table: "reaction_counters",
+		Columns: []Column{
+			{Name: "id", Type: SerialColumn, NotNull: true},
+			{Name: "guild_id", Type: NumericColumn, NotNull: true},
+			{Name: "period", Type: VarcharColumn, Length: 16, NotNull: true},
+			{Name: "emoji", Type: VarcharColumn, Length: 128, NotNull: true},
+			{Name: "note", Type: TextColumn},
+			{Name: "value", Type: IntegerColumn, NotNull: true},
+		},
+		UniqueKey: []string{"guild_id", "period", "emoji"},
+	}, got)
+	assert.Equal(t, []string{"guild_id", "period", "emoji", "note", "value"}, got.DataColumns())
+}
+
+func TestParseSchemaErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+	}{
+		{"no_table", "CREATE INDEX foo ON bar(baz);"},
+		{"unterminated", "CREATE TABLE foo (id SERIAL PRIMARY KEY"},
+		{"unsupported_type", "CREATE TABLE foo (created TIMESTAMP NOT NULL);"},
+		{"missing_type", "CREATE TABLE foo (id);"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSchema(tt.sql)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadSchemas(t *testing.T) {
+	schemas, err := LoadSchemas(docs.Schemas, "schemas")
+	require.NoError(t, err)
+	var names []string
+	for _, schema := range GuildTables(schemas) {
+		names = append(names, schema.Table)
+	}
+	assert.Equal(t, []string{
+		"achievement_progress",
+		"achievements",
+		"cursed_channels",
+		"cursed_word_allowlist",
+		"cursed_word_list",
+		"daily_game_times",
+		"daily_game_win_stats",
+		"game_plays",
+		"infractions",
+		"privacy_opt_outs",
+		"reaction_counters",
+		"starboard_posts",
+		"stat_counters",
+		"stat_removals",
+		"swear_jar",
+		"yearly_rollups",
+	}, names, "guild tables changed, check they're safe to export")
+	assert.Contains(t, schemas, "quotes")
+	assert.Equal(t, []string{"metric", "guild_id", "user_id", "period", "value", "channel_id"}, schemas["stat_counters"].DataColumns())
+	assert.Equal(t, []string{"metric", "guild_id", "user_id", "period", "channel_id"}, schemas["stat_counters"].UniqueKey)
+}
+
+func TestSchema_Validate(t *testing.T) {
+	schema, err := ParseSchema(`CREATE TABLE cursed (
+    id SERIAL PRIMARY KEY,
+    guild_id NUMERIC NOT NULL,
+    period VARCHAR(8) NOT NULL,
+    word TEXT,
+    value INTEGER NOT NULL
+);`)
+	require.NoError(t, err)
+	columns := []string{"guild_id", "period", "word", "value"}
+	tests := []struct {
+		name    string
+		table   Table
+		wantErr string
+	}{
+		{
+			"valid",
+			Table{Name: "cursed", Columns: columns, Rows: [][]pgtype.Text{
+				{text("1"), text("2024-03"), text("heck"), text("3")},
+				{text("1"), text("2024-W05"), {}, text("-1")},
+			}},
+			"",
+		},
+		{
+			"column_order",
+			Table{Name: "cursed", Columns: []string{"value", "word", "period", "guild_id"}, Rows: [][]pgtype.Text{
+				{text("3"), text("heck"), text("2024"), text("1")},
+			}},
+			"",
+		},
+		{"other_table", Table{Name: "quotes", Columns: columns}, "doesn't match schema"},
+		{"missing_column", Table{Name: "cursed", Columns: columns[:3]}, "expected [guild_id period word value]"},
+		{"serial_column", Table{Name: "cursed", Columns: []string{"id", "guild_id", "period", "word"}}, "expected"},
+		{"duplicate_column", Table{Name: "cursed", Columns: []string{"guild_id", "guild_id", "word", "value"}}, "expected"},
+		{
+			"short_row",
+			Table{Name: "cursed", Columns: columns, Rows: [][]pgtype.Text{{text("1"), text("2024")}}},
+			"row 1 has 2 values, expected 4",
+		},
+		{
+			"null",
+			Table{Name: "cursed", Columns: columns, Rows: [][]pgtype.Text{{text("1"), text("2024"), {}, {}}}},
+			"value can't be null",
+		},
+		{
+			"bad_id",
+			Table{Name: "cursed", Columns: columns, Rows: [][]pgtype.Text{{text("-1"), text("2024"), {}, text("1")}}},
+			"guild_id isn't an id",
+		},
+		{
+			"bad_integer",
+			Table{Name: "cursed", Columns: columns, Rows: [][]pgtype.Text{{text("1"), text("2024"), {}, text("1.5")}}},
+			"value isn't an integer",
+		},
+		{
+			"too_long",
+			Table{Name: "cursed", Columns: columns, Rows: [][]pgtype.Text{{text("1"), text("2024-03-15"), {}, text("1")}}},
+			"period is longer than 8 characters",
+		},
+		{
+			"bad_period",
+			Table{Name: "cursed", Columns: columns, Rows: [][]pgtype.Text{{text("1"), text("March"), {}, text("1")}}},
+			"invalid period key March",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.Validate(tt.table)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
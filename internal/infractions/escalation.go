@@ -0,0 +1,42 @@
+package infractions
+
+import (
+	"fmt"
+	"time"
+)
+
+// Escalation times a member out once they reach a number of active warnings. Each guild configures its own.
+type Escalation struct {
+	Warnings int    `mapstructure:"warnings"` // Active warnings that trigger the timeout
+	Timeout  string `mapstructure:"timeout"`  // Duration of the timeout, e.g. "1h"
+	timeout  time.Duration
+}
+
+// Load parses the timeout duration from the raw config value
+func (e *Escalation) Load() error {
+	if e.Warnings <= 0 {
+		return fmt.Errorf("invalid warnings %d for warning escalation", e.Warnings)
+	}
+	var err error
+	if e.timeout, err = time.ParseDuration(e.Timeout); err != nil || e.timeout <= 0 {
+		return fmt.Errorf("invalid timeout %q for warning escalation at %d warnings", e.Timeout, e.Warnings)
+	}
+	return nil
+}
+
+// Duration gets how long members are timed out for
+func (e *Escalation) Duration() time.Duration {
+	return e.timeout
+}
+
+// EscalationFor gets the escalation with the most warnings up to the member's active warnings, or nil if they haven't
+// reached any. Every warning past the last escalation repeats it.
+func EscalationFor(escalations []Escalation, warnings int) *Escalation {
+	var found *Escalation
+	for i := range escalations {
+		if escalations[i].Warnings <= warnings && (found == nil || escalations[i].Warnings > found.Warnings) {
+			found = &escalations[i]
+		}
+	}
+	return found
+}
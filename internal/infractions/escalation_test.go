@@ -0,0 +1,61 @@
+package infractions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscalation_Load(t *testing.T) {
+	tests := []struct {
+		name       string
+		escalation Escalation
+		wantErr    bool
+	}{
+		{"valid", Escalation{Warnings: 3, Timeout: "1h"}, false},
+		{"no warnings", Escalation{Timeout: "1h"}, true},
+		{"missing timeout", Escalation{Warnings: 3}, true},
+		{"invalid timeout", Escalation{Warnings: 3, Timeout: "forever"}, true},
+		{"negative timeout", Escalation{Warnings: 3, Timeout: "-1h"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.escalation.Load()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+	escalation := Escalation{Warnings: 3, Timeout: "1h"}
+	require.NoError(t, escalation.Load())
+	assert.Equal(t, time.Hour, escalation.Duration())
+}
+
+func TestEscalationFor(t *testing.T) {
+	escalations := []Escalation{{Warnings: 5, Timeout: "24h"}, {Warnings: 3, Timeout: "1h"}}
+	tests := []struct {
+		warnings int
+		want     string
+	}{
+		{0, ""},
+		{2, ""},
+		{3, "1h"},
+		{4, "1h"},
+		{5, "24h"},
+		{9, "24h"},
+	}
+	for _, tt := range tests {
+		got := EscalationFor(escalations, tt.warnings)
+		if tt.want == "" {
+			assert.Nil(t, got, "escalation at %d warnings", tt.warnings)
+			continue
+		}
+		require.NotNil(t, got, "no escalation at %d warnings", tt.warnings)
+		assert.Equal(t, tt.want, got.Timeout, "escalation at %d warnings", tt.warnings)
+	}
+	assert.Nil(t, EscalationFor(nil, 10))
+}
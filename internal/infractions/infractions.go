@@ -0,0 +1,96 @@
+// Package infractions keeps each guild's moderation ledger of warnings & cursed posts, & the timeouts warnings
+// escalate to
+package infractions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+// Kinds of infraction
+const (
+	WarningKind = "warning" // warned by a moderator with /warn
+	CursedKind  = "cursed"  // posted cursed words, for guilds that add them to the ledger
+)
+
+const infractionColumns = "id, guild_id, user_id, moderator_id, kind, reason, created_at, pardoned_by"
+
+// Store persists each guild's infractions
+type Store interface {
+	// Add records the infraction, returning its id
+	Add(ctx context.Context, infraction model.Infraction) (int, error)
+	// ForUser gets the user's infractions in the guild, newest first
+	ForUser(ctx context.Context, guildId, userId uint64) ([]model.Infraction, error)
+	// Pardon marks the infraction pardoned by the moderator, returning nil if the guild has no unpardoned infraction
+	// with the id
+	Pardon(ctx context.Context, guildId uint64, id int, moderatorId uint64) (*model.Infraction, error)
+	// ActiveWarnings counts the user's unpardoned warnings in the guild
+	ActiveWarnings(ctx context.Context, guildId, userId uint64) (int, error)
+}
+
+// Infractions is the Postgres backed Store
+type Infractions struct {
+	pool model.DbPool
+}
+
+var _ Store = (*Infractions)(nil)
+
+func New(pool model.DbPool) *Infractions {
+	return &Infractions{pool: pool}
+}
+
+func (i *Infractions) Add(ctx context.Context, infraction model.Infraction) (int, error) {
+	var id int
+	err := i.pool.QueryRow(ctx, `
+INSERT INTO infractions (guild_id, user_id, moderator_id, kind, reason, created_at) VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id`,
+		infraction.GuildId, infraction.UserId, infraction.ModeratorId, infraction.Kind, infraction.Reason, infraction.CreatedAt).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add infraction: %w", err)
+	}
+	return id, nil
+}
+
+func (i *Infractions) ForUser(ctx context.Context, guildId, userId uint64) ([]model.Infraction, error) {
+	var infractions []model.Infraction
+	err := pgxscan.Select(ctx, i.pool, &infractions, `
+SELECT `+infractionColumns+` FROM infractions
+WHERE guild_id = $1 AND user_id = $2
+ORDER BY created_at DESC, id DESC`, guildId, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get infractions: %w", err)
+	}
+	return infractions, nil
+}
+
+func (i *Infractions) Pardon(ctx context.Context, guildId uint64, id int, moderatorId uint64) (*model.Infraction, error) {
+	var infraction model.Infraction
+	err := pgxscan.Get(ctx, i.pool, &infraction, `
+UPDATE infractions SET pardoned_by = $3
+WHERE guild_id = $1 AND id = $2 AND pardoned_by = 0
+RETURNING `+infractionColumns, guildId, id, moderatorId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to pardon infraction: %w", err)
+	}
+	return &infraction, nil
+}
+
+func (i *Infractions) ActiveWarnings(ctx context.Context, guildId, userId uint64) (int, error) {
+	var count int
+	err := i.pool.QueryRow(ctx, `
+SELECT COUNT(*) FROM infractions
+WHERE guild_id = $1 AND user_id = $2 AND kind = $3 AND pardoned_by = 0`, guildId, userId, WarningKind).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count warnings: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,100 @@
+package infractions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+var infractionRowColumns = []string{"id", "guild_id", "user_id", "moderator_id", "kind", "reason", "created_at", "pardoned_by"}
+
+func TestInfractions_Add(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`INSERT INTO infractions \(guild_id, user_id, moderator_id, kind, reason, created_at\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6\)
+RETURNING id`).
+		WithArgs(uint64(1), uint64(2), uint64(3), WarningKind, "spam", int64(1700000000)).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(12))
+	got, err := New(mockDb).Add(context.Background(), model.Infraction{
+		GuildId: 1, UserId: 2, ModeratorId: 3, Kind: WarningKind, Reason: "spam", CreatedAt: 1700000000,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 12, got)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestInfractions_ForUser(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`SELECT id, guild_id, user_id, moderator_id, kind, reason, created_at, pardoned_by FROM infractions
+WHERE guild_id = \$1 AND user_id = \$2
+ORDER BY created_at DESC, id DESC`).
+		WithArgs(uint64(1), uint64(2)).
+		WillReturnRows(pgxmock.NewRows(infractionRowColumns).
+			AddRow(13, uint64(1), uint64(2), uint64(0), CursedKind, "posted `heck`", int64(1700000100), uint64(0)).
+			AddRow(12, uint64(1), uint64(2), uint64(3), WarningKind, "spam", int64(1700000000), uint64(4)))
+	got, err := New(mockDb).ForUser(context.Background(), 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []model.Infraction{
+		{Id: 13, GuildId: 1, UserId: 2, Kind: CursedKind, Reason: "posted `heck`", CreatedAt: 1700000100},
+		{Id: 12, GuildId: 1, UserId: 2, ModeratorId: 3, Kind: WarningKind, Reason: "spam", CreatedAt: 1700000000, PardonedBy: 4},
+	}, got)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestInfractions_Pardon(t *testing.T) {
+	tests := []struct {
+		name string
+		rows *pgxmock.Rows
+		want *model.Infraction
+	}{
+		{
+			"pardoned",
+			pgxmock.NewRows(infractionRowColumns).AddRow(12, uint64(1), uint64(2), uint64(3), WarningKind, "spam", int64(1700000000), uint64(4)),
+			&model.Infraction{Id: 12, GuildId: 1, UserId: 2, ModeratorId: 3, Kind: WarningKind, Reason: "spam", CreatedAt: 1700000000, PardonedBy: 4},
+		},
+		{"not found", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb, err := pgxmock.NewPool()
+			require.Nil(t, err, "failed to build pool")
+			defer mockDb.Close()
+			query := mockDb.ExpectQuery(`UPDATE infractions SET pardoned_by = \$3
+WHERE guild_id = \$1 AND id = \$2 AND pardoned_by = 0
+RETURNING id, guild_id, user_id, moderator_id, kind, reason, created_at, pardoned_by`).
+				WithArgs(uint64(1), 12, uint64(4))
+			if tt.rows == nil {
+				query.WillReturnError(pgx.ErrNoRows)
+			} else {
+				query.WillReturnRows(tt.rows)
+			}
+			got, err := New(mockDb).Pardon(context.Background(), 1, 12, 4)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+		})
+	}
+}
+
+func TestInfractions_ActiveWarnings(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`SELECT COUNT\(\*\) FROM infractions
+WHERE guild_id = \$1 AND user_id = \$2 AND kind = \$3 AND pardoned_by = 0`).
+		WithArgs(uint64(1), uint64(2), WarningKind).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(3))
+	got, err := New(mockDb).ActiveWarnings(context.Background(), 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, got)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
@@ -0,0 +1,19 @@
+package model
+
+// Achievement is an achievement earned by a user in a guild
+type Achievement struct {
+	GuildId     uint64
+	UserId      uint64
+	Achievement string // Id of the rule the achievement was earned for
+	AwardedOn   string // Day key in the guild's timezone
+}
+
+// AchievementProgress is a user's progress towards the achievements sharing a progress key. Guild wide progress has a
+// UserId of 0.
+type AchievementProgress struct {
+	GuildId  uint64
+	UserId   uint64
+	Progress string
+	Value    int
+	Day      string // Day key of the last progress, for day based rules
+}
@@ -0,0 +1,21 @@
+package model
+
+// CounterStat is the value of a tracked metric for a user in a report period
+type CounterStat struct {
+	Metric  string
+	GuildId uint64
+	UserId  uint64
+	Period  string
+	Value   int
+}
+
+// ChannelStat is the value of a tracked metric in a channel for a report period. UserId is set when the stat is for a
+// single user in the channel.
+type ChannelStat struct {
+	Metric    string
+	GuildId   uint64
+	ChannelId uint64
+	UserId    uint64
+	Period    string
+	Value     int
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// DailyGameTimePlay is a single solve of a duration scored daily game (e.g. NYT Mini, LinkedIn Queens)
+type DailyGameTimePlay struct {
+	GuildId  uint64
+	UserId   uint64
+	Game     string
+	Duration time.Duration
+}
+
+// DailyGameTimeStat is the aggregate of a user's solve times for a game in a report period
+type DailyGameTimeStat struct {
+	GuildId        uint64
+	UserId         uint64
+	Period         string
+	Game           string
+	PlayCount      int
+	FastestSeconds int
+	MedianSeconds  float64
+}
+
+func (d DailyGameTimeStat) FormatTimes() string {
+	if d.PlayCount == 0 {
+		return "Zero plays"
+	}
+	return fmt.Sprintf("fastest %s, median %s over %d plays",
+		FormatSolveTime(time.Duration(d.FastestSeconds)*time.Second),
+		FormatSolveTime(time.Duration(d.MedianSeconds*float64(time.Second))),
+		d.PlayCount)
+}
+
+// FormatSolveTime formats a duration as m:ss, or h:mm:ss for durations of an hour or more
+func FormatSolveTime(d time.Duration) string {
+	total := int(d.Round(time.Second).Seconds())
+	hours := total / 3600
+	minutes := (total % 3600) / 60
+	seconds := total % 60
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%d:%02d", minutes, seconds)
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDailyGameTimeStat_FormatTimes(t *testing.T) {
+	tests := []struct {
+		name string
+		stat DailyGameTimeStat
+		want string
+	}{
+		{
+			"baseline",
+			DailyGameTimeStat{
+				GuildId:        1,
+				UserId:         2,
+				Period:         "2024-07",
+				Game:           "Mini",
+				PlayCount:      12,
+				FastestSeconds: 31,
+				MedianSeconds:  52.5,
+			},
+			"fastest 0:31, median 0:53 over 12 plays",
+		},
+		{
+			"zero_plays_safety_catch",
+			DailyGameTimeStat{Game: "Queens"},
+			"Zero plays",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.stat.FormatTimes())
+		})
+	}
+}
+
+func TestFormatSolveTime(t *testing.T) {
+	tests := []struct {
+		name string
+		d    time.Duration
+		want string
+	}{
+		{"seconds", 42 * time.Second, "0:42"},
+		{"minutes", 12*time.Minute + 5*time.Second, "12:05"},
+		{"hours", time.Hour + 2*time.Minute + 3*time.Second, "1:02:03"},
+		{"rounds", 1500 * time.Millisecond, "0:02"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FormatSolveTime(tt.d))
+		})
+	}
+}
@@ -7,13 +7,14 @@ type DailyGamePlay struct {
 	UserId  uint64
 	Tries   uint
 	Win     bool
+	Game    string
 }
 
 type DailyGameWinStat struct {
 	Id            uint
 	GuildId       uint64
 	UserId        uint64
-	ReportMonth   string
+	Period        string
 	PlayCount     int
 	GuessCount    int
 	WinCount      int
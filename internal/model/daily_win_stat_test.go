@@ -11,7 +11,7 @@ func TestDailyGameWinStat_FormatWins(t *testing.T) {
 		Id            uint
 		GuildId       uint64
 		UserId        uint64
-		ReportMonth   string
+		Period        string
 		PlayCount     int
 		GuessCount    int
 		WinCount      int
@@ -28,7 +28,7 @@ func TestDailyGameWinStat_FormatWins(t *testing.T) {
 			fields{
 				Id:            42,
 				GuildId:       43,
-				ReportMonth:   "2023-10",
+				Period:        "2023-10",
 				PlayCount:     30,
 				GuessCount:    105,
 				WinCount:      20,
@@ -42,7 +42,7 @@ func TestDailyGameWinStat_FormatWins(t *testing.T) {
 			fields{
 				Id:            44,
 				GuildId:       45,
-				ReportMonth:   "2023-10",
+				Period:        "2023-10",
 				PlayCount:     0,
 				GuessCount:    0,
 				WinCount:      0,
@@ -58,7 +58,7 @@ func TestDailyGameWinStat_FormatWins(t *testing.T) {
 				Id:            tt.fields.Id,
 				GuildId:       tt.fields.GuildId,
 				UserId:        tt.fields.UserId,
-				ReportMonth:   tt.fields.ReportMonth,
+				Period:        tt.fields.Period,
 				PlayCount:     tt.fields.PlayCount,
 				GuessCount:    tt.fields.GuessCount,
 				WinCount:      tt.fields.WinCount,
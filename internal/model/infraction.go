@@ -0,0 +1,13 @@
+package model
+
+// Infraction is an entry in a guild's moderation ledger for a user
+type Infraction struct {
+	Id          int
+	GuildId     uint64
+	UserId      uint64
+	ModeratorId uint64 // 0 for infractions the bot recorded
+	Kind        string
+	Reason      string
+	CreatedAt   int64  // Unix seconds
+	PardonedBy  uint64 // Moderator who pardoned the infraction, 0 if it hasn't been
+}
@@ -0,0 +1,26 @@
+package model
+
+import "fmt"
+
+// EmojiStat is the number of times an emoji was used as a reaction in a guild for a report period
+type EmojiStat struct {
+	GuildId uint64
+	Period  string
+	Emoji   string // Unicode emoji, or message format for custom emoji e.g. <:name:id>
+	Value   int
+}
+
+// MessageStat is the number of reactions a message received in a report period
+type MessageStat struct {
+	GuildId   uint64
+	Period    string
+	ChannelId uint64
+	MessageId uint64
+	AuthorId  uint64
+	Value     int
+}
+
+// Link gets the URL to jump to the message
+func (m MessageStat) Link() string {
+	return fmt.Sprintf("https://discord.com/channels/%d/%d/%d", m.GuildId, m.ChannelId, m.MessageId)
+}
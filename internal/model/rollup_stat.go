@@ -0,0 +1,11 @@
+package model
+
+// RollupStat is the value of a stat for a user, rolled up from a month's stats so it's kept for the year's Wrapped
+// after the month is cleaned up
+type RollupStat struct {
+	GuildId uint64
+	UserId  uint64
+	Period  string
+	Stat    string
+	Value   int
+}
@@ -0,0 +1,10 @@
+package model
+
+// StarboardPost links a message to its repost in the guild's starboard channel
+type StarboardPost struct {
+	GuildId            uint64
+	ChannelId          uint64
+	MessageId          uint64
+	StarboardMessageId uint64
+	Count              int // Reaction count shown on the repost
+}
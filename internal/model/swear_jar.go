@@ -0,0 +1,8 @@
+package model
+
+// SwearJarTotal is the number of cursed words a user has put in the swear jar in a guild
+type SwearJarTotal struct {
+	GuildId uint64
+	UserId  uint64
+	Total   int
+}
@@ -112,6 +112,7 @@ func (v *Value) Eval(roller *roller.BaseRoller) (*DNotationResult, error) {
 		return &DNotationResult{
 			Value:    subRes.Value,
 			StrValue: fmt.Sprintf("( %s )", subRes.StrValue),
+			Dice:     subRes.Dice,
 		}, nil
 	} else {
 		return &DNotationResult{
@@ -128,12 +129,17 @@ func (d *Factor) Eval(baseRoller *roller.BaseRoller) (*DNotationResult, error) {
 	}
 	nrolls := leftRes.Value
 	strVal := leftRes.StrValue
+	dice := leftRes.Dice
 	for _, r := range d.Right {
 		rightRes, err := r.Value.Eval(baseRoller)
 		if err != nil {
 			return nil, err
 		}
+		dice = append(dice, rightRes.Dice...)
 		rollRes := baseRoller.DoRoll(nrolls, rightRes.Value)
+		for _, value := range rollRes {
+			dice = append(dice, DieRoll{Sides: rightRes.Value, Value: value})
+		}
 		nrolls = rollRes.Sum()
 		strVal, err = rollRes.String()
 		if err != nil {
@@ -143,6 +149,7 @@ func (d *Factor) Eval(baseRoller *roller.BaseRoller) (*DNotationResult, error) {
 	return &DNotationResult{
 		nrolls,
 		strVal,
+		dice,
 	}, nil
 }
 
@@ -152,6 +159,7 @@ func (t *Term) Eval(baseRoller *roller.BaseRoller) (*DNotationResult, error) {
 		return nil, err
 	}
 	accum := l.Value
+	dice := l.Dice
 	strAccum := new(strings.Builder)
 	_, err = strAccum.WriteString(l.StrValue)
 	if err != nil {
@@ -162,6 +170,7 @@ func (t *Term) Eval(baseRoller *roller.BaseRoller) (*DNotationResult, error) {
 		if err != nil {
 			return nil, err
 		}
+		dice = append(dice, rightFactor.Dice...)
 		opRes, err := r.Operator.Eval(accum, rightFactor.Value)
 		if err != nil {
 			return nil, err
@@ -179,6 +188,7 @@ func (t *Term) Eval(baseRoller *roller.BaseRoller) (*DNotationResult, error) {
 	return &DNotationResult{
 		accum,
 		strAccum.String(),
+		dice,
 	}, nil
 }
 
@@ -189,6 +199,7 @@ func (e *Expression) Eval(baseRoller *roller.BaseRoller) (*DNotationResult, erro
 		return nil, err
 	}
 	accum := l.Value
+	dice := l.Dice
 	strAccum := new(strings.Builder)
 	_, err = strAccum.WriteString(l.StrValue)
 	if err != nil {
@@ -200,6 +211,7 @@ func (e *Expression) Eval(baseRoller *roller.BaseRoller) (*DNotationResult, erro
 		if err != nil {
 			return nil, err
 		}
+		dice = append(dice, rightTerm.Dice...)
 		opRes, err := r.Operator.Eval(accum, rightTerm.Value)
 		if err != nil {
 			return nil, err
@@ -218,6 +230,7 @@ func (e *Expression) Eval(baseRoller *roller.BaseRoller) (*DNotationResult, erro
 	return &DNotationResult{
 		accum,
 		strAccum.String(),
+		dice,
 	}, nil
 }
 
@@ -39,6 +39,7 @@ func TestDNotationParser_DoParse(t *testing.T) {
 			&DNotationResult{
 				Value:    12,
 				StrValue: "[2 + 5 + 5]",
+				Dice:     []DieRoll{{6, 2}, {6, 5}, {6, 5}},
 			},
 			nil,
 		},
@@ -48,6 +49,11 @@ func TestDNotationParser_DoParse(t *testing.T) {
 			&DNotationResult{
 				Value:    27,
 				StrValue: "[2 + 8 + 8 + 6 + 5] + 2 * ( [2 + 6] - [4 + 1 + 4] )",
+				Dice: []DieRoll{
+					{10, 2}, {10, 8}, {10, 8}, {10, 6}, {10, 5},
+					{12, 2}, {12, 6},
+					{4, 4}, {4, 1}, {4, 4},
+				},
 			},
 			nil,
 		},
@@ -8,4 +8,11 @@ type RollParser interface {
 type DNotationResult struct {
 	Value    int
 	StrValue string
+	Dice     []DieRoll // Every die rolled, in order
+}
+
+// DieRoll is the result of rolling a single die
+type DieRoll struct {
+	Sides int
+	Value int
 }
@@ -0,0 +1,47 @@
+// Package privacy records the members who opted out of stat tracking
+package privacy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+// Store persists the users who opted out of stat tracking in each guild. Lookups go through the opt out cache.
+type Store interface {
+	// OptOut stops tracking the user in the guild. Opting out again is a no-op
+	OptOut(ctx context.Context, guildId, userId uint64) error
+	// OptIn resumes tracking the user in the guild
+	OptIn(ctx context.Context, guildId, userId uint64) error
+}
+
+// Privacy is the Postgres backed Store
+type Privacy struct {
+	pool model.DbPool
+}
+
+var _ Store = (*Privacy)(nil)
+
+func New(pool model.DbPool) *Privacy {
+	return &Privacy{pool: pool}
+}
+
+func (p *Privacy) OptOut(ctx context.Context, guildId, userId uint64) error {
+	_, err := p.pool.Exec(ctx, `
+INSERT INTO privacy_opt_outs (guild_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT (guild_id, user_id) DO NOTHING`, guildId, userId)
+	if err != nil {
+		return fmt.Errorf("failed to opt out user: %w", err)
+	}
+	return nil
+}
+
+func (p *Privacy) OptIn(ctx context.Context, guildId, userId uint64) error {
+	_, err := p.pool.Exec(ctx, "DELETE FROM privacy_opt_outs WHERE guild_id = $1 AND user_id = $2", guildId, userId)
+	if err != nil {
+		return fmt.Errorf("failed to opt in user: %w", err)
+	}
+	return nil
+}
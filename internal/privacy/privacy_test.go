@@ -0,0 +1,41 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrivacy_OptOut(t *testing.T) {
+	expectedErr := errors.New("underlying db err")
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectExec(`INSERT INTO privacy_opt_outs \(guild_id, user_id\)\s+VALUES \(\$1, \$2\)\s+ON CONFLICT \(guild_id, user_id\) DO NOTHING`).
+		WithArgs(uint64(100), uint64(200)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockDb.ExpectExec(`INSERT INTO privacy_opt_outs`).WithArgs(uint64(100), uint64(201)).WillReturnError(expectedErr)
+	privacy := New(mockDb)
+	assert.NoError(t, privacy.OptOut(context.Background(), 100, 200))
+	assert.ErrorIs(t, privacy.OptOut(context.Background(), 100, 201), expectedErr, "expected error not wrapped")
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestPrivacy_OptIn(t *testing.T) {
+	expectedErr := errors.New("underlying db err")
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectExec(`DELETE FROM privacy_opt_outs WHERE guild_id = \$1 AND user_id = \$2`).
+		WithArgs(uint64(100), uint64(200)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mockDb.ExpectExec(`DELETE FROM privacy_opt_outs`).WithArgs(uint64(100), uint64(201)).WillReturnError(expectedErr)
+	privacy := New(mockDb)
+	assert.NoError(t, privacy.OptIn(context.Background(), 100, 200))
+	assert.ErrorIs(t, privacy.OptIn(context.Background(), 100, 201), expectedErr, "expected error not wrapped")
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
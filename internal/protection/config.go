@@ -0,0 +1,138 @@
+// Package protection detects message floods, repeated messages, mention spam & mass joins using in memory sliding
+// window counters, for the guild's configured responses
+package protection
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Responses to a tripped rule
+const (
+	DeleteAction   = "delete"   // delete the member's messages counted by the rule
+	TimeoutAction  = "timeout"  // time out the member, or every member in a mass join
+	SlowmodeAction = "slowmode" // turn on slowmode in the channels the messages were in
+	AlertAction    = "alert"    // post an alert to the moderation log, pinging the alert role
+)
+
+// Defaults for guilds that don't set their own
+const (
+	DefaultTimeoutDuration  = 10 * time.Minute
+	DefaultSlowmodeSeconds  = 10
+	DefaultSlowmodeDuration = 5 * time.Minute
+)
+
+var messageActions = []string{DeleteAction, TimeoutAction, SlowmodeAction, AlertAction}
+var joinActions = []string{TimeoutAction, AlertAction}
+
+// Rule trips when Limit events happen within Window
+type Rule struct {
+	Limit   int      `mapstructure:"limit"`   // Events within the window that trip the rule, disabled if 0
+	Window  string   `mapstructure:"window"`  // Duration events are counted over, e.g. "10s"
+	Actions []string `mapstructure:"actions"` // Responses when the rule trips
+	window  time.Duration
+}
+
+func (r *Rule) load(name string, allowed []string) error {
+	if r.Limit < 0 {
+		return fmt.Errorf("invalid %s limit %d", name, r.Limit)
+	}
+	if r.Limit == 0 {
+		return nil
+	}
+	var err error
+	if r.window, err = time.ParseDuration(r.Window); err != nil || r.window <= 0 {
+		return fmt.Errorf("invalid %s window %q", name, r.Window)
+	}
+	for _, action := range r.Actions {
+		if !slices.Contains(allowed, action) {
+			return fmt.Errorf("invalid %s action %s", name, action)
+		}
+	}
+	return nil
+}
+
+// Enabled checks if the rule has a limit
+func (r *Rule) Enabled() bool {
+	return r.Limit > 0
+}
+
+// Has checks if the rule responds with the action
+func (r *Rule) Has(action string) bool {
+	return slices.Contains(r.Actions, action)
+}
+
+// Config is a guild's protection rules & how it responds to them
+type Config struct {
+	Flood            Rule   `mapstructure:"flood"`             // Messages from a member
+	Duplicate        Rule   `mapstructure:"duplicate"`         // Identical messages from a member
+	Mentions         Rule   `mapstructure:"mentions"`          // Users, roles & everyone mentioned by a member
+	Joins            Rule   `mapstructure:"joins"`             // Members joining the guild
+	AlertRoleId      string `mapstructure:"alert_role_id"`     // Role pinged by alerts, none if empty
+	TimeoutDuration  string `mapstructure:"timeout_duration"`  // Duration of timeouts, defaults to 10m
+	SlowmodeSeconds  int    `mapstructure:"slowmode_seconds"`  // Seconds between each member's messages in slowmode, defaults to 10
+	SlowmodeDuration string `mapstructure:"slowmode_duration"` // Duration slowmode stays on, defaults to 5m
+	timeout          time.Duration
+	slowmode         time.Duration
+}
+
+// Load parses the durations from the raw config values
+func (c *Config) Load() error {
+	rules := []struct {
+		name    string
+		rule    *Rule
+		allowed []string
+	}{
+		{FloodKind, &c.Flood, messageActions},
+		{DuplicateKind, &c.Duplicate, messageActions},
+		{MentionsKind, &c.Mentions, messageActions},
+		{JoinsKind, &c.Joins, joinActions},
+	}
+	for _, r := range rules {
+		if err := r.rule.load(r.name, r.allowed); err != nil {
+			return err
+		}
+	}
+	var err error
+	c.timeout, c.slowmode = DefaultTimeoutDuration, DefaultSlowmodeDuration
+	if c.TimeoutDuration != "" {
+		if c.timeout, err = time.ParseDuration(c.TimeoutDuration); err != nil || c.timeout <= 0 {
+			return fmt.Errorf("invalid protection timeout_duration %q", c.TimeoutDuration)
+		}
+	}
+	if c.SlowmodeDuration != "" {
+		if c.slowmode, err = time.ParseDuration(c.SlowmodeDuration); err != nil || c.slowmode <= 0 {
+			return fmt.Errorf("invalid protection slowmode_duration %q", c.SlowmodeDuration)
+		}
+	}
+	if c.SlowmodeSeconds < 0 || c.SlowmodeSeconds > 21600 {
+		return fmt.Errorf("invalid protection slowmode_seconds %d", c.SlowmodeSeconds)
+	}
+	return nil
+}
+
+// Enabled checks if any rule is enabled
+func (c *Config) Enabled() bool {
+	return c != nil && (c.Flood.Enabled() || c.Duplicate.Enabled() || c.Mentions.Enabled() || c.Joins.Enabled())
+}
+
+// Timeout gets the duration of timeouts
+func (c *Config) Timeout() time.Duration {
+	if c.timeout == 0 {
+		return DefaultTimeoutDuration
+	}
+	return c.timeout
+}
+
+// Slowmode gets the seconds between messages in slowmode, & how long slowmode stays on for
+func (c *Config) Slowmode() (int, time.Duration) {
+	seconds, duration := c.SlowmodeSeconds, c.slowmode
+	if seconds == 0 {
+		seconds = DefaultSlowmodeSeconds
+	}
+	if duration == 0 {
+		duration = DefaultSlowmodeDuration
+	}
+	return seconds, duration
+}
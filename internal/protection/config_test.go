@@ -0,0 +1,61 @@
+package protection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Load(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"empty", Config{}, false},
+		{"all rules", Config{
+			Flood:           Rule{Limit: 8, Window: "10s", Actions: []string{DeleteAction, TimeoutAction, AlertAction}},
+			Duplicate:       Rule{Limit: 3, Window: "1m", Actions: []string{DeleteAction}},
+			Mentions:        Rule{Limit: 10, Window: "30s", Actions: []string{SlowmodeAction}},
+			Joins:           Rule{Limit: 10, Window: "1m", Actions: []string{AlertAction, TimeoutAction}},
+			TimeoutDuration: "1h",
+		}, false},
+		{"negative limit", Config{Flood: Rule{Limit: -1}}, true},
+		{"missing window", Config{Flood: Rule{Limit: 8}}, true},
+		{"invalid action", Config{Flood: Rule{Limit: 8, Window: "10s", Actions: []string{"ban"}}}, true},
+		{"delete joins", Config{Joins: Rule{Limit: 10, Window: "1m", Actions: []string{DeleteAction}}}, true},
+		{"invalid timeout", Config{TimeoutDuration: "forever"}, true},
+		{"invalid slowmode", Config{SlowmodeSeconds: -1}, true},
+		{"invalid slowmode duration", Config{SlowmodeDuration: "0s"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Load()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestConfig_defaults(t *testing.T) {
+	config := Config{}
+	require.NoError(t, config.Load())
+	assert.False(t, config.Enabled())
+	assert.Equal(t, DefaultTimeoutDuration, config.Timeout())
+	seconds, duration := config.Slowmode()
+	assert.Equal(t, DefaultSlowmodeSeconds, seconds)
+	assert.Equal(t, DefaultSlowmodeDuration, duration)
+
+	config = Config{Joins: Rule{Limit: 5, Window: "1m"}, TimeoutDuration: "1h", SlowmodeSeconds: 30, SlowmodeDuration: "15m"}
+	require.NoError(t, config.Load())
+	assert.True(t, config.Enabled())
+	assert.Equal(t, time.Hour, config.Timeout())
+	seconds, duration = config.Slowmode()
+	assert.Equal(t, 30, seconds)
+	assert.Equal(t, 15*time.Minute, duration)
+}
@@ -0,0 +1,169 @@
+package protection
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of rule a Trip is for
+const (
+	FloodKind     = "flood"
+	DuplicateKind = "duplicate"
+	MentionsKind  = "mentions"
+	JoinsKind     = "joins"
+)
+
+// sweepInterval is how often counters for members who've gone quiet are dropped
+const sweepInterval = time.Minute
+
+// Message is a post counted by the message rules
+type Message struct {
+	Id        string
+	ChannelId string
+	Content   string
+	Mentions  int
+	Time      time.Time
+}
+
+// Trip is a rule reaching its limit
+type Trip struct {
+	Kind     string
+	Rule     *Rule
+	Messages []Message // messages counted by a message rule
+	Users    []uint64  // members counted by the joins rule
+}
+
+type windowKey struct {
+	guildId uint64
+	userId  uint64 // 0 for the guild's joins
+	kind    string
+}
+
+type windowEvent struct {
+	message Message
+	userId  uint64
+	weight  int
+}
+
+type window struct {
+	events []windowEvent
+	length time.Duration
+}
+
+// Detector keeps the sliding window counters for every guild. Counters are kept in memory, so they start over when the
+// bot restarts. A rule's counter starts over once it trips, so a flood trips once per Limit messages.
+type Detector struct {
+	lock      sync.Mutex
+	windows   map[windowKey]*window
+	lastSweep time.Time
+}
+
+func NewDetector() *Detector {
+	return &Detector{windows: make(map[windowKey]*window)}
+}
+
+// Message counts the member's message, returning the rules it tripped
+func (d *Detector) Message(config *Config, guildId, userId uint64, message Message) []Trip {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.sweep(message.Time)
+	var trips []Trip
+	event := windowEvent{message: message, userId: userId, weight: 1}
+	if config.Flood.Enabled() {
+		if events := d.record(windowKey{guildId, userId, FloodKind}, &config.Flood, event); events != nil {
+			trips = append(trips, messageTrip(FloodKind, &config.Flood, events))
+		}
+	}
+	if config.Duplicate.Enabled() && strings.TrimSpace(message.Content) != "" {
+		// each distinct message is counted separately
+		key := windowKey{guildId, userId, DuplicateKind + ":" + normalizeContent(message.Content)}
+		if events := d.record(key, &config.Duplicate, event); events != nil {
+			trips = append(trips, messageTrip(DuplicateKind, &config.Duplicate, events))
+		}
+	}
+	if config.Mentions.Enabled() && message.Mentions > 0 {
+		event.weight = message.Mentions
+		if events := d.record(windowKey{guildId, userId, MentionsKind}, &config.Mentions, event); events != nil {
+			trips = append(trips, messageTrip(MentionsKind, &config.Mentions, events))
+		}
+	}
+	return trips
+}
+
+// Join counts the member joining the guild, returning the joins rule if it tripped
+func (d *Detector) Join(config *Config, guildId, userId uint64, t time.Time) *Trip {
+	if !config.Joins.Enabled() {
+		return nil
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.sweep(t)
+	events := d.record(windowKey{guildId: guildId, kind: JoinsKind}, &config.Joins, windowEvent{message: Message{Time: t}, userId: userId, weight: 1})
+	if events == nil {
+		return nil
+	}
+	trip := &Trip{Kind: JoinsKind, Rule: &config.Joins}
+	for _, event := range events {
+		trip.Users = append(trip.Users, event.userId)
+	}
+	return trip
+}
+
+// record adds the event to the window, returning the events in it if they reached the rule's limit. Must hold the lock.
+func (d *Detector) record(key windowKey, rule *Rule, event windowEvent) []windowEvent {
+	w, ok := d.windows[key]
+	if !ok {
+		w = &window{}
+		d.windows[key] = w
+	}
+	w.length = rule.window
+	w.prune(event.message.Time)
+	w.events = append(w.events, event)
+	total := 0
+	for _, e := range w.events {
+		total += e.weight
+	}
+	if total < rule.Limit {
+		return nil
+	}
+	delete(d.windows, key)
+	return w.events
+}
+
+// prune drops events that fell out of the window at t
+func (w *window) prune(t time.Time) {
+	cutoff := t.Add(-w.length)
+	i := 0
+	for i < len(w.events) && !w.events[i].message.Time.After(cutoff) {
+		i++
+	}
+	w.events = w.events[i:]
+}
+
+// sweep drops windows with no events left, at most once per sweep interval. Must hold the lock.
+func (d *Detector) sweep(t time.Time) {
+	if t.Sub(d.lastSweep) < sweepInterval {
+		return
+	}
+	d.lastSweep = t
+	for key, w := range d.windows {
+		w.prune(t)
+		if len(w.events) == 0 {
+			delete(d.windows, key)
+		}
+	}
+}
+
+func messageTrip(kind string, rule *Rule, events []windowEvent) Trip {
+	trip := Trip{Kind: kind, Rule: rule}
+	for _, event := range events {
+		trip.Messages = append(trip.Messages, event.message)
+	}
+	return trip
+}
+
+// normalizeContent makes messages differing only in case or spacing identical
+func normalizeContent(content string) string {
+	return strings.Join(strings.Fields(strings.ToLower(content)), " ")
+}
@@ -0,0 +1,113 @@
+package protection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig(t *testing.T) *Config {
+	t.Helper()
+	config := &Config{
+		Flood:     Rule{Limit: 4, Window: "10s"},
+		Duplicate: Rule{Limit: 3, Window: "1m"},
+		Mentions:  Rule{Limit: 5, Window: "30s"},
+		Joins:     Rule{Limit: 3, Window: "1m"},
+	}
+	require.NoError(t, config.Load())
+	return config
+}
+
+func kinds(trips []Trip) []string {
+	var names []string
+	for _, trip := range trips {
+		names = append(names, trip.Kind)
+	}
+	return names
+}
+
+func TestDetector_Flood(t *testing.T) {
+	config := testConfig(t)
+	detector := NewDetector()
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	post := func(user uint64, id string, after time.Duration) []Trip {
+		return detector.Message(config, 1, user, Message{Id: id, ChannelId: "300", Content: id, Time: start.Add(after)})
+	}
+
+	assert.Empty(t, post(2, "a", 0))
+	assert.Empty(t, post(2, "b", time.Second))
+	assert.Empty(t, post(3, "c", 2*time.Second), "other members counted together")
+	assert.Empty(t, post(2, "d", 3*time.Second))
+	trips := post(2, "e", 4*time.Second)
+	require.Equal(t, []string{FloodKind}, kinds(trips))
+	assert.Len(t, trips[0].Messages, 4)
+	assert.Equal(t, "b", trips[0].Messages[1].Id)
+	assert.Empty(t, post(2, "f", 5*time.Second), "counter not reset after tripping")
+
+	// slow posts never fill the window
+	for i := range 10 {
+		assert.Empty(t, post(4, string(rune('g'+i)), time.Duration(i)*4*time.Second))
+	}
+}
+
+func TestDetector_Duplicate(t *testing.T) {
+	config := testConfig(t)
+	config.Flood = Rule{}
+	detector := NewDetector()
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	post := func(content string, after time.Duration) []Trip {
+		return detector.Message(config, 1, 2, Message{Id: content, Content: content, Time: start.Add(after)})
+	}
+
+	assert.Empty(t, post("buy now", 0))
+	assert.Empty(t, post("something else", 15*time.Second))
+	assert.Empty(t, post("BUY  now", 30*time.Second))
+	assert.Equal(t, []string{DuplicateKind}, kinds(post(" buy now", 45*time.Second)))
+	assert.Empty(t, post("", 46*time.Second))
+	assert.Empty(t, post("", 47*time.Second))
+	assert.Empty(t, post("", 48*time.Second), "empty messages counted as duplicates")
+}
+
+func TestDetector_Mentions(t *testing.T) {
+	config := testConfig(t)
+	config.Duplicate = Rule{}
+	detector := NewDetector()
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	post := func(mentions int, after time.Duration) []Trip {
+		return detector.Message(config, 1, 2, Message{Content: "hi", Mentions: mentions, Time: start.Add(after)})
+	}
+
+	assert.Empty(t, post(3, 0))
+	assert.Empty(t, post(0, 20*time.Second))
+	assert.Empty(t, post(1, 35*time.Second), "mentions outside the window counted")
+	assert.Equal(t, []string{MentionsKind}, kinds(post(4, 40*time.Second)))
+}
+
+func TestDetector_Join(t *testing.T) {
+	config := testConfig(t)
+	detector := NewDetector()
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Nil(t, detector.Join(config, 1, 10, start))
+	assert.Nil(t, detector.Join(config, 1, 11, start.Add(10*time.Second)))
+	assert.Nil(t, detector.Join(config, 2, 12, start.Add(20*time.Second)), "other guilds counted together")
+	trip := detector.Join(config, 1, 13, start.Add(30*time.Second))
+	require.NotNil(t, trip)
+	assert.Equal(t, JoinsKind, trip.Kind)
+	assert.Equal(t, []uint64{10, 11, 13}, trip.Users)
+
+	assert.Nil(t, detector.Join(&Config{}, 1, 14, start), "disabled rule counted")
+}
+
+func TestDetector_sweep(t *testing.T) {
+	config := testConfig(t)
+	detector := NewDetector()
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	detector.Message(config, 1, 2, Message{Content: "hi", Mentions: 1, Time: start})
+	detector.Join(config, 1, 3, start)
+	assert.Len(t, detector.windows, 4)
+	detector.Message(config, 1, 4, Message{Time: start.Add(2 * time.Minute)})
+	assert.Len(t, detector.windows, 1, "quiet counters not swept")
+}
@@ -0,0 +1,95 @@
+package quotes
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+// discordMarkupRegex matches mentions, including ones typed as plain text, channel links & custom emoji, which
+// shouldn't be searched for
+var discordMarkupRegex = regexp.MustCompile(`<(?:@[!&]?|#|a?:\w+:)\d+>|@\w+`)
+
+// stopWords are too common to say what a message is about
+var stopWords = map[string]bool{
+	"a": true, "about": true, "all": true, "am": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "can": true, "did": true, "do": true, "does": true, "dont": true, "for": true,
+	"from": true, "get": true, "got": true, "had": true, "has": true, "have": true, "he": true, "her": true, "him": true,
+	"his": true, "how": true, "i": true, "if": true, "im": true, "in": true, "is": true, "it": true, "its": true,
+	"just": true, "me": true, "my": true, "no": true, "not": true, "of": true, "oh": true, "on": true, "or": true,
+	"so": true, "that": true, "the": true, "their": true, "them": true, "then": true, "there": true, "they": true,
+	"this": true, "to": true, "up": true, "was": true, "we": true, "well": true, "what": true, "when": true,
+	"where": true, "which": true, "who": true, "why": true, "will": true, "with": true, "yeah": true, "yes": true,
+	"you": true, "your": true, "youre": true,
+}
+
+// textIndex is an inverted index of the text quotes' words. Quotes are scored against a message by the inverse document
+// frequency of each word they share, scaled down for longer quotes so a short quote about the topic beats a long one
+// that mentions it in passing.
+type textIndex struct {
+	quotes map[string][]int // word to the ids of quotes with it
+	idf    map[string]float64
+	norms  map[int]float64 // quote id to its length scale
+}
+
+func newTextIndex(quotes []model.Quote) *textIndex {
+	index := &textIndex{quotes: make(map[string][]int), idf: make(map[string]float64), norms: make(map[int]float64)}
+	count := 0
+	for _, quote := range quotes {
+		if quote.Type != model.TextQuoteType {
+			continue
+		}
+		words := terms(quote.Data)
+		if len(words) == 0 {
+			continue
+		}
+		count++
+		index.norms[quote.Id] = 1 / math.Sqrt(float64(len(words)))
+		for word := range words {
+			index.quotes[word] = append(index.quotes[word], quote.Id)
+		}
+	}
+	for word, ids := range index.quotes {
+		index.idf[word] = math.Log(1 + float64(count)/float64(len(ids)))
+	}
+	return index
+}
+
+// match scores the quotes sharing words with the text, by quote id
+func (t *textIndex) match(text string) map[int]float64 {
+	scores := make(map[int]float64)
+	for word := range terms(text) {
+		for _, id := range t.quotes[word] {
+			scores[id] += t.idf[word] * t.norms[id]
+		}
+	}
+	return scores
+}
+
+// terms gets the distinct searchable words in the text: lower cased, without apostrophes, stop words or plurals
+func terms(text string) map[string]bool {
+	text = discordMarkupRegex.ReplaceAllString(text, " ")
+	text = strings.NewReplacer("'", "", "’", "").Replace(strings.ToLower(text))
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) }) {
+		if len(word) < 2 || stopWords[word] {
+			continue
+		}
+		words[stem(word)] = true
+	}
+	return words
+}
+
+// stem drops plural endings, so "breads" finds "bread"
+func stem(word string) string {
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us"):
+		return word[:len(word)-1]
+	}
+	return word
+}
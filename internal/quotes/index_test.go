@@ -0,0 +1,68 @@
+package quotes
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+func TestTerms(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"<@123> what about bread?", []string{"bread"}},
+		{"@costanza what about bread?", []string{"bread"}},
+		{"I didn't get any BREADS", []string{"didnt", "any", "bread"}},
+		{"Serenity now! <:serenity:456> <#789>", []string{"serenity", "now"}},
+		{"The parties, the glasses & the bus", []string{"party", "glasse", "bus"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got := terms(tt.text)
+		assert.Len(t, got, len(tt.want), tt.text)
+		for _, word := range tt.want {
+			assert.True(t, got[word], "%q missing %q", tt.text, word)
+		}
+	}
+}
+
+func TestTextIndex_match(t *testing.T) {
+	index := newTextIndex([]model.Quote{
+		{Id: 1, Data: "Bread.", Type: model.TextQuoteType},
+		{Id: 2, Data: "This bread has nuts in it!", Type: model.TextQuoteType},
+		{Id: 3, Data: "Serenity now!", Type: model.TextQuoteType},
+		{Id: 4, Data: "bread.png", Type: model.FileQuoteType},
+		{Id: 5, Data: "I was in the pool!", Type: model.TextQuoteType},
+	})
+	got := index.match("bread with nuts")
+	require.Len(t, got, 2)
+	assert.Greater(t, got[2], got[1], "quote with both words scored lower")
+	assert.Greater(t, index.match("bread")[1], index.match("bread")[2], "shorter quote scored lower")
+	assert.Empty(t, index.match("the weather"))
+}
+
+// Checks the shipped quotes give a quote about the topic
+func TestTextIndex_matchQuotes(t *testing.T) {
+	data, err := os.ReadFile("costanza.json")
+	require.NoError(t, err)
+	var lines []string
+	require.NoError(t, json.Unmarshal(data, &lines))
+	quotes := make([]model.Quote, len(lines))
+	for i, line := range lines {
+		quotes[i] = model.Quote{Id: i + 1, Data: line, Type: model.TextQuoteType}
+	}
+	engine := testEngine(nil)
+	engine.setQuotes(quotes)
+	for range 20 {
+		id, found := engine.relevant("@costanza what about bread?", nil)
+		require.True(t, found)
+		assert.Contains(t, strings.ToLower(lines[id-1]), "bread")
+	}
+}
@@ -2,11 +2,16 @@ package quotes
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"math/rand/v2"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
 
 	"github.com/dmtaylor/costanza/internal/model"
 )
@@ -17,40 +22,77 @@ FROM quotes
 WHERE id = $1
 `
 
+const getQuotesQuery = "SELECT id, data, type FROM quotes ORDER BY id"
+
+// Defaults for picking quotes
+const (
+	DefaultIndexRefresh  = 10 * time.Minute // How often the quote indexes are reloaded, so new quotes can be picked
+	DefaultNoRepeatCount = 10               // Quotes picked in a channel before one can be picked there again
+)
+
+// relevanceCutoff is the share of the best match's score other matches need to be picked, so the best match usually
+// wins without always giving the same quote
+const relevanceCutoff = 0.5
+
+var ErrNoQuotes = errors.New("no quotes found")
+
 type QuoteEngine interface {
-	GetQuoteSql(ctx context.Context) (model.Quote, error)
+	// GetQuote picks a quote for the channel relevant to the text, or a random one if the text is empty or nothing
+	// matches. The channel's recent quotes are avoided.
+	GetQuote(ctx context.Context, channelId string, text string) (model.Quote, error)
 	GetQuoteById(ctx context.Context, id int) (model.Quote, error)
 }
 
+// QuoteEngineImpl picks quotes from an index of their ids, so deleted quotes leave no gaps, & a full text index of the
+// text quotes for picking relevant ones. The indexes are reloaded every refreshInterval, or straight away if a picked
+// quote has been deleted.
 type QuoteEngineImpl struct {
-	rng    *rand.Rand
-	lock   sync.Mutex
-	dbPool model.DbPool
-	size   uint
+	rng             *rand.Rand
+	lock            sync.Mutex
+	dbPool          model.DbPool
+	ids             []int
+	text            *textIndex
+	loaded          time.Time
+	refreshInterval time.Duration
+	noRepeat        int
+	recent          map[string][]int // each channel's last picks, oldest first
 }
 
 func NewQuoteEngine(connPool model.DbPool, seed1, seed2 uint64) (*QuoteEngineImpl, error) {
 	ctx := context.Background()
-	size, err := getQuoteCount(ctx, connPool)
+	quotes, err := getQuotes(ctx, connPool)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get quote count: %w", err)
+		return nil, fmt.Errorf("failed to get quotes: %w", err)
 	}
 	src := rand.NewPCG(seed1, seed2)
 	engine := &QuoteEngineImpl{
-		rng:    rand.New(src),
-		lock:   sync.Mutex{},
-		dbPool: connPool,
-		size:   size,
+		rng:             rand.New(src),
+		lock:            sync.Mutex{},
+		dbPool:          connPool,
+		loaded:          time.Now(),
+		refreshInterval: DefaultIndexRefresh,
+		noRepeat:        DefaultNoRepeatCount,
+		recent:          make(map[string][]int),
 	}
+	engine.setQuotes(quotes)
 
 	return engine, nil
 }
 
-func (q *QuoteEngineImpl) GetQuoteSql(ctx context.Context) (model.Quote, error) {
-	q.lock.Lock()
-	idx := q.rng.IntN(int(q.size))
-	q.lock.Unlock()
-	return q.GetQuoteById(ctx, idx)
+func (q *QuoteEngineImpl) GetQuote(ctx context.Context, channelId string, text string) (model.Quote, error) {
+	id, err := q.pick(ctx, channelId, text, false)
+	if err != nil {
+		return model.Quote{}, err
+	}
+	quote, err := q.GetQuoteById(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// deleted since the index was loaded
+		if id, err = q.pick(ctx, channelId, text, true); err != nil {
+			return model.Quote{}, err
+		}
+		return q.GetQuoteById(ctx, id)
+	}
+	return quote, err
 }
 
 func (q *QuoteEngineImpl) GetQuoteById(ctx context.Context, id int) (model.Quote, error) {
@@ -65,11 +107,77 @@ func (q *QuoteEngineImpl) GetQuoteById(ctx context.Context, id int) (model.Quote
 	return result, nil
 }
 
-func getQuoteCount(ctx context.Context, db model.DbPool) (uint, error) {
-	var size uint
-	err := db.QueryRow(ctx, "SELECT COUNT(*) FROM quotes").Scan(&size)
-	if err != nil {
-		return 0, fmt.Errorf("query failed: %w", err)
+// pick chooses a quote id that isn't one of the channel's recent picks, reloading the indexes first if they're due or
+// refresh is set. A failed reload keeps the old indexes if there are any.
+func (q *QuoteEngineImpl) pick(ctx context.Context, channelId string, text string, refresh bool) (int, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if refresh || time.Since(q.loaded) >= q.refreshInterval {
+		quotes, err := getQuotes(ctx, q.dbPool)
+		if err != nil && len(q.ids) == 0 {
+			return 0, fmt.Errorf("failed to get quotes: %w", err)
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "failed to refresh quotes: "+err.Error())
+		} else {
+			q.setQuotes(quotes)
+			q.loaded = time.Now()
+		}
+	}
+	if len(q.ids) == 0 {
+		return 0, ErrNoQuotes
+	}
+	// always leave at least one quote to pick
+	window := min(q.noRepeat, len(q.ids)-1)
+	recent := q.recent[channelId]
+	recent = recent[max(0, len(recent)-window):]
+	id, found := q.relevant(text, recent)
+	for !found || slices.Contains(recent, id) {
+		id, found = q.ids[q.rng.IntN(len(q.ids))], true
+	}
+	if window > 0 {
+		q.recent[channelId] = append(recent, id)
+	}
+	return id, nil
+}
+
+// relevant picks one of the best matches for the text that isn't recent, returning false if there aren't any
+func (q *QuoteEngineImpl) relevant(text string, recent []int) (int, bool) {
+	if text == "" || q.text == nil {
+		return 0, false
+	}
+	scores := q.text.match(text)
+	best := 0.0
+	for id, score := range scores {
+		if !slices.Contains(recent, id) {
+			best = max(best, score)
+		}
+	}
+	if best == 0 {
+		return 0, false
+	}
+	var candidates []int
+	for id, score := range scores {
+		if score >= best*relevanceCutoff && !slices.Contains(recent, id) {
+			candidates = append(candidates, id)
+		}
+	}
+	slices.Sort(candidates) // map order is random, the pick should only depend on the rng
+	return candidates[q.rng.IntN(len(candidates))], true
+}
+
+func (q *QuoteEngineImpl) setQuotes(quotes []model.Quote) {
+	q.ids = make([]int, len(quotes))
+	for i, quote := range quotes {
+		q.ids[i] = quote.Id
+	}
+	q.text = newTextIndex(quotes)
+}
+
+func getQuotes(ctx context.Context, db model.DbPool) ([]model.Quote, error) {
+	var quotes []model.Quote
+	if err := pgxscan.Select(ctx, db, &quotes, getQuotesQuery); err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
 	}
-	return size, nil
+	return quotes, nil
 }
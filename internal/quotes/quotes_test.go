@@ -2,9 +2,11 @@ package quotes
 
 import (
 	"context"
+	"fmt"
 	"math/rand/v2"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/pashagolub/pgxmock/v2"
@@ -14,73 +16,230 @@ import (
 	"github.com/dmtaylor/costanza/internal/model"
 )
 
-// TODO add more robust tests here, including errors
+func idRows(ids ...int) *pgxmock.Rows {
+	rows := pgxmock.NewRows([]string{"id", "data", "type"})
+	for _, id := range ids {
+		rows.AddRow(id, fmt.Sprintf("quote %d", id), model.TextQuoteType)
+	}
+	return rows
+}
+
+func quoteRows(id int) *pgxmock.Rows {
+	return pgxmock.NewRows([]string{"id", "data", "type"}).AddRow(id, "The purpose of knowledge is action, not knowledge", "quote")
+}
+
+func testEngine(mockDb pgxmock.PgxPoolIface, ids ...int) *QuoteEngineImpl {
+	return &QuoteEngineImpl{
+		rng:             rand.New(rand.NewPCG(2222, 9875)),
+		lock:            sync.Mutex{},
+		dbPool:          mockDb,
+		ids:             ids,
+		loaded:          time.Now(),
+		refreshInterval: DefaultIndexRefresh,
+		noRepeat:        DefaultNoRepeatCount,
+		recent:          make(map[string][]int),
+	}
+}
 
 func TestNewQuoteEngine(t *testing.T) {
-	// expected number of rows
-	var rowCount uint = 20
-	var testSeed1 uint64 = 2222
-	var testSeed2 uint64 = 2223
 	mockdb, err := pgxmock.NewPool()
 	require.Nil(t, err, "failed to build mock db")
-	rows := pgxmock.NewRows([]string{"count"}).AddRow(rowCount)
-	mockdb.ExpectQuery(`SELECT COUNT\(\*\) FROM quotes`).WillReturnRows(rows)
+	mockdb.ExpectQuery(`SELECT id, data, type FROM quotes ORDER BY id`).WillReturnRows(idRows(1, 2, 4))
 
-	testSrc := rand.NewPCG(testSeed1, testSeed2)
-
-	expectedEngine := &QuoteEngineImpl{rng: rand.New(testSrc), lock: sync.Mutex{}, dbPool: mockdb, size: rowCount}
-	got, err := NewQuoteEngine(mockdb, testSeed1, testSeed2)
+	got, err := NewQuoteEngine(mockdb, 2222, 2223)
 	require.Nil(t, err, "got error building quote engine")
-	assert.Equal(t, expectedEngine, got, "quote engine doesn't match expected")
+	assert.Equal(t, rand.New(rand.NewPCG(2222, 2223)), got.rng)
+	assert.Equal(t, []int{1, 2, 4}, got.ids)
+	assert.Len(t, got.text.match("quote"), 3)
+	assert.Equal(t, DefaultIndexRefresh, got.refreshInterval)
+	assert.Equal(t, DefaultNoRepeatCount, got.noRepeat)
 	assert.Nil(t, mockdb.ExpectationsWereMet(), "unmet db expectations")
 }
 
-func TestQuoteEngineImpl_GetQuoteSql(t *testing.T) {
+func TestQuoteEngineImpl_GetQuote(t *testing.T) {
 	mockDb, err := pgxmock.NewPool()
 	require.Nil(t, err, "failed to build mock db")
-	rows := pgxmock.NewRows([]string{"id", "data", "type"}).AddRow(18, "The purpose of knowledge is action, not knowledge", "quote")
+	engine := testEngine(mockDb, 7)
 	mockDb.ExpectQuery(`
 SELECT id, data, type
 FROM quotes
-WHERE id =`).WithArgs(18).WillReturnRows(rows)
-	src := rand.NewPCG(2222, 9875)
-	engine := &QuoteEngineImpl{
-		rng:    rand.New(src),
-		lock:   sync.Mutex{},
-		dbPool: mockDb,
-		size:   25,
-	}
+WHERE id =`).WithArgs(7).WillReturnRows(quoteRows(7))
 	expected := model.Quote{
-		Id:   18,
+		Id:   7,
 		Data: "The purpose of knowledge is action, not knowledge",
 		Type: "quote",
 	}
-	got, err := engine.GetQuoteSql(context.Background())
+	got, err := engine.GetQuote(context.Background(), "300", "")
 	assert.Nil(t, err, "got error")
 	assert.Equal(t, expected, got, "Returned value does not match expected")
 	assert.Nil(t, mockDb.ExpectationsWereMet(), "Unmet db expectation")
+}
 
+// Verify that a quote deleted since the index was loaded reloads the index & picks again
+func TestQuoteEngineImpl_GetQuoteDeleted(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build mock db")
+	engine := testEngine(mockDb, 7)
+	mockDb.ExpectQuery(`SELECT id, data, type`).WithArgs(7).WillReturnRows(pgxmock.NewRows([]string{"id", "data", "type"}))
+	mockDb.ExpectQuery(`SELECT id, data, type FROM quotes ORDER BY id`).WillReturnRows(idRows(9))
+	mockDb.ExpectQuery(`SELECT id, data, type`).WithArgs(9).WillReturnRows(quoteRows(9))
+	got, err := engine.GetQuote(context.Background(), "300", "")
+	require.NoError(t, err)
+	assert.Equal(t, 9, got.Id)
+	assert.Equal(t, []int{9}, engine.ids)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet db expectations")
 }
 
-// Verify that if the row is missing in underlying connection, scanned returns error from pgx
-func TestQuoteEngineImpl_GetQuoteSqlMissingRow(t *testing.T) {
+// Verify that if the row is still missing after reloading the index, the error from pgx is returned
+func TestQuoteEngineImpl_GetQuoteMissingRow(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build mock db")
+	engine := testEngine(mockDb, 18)
+	mockDb.ExpectQuery(`SELECT id, data, type`).WithArgs(18).WillReturnRows(pgxmock.NewRows([]string{"id", "data", "type"}))
+	mockDb.ExpectQuery(`SELECT id, data, type FROM quotes ORDER BY id`).WillReturnRows(idRows(18))
+	mockDb.ExpectQuery(`SELECT id, data, type`).WithArgs(18).WillReturnRows(pgxmock.NewRows([]string{"id", "data", "type"}))
+	_, err = engine.GetQuote(context.Background(), "300", "")
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "did not get missing result error")
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet db expectations")
+}
 
+func TestQuoteEngineImpl_GetQuoteNoQuotes(t *testing.T) {
 	mockDb, err := pgxmock.NewPool()
 	require.Nil(t, err, "failed to build mock db")
-	rows := pgxmock.NewRows([]string{"id", "data", "type"})
-	mockDb.ExpectQuery(`
-SELECT id, data, type
-FROM quotes
-WHERE id =`).WithArgs(18).WillReturnRows(rows)
-	src := rand.NewPCG(2222, 9875)
-	engine := &QuoteEngineImpl{
-		rng:    rand.New(src),
-		lock:   sync.Mutex{},
-		dbPool: mockDb,
-		size:   25,
+	engine := testEngine(mockDb)
+	_, err = engine.GetQuote(context.Background(), "300", "")
+	assert.ErrorIs(t, err, ErrNoQuotes)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet db expectations")
+}
+
+func TestQuoteEngineImpl_pick(t *testing.T) {
+	ctx := context.Background()
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build mock db")
+	// gaps from deleted quotes, with the first & last ids reachable
+	ids := []int{1, 2, 5, 6, 9, 10, 11, 15, 20, 21, 22, 30}
+	engine := testEngine(mockDb, ids...)
+	engine.noRepeat = 4
+	seen := make(map[int]int)
+	var picks []int
+	for range 600 {
+		id, err := engine.pick(ctx, "300", "", false)
+		require.NoError(t, err)
+		assert.Contains(t, ids, id)
+		if len(picks) > 0 {
+			assert.NotContains(t, picks[max(0, len(picks)-engine.noRepeat):], id, "quote repeated within %d picks", engine.noRepeat)
+		}
+		picks = append(picks, id)
+		seen[id]++
 	}
-	_, err = engine.GetQuoteSql(context.Background())
-	assert.ErrorIs(t, err, pgx.ErrNoRows, "did not get missing result error")
+	assert.Len(t, seen, len(ids), "not every quote was picked")
+
+	// each channel has its own recent picks
+	_, err = engine.pick(ctx, "301", "", false)
+	require.NoError(t, err)
+	assert.Len(t, engine.recent["301"], 1)
+	assert.LessOrEqual(t, len(engine.recent["300"]), engine.noRepeat+1)
 	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet db expectations")
+}
+
+func TestQuoteEngineImpl_pickFewQuotes(t *testing.T) {
+	ctx := context.Background()
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build mock db")
+	engine := testEngine(mockDb, 3, 8)
+	var picks []int
+	for range 6 {
+		id, err := engine.pick(ctx, "300", "", false)
+		require.NoError(t, err)
+		picks = append(picks, id)
+	}
+	for i := 1; i < len(picks); i++ {
+		assert.NotEqual(t, picks[i-1], picks[i], "quote repeated with fewer quotes than the no repeat count")
+	}
 
+	engine.ids = []int{3}
+	for range 3 {
+		id, err := engine.pick(ctx, "300", "", false)
+		require.NoError(t, err)
+		assert.Equal(t, 3, id, "only quote not picked")
+	}
+}
+
+func TestQuoteEngineImpl_pickRefresh(t *testing.T) {
+	ctx := context.Background()
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build mock db")
+	engine := testEngine(mockDb, 1)
+	engine.loaded = time.Now().Add(-DefaultIndexRefresh)
+	mockDb.ExpectQuery(`SELECT id, data, type FROM quotes ORDER BY id`).WillReturnRows(idRows(4))
+	id, err := engine.pick(ctx, "300", "", false)
+	require.NoError(t, err)
+	assert.Equal(t, 4, id, "new quote index not used")
+
+	// a failed refresh keeps the old index
+	engine.loaded = time.Now().Add(-DefaultIndexRefresh)
+	mockDb.ExpectQuery(`SELECT id, data, type FROM quotes ORDER BY id`).WillReturnError(pgx.ErrTxClosed)
+	id, err = engine.pick(ctx, "300", "", false)
+	require.NoError(t, err)
+	assert.Equal(t, 4, id)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet db expectations")
+}
+
+func TestQuoteEngineImpl_GetQuoteRelevant(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build mock db")
+	engine := testEngine(mockDb)
+	engine.setQuotes([]model.Quote{
+		{Id: 1, Data: "I'm back, baby!", Type: model.TextQuoteType},
+		{Id: 2, Data: "This bread has nuts in it!", Type: model.TextQuoteType},
+		{Id: 3, Data: "We're living in a society!", Type: model.TextQuoteType},
+	})
+	mockDb.ExpectQuery(`SELECT id, data, type`).WithArgs(2).WillReturnRows(quoteRows(2))
+	got, err := engine.GetQuote(context.Background(), "300", "<@1> what about bread?")
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.Id)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet db expectations")
+}
+
+func TestQuoteEngineImpl_pickRelevant(t *testing.T) {
+	ctx := context.Background()
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build mock db")
+	engine := testEngine(mockDb)
+	engine.noRepeat = 0
+	engine.setQuotes([]model.Quote{
+		{Id: 1, Data: "Bread.", Type: model.TextQuoteType},
+		{Id: 2, Data: "This bread has nuts in it!", Type: model.TextQuoteType},
+		{Id: 3, Data: "Now, because of that stupid rye bread, I gotta keep them all separated for the rest of my life.", Type: model.TextQuoteType},
+		{Id: 4, Data: "Serenity now!", Type: model.TextQuoteType},
+		{Id: 5, Data: "bread.png", Type: model.FileQuoteType},
+		{Id: 6, Data: "I was in the pool!", Type: model.TextQuoteType},
+	})
+
+	// the long quote scores too low to be picked with the short ones around
+	var picks []int
+	for range 20 {
+		id, err := engine.pick(ctx, "300", "bread", false)
+		require.NoError(t, err)
+		picks = append(picks, id)
+	}
+	assert.Contains(t, picks, 1)
+	assert.Contains(t, picks, 2)
+	assert.NotContains(t, picks, 3, "long quote picked over better matches")
+	assert.NotContains(t, picks, 5, "file quote matched")
+
+	// once the best matches are recent, the rest are picked
+	engine.noRepeat = 2
+	engine.recent["301"] = []int{1, 2}
+	id, err := engine.pick(ctx, "301", "breads", false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, id)
+
+	// falls back to a random quote when nothing matches
+	for range 20 {
+		id, err = engine.pick(ctx, "302", "what about the weather?", false)
+		require.NoError(t, err)
+		assert.Contains(t, engine.ids, id)
+	}
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet db expectations")
 }
@@ -0,0 +1,150 @@
+// Package starboard reposts popular messages to a guild's hall of fame channel
+package starboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+// DefaultEmoji is the reaction counted for the starboard when a guild doesn't configure one
+const DefaultEmoji = "⭐"
+
+// DefaultThreshold is the number of reactions needed for a starboard post when a guild doesn't configure one
+const DefaultThreshold = 3
+
+const embedColor = 0xffac33
+const maxDescription = 4096
+
+const postUpsertQuery = `
+INSERT INTO starboard_posts (guild_id, channel_id, message_id, starboard_message_id, count)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (message_id) DO UPDATE
+SET starboard_message_id = EXCLUDED.starboard_message_id, count = EXCLUDED.count`
+
+// Store persists the starboard posts for each message, so restarts don't repost them
+type Store interface {
+	// GetPost gets the starboard post for the message, or nil if it hasn't been posted
+	GetPost(ctx context.Context, messageId uint64) (*model.StarboardPost, error)
+	SavePost(ctx context.Context, post model.StarboardPost) error
+	RemovePost(ctx context.Context, messageId uint64) error
+}
+
+// Starboard is the Postgres backed Store
+type Starboard struct {
+	pool model.DbPool
+}
+
+var _ Store = (*Starboard)(nil)
+
+func New(pool model.DbPool) *Starboard {
+	return &Starboard{pool: pool}
+}
+
+func (s *Starboard) GetPost(ctx context.Context, messageId uint64) (*model.StarboardPost, error) {
+	var post model.StarboardPost
+	err := pgxscan.Get(ctx, s.pool, &post, `
+SELECT guild_id, channel_id, message_id, starboard_message_id, count
+FROM starboard_posts
+WHERE message_id = $1`, messageId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get starboard post: %w", err)
+	}
+	return &post, nil
+}
+
+// SavePost creates the post, or updates the repost id & count if the message was already posted
+func (s *Starboard) SavePost(ctx context.Context, post model.StarboardPost) error {
+	_, err := s.pool.Exec(ctx, postUpsertQuery, post.GuildId, post.ChannelId, post.MessageId, post.StarboardMessageId, post.Count)
+	if err != nil {
+		return fmt.Errorf("failed to save starboard post: %w", err)
+	}
+	return nil
+}
+
+func (s *Starboard) RemovePost(ctx context.Context, messageId uint64) error {
+	_, err := s.pool.Exec(ctx, "DELETE FROM starboard_posts WHERE message_id = $1", messageId)
+	if err != nil {
+		return fmt.Errorf("failed to remove starboard post: %w", err)
+	}
+	return nil
+}
+
+// Count gets the number of reactions on the message for the emoji, in API name format (e.g. ⭐ or name:id)
+func Count(message *discordgo.Message, emoji string) int {
+	for _, reaction := range message.Reactions {
+		if reaction.Emoji != nil && reaction.Emoji.APIName() == emoji {
+			return reaction.Count
+		}
+	}
+	return 0
+}
+
+// Content gets the text shown above the repost embed with the current count
+func Content(message *discordgo.Message, emoji string, count int) string {
+	display := discordgo.Emoji{Name: emoji}
+	if name, id, found := strings.Cut(emoji, ":"); found {
+		display = discordgo.Emoji{Name: name, ID: id}
+	}
+	return fmt.Sprintf("%s **%d** <#%s>", display.MessageFormat(), count, message.ChannelID)
+}
+
+// Embed builds the repost of the message with its author, content, attachments & a link back to it. The message's
+// GuildID must be set, as it's missing on messages fetched from the API.
+func Embed(message *discordgo.Message) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Description: truncate(message.Content, maxDescription),
+		Color:       embedColor,
+		Timestamp:   message.Timestamp.Format(time.RFC3339),
+	}
+	if message.Author != nil {
+		name := message.Author.GlobalName
+		if name == "" {
+			name = message.Author.Username
+		}
+		embed.Author = &discordgo.MessageEmbedAuthor{Name: name, IconURL: message.Author.AvatarURL("")}
+	}
+	var files []string
+	for _, attachment := range message.Attachments {
+		if embed.Image == nil && strings.HasPrefix(attachment.ContentType, "image/") {
+			embed.Image = &discordgo.MessageEmbedImage{URL: attachment.URL}
+			continue
+		}
+		files = append(files, fmt.Sprintf("[%s](%s)", attachment.Filename, attachment.URL))
+	}
+	if len(files) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Attachments",
+			Value: truncate(strings.Join(files, "\n"), 1024),
+		})
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name:  "Source",
+		Value: fmt.Sprintf("[Jump to message](https://discord.com/channels/%s/%s/%s)", message.GuildID, message.ChannelID, message.ID),
+	})
+	return embed
+}
+
+// truncate shortens s to at most limit bytes on a rune boundary, marking it with an ellipsis
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	limit -= len("…")
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit] + "…"
+}
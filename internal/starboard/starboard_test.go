@@ -0,0 +1,153 @@
+package starboard
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+func TestStarboard_GetPost(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`SELECT guild_id, channel_id, message_id, starboard_message_id, count
+FROM starboard_posts
+WHERE message_id = \$1`).
+		WithArgs(uint64(3)).
+		WillReturnRows(pgxmock.NewRows([]string{"guild_id", "channel_id", "message_id", "starboard_message_id", "count"}).
+			AddRow(uint64(1), uint64(2), uint64(3), uint64(4), 5))
+	got, err := New(mockDb).GetPost(context.Background(), 3)
+	require.NoError(t, err)
+	assert.Equal(t, &model.StarboardPost{GuildId: 1, ChannelId: 2, MessageId: 3, StarboardMessageId: 4, Count: 5}, got)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestStarboard_GetPostMissing(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`FROM starboard_posts`).
+		WithArgs(uint64(3)).
+		WillReturnRows(pgxmock.NewRows([]string{"guild_id", "channel_id", "message_id", "starboard_message_id", "count"}))
+	got, err := New(mockDb).GetPost(context.Background(), 3)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestStarboard_GetPostError(t *testing.T) {
+	expectedErr := errors.New("underlying db err")
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`FROM starboard_posts`).WithArgs(uint64(3)).WillReturnError(expectedErr)
+	_, err = New(mockDb).GetPost(context.Background(), 3)
+	assert.ErrorIs(t, err, expectedErr, "expected error not wrapped")
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestStarboard_SavePost(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectExec(`INSERT INTO starboard_posts \(guild_id, channel_id, message_id, starboard_message_id, count\)
+VALUES \(\$1, \$2, \$3, \$4, \$5\)
+ON CONFLICT \(message_id\) DO UPDATE
+SET starboard_message_id = EXCLUDED\.starboard_message_id, count = EXCLUDED\.count`).
+		WithArgs(uint64(1), uint64(2), uint64(3), uint64(4), 5).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	err = New(mockDb).SavePost(context.Background(), model.StarboardPost{GuildId: 1, ChannelId: 2, MessageId: 3, StarboardMessageId: 4, Count: 5})
+	assert.NoError(t, err)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestStarboard_RemovePost(t *testing.T) {
+	expectedErr := errors.New("underlying db err")
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectExec(`DELETE FROM starboard_posts WHERE message_id = \$1`).
+		WithArgs(uint64(3)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mockDb.ExpectExec(`DELETE FROM starboard_posts`).WithArgs(uint64(4)).WillReturnError(expectedErr)
+	starboard := New(mockDb)
+	assert.NoError(t, starboard.RemovePost(context.Background(), 3))
+	assert.ErrorIs(t, starboard.RemovePost(context.Background(), 4), expectedErr, "expected error not wrapped")
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestCount(t *testing.T) {
+	message := &discordgo.Message{Reactions: []*discordgo.MessageReactions{
+		{Count: 2, Emoji: &discordgo.Emoji{Name: "🔥"}},
+		{Count: 4, Emoji: &discordgo.Emoji{Name: "⭐"}},
+		{Count: 7, Emoji: &discordgo.Emoji{Name: "costanza", ID: "1234"}},
+	}}
+	tests := []struct {
+		emoji string
+		want  int
+	}{
+		{"⭐", 4},
+		{"costanza:1234", 7},
+		{"costanza", 0},
+		{"😂", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.emoji, func(t *testing.T) {
+			assert.Equal(t, tt.want, Count(message, tt.emoji))
+		})
+	}
+}
+
+func TestContent(t *testing.T) {
+	message := &discordgo.Message{ChannelID: "300"}
+	assert.Equal(t, "⭐ **3** <#300>", Content(message, "⭐", 3))
+	assert.Equal(t, "<:costanza:1234> **12** <#300>", Content(message, "costanza:1234", 12))
+}
+
+func TestEmbed(t *testing.T) {
+	message := &discordgo.Message{
+		ID:        "500",
+		ChannelID: "300",
+		GuildID:   "100",
+		Content:   "serenity now",
+		Timestamp: time.Date(2024, 3, 14, 15, 30, 0, 0, time.UTC),
+		Author:    &discordgo.User{ID: "200", Username: "frank"},
+		Attachments: []*discordgo.MessageAttachment{
+			{Filename: "notes.txt", URL: "https://cdn.example/notes.txt", ContentType: "text/plain"},
+			{Filename: "festivus.png", URL: "https://cdn.example/festivus.png", ContentType: "image/png"},
+			{Filename: "pole.png", URL: "https://cdn.example/pole.png", ContentType: "image/png"},
+		},
+	}
+	got := Embed(message)
+	assert.Equal(t, "serenity now", got.Description)
+	assert.Equal(t, "2024-03-14T15:30:00Z", got.Timestamp)
+	if assert.NotNil(t, got.Author) {
+		assert.Equal(t, "frank", got.Author.Name)
+	}
+	if assert.NotNil(t, got.Image) {
+		assert.Equal(t, "https://cdn.example/festivus.png", got.Image.URL)
+	}
+	if assert.Len(t, got.Fields, 2) {
+		assert.Equal(t, "[notes.txt](https://cdn.example/notes.txt)\n[pole.png](https://cdn.example/pole.png)", got.Fields[0].Value)
+		assert.Equal(t, "[Jump to message](https://discord.com/channels/100/300/500)", got.Fields[1].Value)
+	}
+
+	message.Author.GlobalName = "Frank Costanza"
+	message.Attachments = nil
+	message.Content = strings.Repeat("é", maxDescription)
+	got = Embed(message)
+	assert.Equal(t, "Frank Costanza", got.Author.Name)
+	assert.Nil(t, got.Image)
+	assert.Len(t, got.Fields, 1)
+	assert.LessOrEqual(t, len(got.Description), maxDescription)
+	assert.True(t, strings.HasSuffix(got.Description, "é…"), "not truncated on a rune boundary")
+}
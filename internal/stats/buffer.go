@@ -0,0 +1,164 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+const flushTimeout = time.Second * 10
+
+// bufferedCounterUpsertQuery is counterUpsertQuery, but skips users removed after the increments were buffered
+const bufferedCounterUpsertQuery = `
+INSERT INTO stat_counters AS sc (metric, guild_id, user_id, period, channel_id, value)
+SELECT $1::VARCHAR, $2::NUMERIC, $3::NUMERIC, $4::VARCHAR, $5::NUMERIC, $6::INTEGER
+WHERE NOT EXISTS (SELECT 1 FROM stat_removals WHERE guild_id = $2 AND user_id = $3 AND removed_at >= $7)
+ON CONFLICT (metric, guild_id, user_id, period, channel_id) DO UPDATE
+SET value = sc.value + EXCLUDED.value`
+
+type counterKey struct {
+	metric    string
+	guildId   uint64
+	userId    uint64
+	period    string
+	channelId uint64
+}
+
+func keyForCounter(counter Counter) counterKey {
+	return counterKey{
+		metric:    counter.Metric,
+		guildId:   counter.GuildId,
+		userId:    counter.UserId,
+		period:    counter.Period,
+		channelId: counter.ChannelId,
+	}
+}
+
+// writeBuffer coalesces counter increments in memory & periodically writes them in a single batch
+type writeBuffer struct {
+	pool          model.DbPool
+	flushInterval time.Duration
+	lock          sync.Mutex
+	pending       map[counterKey]int
+	since         time.Time  // when the oldest pending increment was added, zero when nothing is pending
+	flushing      sync.Mutex // serializes flushes so retried increments aren't reordered
+	done          chan struct{}
+	stopped       sync.WaitGroup
+	closeOnce     sync.Once
+}
+
+func newWriteBuffer(pool model.DbPool, flushInterval time.Duration) *writeBuffer {
+	return &writeBuffer{
+		pool:          pool,
+		flushInterval: flushInterval,
+		pending:       make(map[counterKey]int),
+		done:          make(chan struct{}),
+	}
+}
+
+func (b *writeBuffer) add(key counterKey, count int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.since.IsZero() {
+		b.since = time.Now()
+	}
+	b.pending[key] += count
+}
+
+// take swaps out the pending increments so new increments can accumulate while flushing
+func (b *writeBuffer) take() (map[counterKey]int, time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	pending, since := b.pending, b.since
+	b.pending = make(map[counterKey]int, len(pending))
+	b.since = time.Time{}
+	return pending, since
+}
+
+// restore returns increments that failed to flush to the buffer, keeping the time they were first added
+func (b *writeBuffer) restore(pending map[counterKey]int, since time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for key, count := range pending {
+		b.pending[key] += count
+	}
+	if b.since.IsZero() || since.Before(b.since) {
+		b.since = since
+	}
+}
+
+func (b *writeBuffer) start() {
+	b.stopped.Add(1)
+	go func() {
+		defer b.stopped.Done()
+		ticker := time.NewTicker(b.flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+				if err := b.flush(ctx); err != nil {
+					slog.ErrorContext(ctx, "failed to flush stat buffer: "+err.Error())
+				}
+				cancel()
+			case <-b.done:
+				return
+			}
+		}
+	}()
+}
+
+// flush writes all pending increments in a single batch. The batch runs in an implicit transaction, so on failure all
+// increments are returned to the buffer for the next flush. Increments for users removed since the oldest one was
+// buffered are dropped.
+func (b *writeBuffer) flush(ctx context.Context) error {
+	b.flushing.Lock()
+	defer b.flushing.Unlock()
+	pending, since := b.take()
+	if len(pending) == 0 {
+		return nil
+	}
+	batch, keys := buildCounterBatch(pending, since)
+	results := b.pool.SendBatch(ctx, batch)
+	var err *multierror.Error
+	for _, key := range keys {
+		if _, e := results.Exec(); e != nil {
+			err = multierror.Append(err, fmt.Errorf("failed to flush %s: %w", key.metric, e))
+		}
+	}
+	if e := results.Close(); e != nil {
+		err = multierror.Append(err, fmt.Errorf("failed to close batch: %w", e))
+	}
+	if err != nil {
+		b.restore(pending, since)
+	}
+	return err.ErrorOrNil()
+}
+
+// close stops the periodic flush & drains anything left in the buffer
+func (b *writeBuffer) close(ctx context.Context) error {
+	b.closeOnce.Do(func() {
+		close(b.done)
+	})
+	b.stopped.Wait()
+	return b.flush(ctx)
+}
+
+// buildCounterBatch creates the batch of upserts for increments pending since the given time. Returned keys are in the
+// same order as the queued queries.
+func buildCounterBatch(pending map[counterKey]int, since time.Time) (*pgx.Batch, []counterKey) {
+	batch := &pgx.Batch{}
+	keys := make([]counterKey, 0, len(pending))
+	for key, count := range pending {
+		batch.Queue(bufferedCounterUpsertQuery, key.metric, key.guildId, key.userId, key.period, key.channelId, count, since.Unix())
+		keys = append(keys, key)
+	}
+	return batch, keys
+}
@@ -0,0 +1,59 @@
+package stats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_writeBuffer_add(t *testing.T) {
+	b := newWriteBuffer(nil, time.Minute)
+	key := counterKey{metric: ReactionsMetric, guildId: 1, userId: 2, period: "2024-01"}
+	b.add(key, 1)
+	b.add(key, 2)
+	assert.Equal(t, 3, b.pending[key], "increments not coalesced")
+}
+
+func Test_writeBuffer_take(t *testing.T) {
+	b := newWriteBuffer(nil, time.Minute)
+	key := counterKey{metric: MessagesMetric, guildId: 1, userId: 2, period: "2024-01"}
+	before := time.Now()
+	b.add(key, 5)
+	got, since := b.take()
+	assert.Equal(t, map[counterKey]int{key: 5}, got)
+	assert.False(t, since.Before(before), "since not set when first added")
+	assert.Empty(t, b.pending, "pending not reset")
+	assert.True(t, b.since.IsZero(), "since not reset")
+}
+
+func Test_writeBuffer_restore(t *testing.T) {
+	b := newWriteBuffer(nil, time.Minute)
+	key := counterKey{metric: MessagesMetric, guildId: 1, userId: 2, period: "2024-01"}
+	b.add(key, 2)
+	older := b.since.Add(-time.Minute)
+	b.restore(map[counterKey]int{key: 5}, older)
+	assert.Equal(t, 7, b.pending[key], "restored increments not coalesced")
+	assert.Equal(t, older, b.since, "oldest increment time not kept")
+}
+
+func Test_writeBuffer_flushEmpty(t *testing.T) {
+	b := newWriteBuffer(nil, time.Minute) // nil pool would panic if a batch was sent
+	assert.NoError(t, b.flush(context.Background()))
+}
+
+func Test_buildCounterBatch(t *testing.T) {
+	pending := map[counterKey]int{
+		{metric: MessagesMetric, guildId: 1, userId: 2, period: "2024-01", channelId: 9}: 7,
+		{metric: CursedPostsMetric, guildId: 1, userId: 3, period: "2024-01"}:            2,
+	}
+	since := time.Unix(1704067200, 0)
+	batch, keys := buildCounterBatch(pending, since)
+	if assert.Len(t, keys, 2) && assert.Equal(t, 2, batch.Len()) {
+		for i, key := range keys {
+			assert.Equal(t, bufferedCounterUpsertQuery, batch.QueuedQueries[i].SQL)
+			assert.Equal(t, []any{key.metric, key.guildId, key.userId, key.period, key.channelId, pending[key], int64(1704067200)}, batch.QueuedQueries[i].Arguments)
+		}
+	}
+}
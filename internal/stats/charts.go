@@ -0,0 +1,214 @@
+package stats
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/dmtaylor/costanza/internal/chart"
+)
+
+// dayKeyLayout is the format of per day counter keys, which are kept alongside report periods for charts
+const dayKeyLayout = "2006-01-02"
+
+// DailyRetentionDays is how long per day counters are kept, enough for a year of monthly trends
+const DailyRetentionDays = 400
+
+// WinRateChart charts daily game wins as a percentage of plays. Other charts are for a counter metric.
+const WinRateChart = "win_rate"
+
+// DayKey gets the per day counter key for t, e.g. 2024-01-31. t should already be in the guild's timezone.
+func DayKey(t time.Time) string {
+	return t.Format(dayKeyLayout)
+}
+
+func isDayKey(period string) bool {
+	return len(period) == len(dayKeyLayout)
+}
+
+// ChartRange is how far back a chart goes
+type ChartRange string
+
+const (
+	WeekRange    ChartRange = "week"
+	MonthRange   ChartRange = "month"
+	QuarterRange ChartRange = "quarter"
+	YearRange    ChartRange = "year"
+)
+
+// ChartRanges is every supported chart range, shortest first
+var ChartRanges = []ChartRange{WeekRange, MonthRange, QuarterRange, YearRange}
+
+// Bucket is the interval each chart value covers
+type Bucket int
+
+const (
+	DayBucket Bucket = iota
+	WeekBucket
+	MonthBucket
+)
+
+// start gets the first day of the bucket containing t
+func (b Bucket) start(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch b {
+	case WeekBucket:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case MonthBucket:
+		return firstOfMonth(day)
+	default:
+		return day
+	}
+}
+
+func (b Bucket) label(start time.Time) string {
+	if b == MonthBucket {
+		return start.Format("Jan 2006")
+	}
+	return start.Format("Jan 2")
+}
+
+func (b Bucket) noun() string {
+	switch b {
+	case WeekBucket:
+		return "week"
+	case MonthBucket:
+		return "month"
+	default:
+		return "day"
+	}
+}
+
+// ChartQuery selects the data for a chart
+type ChartQuery struct {
+	GuildId  uint64
+	UserId   uint64 // Chart a single user's activity, or 0 for the whole guild
+	UserName string // Display name for the user in the chart title
+	Metric   string // Counter metric name or WinRateChart
+	From     time.Time
+	To       time.Time // Last day included in the chart
+	Bucket   Bucket
+}
+
+// NewChartQuery creates a query for the range ending on now. Short ranges are charted per day, a quarter per week & a
+// year per month.
+func NewChartQuery(guildId uint64, metric string, chartRange ChartRange, now time.Time) ChartQuery {
+	query := ChartQuery{GuildId: guildId, Metric: metric, To: now}
+	switch chartRange {
+	case WeekRange:
+		query.From, query.Bucket = now.AddDate(0, 0, -6), DayBucket
+	case QuarterRange:
+		query.From, query.Bucket = WeekBucket.start(now).AddDate(0, 0, -7*12), WeekBucket
+	case YearRange:
+		query.From, query.Bucket = firstOfMonth(now).AddDate(0, -11, 0), MonthBucket
+	default:
+		query.From, query.Bucket = now.AddDate(0, 0, -29), DayBucket
+	}
+	return query
+}
+
+// IsChartMetric checks if name can be charted
+func IsChartMetric(name string) bool {
+	if name == WinRateChart {
+		return true
+	}
+	metric, ok := GetMetric(name)
+	return ok && !metric.Hidden
+}
+
+// BuildChart gets the chart for the query. Every bucket in the range is included, with zero for days without activity.
+func BuildChart(ctx context.Context, store StatsStore, query ChartQuery) (chart.Chart, error) {
+	labels, bucketIndex := chartBuckets(query)
+	who := "the guild"
+	if query.UserId != 0 {
+		who = query.UserName
+	}
+	if query.Metric == WinRateChart {
+		plays, err := dailyTotals(ctx, store, query, GamePlaysMetric, bucketIndex, len(labels))
+		if err != nil {
+			return chart.Chart{}, err
+		}
+		wins, err := dailyTotals(ctx, store, query, GameWinsMetric, bucketIndex, len(labels))
+		if err != nil {
+			return chart.Chart{}, err
+		}
+		rates := make([]float64, len(labels))
+		for i := range rates {
+			if plays[i] > 0 {
+				rates[i] = 100 * wins[i] / plays[i]
+			}
+		}
+		return chart.Chart{
+			Title:   fmt.Sprintf("Daily game win rate per %s for %s", query.Bucket.noun(), who),
+			Kind:    chart.Line,
+			Labels:  labels,
+			Series:  []chart.Series{{Name: "win rate", Values: rates}},
+			Percent: true,
+		}, nil
+	}
+	if _, ok := GetMetric(query.Metric); !ok {
+		return chart.Chart{}, UnknownMetricError(query.Metric)
+	}
+	values, err := dailyTotals(ctx, store, query, query.Metric, bucketIndex, len(labels))
+	if err != nil {
+		return chart.Chart{}, err
+	}
+	kind := chart.Line
+	if query.Bucket == DayBucket {
+		kind = chart.Bar
+	}
+	name := strings.ReplaceAll(query.Metric, "_", " ")
+	return chart.Chart{
+		Title:  fmt.Sprintf("%s per %s for %s", strings.ToUpper(name[:1])+name[1:], query.Bucket.noun(), who),
+		Kind:   kind,
+		Labels: labels,
+		Series: []chart.Series{{Name: name, Values: values}},
+	}, nil
+}
+
+// chartBuckets gets the label for each bucket in the query range, & the bucket index for each day key in the range
+func chartBuckets(query ChartQuery) ([]string, map[string]int) {
+	var labels []string
+	bucketIndex := make(map[string]int)
+	lastStart := time.Time{}
+	for day := query.Bucket.start(query.From); !day.After(query.To); day = day.AddDate(0, 0, 1) {
+		if start := query.Bucket.start(day); !start.Equal(lastStart) {
+			labels = append(labels, query.Bucket.label(start))
+			lastStart = start
+		}
+		bucketIndex[DayKey(day)] = len(labels) - 1
+	}
+	return labels, bucketIndex
+}
+
+// dailyTotals sums the metric's per day counters into buckets
+func dailyTotals(ctx context.Context, store StatsStore, query ChartQuery, metric string, bucketIndex map[string]int, bucketCount int) ([]float64, error) {
+	days, err := store.GetDailyCounters(ctx, metric, query.GuildId, query.UserId, DayKey(query.Bucket.start(query.From)), DayKey(query.To))
+	if err != nil {
+		return nil, err
+	}
+	totals := make([]float64, bucketCount)
+	for _, day := range days {
+		if i, ok := bucketIndex[day.Period]; ok {
+			totals[i] += float64(day.Value)
+		}
+	}
+	return totals, nil
+}
+
+// RenderChart builds the chart for the query as a PNG attachment
+func RenderChart(ctx context.Context, store StatsStore, query ChartQuery) (*discordgo.File, error) {
+	c, err := BuildChart(ctx, store, query)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err = c.RenderPNG(&buf); err != nil {
+		return nil, err
+	}
+	return &discordgo.File{Name: query.Metric + ".png", ContentType: "image/png", Reader: &buf}, nil
+}
@@ -0,0 +1,108 @@
+package stats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/internal/chart"
+)
+
+func TestNewChartQuery(t *testing.T) {
+	// a Thursday
+	now := time.Date(2024, 3, 14, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		chartRange ChartRange
+		wantFrom   time.Time
+		wantBucket Bucket
+	}{
+		{WeekRange, time.Date(2024, 3, 8, 15, 30, 0, 0, time.UTC), DayBucket},
+		{MonthRange, time.Date(2024, 2, 14, 15, 30, 0, 0, time.UTC), DayBucket},
+		{QuarterRange, time.Date(2023, 12, 18, 0, 0, 0, 0, time.UTC), WeekBucket},
+		{YearRange, time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC), MonthBucket},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.chartRange), func(t *testing.T) {
+			got := NewChartQuery(1, MessagesMetric, tt.chartRange, now)
+			assert.Equal(t, tt.wantFrom, got.From)
+			assert.Equal(t, now, got.To)
+			assert.Equal(t, tt.wantBucket, got.Bucket)
+		})
+	}
+}
+
+func TestBuildChart(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	counters := []Counter{
+		{Metric: MessagesMetric, GuildId: 1, UserId: 10, Period: "2024-03-12", Increment: 4},
+		{Metric: MessagesMetric, GuildId: 1, UserId: 11, Period: "2024-03-12", Increment: 2},
+		{Metric: MessagesMetric, GuildId: 1, UserId: 10, Period: "2024-03-14", Increment: 1},
+		// monthly key, other guilds & days out of range aren't charted
+		{Metric: MessagesMetric, GuildId: 1, UserId: 10, Period: "2024-03", Increment: 7},
+		{Metric: MessagesMetric, GuildId: 2, UserId: 10, Period: "2024-03-13", Increment: 50},
+		{Metric: MessagesMetric, GuildId: 1, UserId: 10, Period: "2024-03-07", Increment: 50},
+		{Metric: GamePlaysMetric, GuildId: 1, UserId: 10, Period: "2024-03-12", Increment: 4},
+		{Metric: GameWinsMetric, GuildId: 1, UserId: 10, Period: "2024-03-12", Increment: 3},
+	}
+	for _, counter := range counters {
+		require.NoError(t, m.LogCounter(ctx, counter))
+	}
+	now := time.Date(2024, 3, 14, 15, 30, 0, 0, time.UTC)
+	labels := []string{"Mar 8", "Mar 9", "Mar 10", "Mar 11", "Mar 12", "Mar 13", "Mar 14"}
+
+	query := NewChartQuery(1, MessagesMetric, WeekRange, now)
+	got, err := BuildChart(ctx, m, query)
+	require.NoError(t, err)
+	assert.Equal(t, chart.Chart{
+		Title:  "Messages per day for the guild",
+		Kind:   chart.Bar,
+		Labels: labels,
+		Series: []chart.Series{{Name: "messages", Values: []float64{0, 0, 0, 0, 6, 0, 1}}},
+	}, got)
+
+	query.UserId, query.UserName = 11, "Wendy"
+	got, err = BuildChart(ctx, m, query)
+	require.NoError(t, err)
+	assert.Equal(t, "Messages per day for Wendy", got.Title)
+	assert.Equal(t, []float64{0, 0, 0, 0, 2, 0, 0}, got.Series[0].Values)
+
+	got, err = BuildChart(ctx, m, NewChartQuery(1, WinRateChart, WeekRange, now))
+	require.NoError(t, err)
+	assert.True(t, got.Percent)
+	assert.Equal(t, []float64{0, 0, 0, 0, 75, 0, 0}, got.Series[0].Values)
+
+	got, err = BuildChart(ctx, m, NewChartQuery(1, MessagesMetric, YearRange, now))
+	require.NoError(t, err)
+	assert.Equal(t, chart.Line, got.Kind)
+	if assert.Len(t, got.Labels, 12) {
+		assert.Equal(t, "Apr 2023", got.Labels[0])
+		assert.Equal(t, "Mar 2024", got.Labels[11])
+		assert.Equal(t, float64(57), got.Series[0].Values[11])
+	}
+
+	_, err = BuildChart(ctx, m, NewChartQuery(1, "bread", WeekRange, now))
+	assert.ErrorIs(t, err, UnknownMetricError("bread"))
+}
+
+func TestMemoryStore_RemoveDailyCountersBefore(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	for _, period := range []string{"2023-01-31", "2023-02-01", "2023-01"} {
+		require.NoError(t, m.LogCounter(ctx, Counter{Metric: MessagesMetric, GuildId: 1, UserId: 2, Period: period, Increment: 1}))
+	}
+	require.NoError(t, m.RemoveDailyCountersBefore(ctx, "2023-02-01"))
+
+	got, err := m.GetDailyCounters(ctx, MessagesMetric, 1, 0, "2023-01-01", "2023-12-31")
+	require.NoError(t, err)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, "2023-02-01", got[0].Period)
+	}
+	messages, _ := GetMetric(MessagesMetric)
+	monthly, err := m.GetCounterLeaders(ctx, messages, 1, "2023-01", 5)
+	require.NoError(t, err)
+	assert.Len(t, monthly, 1, "report period counters removed")
+}
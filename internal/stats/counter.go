@@ -0,0 +1,144 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+const counterUpsertQuery = `
+INSERT INTO stat_counters AS sc (metric, guild_id, user_id, period, channel_id, value)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (metric, guild_id, user_id, period, channel_id) DO UPDATE
+SET value = sc.value + EXCLUDED.value`
+
+// Counter is an increment to a tracked metric for a user
+type Counter struct {
+	Metric    string
+	GuildId   uint64
+	UserId    uint64
+	Period    string
+	ChannelId uint64 // Channel the activity happened in, 0 if the metric isn't tracked per channel
+	Increment int
+}
+
+// UnknownMetricError returned when logging a counter for a metric not in the Registry
+type UnknownMetricError string
+
+func (u UnknownMetricError) Error() string {
+	return "unknown metric " + string(u)
+}
+
+// LogCounter increments the counter, either through the write buffer or directly with an atomic upsert
+func (s Stats) LogCounter(ctx context.Context, counter Counter) error {
+	if _, ok := GetMetric(counter.Metric); !ok {
+		return UnknownMetricError(counter.Metric)
+	}
+	if s.buffer != nil {
+		s.buffer.add(keyForCounter(counter), counter.Increment)
+		return nil
+	}
+	_, err := s.pool.Exec(ctx, counterUpsertQuery, counter.Metric, counter.GuildId, counter.UserId, counter.Period, counter.ChannelId, counter.Increment)
+	if err != nil {
+		return fmt.Errorf("failed to increment %s counter: %w", counter.Metric, err)
+	}
+	return nil
+}
+
+// GetCounterLeaders gets the top users for the metric in the period, ordered by value summed across channels less any
+// offset metric
+func (s Stats) GetCounterLeaders(ctx context.Context, metric Metric, guildId uint64, period string, limit int) ([]*model.CounterStat, error) {
+	var results []*model.CounterStat
+	err := pgxscan.Select(ctx, s.pool, &results, `
+WITH totals AS (
+    SELECT metric, user_id, SUM(value) AS value
+    FROM stat_counters
+    WHERE metric IN ($1, $4) AND guild_id = $2 AND period = $3
+    GROUP BY metric, user_id
+)
+SELECT $1::text AS metric, $2::numeric AS guild_id, t.user_id, $3::text AS period, t.value - COALESCE(offset_t.value, 0) AS value
+FROM totals t LEFT OUTER JOIN totals offset_t ON offset_t.metric = $4 AND offset_t.user_id = t.user_id
+WHERE t.metric = $1
+ORDER BY value DESC, t.user_id
+LIMIT $5`, metric.Name, guildId, period, metric.OffsetMetric, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s leaders: %w", metric.Name, err)
+	}
+	return results, nil
+}
+
+// GetChannelLeaders gets the channels with the highest total for the metric in the period
+func (s Stats) GetChannelLeaders(ctx context.Context, metric Metric, guildId uint64, period string, limit int) ([]*model.ChannelStat, error) {
+	var results []*model.ChannelStat
+	err := pgxscan.Select(ctx, s.pool, &results, `
+SELECT metric, guild_id, channel_id, 0::numeric AS user_id, period, SUM(value) AS value
+FROM stat_counters
+WHERE metric = $1 AND guild_id = $2 AND period = $3 AND channel_id <> 0
+GROUP BY metric, guild_id, channel_id, period
+ORDER BY value DESC, channel_id
+LIMIT $4`, metric.Name, guildId, period, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s channel leaders: %w", metric.Name, err)
+	}
+	return results, nil
+}
+
+// GetChannelTopUsers gets the top user in each of the channels with the highest total for the metric in the period,
+// ordered by channel total
+func (s Stats) GetChannelTopUsers(ctx context.Context, metric Metric, guildId uint64, period string, limit int) ([]*model.ChannelStat, error) {
+	var results []*model.ChannelStat
+	err := pgxscan.Select(ctx, s.pool, &results, `
+SELECT metric, guild_id, channel_id, user_id, period, value
+FROM (
+    SELECT metric, guild_id, channel_id, user_id, period, value,
+        SUM(value) OVER (PARTITION BY channel_id) AS channel_total,
+        ROW_NUMBER() OVER (PARTITION BY channel_id ORDER BY value DESC, user_id) AS channel_rank
+    FROM stat_counters
+    WHERE metric = $1 AND guild_id = $2 AND period = $3 AND channel_id <> 0
+) ranked
+WHERE channel_rank = 1
+ORDER BY channel_total DESC, channel_id
+LIMIT $4`, metric.Name, guildId, period, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s channel top users: %w", metric.Name, err)
+	}
+	return results, nil
+}
+
+// RemoveCountersForPeriod deletes all registered counters for the period
+func (s Stats) RemoveCountersForPeriod(ctx context.Context, period string) error {
+	_, err := s.pool.Exec(ctx, "DELETE FROM stat_counters WHERE period = $1 AND metric = ANY($2)", period, registeredMetricNames())
+	if err != nil {
+		return fmt.Errorf("failed to delete counters: %w", err)
+	}
+	return nil
+}
+
+// GetDailyCounters gets the metric's total for each day from & to inclusive that has any activity, ordered by day. Totals
+// are for a single user if userId is set, otherwise the whole guild. Day keys are the only 10 character period keys.
+func (s Stats) GetDailyCounters(ctx context.Context, metric string, guildId uint64, userId uint64, from string, to string) ([]*model.CounterStat, error) {
+	var results []*model.CounterStat
+	err := pgxscan.Select(ctx, s.pool, &results, `
+SELECT metric, guild_id, $3::numeric AS user_id, period, SUM(value) AS value
+FROM stat_counters
+WHERE metric = $1 AND guild_id = $2 AND ($3::numeric = 0 OR user_id = $3::numeric)
+    AND char_length(period) = 10 AND period BETWEEN $4 AND $5
+GROUP BY metric, guild_id, period
+ORDER BY period`, metric, guildId, userId, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily %s counters: %w", metric, err)
+	}
+	return results, nil
+}
+
+// RemoveDailyCountersBefore deletes the per day counters for days before day
+func (s Stats) RemoveDailyCountersBefore(ctx context.Context, day string) error {
+	_, err := s.pool.Exec(ctx, "DELETE FROM stat_counters WHERE char_length(period) = 10 AND period < $1", day)
+	if err != nil {
+		return fmt.Errorf("failed to delete daily counters: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+func TestStats_LogCounter(t *testing.T) {
+	counter := Counter{
+		Metric:    CursedPostsMetric,
+		GuildId:   2345,
+		UserId:    111,
+		Period:    "2024-01",
+		ChannelId: 3456,
+		Increment: 2,
+	}
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectExec(`INSERT INTO stat_counters AS sc \(metric, guild_id, user_id, period, channel_id, value\)
+VALUES \(\$1, \$2, \$3, \$4, \$5, \$6\)
+ON CONFLICT \(metric, guild_id, user_id, period, channel_id\) DO UPDATE
+SET value = sc\.value \+ EXCLUDED\.value`).
+		WithArgs(CursedPostsMetric, uint64(2345), uint64(111), "2024-01", uint64(3456), 2).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	stats := New(mockDb)
+	err = stats.LogCounter(context.Background(), counter)
+	assert.Nil(t, err, "failed upserting counter")
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestStats_LogCounterError(t *testing.T) {
+	expectedErr := errors.New("underlying db err")
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectExec(`INSERT INTO stat_counters`).
+		WithArgs(MessagesMetric, uint64(1), uint64(2), "2024-01", uint64(0), 1).
+		WillReturnError(expectedErr)
+	stats := New(mockDb)
+	err = stats.LogCounter(context.Background(), Counter{Metric: MessagesMetric, GuildId: 1, UserId: 2, Period: "2024-01", Increment: 1})
+	if assert.Error(t, err, "missing error") {
+		assert.ErrorIs(t, err, expectedErr, "expected error not wrapped")
+		assert.EqualError(t, err, "failed to increment messages counter: underlying db err")
+	}
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestStats_LogCounterUnknownMetric(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	stats := New(mockDb)
+	err = stats.LogCounter(context.Background(), Counter{Metric: "bread", GuildId: 1, UserId: 2, Period: "2024-01", Increment: 1})
+	assert.ErrorIs(t, err, UnknownMetricError("bread"))
+	assert.EqualError(t, err, "unknown metric bread")
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unknown metric should not hit the db")
+}
+
+func TestStats_LogCounterBuffered(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build mock")
+	defer mockDb.Close()
+
+	stats := New(mockDb)
+	stats.buffer = newWriteBuffer(mockDb, time.Minute)
+	for range 3 {
+		err = stats.LogCounter(context.Background(), Counter{Metric: MessagesMetric, GuildId: 1, UserId: 2, Period: "2023-01", Increment: 1})
+		assert.Nil(t, err, "got error buffering stat")
+	}
+	err = stats.LogCounter(context.Background(), Counter{Metric: CursedPostsMetric, GuildId: 1, UserId: 2, Period: "2023-01", Increment: 4})
+	assert.Nil(t, err, "got error buffering stat")
+	assert.Equal(t, map[counterKey]int{
+		{metric: MessagesMetric, guildId: 1, userId: 2, period: "2023-01"}:    3,
+		{metric: CursedPostsMetric, guildId: 1, userId: 2, period: "2023-01"}: 4,
+	}, stats.buffer.pending, "increments not coalesced")
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "buffered stats should not hit the db")
+}
+
+func TestStats_GetCounterLeaders(t *testing.T) {
+	var guildId uint64 = 1000
+	period := "2024-01"
+	reactions, _ := GetMetric(ReactionsMetric)
+	expectedResults := []*model.CounterStat{
+		{Metric: ReactionsMetric, GuildId: guildId, UserId: 1010, Period: period, Value: 20},
+		{Metric: ReactionsMetric, GuildId: guildId, UserId: 1011, Period: period, Value: -3},
+	}
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	rows := mockDb.NewRows([]string{"metric", "guild_id", "user_id", "period", "value"}).
+		AddRow(ReactionsMetric, guildId, uint64(1010), period, 20).
+		AddRow(ReactionsMetric, guildId, uint64(1011), period, -3)
+	mockDb.ExpectQuery(`FROM totals t LEFT OUTER JOIN totals offset_t ON offset_t\.metric = \$4 AND offset_t\.user_id = t\.user_id
+WHERE t\.metric = \$1
+ORDER BY value DESC, t\.user_id
+LIMIT \$5`).
+		WithArgs(ReactionsMetric, guildId, period, MessagesMetric, 5).
+		WillReturnRows(rows)
+	stats := New(mockDb)
+	got, err := stats.GetCounterLeaders(context.Background(), reactions, guildId, period, 5)
+	require.Nil(t, err, "getting counter leaders failed")
+	assert.Equal(t, expectedResults, got, "results don't match")
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestStats_GetCounterLeadersErr(t *testing.T) {
+	expectedErr := errors.New("underlying db err")
+	messages, _ := GetMetric(MessagesMetric)
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectQuery(`FROM totals t`).
+		WithArgs(MessagesMetric, uint64(999), "2024-01", "", 10).
+		WillReturnError(expectedErr)
+	stats := New(mockDb)
+	res, err := stats.GetCounterLeaders(context.Background(), messages, 999, "2024-01", 10)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+	assert.Nil(t, res)
+	if assert.Error(t, err, "missing error") {
+		assert.ErrorIs(t, err, expectedErr, "expected error not wrapped")
+		assert.EqualError(t, err, "failed to get messages leaders: scany: query multiple result rows: underlying db err")
+	}
+}
+
+func TestStats_GetChannelLeaders(t *testing.T) {
+	var guildId uint64 = 1000
+	period := "2024-01"
+	messages, _ := GetMetric(MessagesMetric)
+	expectedResults := []*model.ChannelStat{
+		{Metric: MessagesMetric, GuildId: guildId, ChannelId: 50, Period: period, Value: 300},
+		{Metric: MessagesMetric, GuildId: guildId, ChannelId: 51, Period: period, Value: 120},
+	}
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	rows := mockDb.NewRows([]string{"metric", "guild_id", "channel_id", "user_id", "period", "value"}).
+		AddRow(MessagesMetric, guildId, uint64(50), uint64(0), period, 300).
+		AddRow(MessagesMetric, guildId, uint64(51), uint64(0), period, 120)
+	mockDb.ExpectQuery(`WHERE metric = \$1 AND guild_id = \$2 AND period = \$3 AND channel_id <> 0
+GROUP BY metric, guild_id, channel_id, period`).
+		WithArgs(MessagesMetric, guildId, period, 3).
+		WillReturnRows(rows)
+	stats := New(mockDb)
+	got, err := stats.GetChannelLeaders(context.Background(), messages, guildId, period, 3)
+	require.Nil(t, err, "getting channel leaders failed")
+	assert.Equal(t, expectedResults, got, "results don't match")
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestStats_GetChannelTopUsers(t *testing.T) {
+	var guildId uint64 = 1000
+	period := "2024-01"
+	messages, _ := GetMetric(MessagesMetric)
+	expectedResults := []*model.ChannelStat{
+		{Metric: MessagesMetric, GuildId: guildId, ChannelId: 50, UserId: 7, Period: period, Value: 90},
+	}
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	rows := mockDb.NewRows([]string{"metric", "guild_id", "channel_id", "user_id", "period", "value"}).
+		AddRow(MessagesMetric, guildId, uint64(50), uint64(7), period, 90)
+	mockDb.ExpectQuery(`WHERE channel_rank = 1
+ORDER BY channel_total DESC, channel_id`).
+		WithArgs(MessagesMetric, guildId, period, 5).
+		WillReturnRows(rows)
+	stats := New(mockDb)
+	got, err := stats.GetChannelTopUsers(context.Background(), messages, guildId, period, 5)
+	require.Nil(t, err, "getting channel top users failed")
+	assert.Equal(t, expectedResults, got, "results don't match")
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestStats_RemoveCountersForPeriod(t *testing.T) {
+	period := "2024-01"
+	db, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build mock db")
+	defer db.Close()
+	db.ExpectExec(`DELETE FROM stat_counters WHERE period = \$1 AND metric = ANY\(\$2\)`).
+		WithArgs(period, []string{MessagesMetric, ReactionsMetric, ReactionsReceivedMetric, CursedChannelPostsMetric, CursedPostsMetric, GamePlaysMetric, GameWinsMetric}).
+		WillReturnResult(pgxmock.NewResult("DELETE", 10))
+	s := Stats{pool: db}
+	err = s.RemoveCountersForPeriod(context.Background(), period)
+	assert.Nil(t, err, "got error when deleting data")
+	assert.Nil(t, db.ExpectationsWereMet(), "unmet mock db expectations")
+}
+
+func TestStats_RemoveCountersForPeriodError(t *testing.T) {
+	expectedErr := errors.New("underlying db err")
+	db, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build mock db")
+	defer db.Close()
+	db.ExpectExec(`DELETE FROM stat_counters`).
+		WithArgs("2024-01", registeredMetricNames()).
+		WillReturnError(expectedErr)
+	s := Stats{pool: db}
+	err = s.RemoveCountersForPeriod(context.Background(), "2024-01")
+	assert.ErrorIs(t, err, expectedErr, "expected error not wrapped")
+	assert.Nil(t, db.ExpectationsWereMet(), "unmet mock db expectations")
+}
+
+func TestStats_GetDailyCounters(t *testing.T) {
+	var guildId uint64 = 1000
+	expectedResults := []*model.CounterStat{
+		{Metric: MessagesMetric, GuildId: guildId, Period: "2024-01-02", Value: 40},
+		{Metric: MessagesMetric, GuildId: guildId, Period: "2024-01-05", Value: 12},
+	}
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	rows := mockDb.NewRows([]string{"metric", "guild_id", "user_id", "period", "value"}).
+		AddRow(MessagesMetric, guildId, uint64(0), "2024-01-02", 40).
+		AddRow(MessagesMetric, guildId, uint64(0), "2024-01-05", 12)
+	mockDb.ExpectQuery(`AND char_length\(period\) = 10 AND period BETWEEN \$4 AND \$5`).
+		WithArgs(MessagesMetric, guildId, uint64(0), "2024-01-01", "2024-01-31").
+		WillReturnRows(rows)
+	stats := New(mockDb)
+	got, err := stats.GetDailyCounters(context.Background(), MessagesMetric, guildId, 0, "2024-01-01", "2024-01-31")
+	require.Nil(t, err, "getting daily counters failed")
+	assert.Equal(t, expectedResults, got, "results don't match")
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestStats_RemoveDailyCountersBefore(t *testing.T) {
+	db, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build mock db")
+	defer db.Close()
+	db.ExpectExec(`DELETE FROM stat_counters WHERE char_length\(period\) = 10 AND period < \$1`).
+		WithArgs("2023-01-01").
+		WillReturnResult(pgxmock.NewResult("DELETE", 40))
+	s := Stats{pool: db}
+	err = s.RemoveDailyCountersBefore(context.Background(), "2023-01-01")
+	assert.Nil(t, err, "got error when deleting data")
+	assert.Nil(t, db.ExpectationsWereMet(), "unmet mock db expectations")
+}
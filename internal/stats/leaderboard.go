@@ -0,0 +1,212 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+// Report section names, in addition to the counter metric names in the Registry
+const (
+	DailyGamesSection     = "daily_games"
+	SpeedDemonsSection    = "speed_demons"
+	ActiveChannelsSection = "active_channels"
+	ChannelPostersSection = "channel_posters"
+	TopEmojiSection       = "top_emoji"
+	TopMessagesSection    = "top_messages"
+)
+
+// DefaultLeaderboardSize number of entries in each leaderboard section when a guild doesn't configure it
+const DefaultLeaderboardSize = 5
+
+// LeaderboardQuery selects the guild, period & number of entries for a leaderboard
+type LeaderboardQuery struct {
+	GuildId   uint64
+	Period    Period
+	PeriodKey string
+	Limit     int
+	Exclude   []uint64 // users who opted out of tracking, left off every section
+}
+
+// fetchLimit gets the number of entries to fetch so Limit are left after removing excluded users
+func (q LeaderboardQuery) fetchLimit() int {
+	return q.Limit + len(q.Exclude)
+}
+
+// without removes the excluded users' entries, keeping up to Limit
+func without[T any](query LeaderboardQuery, results []T, userId func(T) uint64) []T {
+	kept := make([]T, 0, len(results))
+	for _, result := range results {
+		if !slices.Contains(query.Exclude, userId(result)) {
+			kept = append(kept, result)
+		}
+	}
+	return truncate(kept, query.Limit)
+}
+
+// Section is a single part of a leaderboard report
+type Section struct {
+	Name string
+	// build gets the section's content, with no entries if there's nothing to report
+	build func(ctx context.Context, store StatsStore, query LeaderboardQuery) (ReportSection, error)
+}
+
+// Sections gets every report section in report order: registered counters, daily games, channel breakdowns, then
+// reaction highlights
+func Sections() []Section {
+	sections := make([]Section, 0, len(Registry)+6)
+	for _, metric := range Registry {
+		if metric.Hidden {
+			continue
+		}
+		sections = append(sections, Section{
+			Name: metric.Name,
+			build: func(ctx context.Context, store StatsStore, query LeaderboardQuery) (ReportSection, error) {
+				leaders, err := store.GetCounterLeaders(ctx, metric, query.GuildId, query.PeriodKey, query.fetchLimit())
+				leaders = without(query, leaders, func(s *model.CounterStat) uint64 { return s.UserId })
+				if err != nil || len(leaders) < 1 {
+					return ReportSection{}, err
+				}
+				return BuildCounterReport(metric, query.Period, leaders), nil
+			},
+		})
+	}
+	messages, _ := GetMetric(MessagesMetric)
+	return append(sections,
+		Section{
+			Name: DailyGamesSection,
+			build: func(ctx context.Context, store StatsStore, query LeaderboardQuery) (ReportSection, error) {
+				winners, err := store.GetDailyGameLeaders(ctx, query.GuildId, query.PeriodKey, query.fetchLimit())
+				winners = without(query, winners, func(s *model.DailyGameWinStat) uint64 { return s.UserId })
+				if err != nil || len(winners) < 1 {
+					return ReportSection{}, err
+				}
+				return BuildGameWinReport(query.Period, winners), nil
+			},
+		},
+		Section{
+			Name: SpeedDemonsSection,
+			build: func(ctx context.Context, store StatsStore, query LeaderboardQuery) (ReportSection, error) {
+				solvers, err := store.GetDailyGameTimeLeaders(ctx, query.GuildId, query.PeriodKey, query.fetchLimit())
+				solvers = withoutSolvers(query, solvers)
+				if err != nil || len(solvers) < 1 {
+					return ReportSection{}, err
+				}
+				return BuildSpeedDemonReport(query.Period, solvers), nil
+			},
+		},
+		Section{
+			Name: ActiveChannelsSection,
+			build: func(ctx context.Context, store StatsStore, query LeaderboardQuery) (ReportSection, error) {
+				channels, err := store.GetChannelLeaders(ctx, messages, query.GuildId, query.PeriodKey, query.Limit)
+				if err != nil || len(channels) < 1 {
+					return ReportSection{}, err
+				}
+				return BuildActiveChannelReport(query.Period, channels), nil
+			},
+		},
+		Section{
+			Name: ChannelPostersSection,
+			build: func(ctx context.Context, store StatsStore, query LeaderboardQuery) (ReportSection, error) {
+				posters, err := store.GetChannelTopUsers(ctx, messages, query.GuildId, query.PeriodKey, query.fetchLimit())
+				posters = without(query, posters, func(s *model.ChannelStat) uint64 { return s.UserId })
+				if err != nil || len(posters) < 1 {
+					return ReportSection{}, err
+				}
+				return BuildChannelPosterReport(query.Period, posters), nil
+			},
+		},
+		Section{
+			Name: TopEmojiSection,
+			build: func(ctx context.Context, store StatsStore, query LeaderboardQuery) (ReportSection, error) {
+				emoji, err := store.GetTopEmoji(ctx, query.GuildId, query.PeriodKey, query.Limit)
+				if err != nil || len(emoji) < 1 {
+					return ReportSection{}, err
+				}
+				return BuildTopEmojiReport(query.Period, emoji), nil
+			},
+		},
+		Section{
+			Name: TopMessagesSection,
+			build: func(ctx context.Context, store StatsStore, query LeaderboardQuery) (ReportSection, error) {
+				messages, err := store.GetTopMessages(ctx, query.GuildId, query.PeriodKey, query.fetchLimit())
+				messages = without(query, messages, func(s *model.MessageStat) uint64 { return s.AuthorId })
+				if err != nil || len(messages) < 1 {
+					return ReportSection{}, err
+				}
+				return BuildTopMessageReport(query.Period, messages), nil
+			},
+		},
+	)
+}
+
+// withoutSolvers removes the excluded users' solve times, keeping up to Limit for each game
+func withoutSolvers(query LeaderboardQuery, solvers []*model.DailyGameTimeStat) []*model.DailyGameTimeStat {
+	kept := make([]*model.DailyGameTimeStat, 0, len(solvers))
+	perGame := make(map[string]int)
+	for _, solver := range solvers {
+		if slices.Contains(query.Exclude, solver.UserId) || perGame[solver.Game] >= query.Limit {
+			continue
+		}
+		perGame[solver.Game]++
+		kept = append(kept, solver)
+	}
+	return kept
+}
+
+// IsSection checks if name is a known report section
+func IsSection(name string) bool {
+	for _, section := range Sections() {
+		if section.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterSections gets the report sections that are enabled, in report order
+func FilterSections(enabled func(name string) bool) []Section {
+	var sections []Section
+	for _, section := range Sections() {
+		if enabled(section.Name) {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
+
+// BuildLeaderboard builds each section concurrently, returned in section order. Sections with nothing to report are
+// skipped. Sections that succeeded are still returned if others fail.
+func BuildLeaderboard(ctx context.Context, store StatsStore, query LeaderboardQuery, sections []Section) ([]ReportSection, error) {
+	built := make([]ReportSection, len(sections))
+	errs := make([]error, len(sections))
+	var wg sync.WaitGroup
+	for i, section := range sections {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			built[i], errs[i] = section.build(ctx, store, query)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("failed to build %s section: %w", section.Name, errs[i])
+			}
+		}()
+	}
+	wg.Wait()
+	var err *multierror.Error
+	reports := make([]ReportSection, 0, len(sections))
+	for i := range sections {
+		if errs[i] != nil {
+			err = multierror.Append(err, errs[i])
+			continue
+		}
+		if len(built[i].Entries) > 0 {
+			reports = append(reports, built[i])
+		}
+	}
+	return reports, err.ErrorOrNil()
+}
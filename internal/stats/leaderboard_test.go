@@ -0,0 +1,80 @@
+package stats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+func TestIsSection(t *testing.T) {
+	for _, name := range []string{MessagesMetric, CursedPostsMetric, DailyGamesSection, SpeedDemonsSection, ActiveChannelsSection, ChannelPostersSection} {
+		assert.True(t, IsSection(name), "expected %s to be a section", name)
+	}
+	assert.False(t, IsSection("bread"))
+}
+
+func TestFilterSections(t *testing.T) {
+	sections := FilterSections(func(name string) bool {
+		return name == ActiveChannelsSection || name == MessagesMetric
+	})
+	if assert.Len(t, sections, 2) {
+		// report order is kept
+		assert.Equal(t, MessagesMetric, sections[0].Name)
+		assert.Equal(t, ActiveChannelsSection, sections[1].Name)
+	}
+}
+
+func TestBuildLeaderboard(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	for userId := range uint64(3) {
+		require.NoError(t, m.LogCounter(ctx, Counter{Metric: MessagesMetric, GuildId: 1, UserId: userId + 1, Period: "2024-W10", ChannelId: 40, Increment: int(userId) + 1}))
+	}
+	require.NoError(t, m.LogDailyGameActivity(ctx, model.DailyGamePlay{GuildId: 1, UserId: 2, Tries: 3, Win: true}, "2024-W10"))
+
+	query := LeaderboardQuery{GuildId: 1, Period: WeeklyPeriod, PeriodKey: "2024-W10", Limit: 2}
+	reports, err := BuildLeaderboard(ctx, m, query, Sections())
+	require.NoError(t, err)
+	got := make([]string, len(reports))
+	for i, report := range reports {
+		got[i] = report.Text()
+	}
+	want := []string{
+		"Top posters for the week are:\n#1: <@3> with 3 messages\n#2: <@2> with 2 messages\n",
+		"Top game winners for the week are:\n#1: <@2> with 1 wins (win rate 100.00%, average guesses 3.00, longest streak 1)\n",
+		"Most active channels for the week are:\n#1: <#40> with 6 messages\n",
+		"Top posters by channel for the week are:\n<#40>: <@3> with 3 messages\n",
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestBuildLeaderboardExclude(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	for userId := range uint64(3) {
+		require.NoError(t, m.LogCounter(ctx, Counter{Metric: MessagesMetric, GuildId: 1, UserId: userId + 1, Period: "2024-W10", ChannelId: 40, Increment: int(userId) + 1}))
+		require.NoError(t, m.LogDailyGameTime(ctx, model.DailyGameTimePlay{GuildId: 1, UserId: userId + 1, Game: "Mini", Duration: time.Duration(userId+1) * time.Minute}, "2024-W10"))
+	}
+	require.NoError(t, m.LogDailyGameActivity(ctx, model.DailyGamePlay{GuildId: 1, UserId: 3, Tries: 3, Win: true}, "2024-W10"))
+	require.NoError(t, m.LogReaction(ctx, Reaction{GuildId: 1, Period: "2024-W10", ChannelId: 40, MessageId: 500, AuthorId: 3, Emoji: "🔥", Increment: 2}))
+
+	query := LeaderboardQuery{GuildId: 1, Period: WeeklyPeriod, PeriodKey: "2024-W10", Limit: 2, Exclude: []uint64{3}}
+	reports, err := BuildLeaderboard(ctx, m, query, Sections())
+	require.NoError(t, err)
+	got := make([]string, len(reports))
+	for i, report := range reports {
+		got[i] = report.Text()
+	}
+	want := []string{
+		"Top posters for the week are:\n#1: <@2> with 2 messages\n#2: <@1> with 1 messages\n",
+		"Speed demons for the week are:\nMini:\n#1: <@1> with fastest 1:00, median 1:00 over 1 plays\n#2: <@2> with fastest 2:00, median 2:00 over 1 plays\n",
+		"Most active channels for the week are:\n#1: <#40> with 6 messages\n",
+		"Top reaction emoji for the week are:\n#1: 🔥 used 2 times\n",
+	}
+	assert.Equal(t, want, got, "excluded users are left off, but still count towards guild totals")
+}
@@ -0,0 +1,557 @@
+package stats
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+type gameStatKey struct {
+	guildId uint64
+	userId  uint64
+	period  string
+}
+
+type reactionKey struct {
+	guildId   uint64
+	period    string
+	channelId uint64
+	messageId uint64
+	authorId  uint64
+	emoji     string
+}
+
+type gameTimeKey struct {
+	guildId uint64
+	userId  uint64
+	period  string
+	game    string
+}
+
+type gamePlayKey struct {
+	guildId uint64
+	userId  uint64
+	period  string
+	game    string
+}
+
+type rollupKey struct {
+	guildId uint64
+	userId  uint64
+	period  string
+	stat    string
+}
+
+// MemoryStore is an in-process StatsStore with the same semantics as the Postgres backed Stats. Data is lost on restart,
+// so it's meant for tests & local development. It only replaces the stats tables, the bot still needs Postgres for
+// everything else.
+type MemoryStore struct {
+	lock      sync.RWMutex
+	counters  map[counterKey]int
+	gameStats map[gameStatKey]*model.DailyGameWinStat
+	gameTimes map[gameTimeKey][]int
+	reactions map[reactionKey]int
+	gamePlays map[gamePlayKey]int
+	rollups   map[rollupKey]int
+	nextId    uint
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters:  make(map[counterKey]int),
+		gameStats: make(map[gameStatKey]*model.DailyGameWinStat),
+		gameTimes: make(map[gameTimeKey][]int),
+		reactions: make(map[reactionKey]int),
+		gamePlays: make(map[gamePlayKey]int),
+		rollups:   make(map[rollupKey]int),
+	}
+}
+
+func (m *MemoryStore) LogCounter(_ context.Context, counter Counter) error {
+	if _, ok := GetMetric(counter.Metric); !ok {
+		return UnknownMetricError(counter.Metric)
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.counters[keyForCounter(counter)] += counter.Increment
+	return nil
+}
+
+func (m *MemoryStore) GetCounterLeaders(_ context.Context, metric Metric, guildId uint64, period string, limit int) ([]*model.CounterStat, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	totals := make(map[uint64]int)
+	offsets := make(map[uint64]int)
+	for key, value := range m.counters {
+		if key.guildId != guildId || key.period != period {
+			continue
+		}
+		switch key.metric {
+		case metric.Name:
+			totals[key.userId] += value
+		case metric.OffsetMetric:
+			offsets[key.userId] += value
+		}
+	}
+	results := make([]*model.CounterStat, 0, len(totals))
+	for userId, value := range totals {
+		results = append(results, &model.CounterStat{
+			Metric:  metric.Name,
+			GuildId: guildId,
+			UserId:  userId,
+			Period:  period,
+			Value:   value - offsets[userId],
+		})
+	}
+	slices.SortFunc(results, func(a, b *model.CounterStat) int {
+		return cmp.Or(cmp.Compare(b.Value, a.Value), cmp.Compare(a.UserId, b.UserId))
+	})
+	return truncate(results, limit), nil
+}
+
+func (m *MemoryStore) GetChannelLeaders(_ context.Context, metric Metric, guildId uint64, period string, limit int) ([]*model.ChannelStat, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	totals := m.channelTotals(metric, guildId, period)
+	results := make([]*model.ChannelStat, 0, len(totals))
+	for channelId, value := range totals {
+		results = append(results, &model.ChannelStat{
+			Metric:    metric.Name,
+			GuildId:   guildId,
+			ChannelId: channelId,
+			Period:    period,
+			Value:     value,
+		})
+	}
+	slices.SortFunc(results, func(a, b *model.ChannelStat) int {
+		return cmp.Or(cmp.Compare(b.Value, a.Value), cmp.Compare(a.ChannelId, b.ChannelId))
+	})
+	return truncate(results, limit), nil
+}
+
+func (m *MemoryStore) GetChannelTopUsers(_ context.Context, metric Metric, guildId uint64, period string, limit int) ([]*model.ChannelStat, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	totals := m.channelTotals(metric, guildId, period)
+	topUsers := make(map[uint64]*model.ChannelStat, len(totals))
+	for key, value := range m.counters {
+		if key.metric != metric.Name || key.guildId != guildId || key.period != period || key.channelId == 0 {
+			continue
+		}
+		top, ok := topUsers[key.channelId]
+		if !ok || value > top.Value || (value == top.Value && key.userId < top.UserId) {
+			topUsers[key.channelId] = &model.ChannelStat{
+				Metric:    metric.Name,
+				GuildId:   guildId,
+				ChannelId: key.channelId,
+				UserId:    key.userId,
+				Period:    period,
+				Value:     value,
+			}
+		}
+	}
+	results := slices.Collect(maps.Values(topUsers))
+	slices.SortFunc(results, func(a, b *model.ChannelStat) int {
+		return cmp.Or(cmp.Compare(totals[b.ChannelId], totals[a.ChannelId]), cmp.Compare(a.ChannelId, b.ChannelId))
+	})
+	return truncate(results, limit), nil
+}
+
+// channelTotals sums the metric per channel. Caller must hold the lock.
+func (m *MemoryStore) channelTotals(metric Metric, guildId uint64, period string) map[uint64]int {
+	totals := make(map[uint64]int)
+	for key, value := range m.counters {
+		if key.metric == metric.Name && key.guildId == guildId && key.period == period && key.channelId != 0 {
+			totals[key.channelId] += value
+		}
+	}
+	return totals
+}
+
+func (m *MemoryStore) RemoveCountersForPeriod(_ context.Context, period string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key := range m.counters {
+		if key.period == period {
+			delete(m.counters, key)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) GetDailyCounters(_ context.Context, metric string, guildId uint64, userId uint64, from string, to string) ([]*model.CounterStat, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	totals := make(map[string]int)
+	for key, value := range m.counters {
+		if key.metric != metric || key.guildId != guildId || (userId != 0 && key.userId != userId) {
+			continue
+		}
+		if isDayKey(key.period) && key.period >= from && key.period <= to {
+			totals[key.period] += value
+		}
+	}
+	results := make([]*model.CounterStat, 0, len(totals))
+	for _, day := range slices.Sorted(maps.Keys(totals)) {
+		results = append(results, &model.CounterStat{
+			Metric:  metric,
+			GuildId: guildId,
+			UserId:  userId,
+			Period:  day,
+			Value:   totals[day],
+		})
+	}
+	return results, nil
+}
+
+func (m *MemoryStore) RemoveDailyCountersBefore(_ context.Context, day string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key := range m.counters {
+		if isDayKey(key.period) && key.period < day {
+			delete(m.counters, key)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) LogReaction(_ context.Context, reaction Reaction) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	key := reactionKey{
+		guildId:   reaction.GuildId,
+		period:    reaction.Period,
+		channelId: reaction.ChannelId,
+		messageId: reaction.MessageId,
+		authorId:  reaction.AuthorId,
+		emoji:     reaction.Emoji,
+	}
+	m.reactions[key] += reaction.Increment
+	return nil
+}
+
+func (m *MemoryStore) GetTopEmoji(_ context.Context, guildId uint64, period string, limit int) ([]*model.EmojiStat, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	totals := make(map[string]int)
+	for key, value := range m.reactions {
+		if key.guildId == guildId && key.period == period {
+			totals[key.emoji] += value
+		}
+	}
+	var results []*model.EmojiStat
+	for emoji, value := range totals {
+		if value > 0 {
+			results = append(results, &model.EmojiStat{GuildId: guildId, Period: period, Emoji: emoji, Value: value})
+		}
+	}
+	slices.SortFunc(results, func(a, b *model.EmojiStat) int {
+		return cmp.Or(cmp.Compare(b.Value, a.Value), cmp.Compare(a.Emoji, b.Emoji))
+	})
+	return truncate(results, limit), nil
+}
+
+func (m *MemoryStore) GetTopMessages(_ context.Context, guildId uint64, period string, limit int) ([]*model.MessageStat, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	totals := make(map[uint64]*model.MessageStat)
+	for key, value := range m.reactions {
+		if key.guildId != guildId || key.period != period {
+			continue
+		}
+		stat, ok := totals[key.messageId]
+		if !ok {
+			stat = &model.MessageStat{GuildId: guildId, Period: period, ChannelId: key.channelId, MessageId: key.messageId, AuthorId: key.authorId}
+			totals[key.messageId] = stat
+		}
+		stat.Value += value
+	}
+	var results []*model.MessageStat
+	for _, stat := range totals {
+		if stat.Value > 0 {
+			results = append(results, stat)
+		}
+	}
+	slices.SortFunc(results, func(a, b *model.MessageStat) int {
+		return cmp.Or(cmp.Compare(b.Value, a.Value), cmp.Compare(a.MessageId, b.MessageId))
+	})
+	return truncate(results, limit), nil
+}
+
+func (m *MemoryStore) RemoveReactionsForPeriod(_ context.Context, period string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key := range m.reactions {
+		if key.period == period {
+			delete(m.reactions, key)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) LogDailyGameActivity(_ context.Context, gamePlay model.DailyGamePlay, period string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	key := gameStatKey{guildId: gamePlay.GuildId, userId: gamePlay.UserId, period: period}
+	stat, ok := m.gameStats[key]
+	if !ok {
+		m.nextId++
+		stat = &model.DailyGameWinStat{
+			Id:      m.nextId,
+			GuildId: gamePlay.GuildId,
+			UserId:  gamePlay.UserId,
+			Period:  period,
+		}
+		m.gameStats[key] = stat
+	}
+	stat.PlayCount++
+	stat.GuessCount += int(gamePlay.Tries)
+	if gamePlay.Win {
+		stat.WinCount++
+		stat.CurrentStreak++
+		stat.MaxStreak = max(stat.MaxStreak, stat.CurrentStreak)
+	} else {
+		stat.CurrentStreak = 0
+	}
+	return nil
+}
+
+func (m *MemoryStore) GetDailyGameLeaders(_ context.Context, guildId uint64, period string, limit int) ([]*model.DailyGameWinStat, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	var results []*model.DailyGameWinStat
+	for key, stat := range m.gameStats {
+		if key.guildId == guildId && key.period == period {
+			statCopy := *stat
+			results = append(results, &statCopy)
+		}
+	}
+	slices.SortFunc(results, func(a, b *model.DailyGameWinStat) int {
+		return cmp.Or(cmp.Compare(b.WinCount, a.WinCount), cmp.Compare(a.UserId, b.UserId))
+	})
+	return truncate(results, limit), nil
+}
+
+func (m *MemoryStore) RemoveDailyGameLeadersForPeriod(_ context.Context, period string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key := range m.gameStats {
+		if key.period == period {
+			delete(m.gameStats, key)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) LogDailyGameTime(_ context.Context, gamePlay model.DailyGameTimePlay, period string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	key := gameTimeKey{guildId: gamePlay.GuildId, userId: gamePlay.UserId, period: period, game: gamePlay.Game}
+	m.gameTimes[key] = append(m.gameTimes[key], int(gamePlay.Duration.Seconds()))
+	return nil
+}
+
+func (m *MemoryStore) GetDailyGameTimeLeaders(_ context.Context, guildId uint64, period string, limit int) ([]*model.DailyGameTimeStat, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	byGame := make(map[string][]*model.DailyGameTimeStat)
+	for key, times := range m.gameTimes {
+		if key.guildId != guildId || key.period != period || len(times) == 0 {
+			continue
+		}
+		sorted := slices.Sorted(slices.Values(times))
+		byGame[key.game] = append(byGame[key.game], &model.DailyGameTimeStat{
+			GuildId:        key.guildId,
+			UserId:         key.userId,
+			Period:         key.period,
+			Game:           key.game,
+			PlayCount:      len(sorted),
+			FastestSeconds: sorted[0],
+			MedianSeconds:  median(sorted),
+		})
+	}
+	var results []*model.DailyGameTimeStat
+	for _, game := range slices.Sorted(maps.Keys(byGame)) {
+		gameStats := byGame[game]
+		slices.SortFunc(gameStats, func(a, b *model.DailyGameTimeStat) int {
+			return cmp.Or(
+				cmp.Compare(a.MedianSeconds, b.MedianSeconds),
+				cmp.Compare(a.FastestSeconds, b.FastestSeconds),
+				cmp.Compare(a.UserId, b.UserId),
+			)
+		})
+		results = append(results, truncate(gameStats, limit)...)
+	}
+	return results, nil
+}
+
+func (m *MemoryStore) RemoveDailyGameTimesForPeriod(_ context.Context, period string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key := range m.gameTimes {
+		if key.period == period {
+			delete(m.gameTimes, key)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) LogGamePlay(_ context.Context, guildId uint64, userId uint64, game string, period string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.gamePlays[gamePlayKey{guildId: guildId, userId: userId, period: period, game: game}]++
+	return nil
+}
+
+func (m *MemoryStore) RemoveGamePlaysForPeriod(_ context.Context, period string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key := range m.gamePlays {
+		if key.period == period {
+			delete(m.gamePlays, key)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) RollupPeriod(_ context.Context, period string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	maps.Copy(m.rollups, m.periodTotals(period, func(uint64) bool { return true }))
+	return nil
+}
+
+// periodTotals gets each user's stats for the period in guilds matching the filter, keyed as they're rolled up.
+// Callers must hold the lock.
+func (m *MemoryStore) periodTotals(period string, guild func(uint64) bool) map[rollupKey]int {
+	totals := make(map[rollupKey]int)
+	for key, value := range m.counters {
+		if key.period == period && guild(key.guildId) && slices.Contains(rollupMetrics, key.metric) {
+			totals[rollupKey{guildId: key.guildId, userId: key.userId, period: period, stat: key.metric}] += value
+		}
+	}
+	for key, stat := range m.gameStats {
+		if key.period == period && guild(key.guildId) {
+			totals[rollupKey{guildId: key.guildId, userId: key.userId, period: period, stat: GameWinsMetric}] = stat.WinCount
+			totals[rollupKey{guildId: key.guildId, userId: key.userId, period: period, stat: MaxStreakStat}] = stat.MaxStreak
+		}
+	}
+	for key, plays := range m.gamePlays {
+		if key.period == period && guild(key.guildId) {
+			totals[rollupKey{guildId: key.guildId, userId: key.userId, period: period, stat: gameStatPrefix + key.game}] = plays
+		}
+	}
+	return totals
+}
+
+func (m *MemoryStore) GetPendingRollups(_ context.Context, guildId uint64, year string) ([]*model.RollupStat, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	rolledUp := make(map[string]bool)
+	for key := range m.rollups {
+		if key.guildId == guildId {
+			rolledUp[key.period] = true
+		}
+	}
+	var results []*model.RollupStat
+	for _, month := range yearMonths(year) {
+		if rolledUp[month] {
+			continue
+		}
+		for key, value := range m.periodTotals(month, func(id uint64) bool { return id == guildId }) {
+			results = append(results, &model.RollupStat{GuildId: guildId, UserId: key.userId, Period: month, Stat: key.stat, Value: value})
+		}
+	}
+	slices.SortFunc(results, func(a, b *model.RollupStat) int {
+		return cmp.Or(cmp.Compare(a.Period, b.Period), cmp.Compare(a.UserId, b.UserId), cmp.Compare(a.Stat, b.Stat))
+	})
+	return results, nil
+}
+
+func (m *MemoryStore) GetYearlyRollups(_ context.Context, guildId uint64, year string) ([]*model.RollupStat, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	totals := make(map[rollupKey]*model.RollupStat)
+	for key, value := range m.rollups {
+		if key.guildId != guildId || !strings.HasPrefix(key.period, year+"-") {
+			continue
+		}
+		yearKey := rollupKey{guildId: guildId, userId: key.userId, period: year, stat: key.stat}
+		stat, ok := totals[yearKey]
+		if !ok {
+			stat = &model.RollupStat{GuildId: guildId, UserId: key.userId, Period: year, Stat: key.stat}
+			totals[yearKey] = stat
+		}
+		if key.stat == MaxStreakStat {
+			stat.Value = max(stat.Value, value)
+		} else {
+			stat.Value += value
+		}
+	}
+	results := slices.Collect(maps.Values(totals))
+	slices.SortFunc(results, func(a, b *model.RollupStat) int {
+		return cmp.Or(cmp.Compare(a.UserId, b.UserId), cmp.Compare(a.Stat, b.Stat))
+	})
+	return results, nil
+}
+
+func (m *MemoryStore) RemoveUser(_ context.Context, guildId uint64, userId uint64) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key := range m.counters {
+		if key.guildId == guildId && key.userId == userId {
+			delete(m.counters, key)
+		}
+	}
+	for key := range m.gameStats {
+		if key.guildId == guildId && key.userId == userId {
+			delete(m.gameStats, key)
+		}
+	}
+	for key := range m.gameTimes {
+		if key.guildId == guildId && key.userId == userId {
+			delete(m.gameTimes, key)
+		}
+	}
+	for key := range m.reactions {
+		if key.guildId == guildId && key.authorId == userId {
+			delete(m.reactions, key)
+		}
+	}
+	for key := range m.gamePlays {
+		if key.guildId == guildId && key.userId == userId {
+			delete(m.gamePlays, key)
+		}
+	}
+	for key := range m.rollups {
+		if key.guildId == guildId && key.userId == userId {
+			delete(m.rollups, key)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) Close(_ context.Context) error {
+	return nil
+}
+
+// median matches postgres percentile_cont(0.5) for sorted, non-empty values
+func median(sorted []int) float64 {
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return float64(sorted[mid])
+	}
+	return float64(sorted[mid-1]+sorted[mid]) / 2
+}
+
+func truncate[T any](results []T, limit int) []T {
+	if len(results) > limit {
+		return results[:limit]
+	}
+	return results
+}
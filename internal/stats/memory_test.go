@@ -0,0 +1,256 @@
+package stats
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+func TestMemoryStore_LogCounterUnknownMetric(t *testing.T) {
+	m := NewMemoryStore()
+	err := m.LogCounter(context.Background(), Counter{Metric: "bogus", GuildId: 1, UserId: 2, Period: "2024-01", Increment: 1})
+	assert.ErrorIs(t, err, UnknownMetricError("bogus"))
+}
+
+func TestMemoryStore_GetCounterLeaders(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	counts := map[uint64]int{1: 3, 2: 10, 3: 7, 4: 7, 5: 1, 6: 2}
+	for userId, count := range counts {
+		for range count {
+			require.NoError(t, m.LogCounter(ctx, Counter{Metric: MessagesMetric, GuildId: 99, UserId: userId, Period: "2024-01", Increment: 1}))
+		}
+	}
+	// different guild & period shouldn't show up
+	require.NoError(t, m.LogCounter(ctx, Counter{Metric: MessagesMetric, GuildId: 98, UserId: 1, Period: "2024-01", Increment: 50}))
+	require.NoError(t, m.LogCounter(ctx, Counter{Metric: MessagesMetric, GuildId: 99, UserId: 1, Period: "2024-02", Increment: 50}))
+
+	messages, _ := GetMetric(MessagesMetric)
+	got, err := m.GetCounterLeaders(ctx, messages, 99, "2024-01", 5)
+	require.NoError(t, err)
+	want := []*model.CounterStat{
+		{Metric: MessagesMetric, GuildId: 99, UserId: 2, Period: "2024-01", Value: 10},
+		{Metric: MessagesMetric, GuildId: 99, UserId: 3, Period: "2024-01", Value: 7},
+		{Metric: MessagesMetric, GuildId: 99, UserId: 4, Period: "2024-01", Value: 7},
+		{Metric: MessagesMetric, GuildId: 99, UserId: 1, Period: "2024-01", Value: 3},
+		{Metric: MessagesMetric, GuildId: 99, UserId: 6, Period: "2024-01", Value: 2},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestMemoryStore_GetCounterLeadersOffset(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	require.NoError(t, m.LogCounter(ctx, Counter{Metric: ReactionsMetric, GuildId: 1, UserId: 10, Period: "2024-01", Increment: 20}))
+	require.NoError(t, m.LogCounter(ctx, Counter{Metric: MessagesMetric, GuildId: 1, UserId: 10, Period: "2024-01", Increment: 5}))
+	require.NoError(t, m.LogCounter(ctx, Counter{Metric: ReactionsMetric, GuildId: 1, UserId: 11, Period: "2024-01", Increment: 8}))
+	require.NoError(t, m.LogCounter(ctx, Counter{Metric: ReactionsMetric, GuildId: 1, UserId: 12, Period: "2024-01", Increment: 2}))
+	require.NoError(t, m.LogCounter(ctx, Counter{Metric: MessagesMetric, GuildId: 1, UserId: 12, Period: "2024-01", Increment: 9}))
+
+	reactions, _ := GetMetric(ReactionsMetric)
+	got, err := m.GetCounterLeaders(ctx, reactions, 1, "2024-01", 5)
+	require.NoError(t, err)
+	want := []*model.CounterStat{
+		{Metric: ReactionsMetric, GuildId: 1, UserId: 10, Period: "2024-01", Value: 15},
+		{Metric: ReactionsMetric, GuildId: 1, UserId: 11, Period: "2024-01", Value: 8},
+		{Metric: ReactionsMetric, GuildId: 1, UserId: 12, Period: "2024-01", Value: -7},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestMemoryStore_RemoveCountersForPeriod(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	require.NoError(t, m.LogCounter(ctx, Counter{Metric: CursedPostsMetric, GuildId: 1, UserId: 2, Period: "2024-01", Increment: 1}))
+	require.NoError(t, m.LogCounter(ctx, Counter{Metric: CursedPostsMetric, GuildId: 1, UserId: 2, Period: "2024-02", Increment: 1}))
+	require.NoError(t, m.RemoveCountersForPeriod(ctx, "2024-01"))
+
+	cursed, _ := GetMetric(CursedPostsMetric)
+	got, err := m.GetCounterLeaders(ctx, cursed, 1, "2024-01", 5)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+	got, err = m.GetCounterLeaders(ctx, cursed, 1, "2024-02", 5)
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+}
+
+func TestMemoryStore_LogDailyGameActivity(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	plays := []model.DailyGamePlay{
+		{GuildId: 1, UserId: 2, Tries: 3, Win: true},
+		{GuildId: 1, UserId: 2, Tries: 4, Win: true},
+		{GuildId: 1, UserId: 2, Tries: 6, Win: false},
+		{GuildId: 1, UserId: 2, Tries: 2, Win: true},
+	}
+	for _, play := range plays {
+		require.NoError(t, m.LogDailyGameActivity(ctx, play, "2023-10"))
+	}
+	got, err := m.GetDailyGameLeaders(ctx, 1, "2023-10", 5)
+	require.NoError(t, err)
+	want := []*model.DailyGameWinStat{
+		{
+			Id:            1,
+			GuildId:       1,
+			UserId:        2,
+			Period:        "2023-10",
+			PlayCount:     4,
+			GuessCount:    15,
+			WinCount:      3,
+			CurrentStreak: 1,
+			MaxStreak:     2,
+		},
+	}
+	assert.Equal(t, want, got)
+
+	require.NoError(t, m.RemoveDailyGameLeadersForPeriod(ctx, "2023-10"))
+	got, err = m.GetDailyGameLeaders(ctx, 1, "2023-10", 5)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestMemoryStore_GetDailyGameTimeLeaders(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	times := []struct {
+		userId  uint64
+		game    string
+		seconds int
+	}{
+		{1, "Queens", 80},
+		{2, "Mini", 30},
+		{2, "Mini", 50},
+		{3, "Mini", 20},
+		{3, "Mini", 90},
+		{3, "Mini", 45},
+	}
+	for _, tt := range times {
+		play := model.DailyGameTimePlay{GuildId: 7, UserId: tt.userId, Game: tt.game, Duration: time.Duration(tt.seconds) * time.Second}
+		require.NoError(t, m.LogDailyGameTime(ctx, play, "2024-07"))
+	}
+	got, err := m.GetDailyGameTimeLeaders(ctx, 7, "2024-07", 5)
+	require.NoError(t, err)
+	want := []*model.DailyGameTimeStat{
+		{GuildId: 7, UserId: 2, Period: "2024-07", Game: "Mini", PlayCount: 2, FastestSeconds: 30, MedianSeconds: 40},
+		{GuildId: 7, UserId: 3, Period: "2024-07", Game: "Mini", PlayCount: 3, FastestSeconds: 20, MedianSeconds: 45},
+		{GuildId: 7, UserId: 1, Period: "2024-07", Game: "Queens", PlayCount: 1, FastestSeconds: 80, MedianSeconds: 80},
+	}
+	assert.Equal(t, want, got)
+
+	require.NoError(t, m.RemoveDailyGameTimesForPeriod(ctx, "2024-07"))
+	got, err = m.GetDailyGameTimeLeaders(ctx, 7, "2024-07", 5)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestMemoryStore_ConcurrentLogCounter(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, m.LogCounter(ctx, Counter{Metric: MessagesMetric, GuildId: 1, UserId: 2, Period: "2024-01", Increment: 1}))
+		}()
+	}
+	wg.Wait()
+	messages, _ := GetMetric(MessagesMetric)
+	got, err := m.GetCounterLeaders(ctx, messages, 1, "2024-01", 5)
+	require.NoError(t, err)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, 50, got[0].Value)
+	}
+}
+
+func TestMemoryStore_GetCounterLeadersLimit(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	for userId := range uint64(8) {
+		require.NoError(t, m.LogCounter(ctx, Counter{Metric: MessagesMetric, GuildId: 1, UserId: userId, Period: "2024-01", Increment: int(userId) + 1}))
+	}
+	messages, _ := GetMetric(MessagesMetric)
+	got, err := m.GetCounterLeaders(ctx, messages, 1, "2024-01", 3)
+	require.NoError(t, err)
+	if assert.Len(t, got, 3) {
+		assert.Equal(t, []uint64{7, 6, 5}, []uint64{got[0].UserId, got[1].UserId, got[2].UserId})
+	}
+}
+
+func TestMemoryStore_ChannelStats(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	counters := []Counter{
+		{Metric: MessagesMetric, GuildId: 1, UserId: 10, Period: "2024-01", ChannelId: 100, Increment: 5},
+		{Metric: MessagesMetric, GuildId: 1, UserId: 11, Period: "2024-01", ChannelId: 100, Increment: 3},
+		{Metric: MessagesMetric, GuildId: 1, UserId: 11, Period: "2024-01", ChannelId: 200, Increment: 9},
+		{Metric: MessagesMetric, GuildId: 1, UserId: 12, Period: "2024-01", ChannelId: 300, Increment: 1},
+		// other metrics & periods aren't included
+		{Metric: CursedPostsMetric, GuildId: 1, UserId: 12, Period: "2024-01", Increment: 50},
+		{Metric: MessagesMetric, GuildId: 1, UserId: 12, Period: "2024-02", ChannelId: 300, Increment: 50},
+	}
+	for _, counter := range counters {
+		require.NoError(t, m.LogCounter(ctx, counter))
+	}
+	messages, _ := GetMetric(MessagesMetric)
+
+	// user totals are summed across channels
+	users, err := m.GetCounterLeaders(ctx, messages, 1, "2024-01", 5)
+	require.NoError(t, err)
+	if assert.Len(t, users, 3) {
+		assert.Equal(t, uint64(11), users[0].UserId)
+		assert.Equal(t, 12, users[0].Value)
+	}
+
+	channels, err := m.GetChannelLeaders(ctx, messages, 1, "2024-01", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []*model.ChannelStat{
+		{Metric: MessagesMetric, GuildId: 1, ChannelId: 200, Period: "2024-01", Value: 9},
+		{Metric: MessagesMetric, GuildId: 1, ChannelId: 100, Period: "2024-01", Value: 8},
+	}, channels)
+
+	topUsers, err := m.GetChannelTopUsers(ctx, messages, 1, "2024-01", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []*model.ChannelStat{
+		{Metric: MessagesMetric, GuildId: 1, ChannelId: 200, UserId: 11, Period: "2024-01", Value: 9},
+		{Metric: MessagesMetric, GuildId: 1, ChannelId: 100, UserId: 10, Period: "2024-01", Value: 5},
+		{Metric: MessagesMetric, GuildId: 1, ChannelId: 300, UserId: 12, Period: "2024-01", Value: 1},
+	}, topUsers)
+}
+
+func TestMemoryStore_RemoveUser(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	require.NoError(t, m.LogCounter(ctx, Counter{Metric: MessagesMetric, GuildId: 1, UserId: 10, Period: "2024-01", Increment: 5}))
+	require.NoError(t, m.LogCounter(ctx, Counter{Metric: MessagesMetric, GuildId: 1, UserId: 11, Period: "2024-01", Increment: 3}))
+	require.NoError(t, m.LogCounter(ctx, Counter{Metric: MessagesMetric, GuildId: 2, UserId: 10, Period: "2024-01", Increment: 7}))
+	require.NoError(t, m.LogDailyGameActivity(ctx, model.DailyGamePlay{GuildId: 1, UserId: 10, Tries: 3, Win: true}, "2024-01"))
+	require.NoError(t, m.LogDailyGameTime(ctx, model.DailyGameTimePlay{GuildId: 1, UserId: 10, Game: "Mini", Duration: time.Minute}, "2024-01"))
+	require.NoError(t, m.LogReaction(ctx, Reaction{GuildId: 1, Period: "2024-01", MessageId: 500, AuthorId: 10, Emoji: "🔥", Increment: 2}))
+
+	require.NoError(t, m.RemoveUser(ctx, 1, 10))
+	messages, _ := GetMetric(MessagesMetric)
+	leaders, err := m.GetCounterLeaders(ctx, messages, 1, "2024-01", 5)
+	require.NoError(t, err)
+	if assert.Len(t, leaders, 1) {
+		assert.Equal(t, uint64(11), leaders[0].UserId)
+	}
+	leaders, err = m.GetCounterLeaders(ctx, messages, 2, "2024-01", 5)
+	require.NoError(t, err)
+	assert.Len(t, leaders, 1, "other guilds are kept")
+	winners, err := m.GetDailyGameLeaders(ctx, 1, "2024-01", 5)
+	require.NoError(t, err)
+	assert.Empty(t, winners)
+	solvers, err := m.GetDailyGameTimeLeaders(ctx, 1, "2024-01", 5)
+	require.NoError(t, err)
+	assert.Empty(t, solvers)
+	topMessages, err := m.GetTopMessages(ctx, 1, "2024-01", 5)
+	require.NoError(t, err)
+	assert.Empty(t, topMessages)
+}
@@ -0,0 +1,127 @@
+package stats
+
+import (
+	"fmt"
+	"time"
+)
+
+// Period is a reporting bucket that stats are grouped into
+type Period string
+
+// Supported report periods
+const (
+	WeeklyPeriod    Period = "weekly"
+	MonthlyPeriod   Period = "monthly"
+	QuarterlyPeriod Period = "quarterly"
+	YearlyPeriod    Period = "yearly"
+)
+
+// Periods is every supported report period, shortest first
+var Periods = []Period{WeeklyPeriod, MonthlyPeriod, QuarterlyPeriod, YearlyPeriod}
+
+// InvalidPeriodError returned when parsing an unsupported period name
+type InvalidPeriodError string
+
+func (i InvalidPeriodError) Error() string {
+	return "invalid report period " + string(i)
+}
+
+// ParsePeriod gets the period with the given name
+func ParsePeriod(name string) (Period, error) {
+	for _, p := range Periods {
+		if string(p) == name {
+			return p, nil
+		}
+	}
+	return "", InvalidPeriodError(name)
+}
+
+// Key gets the stored period key containing t, e.g. 2024-W05, 2024-01, 2024-Q1 or 2024. t should already be in the
+// guild's timezone.
+func (p Period) Key(t time.Time) string {
+	switch p {
+	case WeeklyPeriod:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case QuarterlyPeriod:
+		return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+	case YearlyPeriod:
+		return t.Format("2006")
+	default:
+		return t.Format("2006-01")
+	}
+}
+
+// PreviousKey gets the key for the period before the one containing now
+func (p Period) PreviousKey(now time.Time) string {
+	switch p {
+	case WeeklyPeriod:
+		return p.Key(now.AddDate(0, 0, -7))
+	case QuarterlyPeriod:
+		return p.Key(firstOfMonth(now).AddDate(0, -3, 0))
+	case YearlyPeriod:
+		return p.Key(firstOfMonth(now).AddDate(-1, 0, 0))
+	default:
+		return p.Key(firstOfMonth(now).AddDate(0, -1, 0))
+	}
+}
+
+// Noun gets the name of a single period for reports, e.g. "week"
+func (p Period) Noun() string {
+	switch p {
+	case WeeklyPeriod:
+		return "week"
+	case QuarterlyPeriod:
+		return "quarter"
+	case YearlyPeriod:
+		return "year"
+	default:
+		return "month"
+	}
+}
+
+// InvalidPeriodKeyError returned when parsing a stored period key that isn't in any supported format
+type InvalidPeriodKeyError string
+
+func (i InvalidPeriodKeyError) Error() string {
+	return "invalid period key " + string(i)
+}
+
+// PeriodStart gets the first day of a stored period key, including per day keys. The date is in UTC, as keys don't
+// record the guild's timezone.
+func PeriodStart(key string) (time.Time, error) {
+	var year, n int
+	switch {
+	case len(key) == 4:
+		if t, err := time.Parse("2006", key); err == nil {
+			return t, nil
+		}
+	case len(key) == 7 && key[5] == 'Q':
+		if _, err := fmt.Sscanf(key, "%4d-Q%1d", &year, &n); err == nil && n >= 1 && n <= 4 {
+			return time.Date(year, time.Month(3*(n-1)+1), 1, 0, 0, 0, 0, time.UTC), nil
+		}
+	case len(key) == 8 && key[5] == 'W':
+		if _, err := fmt.Sscanf(key, "%4d-W%2d", &year, &n); err == nil && n >= 1 && n <= 53 {
+			// ISO week 1 is the week containing January 4th
+			jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+			start := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+7*(n-1))
+			if y, _ := start.ISOWeek(); y == year {
+				return start, nil
+			}
+		}
+	case len(key) == 7:
+		if t, err := time.Parse("2006-01", key); err == nil {
+			return t, nil
+		}
+	case isDayKey(key):
+		if t, err := time.Parse(dayKeyLayout, key); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, InvalidPeriodKeyError(key)
+}
+
+// firstOfMonth avoids AddDate normalizing e.g. March 31st minus one month into March
+func firstOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
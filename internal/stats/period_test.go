@@ -0,0 +1,92 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePeriod(t *testing.T) {
+	got, err := ParsePeriod("quarterly")
+	assert.NoError(t, err)
+	assert.Equal(t, QuarterlyPeriod, got)
+
+	_, err = ParsePeriod("fortnightly")
+	assert.ErrorIs(t, err, InvalidPeriodError("fortnightly"))
+}
+
+func TestPeriod_Key(t *testing.T) {
+	tests := []struct {
+		name   string
+		period Period
+		t      time.Time
+		want   string
+	}{
+		{"weekly", WeeklyPeriod, time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC), "2024-W05"},
+		{"weekly_iso_year", WeeklyPeriod, time.Date(2024, 12, 30, 12, 0, 0, 0, time.UTC), "2025-W01"},
+		{"monthly", MonthlyPeriod, time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC), "2024-01"},
+		{"quarterly", QuarterlyPeriod, time.Date(2024, 8, 15, 12, 0, 0, 0, time.UTC), "2024-Q3"},
+		{"quarterly_end", QuarterlyPeriod, time.Date(2024, 12, 31, 12, 0, 0, 0, time.UTC), "2024-Q4"},
+		{"yearly", YearlyPeriod, time.Date(2024, 8, 15, 12, 0, 0, 0, time.UTC), "2024"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.period.Key(tt.t))
+		})
+	}
+}
+
+func TestPeriod_PreviousKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		period Period
+		now    time.Time
+		want   string
+	}{
+		{"weekly", WeeklyPeriod, time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), "2023-W52"},
+		{"monthly", MonthlyPeriod, time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC), "2024-02"},
+		{"monthly_late_in_month", MonthlyPeriod, time.Date(2024, 3, 31, 9, 0, 0, 0, time.UTC), "2024-02"},
+		{"monthly_new_year", MonthlyPeriod, time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), "2023-12"},
+		{"quarterly", QuarterlyPeriod, time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC), "2024-Q1"},
+		{"quarterly_new_year", QuarterlyPeriod, time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), "2023-Q4"},
+		{"yearly", YearlyPeriod, time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), "2023"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.period.PreviousKey(tt.now))
+		})
+	}
+}
+
+func TestPeriodStart(t *testing.T) {
+	tests := []struct {
+		key     string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2024", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2024-Q3", time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2024-03", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2024-W05", time.Date(2024, 1, 29, 0, 0, 0, 0, time.UTC), false},
+		{"2025-W01", time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC), false},
+		{"2020-W53", time.Date(2020, 12, 28, 0, 0, 0, 0, time.UTC), false},
+		{"2024-03-15", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), false},
+		{"2024-Q5", time.Time{}, true},
+		{"2024-W53", time.Time{}, true},
+		{"2024-13", time.Time{}, true},
+		{"March", time.Time{}, true},
+		{"", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := PeriodStart(tt.key)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, InvalidPeriodKeyError(tt.key))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
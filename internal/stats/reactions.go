@@ -0,0 +1,87 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+const reactionUpsertQuery = `
+INSERT INTO reaction_counters AS rc (guild_id, period, channel_id, message_id, author_id, emoji, value)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (guild_id, period, message_id, emoji) DO UPDATE
+SET value = rc.value + EXCLUDED.value`
+
+// Reaction is an increment, or decrement for removals, to the count of an emoji on a message
+type Reaction struct {
+	GuildId   uint64
+	Period    string
+	ChannelId uint64
+	MessageId uint64
+	AuthorId  uint64 // Author of the reacted message
+	Emoji     string
+	Increment int
+}
+
+// LogReaction updates the count of the emoji on the message with an atomic upsert
+func (s Stats) LogReaction(ctx context.Context, reaction Reaction) error {
+	_, err := s.pool.Exec(ctx, reactionUpsertQuery,
+		reaction.GuildId,
+		reaction.Period,
+		reaction.ChannelId,
+		reaction.MessageId,
+		reaction.AuthorId,
+		reaction.Emoji,
+		reaction.Increment,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to log reaction: %w", err)
+	}
+	return nil
+}
+
+// GetTopEmoji gets the most used reaction emoji in the period
+func (s Stats) GetTopEmoji(ctx context.Context, guildId uint64, period string, limit int) ([]*model.EmojiStat, error) {
+	var results []*model.EmojiStat
+	err := pgxscan.Select(ctx, s.pool, &results, `
+SELECT guild_id, period, emoji, SUM(value) AS value
+FROM reaction_counters
+WHERE guild_id = $1 AND period = $2
+GROUP BY guild_id, period, emoji
+HAVING SUM(value) > 0
+ORDER BY value DESC, emoji
+LIMIT $3`, guildId, period, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top emoji: %w", err)
+	}
+	return results, nil
+}
+
+// GetTopMessages gets the messages with the most reactions in the period. Ties go to the earlier message.
+func (s Stats) GetTopMessages(ctx context.Context, guildId uint64, period string, limit int) ([]*model.MessageStat, error) {
+	var results []*model.MessageStat
+	err := pgxscan.Select(ctx, s.pool, &results, `
+SELECT guild_id, period, channel_id, message_id, author_id, SUM(value) AS value
+FROM reaction_counters
+WHERE guild_id = $1 AND period = $2
+GROUP BY guild_id, period, channel_id, message_id, author_id
+HAVING SUM(value) > 0
+ORDER BY value DESC, message_id
+LIMIT $3`, guildId, period, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top messages: %w", err)
+	}
+	return results, nil
+}
+
+// RemoveReactionsForPeriod deletes all reaction counts for the period
+func (s Stats) RemoveReactionsForPeriod(ctx context.Context, period string) error {
+	_, err := s.pool.Exec(ctx, "DELETE FROM reaction_counters WHERE period = $1", period)
+	if err != nil {
+		return fmt.Errorf("failed to delete reactions: %w", err)
+	}
+	return nil
+}
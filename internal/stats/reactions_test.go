@@ -0,0 +1,149 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+func TestStats_LogReaction(t *testing.T) {
+	reaction := Reaction{
+		GuildId:   2345,
+		Period:    "2024-01",
+		ChannelId: 3456,
+		MessageId: 4567,
+		AuthorId:  111,
+		Emoji:     "🔥",
+		Increment: -1,
+	}
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectExec(`INSERT INTO reaction_counters AS rc \(guild_id, period, channel_id, message_id, author_id, emoji, value\)
+VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7\)
+ON CONFLICT \(guild_id, period, message_id, emoji\) DO UPDATE
+SET value = rc\.value \+ EXCLUDED\.value`).
+		WithArgs(uint64(2345), "2024-01", uint64(3456), uint64(4567), uint64(111), "🔥", -1).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	stats := New(mockDb)
+	err = stats.LogReaction(context.Background(), reaction)
+	assert.Nil(t, err, "failed upserting reaction")
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestStats_LogReactionError(t *testing.T) {
+	expectedErr := errors.New("underlying db err")
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	mockDb.ExpectExec(`INSERT INTO reaction_counters`).
+		WithArgs(uint64(1), "2024-01", uint64(2), uint64(3), uint64(4), "🔥", 1).
+		WillReturnError(expectedErr)
+	stats := New(mockDb)
+	err = stats.LogReaction(context.Background(), Reaction{GuildId: 1, Period: "2024-01", ChannelId: 2, MessageId: 3, AuthorId: 4, Emoji: "🔥", Increment: 1})
+	assert.ErrorIs(t, err, expectedErr, "expected error not wrapped")
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestStats_GetTopEmoji(t *testing.T) {
+	var guildId uint64 = 1000
+	period := "2024-01"
+	expectedResults := []*model.EmojiStat{
+		{GuildId: guildId, Period: period, Emoji: "🔥", Value: 30},
+		{GuildId: guildId, Period: period, Emoji: "<:costanza:1234>", Value: 12},
+	}
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	rows := mockDb.NewRows([]string{"guild_id", "period", "emoji", "value"}).
+		AddRow(guildId, period, "🔥", 30).
+		AddRow(guildId, period, "<:costanza:1234>", 12)
+	mockDb.ExpectQuery(`GROUP BY guild_id, period, emoji
+HAVING SUM\(value\) > 0`).
+		WithArgs(guildId, period, 5).
+		WillReturnRows(rows)
+	stats := New(mockDb)
+	got, err := stats.GetTopEmoji(context.Background(), guildId, period, 5)
+	require.Nil(t, err, "getting top emoji failed")
+	assert.Equal(t, expectedResults, got, "results don't match")
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestStats_GetTopMessages(t *testing.T) {
+	var guildId uint64 = 1000
+	period := "2024-01"
+	expectedResults := []*model.MessageStat{
+		{GuildId: guildId, Period: period, ChannelId: 20, MessageId: 300, AuthorId: 7, Value: 15},
+	}
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build pool")
+	defer mockDb.Close()
+	rows := mockDb.NewRows([]string{"guild_id", "period", "channel_id", "message_id", "author_id", "value"}).
+		AddRow(guildId, period, uint64(20), uint64(300), uint64(7), 15)
+	mockDb.ExpectQuery(`GROUP BY guild_id, period, channel_id, message_id, author_id
+HAVING SUM\(value\) > 0
+ORDER BY value DESC, message_id`).
+		WithArgs(guildId, period, 1).
+		WillReturnRows(rows)
+	stats := New(mockDb)
+	got, err := stats.GetTopMessages(context.Background(), guildId, period, 1)
+	require.Nil(t, err, "getting top messages failed")
+	assert.Equal(t, expectedResults, got, "results don't match")
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet expectations")
+}
+
+func TestStats_RemoveReactionsForPeriod(t *testing.T) {
+	db, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build mock db")
+	defer db.Close()
+	db.ExpectExec(`DELETE FROM reaction_counters WHERE period = \$1`).
+		WithArgs("2024-01").
+		WillReturnResult(pgxmock.NewResult("DELETE", 10))
+	s := Stats{pool: db}
+	err = s.RemoveReactionsForPeriod(context.Background(), "2024-01")
+	assert.Nil(t, err, "got error when deleting data")
+	assert.Nil(t, db.ExpectationsWereMet(), "unmet mock db expectations")
+}
+
+func TestMemoryStore_Reactions(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	reactions := []Reaction{
+		{GuildId: 1, Period: "2024-01", ChannelId: 10, MessageId: 100, AuthorId: 5, Emoji: "🔥", Increment: 1},
+		{GuildId: 1, Period: "2024-01", ChannelId: 10, MessageId: 100, AuthorId: 5, Emoji: "😂", Increment: 1},
+		{GuildId: 1, Period: "2024-01", ChannelId: 10, MessageId: 100, AuthorId: 5, Emoji: "🔥", Increment: 1},
+		{GuildId: 1, Period: "2024-01", ChannelId: 11, MessageId: 101, AuthorId: 6, Emoji: "😂", Increment: 1},
+		{GuildId: 1, Period: "2024-01", ChannelId: 11, MessageId: 102, AuthorId: 6, Emoji: "👎", Increment: 1},
+		// removed reactions don't count
+		{GuildId: 1, Period: "2024-01", ChannelId: 11, MessageId: 102, AuthorId: 6, Emoji: "👎", Increment: -1},
+		{GuildId: 2, Period: "2024-01", ChannelId: 12, MessageId: 103, AuthorId: 6, Emoji: "👎", Increment: 10},
+	}
+	for _, reaction := range reactions {
+		require.NoError(t, m.LogReaction(ctx, reaction))
+	}
+
+	emoji, err := m.GetTopEmoji(ctx, 1, "2024-01", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []*model.EmojiStat{
+		{GuildId: 1, Period: "2024-01", Emoji: "🔥", Value: 2},
+		{GuildId: 1, Period: "2024-01", Emoji: "😂", Value: 2},
+	}, emoji)
+
+	messages, err := m.GetTopMessages(ctx, 1, "2024-01", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []*model.MessageStat{
+		{GuildId: 1, Period: "2024-01", ChannelId: 10, MessageId: 100, AuthorId: 5, Value: 3},
+		{GuildId: 1, Period: "2024-01", ChannelId: 11, MessageId: 101, AuthorId: 6, Value: 1},
+	}, messages)
+
+	require.NoError(t, m.RemoveReactionsForPeriod(ctx, "2024-01"))
+	messages, err = m.GetTopMessages(ctx, 1, "2024-01", 5)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
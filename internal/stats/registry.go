@@ -0,0 +1,93 @@
+package stats
+
+import "fmt"
+
+// Metric names stored in the stat_counters table
+const (
+	MessagesMetric           = "messages"
+	ReactionsMetric          = "reactions"
+	ReactionsReceivedMetric  = "reactions_received"
+	CursedChannelPostsMetric = "cursed_channel_posts"
+	CursedPostsMetric        = "cursed_posts"
+	GamePlaysMetric          = "game_plays"
+	GameWinsMetric           = "game_wins"
+)
+
+// Metric describes a tracked counter & how it's reported
+type Metric struct {
+	Name        string // Name stored with the counter
+	ReportTitle string // Heading format for the leaderboard section, given the period noun
+	LineFormat  string // Format for a leaderboard entry, given the user mention & value
+	// OffsetMetric if set is subtracted from this metric's value when ranking, e.g. reactions given less messages sent
+	OffsetMetric string
+	// Hidden metrics are only counted per day for charts & aren't shown on leaderboards
+	Hidden bool
+}
+
+// FormatTitle formats the leaderboard heading for the report period
+func (m Metric) FormatTitle(period Period) string {
+	return fmt.Sprintf(m.ReportTitle, period.Noun())
+}
+
+// FormatResult formats a single leaderboard entry
+func (m Metric) FormatResult(userString string, value int) string {
+	return fmt.Sprintf(m.LineFormat, userString, value)
+}
+
+// Registry is the set of tracked counters, in report order. Adding a new counter only requires a new entry here & a
+// call to LogCounter with the metric name.
+var Registry = []Metric{
+	{
+		Name:        MessagesMetric,
+		ReportTitle: "Top posters for the %s are:",
+		LineFormat:  "%s with %d messages",
+	},
+	{
+		Name:         ReactionsMetric,
+		ReportTitle:  "Top reaction scores for the %s are:",
+		LineFormat:   "%s with score %d",
+		OffsetMetric: MessagesMetric,
+	},
+	{
+		Name:        ReactionsReceivedMetric,
+		ReportTitle: "Most reacted to posters for the %s are:",
+		LineFormat:  "%s with %d reactions",
+	},
+	{
+		Name:        CursedChannelPostsMetric,
+		ReportTitle: "Most contained users for the %s are:",
+		LineFormat:  "%s with %d posts",
+	},
+	{
+		Name:        CursedPostsMetric,
+		ReportTitle: "Most cursed language used this %s:",
+		LineFormat:  "%s with %d incidents",
+	},
+	{
+		Name:   GamePlaysMetric,
+		Hidden: true,
+	},
+	{
+		Name:   GameWinsMetric,
+		Hidden: true,
+	},
+}
+
+// GetMetric looks up a registered metric by name
+func GetMetric(name string) (Metric, bool) {
+	for _, m := range Registry {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return Metric{}, false
+}
+
+// registeredMetricNames gets the names of all registered metrics
+func registeredMetricNames() []string {
+	names := make([]string, len(Registry))
+	for i, m := range Registry {
+		names[i] = m.Name
+	}
+	return names
+}
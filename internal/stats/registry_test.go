@@ -0,0 +1,48 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMetric(t *testing.T) {
+	got, ok := GetMetric(ReactionsMetric)
+	if assert.True(t, ok, "registered metric missing") {
+		assert.Equal(t, ReactionsMetric, got.Name)
+		assert.Equal(t, MessagesMetric, got.OffsetMetric)
+	}
+	_, ok = GetMetric("bread")
+	assert.False(t, ok, "found unregistered metric")
+}
+
+func TestMetric_FormatResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		metric     string
+		userString string
+		value      int
+		want       string
+	}{
+		{"messages", MessagesMetric, "Dick Halloran", 101, "Dick Halloran with 101 messages"},
+		{"negative_score", ReactionsMetric, "Jack Torrance", -5, "Jack Torrance with score -5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := GetMetric(tt.metric)
+			assert.Equal(t, tt.want, m.FormatResult(tt.userString, tt.value))
+		})
+	}
+}
+
+func TestRegistryNamesUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, m := range Registry {
+		assert.False(t, seen[m.Name], "duplicate metric %s", m.Name)
+		seen[m.Name] = true
+		if m.OffsetMetric != "" {
+			_, ok := GetMetric(m.OffsetMetric)
+			assert.True(t, ok, "offset metric %s not registered", m.OffsetMetric)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord message & embed size limits
+const (
+	maxEmbedFields     = 25
+	maxEmbedTitle      = 256
+	maxFieldName       = 256
+	maxFieldValue      = 1024
+	maxEmbedTotal      = 6000
+	maxMessageLength   = 2000
+	continuedSuffix    = " (cont.)"
+	leaderboardColor   = 0xf1c40f
+	pageButtonIdPrefix = "leaderboard_page"
+)
+
+var medals = map[int]string{1: "🥇", 2: "🥈", 3: "🥉"}
+
+// LeaderboardPage identifies a single page of a rendered leaderboard, encoded in the custom id of its page buttons.
+// The guild comes from the interaction, so isn't included.
+type LeaderboardPage struct {
+	Period    Period
+	PeriodKey string
+	Page      int
+}
+
+var ErrNotPageButton = errors.New("not a leaderboard page button")
+
+// CustomId encodes the page for use as a button custom id
+func (p LeaderboardPage) CustomId() string {
+	return fmt.Sprintf("%s:%s:%s:%d", pageButtonIdPrefix, p.Period, p.PeriodKey, p.Page)
+}
+
+// ParseLeaderboardPage decodes a page button custom id. Returns ErrNotPageButton for other components' ids.
+func ParseLeaderboardPage(customId string) (LeaderboardPage, error) {
+	parts := strings.Split(customId, ":")
+	if len(parts) != 4 || parts[0] != pageButtonIdPrefix {
+		return LeaderboardPage{}, ErrNotPageButton
+	}
+	period, err := ParsePeriod(parts[1])
+	if err != nil {
+		return LeaderboardPage{}, fmt.Errorf("invalid page button %s: %w", customId, err)
+	}
+	page, err := strconv.Atoi(parts[3])
+	if err != nil || page < 0 {
+		return LeaderboardPage{}, fmt.Errorf("invalid page number in button %s", customId)
+	}
+	return LeaderboardPage{Period: period, PeriodKey: parts[2], Page: page}, nil
+}
+
+// LeaderboardTitle gets the embed title for a period's leaderboard
+func LeaderboardTitle(period Period, periodKey string) string {
+	return fmt.Sprintf("Leaderboard for the %s of %s", period.Noun(), periodKey)
+}
+
+// embedLine formats an entry for an embed field, with medals for the top three
+func embedLine(entry ReportEntry) string {
+	switch {
+	case entry.Heading:
+		return "**" + entry.Text + "**"
+	case entry.Rank > 0:
+		if medal, ok := medals[entry.Rank]; ok {
+			return medal + " " + entry.Text
+		}
+		return fmt.Sprintf("`#%d` %s", entry.Rank, entry.Text)
+	default:
+		return entry.Text
+	}
+}
+
+// sectionFields splits a section into embed fields, continuing into new fields when the value limit is reached
+func sectionFields(section ReportSection) []*discordgo.MessageEmbedField {
+	name := truncateText(section.Title, maxFieldName)
+	var fields []*discordgo.MessageEmbedField
+	value := strings.Builder{}
+	flush := func() {
+		fieldName := name
+		if len(fields) > 0 {
+			fieldName = truncateText(section.Title, maxFieldName-len(continuedSuffix)) + continuedSuffix
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: fieldName, Value: value.String()})
+		value.Reset()
+	}
+	for _, entry := range section.Entries {
+		line := truncateText(embedLine(entry), maxFieldValue)
+		if value.Len() > 0 && value.Len()+len(line)+1 > maxFieldValue {
+			flush()
+		}
+		if value.Len() > 0 {
+			value.WriteString("\n")
+		}
+		value.WriteString(line)
+	}
+	if value.Len() > 0 || len(fields) == 0 {
+		flush()
+	}
+	return fields
+}
+
+// RenderEmbeds puts every section into embed fields, split into pages when the fields exceed a single embed's limits.
+// Pages have a footer with the page number when there's more than one.
+func RenderEmbeds(title string, sections []ReportSection) []*discordgo.MessageEmbed {
+	title = truncateText(title, maxEmbedTitle)
+	// leave room for the page footer
+	budget := maxEmbedTotal - len(title) - len("Page 999/999")
+	var pages []*discordgo.MessageEmbed
+	current := &discordgo.MessageEmbed{Title: title, Color: leaderboardColor}
+	size := 0
+	for _, section := range sections {
+		for _, field := range sectionFields(section) {
+			fieldSize := len(field.Name) + len(field.Value)
+			if len(current.Fields) == maxEmbedFields || (len(current.Fields) > 0 && size+fieldSize > budget) {
+				pages = append(pages, current)
+				current = &discordgo.MessageEmbed{Title: title, Color: leaderboardColor}
+				size = 0
+			}
+			current.Fields = append(current.Fields, field)
+			size += fieldSize
+		}
+	}
+	pages = append(pages, current)
+	if len(pages) > 1 {
+		for i, page := range pages {
+			page.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Page %d/%d", i+1, len(pages))}
+		}
+	}
+	return pages
+}
+
+// RenderText formats the sections as plain text messages, for channels where embeds can't be sent. Sections are
+// combined into as few messages as fit Discord's message length limit.
+func RenderText(sections []ReportSection) []string {
+	var messages []string
+	current := strings.Builder{}
+	add := func(text string) {
+		if current.Len() > 0 && current.Len()+len(text) > maxMessageLength {
+			messages = append(messages, current.String())
+			current.Reset()
+		}
+		current.WriteString(text)
+	}
+	for i, section := range sections {
+		if i > 0 {
+			add("\n")
+		}
+		for _, line := range strings.SplitAfter(section.Text(), "\n") {
+			if line != "" {
+				add(truncateText(line, maxMessageLength))
+			}
+		}
+	}
+	if current.Len() > 0 {
+		messages = append(messages, current.String())
+	}
+	return messages
+}
+
+// PageButtons gets the previous & next buttons for a page of a leaderboard with pageCount pages. Returns nil when
+// everything fits on a single page.
+func PageButtons(page LeaderboardPage, pageCount int) []discordgo.MessageComponent {
+	if pageCount < 2 {
+		return nil
+	}
+	prev, next := page, page
+	prev.Page = max(page.Page-1, 0)
+	next.Page = min(page.Page+1, pageCount-1)
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Previous",
+					Style:    discordgo.SecondaryButton,
+					CustomID: prev.CustomId(),
+					Disabled: page.Page <= 0,
+				},
+				discordgo.Button{
+					Label:    "Next",
+					Style:    discordgo.SecondaryButton,
+					CustomID: next.CustomId(),
+					Disabled: page.Page >= pageCount-1,
+				},
+			},
+		},
+	}
+}
+
+// truncateText shortens s to at most limit bytes without splitting a character
+func truncateText(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit]
+}
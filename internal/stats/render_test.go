@@ -0,0 +1,108 @@
+package stats
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rankedSection(title string, count int) ReportSection {
+	section := ReportSection{Title: title}
+	for i := range count {
+		section.Entries = append(section.Entries, ReportEntry{Rank: i + 1, Text: fmt.Sprintf("<@%d> with %d messages", 100000000000000000+i, 1000-i)})
+	}
+	return section
+}
+
+func TestRenderEmbeds(t *testing.T) {
+	sections := []ReportSection{
+		rankedSection("Top posters for the month are:", 4),
+		{Title: "Speed demons for the month are:", Entries: []ReportEntry{
+			{Heading: true, Text: "Mini"},
+			{Rank: 1, Text: "<@1> with 0:30"},
+		}},
+	}
+	pages := RenderEmbeds("Leaderboard for the month of 2024-03", sections)
+	require.Len(t, pages, 1)
+	assert.Nil(t, pages[0].Footer)
+	want := []*discordgo.MessageEmbedField{
+		{
+			Name: "Top posters for the month are:",
+			Value: "🥇 <@100000000000000000> with 1000 messages\n" +
+				"🥈 <@100000000000000001> with 999 messages\n" +
+				"🥉 <@100000000000000002> with 998 messages\n" +
+				"`#4` <@100000000000000003> with 997 messages",
+		},
+		{Name: "Speed demons for the month are:", Value: "**Mini**\n🥇 <@1> with 0:30"},
+	}
+	assert.Equal(t, want, pages[0].Fields)
+}
+
+func TestRenderEmbedsLimits(t *testing.T) {
+	var sections []ReportSection
+	for i := range 10 {
+		sections = append(sections, rankedSection(fmt.Sprintf("Section %d", i), 50))
+	}
+	pages := RenderEmbeds("Leaderboard", sections)
+	require.Greater(t, len(pages), 1)
+	fieldCount := 0
+	for i, page := range pages {
+		assert.LessOrEqual(t, len(page.Fields), maxEmbedFields)
+		size := len(page.Title) + len(page.Footer.Text)
+		for _, field := range page.Fields {
+			assert.LessOrEqual(t, len(field.Value), maxFieldValue)
+			size += len(field.Name) + len(field.Value)
+		}
+		assert.LessOrEqual(t, size, maxEmbedTotal)
+		assert.Equal(t, fmt.Sprintf("Page %d/%d", i+1, len(pages)), page.Footer.Text)
+		fieldCount += len(page.Fields)
+	}
+	// long sections are continued in following fields
+	assert.Greater(t, fieldCount, len(sections))
+	assert.Equal(t, "Section 0 (cont.)", pages[0].Fields[1].Name)
+}
+
+func TestRenderText(t *testing.T) {
+	sections := []ReportSection{rankedSection("First:", 2), rankedSection("Second:", 1)}
+	got := RenderText(sections)
+	want := []string{"First:\n#1: <@100000000000000000> with 1000 messages\n#2: <@100000000000000001> with 999 messages\n" +
+		"\nSecond:\n#1: <@100000000000000000> with 1000 messages\n"}
+	assert.Equal(t, want, got)
+
+	long := RenderText([]ReportSection{rankedSection("Long:", 100)})
+	require.Greater(t, len(long), 1)
+	for _, message := range long {
+		assert.LessOrEqual(t, len(message), maxMessageLength)
+		assert.True(t, strings.HasSuffix(message, "\n"), "message split mid line")
+	}
+}
+
+func TestLeaderboardPage(t *testing.T) {
+	page := LeaderboardPage{Period: WeeklyPeriod, PeriodKey: "2024-W05", Page: 2}
+	got, err := ParseLeaderboardPage(page.CustomId())
+	require.NoError(t, err)
+	assert.Equal(t, page, got)
+
+	_, err = ParseLeaderboardPage("roll_button")
+	assert.ErrorIs(t, err, ErrNotPageButton)
+	_, err = ParseLeaderboardPage("leaderboard_page:daily:2024-01-01:0")
+	assert.ErrorIs(t, err, InvalidPeriodError("daily"))
+	_, err = ParseLeaderboardPage("leaderboard_page:monthly:2024-01:x")
+	assert.Error(t, err)
+}
+
+func TestPageButtons(t *testing.T) {
+	assert.Nil(t, PageButtons(LeaderboardPage{Period: MonthlyPeriod, PeriodKey: "2024-01"}, 1))
+
+	buttons := PageButtons(LeaderboardPage{Period: MonthlyPeriod, PeriodKey: "2024-01"}, 3)
+	require.Len(t, buttons, 1)
+	row := buttons[0].(discordgo.ActionsRow)
+	prev, next := row.Components[0].(discordgo.Button), row.Components[1].(discordgo.Button)
+	assert.True(t, prev.Disabled)
+	assert.False(t, next.Disabled)
+	assert.Equal(t, "leaderboard_page:monthly:2024-01:1", next.CustomID)
+}
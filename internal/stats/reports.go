@@ -10,65 +10,114 @@ import (
 	"github.com/dmtaylor/costanza/internal/model"
 )
 
-// BuildMessageReport creates the message for message stat report
-func BuildMessageReport(stats []*model.DiscordUsageStat) string {
+// ReportEntry is a single line in a report section
+type ReportEntry struct {
+	Rank    int    // Position on the leaderboard, 0 for unranked lines
+	Heading bool   // Starts a subsection, e.g. a game name
+	Text    string // Line content without the rank
+}
+
+// ReportSection is the content of a single leaderboard, independent of how it's displayed
+type ReportSection struct {
+	Title   string
+	Entries []ReportEntry
+}
+
+// Text formats the section as a plain text message
+func (r ReportSection) Text() string {
 	builder := strings.Builder{}
-	builder.WriteString("Top posters for the month are:\n")
-	for i, userStat := range stats {
-		user := discordgo.User{ID: strconv.FormatUint(userStat.UserId, 10)}
-		line := fmt.Sprintf("#%d: %s with %d messages\n", i+1, user.Mention(), userStat.MessageCount)
-		builder.WriteString(line)
+	builder.WriteString(r.Title + "\n")
+	for _, entry := range r.Entries {
+		switch {
+		case entry.Heading:
+			builder.WriteString(entry.Text + ":\n")
+		case entry.Rank > 0:
+			builder.WriteString(fmt.Sprintf("#%d: %s\n", entry.Rank, entry.Text))
+		default:
+			builder.WriteString(entry.Text + "\n")
+		}
 	}
-
 	return builder.String()
 }
 
-// BuildGameWinReport creates the message for daily game winner reports
-func BuildGameWinReport(topWinners []*model.DailyGameWinStat) string {
-	builder := strings.Builder{}
-	builder.WriteString("Top game winners for the month are:\n")
+// BuildCounterReport creates the section for a counter metric's leaderboard
+func BuildCounterReport(metric Metric, period Period, stats []*model.CounterStat) ReportSection {
+	section := ReportSection{Title: metric.FormatTitle(period)}
+	for i, counterStat := range stats {
+		user := discordgo.User{ID: strconv.FormatUint(counterStat.UserId, 10)}
+		section.Entries = append(section.Entries, ReportEntry{Rank: i + 1, Text: metric.FormatResult(user.Mention(), counterStat.Value)})
+	}
+	return section
+}
+
+// BuildGameWinReport creates the section for daily game winner reports
+func BuildGameWinReport(period Period, topWinners []*model.DailyGameWinStat) ReportSection {
+	section := ReportSection{Title: fmt.Sprintf("Top game winners for the %s are:", period.Noun())}
 	for i, dailyGameStat := range topWinners {
 		user := discordgo.User{ID: strconv.FormatUint(dailyGameStat.UserId, 10)}
-		line := fmt.Sprintf("#%d: %s with %s\n", i+1, user.Mention(), dailyGameStat.FormatWins())
-		builder.WriteString(line)
+		section.Entries = append(section.Entries, ReportEntry{Rank: i + 1, Text: user.Mention() + " with " + dailyGameStat.FormatWins()})
 	}
-	return builder.String()
+	return section
 }
 
-// BuildReactionScoreReport creates the message for reaction scores
-func BuildReactionScoreReport(topReactionScores []*model.DiscordReactionScore) string {
-	builder := strings.Builder{}
-	builder.WriteString("Top reaction scores are:\n")
-	for i, reactionScore := range topReactionScores {
-		user := discordgo.User{ID: strconv.FormatUint(reactionScore.UserId, 10)}
-		line := fmt.Sprintf("#%d: %s\n", i+1, reactionScore.FormatResult(user.Mention()))
-		builder.WriteString(line)
+// BuildSpeedDemonReport creates the section for the fastest solvers of timed daily games. Stats are expected to be
+// grouped by game.
+func BuildSpeedDemonReport(period Period, timeStats []*model.DailyGameTimeStat) ReportSection {
+	section := ReportSection{Title: fmt.Sprintf("Speed demons for the %s are:", period.Noun())}
+	currentGame := ""
+	rank := 0
+	for _, timeStat := range timeStats {
+		if timeStat.Game != currentGame {
+			currentGame = timeStat.Game
+			rank = 0
+			section.Entries = append(section.Entries, ReportEntry{Heading: true, Text: currentGame})
+		}
+		rank++
+		user := discordgo.User{ID: strconv.FormatUint(timeStat.UserId, 10)}
+		section.Entries = append(section.Entries, ReportEntry{Rank: rank, Text: user.Mention() + " with " + timeStat.FormatTimes()})
 	}
-
-	return builder.String()
+	return section
 }
 
-// BuildCursedChannelPostReport creates the message for contained users
-func BuildCursedChannelPostReport(cursedChannelPosters []*model.CursedChannelPost) string {
-	builder := strings.Builder{}
-	builder.WriteString("Most contained users are:\n")
-	for i, ccp := range cursedChannelPosters {
-		user := discordgo.User{ID: strconv.FormatUint(ccp.UserId, 10)}
-		line := fmt.Sprintf("#%d: %s with %d posts\n", i+1, user.Mention(), ccp.MessageCount)
-		builder.WriteString(line)
+// BuildActiveChannelReport creates the section for the channels with the most activity
+func BuildActiveChannelReport(period Period, channelStats []*model.ChannelStat) ReportSection {
+	section := ReportSection{Title: fmt.Sprintf("Most active channels for the %s are:", period.Noun())}
+	for i, channelStat := range channelStats {
+		channel := discordgo.Channel{ID: strconv.FormatUint(channelStat.ChannelId, 10)}
+		section.Entries = append(section.Entries, ReportEntry{Rank: i + 1, Text: fmt.Sprintf("%s with %d messages", channel.Mention(), channelStat.Value)})
 	}
+	return section
+}
 
-	return builder.String()
+// BuildChannelPosterReport creates the section for the top poster in each of the most active channels
+func BuildChannelPosterReport(period Period, channelStats []*model.ChannelStat) ReportSection {
+	section := ReportSection{Title: fmt.Sprintf("Top posters by channel for the %s are:", period.Noun())}
+	for _, channelStat := range channelStats {
+		channel := discordgo.Channel{ID: strconv.FormatUint(channelStat.ChannelId, 10)}
+		user := discordgo.User{ID: strconv.FormatUint(channelStat.UserId, 10)}
+		section.Entries = append(section.Entries, ReportEntry{Text: fmt.Sprintf("%s: %s with %d messages", channel.Mention(), user.Mention(), channelStat.Value)})
+	}
+	return section
 }
 
-func BuildCursedPostReport(cursedPostStats []*model.CursedPostStat) string {
-	builder := strings.Builder{}
-	builder.WriteString("Most cursed language used:\n")
-	for i, cps := range cursedPostStats {
-		user := discordgo.User{ID: strconv.FormatUint(cps.UserId, 10)}
-		line := fmt.Sprintf("#%d: %s with %d incidents\n", i+1, user.Mention(), cps.MessageCount)
-		builder.WriteString(line)
+// BuildTopEmojiReport creates the section for the most used reaction emoji
+func BuildTopEmojiReport(period Period, emojiStats []*model.EmojiStat) ReportSection {
+	section := ReportSection{Title: fmt.Sprintf("Top reaction emoji for the %s are:", period.Noun())}
+	for i, emojiStat := range emojiStats {
+		section.Entries = append(section.Entries, ReportEntry{Rank: i + 1, Text: fmt.Sprintf("%s used %d times", emojiStat.Emoji, emojiStat.Value)})
 	}
+	return section
+}
 
-	return builder.String()
+// BuildTopMessageReport creates the section for the messages with the most reactions, linking to each message
+func BuildTopMessageReport(period Period, messageStats []*model.MessageStat) ReportSection {
+	section := ReportSection{Title: fmt.Sprintf("Messages of the %s are:", period.Noun())}
+	for i, messageStat := range messageStats {
+		user := discordgo.User{ID: strconv.FormatUint(messageStat.AuthorId, 10)}
+		section.Entries = append(section.Entries, ReportEntry{
+			Rank: i + 1,
+			Text: fmt.Sprintf("%s with %d reactions: %s", user.Mention(), messageStat.Value, messageStat.Link()),
+		})
+	}
+	return section
 }
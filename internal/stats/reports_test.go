@@ -79,191 +79,113 @@ func TestBuildGameWinReport(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := BuildGameWinReport(tt.args.topWinners)
+			got := BuildGameWinReport(MonthlyPeriod, tt.args.topWinners).Text()
 			assert.Equalf(t, tt.want, got, "BuildGameWinReport(%v)", tt.args.topWinners)
 		})
 	}
 }
 
-func TestBuildMessageReport(t *testing.T) {
+func TestBuildSpeedDemonReport(t *testing.T) {
+	timeStats := []*model.DailyGameTimeStat{
+		{GuildId: 1, UserId: 11, Period: "2024-07", Game: "Mini", PlayCount: 5, FastestSeconds: 21, MedianSeconds: 30},
+		{GuildId: 1, UserId: 12, Period: "2024-07", Game: "Mini", PlayCount: 8, FastestSeconds: 25, MedianSeconds: 41},
+		{GuildId: 1, UserId: 12, Period: "2024-07", Game: "Queens", PlayCount: 3, FastestSeconds: 62, MedianSeconds: 75},
+	}
+	want := "Speed demons for the month are:\n" +
+		"Mini:\n" +
+		"#1: <@11> with fastest 0:21, median 0:30 over 5 plays\n" +
+		"#2: <@12> with fastest 0:25, median 0:41 over 8 plays\n" +
+		"Queens:\n" +
+		"#1: <@12> with fastest 1:02, median 1:15 over 3 plays\n"
+	assert.Equal(t, want, BuildSpeedDemonReport(MonthlyPeriod, timeStats).Text())
+}
+
+func TestBuildCounterReport(t *testing.T) {
+	messages, _ := GetMetric(MessagesMetric)
+	reactions, _ := GetMetric(ReactionsMetric)
+	contained, _ := GetMetric(CursedChannelPostsMetric)
+	cursed, _ := GetMetric(CursedPostsMetric)
 	tests := []struct {
-		name  string
-		stats []*model.DiscordUsageStat
-		want  string
+		name   string
+		metric Metric
+		period Period
+		stats  []*model.CounterStat
+		want   string
 	}{
 		{
-			"basic",
-			[]*model.DiscordUsageStat{
-				{
-					Id:           990,
-					GuildId:      888888,
-					UserId:       4523,
-					ReportMonth:  "2024-01",
-					MessageCount: 101,
-				},
-				{
-					Id:           53,
-					GuildId:      888888,
-					UserId:       9923,
-					ReportMonth:  "2024-01",
-					MessageCount: 99,
-				},
-				{
-					Id:           991,
-					GuildId:      888888,
-					UserId:       1023,
-					ReportMonth:  "2024-01",
-					MessageCount: 98,
-				},
+			"messages",
+			messages,
+			MonthlyPeriod,
+			[]*model.CounterStat{
+				{Metric: MessagesMetric, GuildId: 888888, UserId: 4523, Period: "2024-01", Value: 101},
+				{Metric: MessagesMetric, GuildId: 888888, UserId: 9923, Period: "2024-01", Value: 99},
+				{Metric: MessagesMetric, GuildId: 888888, UserId: 1023, Period: "2024-01", Value: 98},
 			},
 			"Top posters for the month are:\n" +
 				"#1: <@4523> with 101 messages\n" +
 				"#2: <@9923> with 99 messages\n" +
 				"#3: <@1023> with 98 messages\n",
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := BuildMessageReport(tt.stats)
-			assert.Equalf(t, tt.want, got, "BuildMessageReport(%v, %v)", tt.stats)
-		})
-	}
-}
-
-func TestBuildReactionScoreReport(t *testing.T) {
-	tests := []struct {
-		name              string
-		topReactionScores []*model.DiscordReactionScore
-		want              string
-	}{
 		{
-			"basic",
-			[]*model.DiscordReactionScore{
-				{
-					GuildId:     990,
-					UserId:      10240,
-					ReportMonth: "2024-01",
-					Score:       59,
-				},
-				{
-					GuildId:     990,
-					UserId:      7892,
-					ReportMonth: "2024-01",
-					Score:       44,
-				},
-				{
-					GuildId:     990,
-					UserId:      40890,
-					ReportMonth: "2024-01",
-					Score:       32,
-				},
+			"reaction_scores",
+			reactions,
+			WeeklyPeriod,
+			[]*model.CounterStat{
+				{Metric: ReactionsMetric, GuildId: 1, UserId: 111, Period: "2024-01", Value: 30},
+				{Metric: ReactionsMetric, GuildId: 1, UserId: 112, Period: "2024-01", Value: -5},
 			},
-			"Top reaction scores are:\n" +
-				"#1: <@10240> with score 59\n" +
-				"#2: <@7892> with score 44\n" +
-				"#3: <@40890> with score 32\n",
+			"Top reaction scores for the week are:\n" +
+				"#1: <@111> with score 30\n" +
+				"#2: <@112> with score -5\n",
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := BuildReactionScoreReport(tt.topReactionScores)
-			assert.Equalf(t, tt.want, got, "BuildReactionScoreReport(%v, %v)", tt.topReactionScores)
-		})
-	}
-}
-
-func TestBuildCursedChannelPostReport(t *testing.T) {
-	tests := []struct {
-		name    string
-		posters []*model.CursedChannelPost
-		want    string
-	}{
 		{
-			"basic",
-			[]*model.CursedChannelPost{
-				{
-					5,
-					9090,
-					3333,
-					"2024-01",
-					90,
-				},
-				{
-					2,
-					9090,
-					3334,
-					"2024-01",
-					75,
-				},
-				{
-					10,
-					9090,
-					3335,
-					"2024-01",
-					50,
-				},
-				{
-					87,
-					9090,
-					3336,
-					"2024-01",
-					10,
-				},
+			"contained_users",
+			contained,
+			QuarterlyPeriod,
+			[]*model.CounterStat{
+				{Metric: CursedChannelPostsMetric, GuildId: 1, UserId: 2345, Period: "2024-01", Value: 250},
+				{Metric: CursedChannelPostsMetric, GuildId: 1, UserId: 3456, Period: "2024-01", Value: 200},
 			},
-			"Most contained users are:\n" +
-				"#1: <@3333> with 90 posts\n" +
-				"#2: <@3334> with 75 posts\n" +
-				"#3: <@3335> with 50 posts\n" +
-				"#4: <@3336> with 10 posts\n",
+			"Most contained users for the quarter are:\n" +
+				"#1: <@2345> with 250 posts\n" +
+				"#2: <@3456> with 200 posts\n",
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			assert.Equalf(t, tt.want, BuildCursedChannelPostReport(tt.posters), "BuildCursedChannelPostReport(%v)", tt.posters)
-		})
-	}
-}
-
-func TestBuildCursedPostReport(t *testing.T) {
-	tests := []struct {
-		name            string
-		cursedPostStats []*model.CursedPostStat
-		want            string
-	}{
 		{
-			"basic",
-			[]*model.CursedPostStat{
-				{
-					98,
-					9999,
-					554,
-					"2024-01",
-					9867,
-				},
-				{
-					1241,
-					9999,
-					582,
-					"2024-01",
-					600,
-				},
-				{
-					10,
-					9999,
-					525,
-					"2024-01",
-					143,
-				},
+			"cursed_posts",
+			cursed,
+			YearlyPeriod,
+			[]*model.CounterStat{
+				{Metric: CursedPostsMetric, GuildId: 1, UserId: 2345, Period: "2024-01", Value: 30},
 			},
-			"Most cursed language used:\n" +
-				"#1: <@554> with 9867 incidents\n" +
-				"#2: <@582> with 600 incidents\n" +
-				"#3: <@525> with 143 incidents\n",
+			"Most cursed language used this year:\n" +
+				"#1: <@2345> with 30 incidents\n",
+		},
+		{
+			"empty",
+			messages,
+			MonthlyPeriod,
+			nil,
+			"Top posters for the month are:\n",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.Equalf(t, tt.want, BuildCursedPostReport(tt.cursedPostStats), "BuildCursedPostReport(%v)", tt.cursedPostStats)
+			assert.Equal(t, tt.want, BuildCounterReport(tt.metric, tt.period, tt.stats).Text())
 		})
 	}
 }
+
+func TestBuildTopEmojiReport(t *testing.T) {
+	got := BuildTopEmojiReport(WeeklyPeriod, []*model.EmojiStat{
+		{GuildId: 1, Period: "2024-W05", Emoji: "🔥", Value: 12},
+		{GuildId: 1, Period: "2024-W05", Emoji: "<:costanza:1234>", Value: 4},
+	}).Text()
+	assert.Equal(t, "Top reaction emoji for the week are:\n#1: 🔥 used 12 times\n#2: <:costanza:1234> used 4 times\n", got)
+}
+
+func TestBuildTopMessageReport(t *testing.T) {
+	got := BuildTopMessageReport(MonthlyPeriod, []*model.MessageStat{
+		{GuildId: 1, Period: "2024-01", ChannelId: 20, MessageId: 300, AuthorId: 7, Value: 15},
+	}).Text()
+	assert.Equal(t, "Messages of the month are:\n#1: <@7> with 15 reactions: https://discord.com/channels/1/20/300\n", got)
+}
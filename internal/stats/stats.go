@@ -2,205 +2,75 @@ package stats
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"time"
 
 	"github.com/georgysavva/scany/v2/pgxscan"
-	"github.com/jackc/pgx/v5"
 
 	"github.com/dmtaylor/costanza/internal/model"
 )
 
-// TODO add interface for this & rename struct. Should have done this from the beginning but whatever
-// TODO logging activity is the same function for different tables. Create a more generic method for logging updates
-// and pass in table name as argument
-
+// Stats is the Postgres backed StatsStore
 type Stats struct {
-	pool model.DbPool
+	pool   model.DbPool
+	buffer *writeBuffer // buffers counter increments when set, nil writes through
 }
 
 func New(pool model.DbPool) Stats {
 	return Stats{
-		pool,
-	}
-}
-
-func (s Stats) LogActivity(ctx context.Context, guildId, userId uint64, reportMonth string) error {
-	var err error
-
-	var existingLogId uint
-	err = s.pool.QueryRow(ctx, `
-SELECT id
-FROM discord_usage_stats
-WHERE guild_id = $1 AND user_id = $2 AND report_month = $3
-`, guildId, userId, reportMonth).Scan(&existingLogId)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			// No usage so far, insert new record
-			_, err = s.pool.Exec(ctx, "INSERT INTO discord_usage_stats(guild_id, user_id, report_month) VALUES ($1, $2, $3)",
-				guildId,
-				userId,
-				reportMonth)
-			if err != nil {
-				return fmt.Errorf("failed to insert new record: %w", err)
-			}
-			return nil
-		} else {
-			// Error case
-			return fmt.Errorf("failed to get existing stat: %w", err)
-		}
-	}
-	_, err = s.pool.Exec(ctx, `
-UPDATE discord_usage_stats
-SET message_count = message_count + 1
-WHERE id = $1
-`, existingLogId)
-	if err != nil {
-		return fmt.Errorf("failed to increment message stat: %w", err)
-	}
-
-	return nil
-}
-
-func (s Stats) LogReaction(ctx context.Context, guildId, userId uint64, reportMonth string) error {
-
-	var existingLogId uint
-	err := s.pool.QueryRow(ctx, `
-SELECT id
-FROM discord_reaction_stats
-WHERE guild_id = $1 AND user_id = $2 AND report_month = $3
-`, guildId, userId, reportMonth).Scan(&existingLogId)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			_, err = s.pool.Exec(ctx, "INSERT INTO discord_reaction_stats(guild_id, user_id, report_month) VALUES ($1, $2, $3)",
-				guildId,
-				userId,
-				reportMonth,
-			)
-			if err != nil {
-				return fmt.Errorf("failed to insert new reaction record: %w", err)
-			}
-			return nil
-		} else {
-			return fmt.Errorf("failed to get existing reaction stat: %w", err)
-		}
-	}
-	_, err = s.pool.Exec(ctx, `
-UPDATE discord_reaction_stats
-SET message_count = message_count + 1
-WHERE id = $1
-`, existingLogId)
-	if err != nil {
-		return fmt.Errorf("failed to increment reaction stat: %w", err)
-	}
-
-	return nil
-}
-
-func (s Stats) GetReactionLeadersForMonth(ctx context.Context, guildId uint64, reportMonth string) ([]*model.DiscordReactionScore, error) {
-	var scores []*model.DiscordReactionScore
-	err := pgxscan.Select(ctx, s.pool, &scores, `
-SELECT drs.guild_id, drs.user_id, drs.report_month, drs.message_count - COALESCE(dus.message_count, 0) AS score
-FROM discord_reaction_stats drs LEFT OUTER JOIN discord_usage_stats dus USING (guild_id, user_id, report_month)
-WHERE drs.guild_id = $1 AND drs.report_month = $2
-ORDER BY score DESC
-LIMIT 5`, guildId, reportMonth)
-	if err != nil {
-		return nil, fmt.Errorf("reaction leader query failed: %w", err)
+		pool: pool,
 	}
-
-	return scores, nil
 }
 
-func (s Stats) RemoveReactionLogForMonth(ctx context.Context, reportMonth string) error {
-	_, err := s.pool.Exec(ctx, "DELETE FROM discord_reaction_stats WHERE report_month = $1", reportMonth)
-	if err != nil {
-		return fmt.Errorf("failed to delete reaction stats: %w", err)
+// StartWriteBuffer enables coalescing of counter increments, flushing them to the db every flushInterval. This must be
+// called before the stats service is shared across goroutines. Call Close to drain pending increments.
+func (s *Stats) StartWriteBuffer(flushInterval time.Duration) {
+	if s.buffer != nil {
+		return
 	}
-	return nil
+	s.buffer = newWriteBuffer(s.pool, flushInterval)
+	s.buffer.start()
 }
 
-func (s Stats) GetLeaders(ctx context.Context, guildId uint64, reportMonth string) ([]*model.DiscordUsageStat, error) {
-	var stats []*model.DiscordUsageStat
-	err := pgxscan.Select(ctx, s.pool, &stats, `
-SELECT *
-FROM discord_usage_stats
-WHERE guild_id = $1 AND report_month = $2
-ORDER BY message_count DESC
-LIMIT 5
-`, guildId, reportMonth)
-	if err != nil {
-		return nil, fmt.Errorf("failed to pull top post stats: %w", err)
+// Close flushes any buffered counter increments & stops the write buffer. No-op if buffering isn't enabled
+func (s *Stats) Close(ctx context.Context) error {
+	if s.buffer == nil {
+		return nil
 	}
-	return stats, nil
+	return s.buffer.close(ctx)
 }
 
-func (s Stats) RemoveMonthActivity(ctx context.Context, reportMonth string) error {
-	_, err := s.pool.Exec(ctx, "DELETE FROM discord_usage_stats WHERE report_month = $1", reportMonth)
-	if err != nil {
-		return fmt.Errorf("failed to delete usage stats: %w", err)
+func (s Stats) LogDailyGameActivity(ctx context.Context, gamePlay model.DailyGamePlay, period string) error {
+	var win int
+	if gamePlay.Win {
+		win = 1
 	}
-	return nil
-}
-
-func (s Stats) LogDailyGameActivity(ctx context.Context, gamePlay model.DailyGamePlay, reportMonth string) error {
-	var gameWinStat model.DailyGameWinStat
-	err := pgxscan.Get(ctx, s.pool, &gameWinStat, `
-SELECT *
-FROM daily_game_win_stats
-WHERE guild_id = $1 AND user_id = $2 AND report_month = $3`, gamePlay.GuildId, gamePlay.UserId, reportMonth)
+	_, err := s.pool.Exec(ctx, `
+INSERT INTO daily_game_win_stats AS dgws (guild_id, user_id, period, play_count, guess_count, win_count, current_streak, max_streak)
+VALUES ($1, $2, $3, 1, $4, $5, $5, $5)
+ON CONFLICT (guild_id, user_id, period) DO UPDATE
+SET play_count = dgws.play_count + 1,
+    guess_count = dgws.guess_count + EXCLUDED.guess_count,
+    win_count = dgws.win_count + EXCLUDED.win_count,
+    current_streak = CASE WHEN EXCLUDED.win_count > 0 THEN dgws.current_streak + 1 ELSE 0 END,
+    max_streak = GREATEST(dgws.max_streak, CASE WHEN EXCLUDED.win_count > 0 THEN dgws.current_streak + 1 ELSE 0 END)`,
+		gamePlay.GuildId, gamePlay.UserId, period, gamePlay.Tries, win)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			var winCount int
-			var currentStreak int
-			var maxStreak int
-			if gamePlay.Win {
-				winCount = 1
-				currentStreak = 1
-				maxStreak = 1
-			}
-			_, err := s.pool.Exec(ctx, `
-INSERT INTO daily_game_win_stats(guild_id, user_id, report_month, play_count, guess_count, win_count, current_streak, max_streak)
-VALUES ($1, $2, $3, 1, $4, $5, $6, $7)`, gamePlay.GuildId, gamePlay.UserId, reportMonth, gamePlay.Tries, winCount, currentStreak, maxStreak)
-			if err != nil {
-				return fmt.Errorf("failed to insert new row for game stats: %w", err)
-			}
-		} else {
-			return fmt.Errorf("failed to get existing game stat row: %w", err)
-		}
-	} else {
-		gameWinStat.GuessCount += int(gamePlay.Tries)
-		if gamePlay.Win {
-			gameWinStat.WinCount += 1
-			gameWinStat.CurrentStreak += 1
-			if gameWinStat.CurrentStreak > gameWinStat.MaxStreak {
-				gameWinStat.MaxStreak = gameWinStat.CurrentStreak
-			}
-		} else {
-			gameWinStat.CurrentStreak = 0
-		}
-		_, err := s.pool.Exec(ctx, `
-UPDATE daily_game_win_stats
-SET play_count = play_count + 1, guess_count = $1, win_count = $2, current_streak = $3, max_streak = $4
-WHERE id = $5`, gameWinStat.GuessCount, gameWinStat.WinCount, gameWinStat.CurrentStreak, gameWinStat.MaxStreak, gameWinStat.Id)
-		if err != nil {
-			return fmt.Errorf("failed to update win stats: %w", err)
-		}
-
+		return fmt.Errorf("failed to upsert win stats: %w", err)
 	}
 
 	return nil
 }
 
-func (s Stats) GetDailyGameLeaders(ctx context.Context, guildId uint64, reportMonth string) ([]*model.DailyGameWinStat, error) {
+func (s Stats) GetDailyGameLeaders(ctx context.Context, guildId uint64, period string, limit int) ([]*model.DailyGameWinStat, error) {
 	var gameLeaders []*model.DailyGameWinStat
 
 	err := pgxscan.Select(ctx, s.pool, &gameLeaders, `
 SELECT *
 FROM daily_game_win_stats
-WHERE guild_id = $1 AND report_month = $2
-ORDER BY win_count DESC
-LIMIT 5`, guildId, reportMonth)
+WHERE guild_id = $1 AND period = $2
+ORDER BY win_count DESC, user_id
+LIMIT $3`, guildId, period, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to pull top winners: %w", err)
 	}
@@ -208,123 +78,122 @@ LIMIT 5`, guildId, reportMonth)
 	return gameLeaders, nil
 }
 
-func (s Stats) RemoveDailyGameLeadersForMonth(ctx context.Context, reportMonth string) error {
-	_, err := s.pool.Exec(ctx, `DELETE FROM daily_game_win_stats WHERE report_month = $1`, reportMonth)
+func (s Stats) RemoveDailyGameLeadersForPeriod(ctx context.Context, period string) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM daily_game_win_stats WHERE period = $1`, period)
 	if err != nil {
-		return fmt.Errorf("failed to delete stats for month: %w", err)
+		return fmt.Errorf("failed to delete stats for period: %w", err)
 	}
 	return nil
 }
 
-func (s Stats) LogCursedChannelPost(ctx context.Context, guildId, userId uint64, reportMonth string) error {
-	var existingLogId uint
-	err := s.pool.QueryRow(ctx,
-		"SELECT id FROM discord_cursed_channel_stats WHERE guild_id = $1 AND user_id = $2 AND report_month = $3",
-		guildId,
-		userId,
-		reportMonth).Scan(&existingLogId)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			_, err = s.pool.Exec(ctx,
-				"INSERT INTO discord_cursed_channel_stats(guild_id, user_id, report_month) VALUES ($1, $2, $3)",
-				guildId,
-				userId,
-				reportMonth,
-			)
-			if err != nil {
-				return fmt.Errorf("failed to insert new cursed channel post record: %w", err)
-			}
-			return nil
-		} else {
-			return fmt.Errorf("failed to get existing cursed channel post record: %w", err)
-		}
-	}
-	_, err = s.pool.Exec(ctx,
-		"UPDATE discord_cursed_channel_stats SET message_count = message_count + 1 WHERE id = $1",
-		existingLogId)
+func (s Stats) LogDailyGameTime(ctx context.Context, gamePlay model.DailyGameTimePlay, period string) error {
+	_, err := s.pool.Exec(ctx, `
+INSERT INTO daily_game_times(guild_id, user_id, period, game, solve_seconds)
+VALUES ($1, $2, $3, $4, $5)`,
+		gamePlay.GuildId,
+		gamePlay.UserId,
+		period,
+		gamePlay.Game,
+		int(gamePlay.Duration.Seconds()),
+	)
 	if err != nil {
-		return fmt.Errorf("failed to increment cursed channel post record: %w", err)
+		return fmt.Errorf("failed to insert game time: %w", err)
 	}
 	return nil
 }
 
-func (s Stats) RemoveCursedChannelPostStatsForMonth(ctx context.Context, reportMonth string) error {
-	_, err := s.pool.Exec(ctx, "DELETE FROM discord_cursed_channel_stats WHERE report_month = $1", reportMonth)
+// GetDailyGameTimeLeaders gets the fastest users for each timed game, ranked by median solve time. limit applies per game.
+func (s Stats) GetDailyGameTimeLeaders(ctx context.Context, guildId uint64, period string, limit int) ([]*model.DailyGameTimeStat, error) {
+	var timeLeaders []*model.DailyGameTimeStat
+	err := pgxscan.Select(ctx, s.pool, &timeLeaders, `
+SELECT guild_id, user_id, period, game, play_count, fastest_seconds, median_seconds
+FROM (
+    SELECT guild_id, user_id, period, game,
+        COUNT(*) AS play_count,
+        MIN(solve_seconds) AS fastest_seconds,
+        percentile_cont(0.5) WITHIN GROUP (ORDER BY solve_seconds) AS median_seconds,
+        ROW_NUMBER() OVER (PARTITION BY game ORDER BY percentile_cont(0.5) WITHIN GROUP (ORDER BY solve_seconds), MIN(solve_seconds), user_id) AS game_rank
+    FROM daily_game_times
+    WHERE guild_id = $1 AND period = $2
+    GROUP BY guild_id, user_id, period, game
+) ranked
+WHERE game_rank <= $3
+ORDER BY game, game_rank`, guildId, period, limit)
 	if err != nil {
-		return fmt.Errorf("failed to delete cursed channel post stats: %w", err)
+		return nil, fmt.Errorf("failed to pull fastest solvers: %w", err)
 	}
-	return nil
+
+	return timeLeaders, nil
 }
 
-func (s Stats) GetTopCursedChannelPosters(ctx context.Context, guildId uint64, reportMonth string) ([]*model.CursedChannelPost, error) {
-	var results []*model.CursedChannelPost
-	err := pgxscan.Select(
-		ctx,
-		s.pool,
-		&results,
-		"SELECT * FROM discord_cursed_channel_stats WHERE guild_id = $1 AND report_month = $2 ORDER BY message_count DESC LIMIT 5",
-		guildId,
-		reportMonth,
-	)
+func (s Stats) RemoveDailyGameTimesForPeriod(ctx context.Context, period string) error {
+	_, err := s.pool.Exec(ctx, "DELETE FROM daily_game_times WHERE period = $1", period)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get cursed channel post leaders: %w", err)
+		return fmt.Errorf("failed to delete game times for period: %w", err)
 	}
-	return results, nil
+	return nil
 }
 
-func (s Stats) LogCursedPost(ctx context.Context, guildId, userId uint64, reportMonth string, count int) error {
-	var existingLogId uint
-	err := s.pool.QueryRow(ctx,
-		"SELECT id FROM discord_cursed_posts_stats WHERE guild_id = $1 AND user_id = $2 AND report_month = $3",
-		guildId,
-		userId,
-		reportMonth).Scan(&existingLogId)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			_, err = s.pool.Exec(ctx,
-				"INSERT INTO discord_cursed_posts_stats(guild_id, user_id, report_month, message_count) VALUES ($1, $2, $3, $4)",
-				guildId,
-				userId,
-				reportMonth,
-				count,
-			)
-			if err != nil {
-				return fmt.Errorf("failed to insert new cursed channel post record: %w", err)
-			}
-			return nil
-		} else {
-			return fmt.Errorf("failed to get existing cursed channel post record: %w", err)
-		}
-	}
-	_, err = s.pool.Exec(ctx,
-		"UPDATE discord_cursed_posts_stats SET message_count = message_count + $1 WHERE id = $2",
-		count, existingLogId)
+// LogGamePlay counts a play of the named game for the user, so each member's favourite game can be found
+func (s Stats) LogGamePlay(ctx context.Context, guildId uint64, userId uint64, game string, period string) error {
+	_, err := s.pool.Exec(ctx, `
+INSERT INTO game_plays AS gp (guild_id, user_id, period, game, play_count)
+VALUES ($1, $2, $3, $4, 1)
+ON CONFLICT (guild_id, user_id, period, game) DO UPDATE
+SET play_count = gp.play_count + 1`, guildId, userId, period, game)
 	if err != nil {
-		return fmt.Errorf("failed to increment cursed channel post record: %w", err)
+		return fmt.Errorf("failed to count %s play: %w", game, err)
 	}
 	return nil
 }
 
-func (s Stats) RemoveCursedPostStatsForMonth(ctx context.Context, reportMonth string) error {
-	_, err := s.pool.Exec(ctx, "DELETE FROM discord_cursed_posts_stats WHERE report_month = $1", reportMonth)
+func (s Stats) RemoveGamePlaysForPeriod(ctx context.Context, period string) error {
+	_, err := s.pool.Exec(ctx, "DELETE FROM game_plays WHERE period = $1", period)
 	if err != nil {
-		return fmt.Errorf("failed to delete cursed post stats: %w", err)
+		return fmt.Errorf("failed to delete game plays for period: %w", err)
 	}
 	return nil
 }
 
-func (s Stats) GetTopCursedPosters(ctx context.Context, guildId uint64, reportMonth string) ([]*model.CursedPostStat, error) {
-	var results []*model.CursedPostStat
-	err := pgxscan.Select(
-		ctx,
-		s.pool,
-		&results,
-		"SELECT * FROM discord_cursed_posts_stats WHERE guild_id = $1 AND report_month = $2 ORDER BY message_count DESC LIMIT 5",
-		guildId,
-		reportMonth,
-	)
+// userTables are the stats tables with rows for a single user, & the column holding the user
+var userTables = []struct{ table, column string }{
+	{"stat_counters", "user_id"},
+	{"daily_game_win_stats", "user_id"},
+	{"daily_game_times", "user_id"},
+	{"reaction_counters", "author_id"},
+	{"game_plays", "user_id"},
+	{"yearly_rollups", "user_id"},
+}
+
+const removalUpsertQuery = `
+INSERT INTO stat_removals (guild_id, user_id, removed_at) VALUES ($1, $2, $3)
+ON CONFLICT (guild_id, user_id) DO UPDATE SET removed_at = EXCLUDED.removed_at`
+
+// RemoveUser deletes all of the user's stats in the guild, in a single transaction. Buffered counter increments are
+// flushed first so they can't be written after the delete. The removal is recorded so other processes drop any
+// increments they buffered before it, e.g. the bot's when removing from the CLI.
+func (s Stats) RemoveUser(ctx context.Context, guildId uint64, userId uint64) error {
+	if s.buffer != nil {
+		if err := s.buffer.flush(ctx); err != nil {
+			return fmt.Errorf("failed to flush counters before removing user: %w", err)
+		}
+	}
+	tx, err := s.pool.Begin(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get cursed channel post leaders: %w", err)
+		return fmt.Errorf("failed to start user removal: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	for _, t := range userTables {
+		_, err = tx.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE guild_id = $1 AND %s = $2", t.table, t.column), guildId, userId)
+		if err != nil {
+			return fmt.Errorf("failed to remove user from %s: %w", t.table, err)
+		}
+	}
+	if _, err = tx.Exec(ctx, removalUpsertQuery, guildId, userId, time.Now().Unix()); err != nil {
+		return fmt.Errorf("failed to record user removal: %w", err)
 	}
-	return results, nil
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit user removal: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,49 @@
+package stats
+
+import (
+	"context"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+// StatsStore records & reports on guild activity stats
+type StatsStore interface {
+	LogCounter(ctx context.Context, counter Counter) error
+	GetCounterLeaders(ctx context.Context, metric Metric, guildId uint64, period string, limit int) ([]*model.CounterStat, error)
+	GetChannelLeaders(ctx context.Context, metric Metric, guildId uint64, period string, limit int) ([]*model.ChannelStat, error)
+	GetChannelTopUsers(ctx context.Context, metric Metric, guildId uint64, period string, limit int) ([]*model.ChannelStat, error)
+	RemoveCountersForPeriod(ctx context.Context, period string) error
+	GetDailyCounters(ctx context.Context, metric string, guildId uint64, userId uint64, from string, to string) ([]*model.CounterStat, error)
+	RemoveDailyCountersBefore(ctx context.Context, day string) error
+
+	LogReaction(ctx context.Context, reaction Reaction) error
+	GetTopEmoji(ctx context.Context, guildId uint64, period string, limit int) ([]*model.EmojiStat, error)
+	GetTopMessages(ctx context.Context, guildId uint64, period string, limit int) ([]*model.MessageStat, error)
+	RemoveReactionsForPeriod(ctx context.Context, period string) error
+
+	LogDailyGameActivity(ctx context.Context, gamePlay model.DailyGamePlay, period string) error
+	GetDailyGameLeaders(ctx context.Context, guildId uint64, period string, limit int) ([]*model.DailyGameWinStat, error)
+	RemoveDailyGameLeadersForPeriod(ctx context.Context, period string) error
+
+	LogDailyGameTime(ctx context.Context, gamePlay model.DailyGameTimePlay, period string) error
+	GetDailyGameTimeLeaders(ctx context.Context, guildId uint64, period string, limit int) ([]*model.DailyGameTimeStat, error)
+	RemoveDailyGameTimesForPeriod(ctx context.Context, period string) error
+
+	LogGamePlay(ctx context.Context, guildId uint64, userId uint64, game string, period string) error
+	RemoveGamePlaysForPeriod(ctx context.Context, period string) error
+
+	// RollupPeriod keeps a month's stats for the year's Wrapped, replacing any previous rollup of the month
+	RollupPeriod(ctx context.Context, period string) error
+	GetYearlyRollups(ctx context.Context, guildId uint64, year string) ([]*model.RollupStat, error)
+	// GetPendingRollups reads the year's months that haven't been rolled up yet, without rolling them up
+	GetPendingRollups(ctx context.Context, guildId uint64, year string) ([]*model.RollupStat, error)
+
+	// RemoveUser deletes every stat recorded for the user in the guild
+	RemoveUser(ctx context.Context, guildId uint64, userId uint64) error
+
+	// Close flushes any pending writes
+	Close(ctx context.Context) error
+}
+
+var _ StatsStore = (*Stats)(nil)
+var _ StatsStore = (*MemoryStore)(nil)
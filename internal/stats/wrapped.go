@@ -0,0 +1,326 @@
+package stats
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/georgysavva/scany/v2/pgxscan"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+// Stats rolled up from each month for the year's Wrapped, besides the counters in rollupMetrics
+const (
+	MaxStreakStat  = "max_streak"
+	gameStatPrefix = "game:" // followed by the game name, for plays of each game
+)
+
+// rollupMetrics are the counters kept for the year's Wrapped
+var rollupMetrics = []string{MessagesMetric, ReactionsMetric, ReactionsReceivedMetric, CursedPostsMetric, CursedChannelPostsMetric}
+
+// RollupPeriod copies each user's stats for a month into yearly_rollups, so they're kept for the year's Wrapped after
+// the month is cleaned up. Rolling up a month again replaces its previous rollup. Buffered counter increments are
+// flushed first so they're included.
+func (s Stats) RollupPeriod(ctx context.Context, period string) error {
+	if s.buffer != nil {
+		if err := s.buffer.flush(ctx); err != nil {
+			return fmt.Errorf("failed to flush counters before rollup: %w", err)
+		}
+	}
+	_, err := s.pool.Exec(ctx, `
+INSERT INTO yearly_rollups AS yr (guild_id, user_id, period, stat, value)
+SELECT guild_id, user_id, period, metric, SUM(value)::integer
+FROM stat_counters
+WHERE period = $1 AND metric = ANY($2)
+GROUP BY guild_id, user_id, period, metric
+UNION ALL
+SELECT guild_id, user_id, period, $3::text, win_count FROM daily_game_win_stats WHERE period = $1
+UNION ALL
+SELECT guild_id, user_id, period, $4::text, max_streak FROM daily_game_win_stats WHERE period = $1
+UNION ALL
+SELECT guild_id, user_id, period, $5::text || game, play_count FROM game_plays WHERE period = $1
+ON CONFLICT (guild_id, user_id, period, stat) DO UPDATE
+SET value = EXCLUDED.value`, period, rollupMetrics, GameWinsMetric, MaxStreakStat, gameStatPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to roll up %s: %w", period, err)
+	}
+	return nil
+}
+
+// GetYearlyRollups gets each user's rolled up stats for the year, summed across its months. Streaks are the best of
+// any month.
+func (s Stats) GetYearlyRollups(ctx context.Context, guildId uint64, year string) ([]*model.RollupStat, error) {
+	var results []*model.RollupStat
+	err := pgxscan.Select(ctx, s.pool, &results, `
+SELECT guild_id, user_id, $2::text AS period, stat,
+    CASE WHEN stat = $3 THEN MAX(value) ELSE SUM(value) END AS value
+FROM yearly_rollups
+WHERE guild_id = $1 AND period LIKE $2 || '-%'
+GROUP BY guild_id, user_id, stat
+ORDER BY user_id, stat`, guildId, year, MaxStreakStat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rollups for %s: %w", year, err)
+	}
+	return results, nil
+}
+
+// GetPendingRollups gets each user's stats for the months of the year that haven't been rolled up for the guild yet,
+// in the same form as their rollups. Nothing is written.
+func (s Stats) GetPendingRollups(ctx context.Context, guildId uint64, year string) ([]*model.RollupStat, error) {
+	var results []*model.RollupStat
+	err := pgxscan.Select(ctx, s.pool, &results, `
+WITH live AS (
+    SELECT user_id, period, metric AS stat, SUM(value)::integer AS value
+    FROM stat_counters
+    WHERE guild_id = $1 AND period = ANY($2) AND metric = ANY($3)
+    GROUP BY user_id, period, metric
+    UNION ALL
+    SELECT user_id, period, $4::text, win_count FROM daily_game_win_stats WHERE guild_id = $1 AND period = ANY($2)
+    UNION ALL
+    SELECT user_id, period, $5::text, max_streak FROM daily_game_win_stats WHERE guild_id = $1 AND period = ANY($2)
+    UNION ALL
+    SELECT user_id, period, $6::text || game, play_count FROM game_plays WHERE guild_id = $1 AND period = ANY($2)
+)
+SELECT $1::numeric AS guild_id, user_id, period, stat, value
+FROM live
+WHERE period NOT IN (SELECT period FROM yearly_rollups WHERE guild_id = $1 AND period = ANY($2))
+ORDER BY period, user_id, stat`, guildId, yearMonths(year), rollupMetrics, GameWinsMetric, MaxStreakStat, gameStatPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending rollups for %s: %w", year, err)
+	}
+	return results, nil
+}
+
+// yearMonths gets the monthly period keys for the year
+func yearMonths(year string) []string {
+	months := make([]string, 0, 12)
+	for month := 1; month <= 12; month++ {
+		months = append(months, fmt.Sprintf("%s-%02d", year, month))
+	}
+	return months
+}
+
+// WrappedMember is a member's stats for the year
+type WrappedMember struct {
+	UserId             uint64
+	Messages           int
+	Reactions          int
+	ReactionsReceived  int
+	CursedPosts        int
+	CursedChannelPosts int
+	GamesPlayed        int
+	GameWins           int
+	BestStreak         int
+	FavouriteGame      string
+	FavouriteGamePlays int
+}
+
+// LurkerScore is reactions given less messages sent, the same as the reactions leaderboard
+func (w WrappedMember) LurkerScore() int {
+	return w.Reactions - w.Messages
+}
+
+// Wrapped is a guild's summary of the year
+type Wrapped struct {
+	GuildId uint64
+	Year    string
+	Members []*WrappedMember // Ordered by messages sent
+	Games   map[string]int   // Plays of each game across the guild
+}
+
+// BuildWrapped gets the guild's Wrapped for the year, leaving out excluded users. Months that haven't been rolled up
+// by the monthly cleanup yet are read from the live stats, so the year so far is included.
+func BuildWrapped(ctx context.Context, store StatsStore, guildId uint64, year string, exclude []uint64) (*Wrapped, error) {
+	rollups, err := store.GetYearlyRollups(ctx, guildId, year)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := store.GetPendingRollups(ctx, guildId, year)
+	if err != nil {
+		return nil, err
+	}
+	return newWrapped(guildId, year, append(rollups, pending...), exclude), nil
+}
+
+// newWrapped collects the rolled up stats into each member's Wrapped. Stats are summed across rollups, except streaks
+// which are the best of any.
+func newWrapped(guildId uint64, year string, rollups []*model.RollupStat, exclude []uint64) *Wrapped {
+	wrapped := &Wrapped{GuildId: guildId, Year: year, Games: make(map[string]int)}
+	members := make(map[uint64]*WrappedMember)
+	plays := make(map[uint64]map[string]int) // each member's plays of each game
+	for _, rollup := range rollups {
+		if slices.Contains(exclude, rollup.UserId) {
+			continue
+		}
+		member, ok := members[rollup.UserId]
+		if !ok {
+			member = &WrappedMember{UserId: rollup.UserId}
+			members[rollup.UserId] = member
+			wrapped.Members = append(wrapped.Members, member)
+		}
+		switch rollup.Stat {
+		case MessagesMetric:
+			member.Messages += rollup.Value
+		case ReactionsMetric:
+			member.Reactions += rollup.Value
+		case ReactionsReceivedMetric:
+			member.ReactionsReceived += rollup.Value
+		case CursedPostsMetric:
+			member.CursedPosts += rollup.Value
+		case CursedChannelPostsMetric:
+			member.CursedChannelPosts += rollup.Value
+		case GameWinsMetric:
+			member.GameWins += rollup.Value
+		case MaxStreakStat:
+			member.BestStreak = max(member.BestStreak, rollup.Value)
+		default:
+			game, ok := strings.CutPrefix(rollup.Stat, gameStatPrefix)
+			if !ok {
+				continue
+			}
+			member.GamesPlayed += rollup.Value
+			wrapped.Games[game] += rollup.Value
+			if plays[rollup.UserId] == nil {
+				plays[rollup.UserId] = make(map[string]int)
+			}
+			plays[rollup.UserId][game] += rollup.Value
+		}
+	}
+	for _, member := range wrapped.Members {
+		member.FavouriteGame, member.FavouriteGamePlays = favourite(plays[member.UserId])
+	}
+	slices.SortFunc(wrapped.Members, func(a, b *WrappedMember) int {
+		return cmp.Or(cmp.Compare(b.Messages, a.Messages), cmp.Compare(a.UserId, b.UserId))
+	})
+	return wrapped
+}
+
+// WrappedTitle gets the embed title for a guild's Wrapped
+func WrappedTitle(year string) string {
+	return fmt.Sprintf("Costanza Wrapped %s", year)
+}
+
+// Member gets the user's Wrapped, false if they have no stats for the year
+func (w *Wrapped) Member(userId uint64) (*WrappedMember, bool) {
+	for _, member := range w.Members {
+		if member.UserId == userId {
+			return member, true
+		}
+	}
+	return nil, false
+}
+
+// superlative is a guild wide award for the member with the highest value
+type superlative struct {
+	title  string
+	value  func(*WrappedMember) int
+	format string // Result formatted with the member & value
+}
+
+var superlatives = []superlative{
+	{"Chatterbox", func(m *WrappedMember) int { return m.Messages }, "%s with %d messages"},
+	{"Top lurker", (*WrappedMember).LurkerScore, "%s with a lurker score of %d"},
+	{"Crowd pleaser", func(m *WrappedMember) int { return m.ReactionsReceived }, "%s with %d reactions received"},
+	{"Potty mouth", func(m *WrappedMember) int { return m.CursedPosts }, "%s with %d cursed posts"},
+	{"Gamer", func(m *WrappedMember) int { return m.GamesPlayed }, "%s with %d games played"},
+	{"Streaker", func(m *WrappedMember) int { return m.BestStreak }, "%s with a %d game win streak"},
+}
+
+// Sections gets the report sections for the guild's year: guild totals, superlatives & a line for each member
+func (w *Wrapped) Sections() []ReportSection {
+	totals := ReportSection{Title: "The year in numbers"}
+	messages, games := 0, 0
+	for _, member := range w.Members {
+		messages += member.Messages
+		games += member.GamesPlayed
+	}
+	totals.Entries = append(totals.Entries,
+		ReportEntry{Text: fmt.Sprintf("%d messages from %d members", messages, len(w.Members))},
+		ReportEntry{Text: fmt.Sprintf("%d daily games played", games)},
+	)
+	if game, plays := favourite(w.Games); plays > 0 {
+		totals.Entries = append(totals.Entries, ReportEntry{Text: fmt.Sprintf("Most played game was %s with %d plays", game, plays)})
+	}
+
+	awards := ReportSection{Title: "Superlatives"}
+	for _, award := range superlatives {
+		var winner *WrappedMember
+		for _, member := range w.Members {
+			if award.value(member) > 0 && (winner == nil || award.value(member) > award.value(winner)) {
+				winner = member
+			}
+		}
+		if winner != nil {
+			text := award.title + ": " + fmt.Sprintf(award.format, userMention(winner.UserId), award.value(winner))
+			awards.Entries = append(awards.Entries, ReportEntry{Text: text})
+		}
+	}
+
+	members := ReportSection{Title: "Members"}
+	for _, member := range w.Members {
+		members.Entries = append(members.Entries, ReportEntry{Text: userMention(member.UserId) + ": " + member.Summary()})
+	}
+	sections := []ReportSection{totals}
+	if len(awards.Entries) > 0 {
+		sections = append(sections, awards)
+	}
+	return append(sections, members)
+}
+
+// Summary formats the member's year on a single line
+func (w WrappedMember) Summary() string {
+	parts := []string{
+		fmt.Sprintf("%d messages", w.Messages),
+		fmt.Sprintf("lurker score %d", w.LurkerScore()),
+		fmt.Sprintf("%d cursed posts", w.CursedPosts),
+	}
+	if w.GamesPlayed > 0 {
+		parts = append(parts,
+			fmt.Sprintf("best streak %d", w.BestStreak),
+			fmt.Sprintf("favourite game %s", w.FavouriteGame),
+		)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Section gets the report section for a single member's year
+func (w WrappedMember) Section() ReportSection {
+	section := ReportSection{Title: "Year in review"}
+	section.Entries = append(section.Entries,
+		ReportEntry{Text: userMention(w.UserId)},
+		ReportEntry{Text: fmt.Sprintf("Messages sent: %d", w.Messages)},
+		ReportEntry{Text: fmt.Sprintf("Reactions received: %d", w.ReactionsReceived)},
+		ReportEntry{Text: fmt.Sprintf("Lurker score: %d", w.LurkerScore())},
+		ReportEntry{Text: fmt.Sprintf("Cursed posts: %d (%d on cursed channels)", w.CursedPosts, w.CursedChannelPosts)},
+	)
+	if w.GamesPlayed > 0 {
+		section.Entries = append(section.Entries,
+			ReportEntry{Text: fmt.Sprintf("Daily games played: %d (%d wins)", w.GamesPlayed, w.GameWins)},
+			ReportEntry{Text: fmt.Sprintf("Best game streak: %d", w.BestStreak)},
+			ReportEntry{Text: fmt.Sprintf("Favourite game: %s (%d plays)", w.FavouriteGame, w.FavouriteGamePlays)},
+		)
+	}
+	return section
+}
+
+// favourite gets the game with the most plays, ties broken by name
+func favourite(games map[string]int) (string, int) {
+	best, plays := "", 0
+	for _, game := range slices.Sorted(maps.Keys(games)) {
+		if games[game] > plays {
+			best, plays = game, games[game]
+		}
+	}
+	return best, plays
+}
+
+func userMention(userId uint64) string {
+	user := discordgo.User{ID: strconv.FormatUint(userId, 10)}
+	return user.Mention()
+}
@@ -0,0 +1,171 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dmtaylor/costanza/internal/model"
+)
+
+func TestStats_RollupPeriod(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build mock")
+	defer mockDb.Close()
+	mockDb.ExpectExec(`INSERT INTO yearly_rollups AS yr \(guild_id, user_id, period, stat, value\)(.|\n)+ON CONFLICT \(guild_id, user_id, period, stat\) DO UPDATE\s+SET value = EXCLUDED.value`).
+		WithArgs("2024-03", rollupMetrics, GameWinsMetric, MaxStreakStat, gameStatPrefix).
+		WillReturnResult(pgxmock.NewResult("INSERT", 12))
+	stats := New(mockDb)
+	assert.NoError(t, stats.RollupPeriod(context.Background(), "2024-03"))
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet db expectations")
+}
+
+func TestStats_RollupPeriodError(t *testing.T) {
+	expectedErr := errors.New("underlying db err")
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build mock")
+	defer mockDb.Close()
+	mockDb.ExpectExec(`INSERT INTO yearly_rollups`).
+		WithArgs("2024-03", rollupMetrics, GameWinsMetric, MaxStreakStat, gameStatPrefix).
+		WillReturnError(expectedErr)
+	stats := New(mockDb)
+	assert.ErrorIs(t, stats.RollupPeriod(context.Background(), "2024-03"), expectedErr, "expected error not wrapped")
+}
+
+func TestStats_GetYearlyRollups(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build mock")
+	defer mockDb.Close()
+	rows := mockDb.NewRows([]string{"guild_id", "user_id", "period", "stat", "value"}).
+		AddRow(uint64(1), uint64(10), "2024", MaxStreakStat, 6).
+		AddRow(uint64(1), uint64(10), "2024", MessagesMetric, 300)
+	mockDb.ExpectQuery(`FROM yearly_rollups\s+WHERE guild_id = \$1 AND period LIKE \$2 \|\| '-%'`).
+		WithArgs(uint64(1), "2024", MaxStreakStat).
+		WillReturnRows(rows)
+	stats := New(mockDb)
+	got, err := stats.GetYearlyRollups(context.Background(), 1, "2024")
+	require.NoError(t, err)
+	assert.Equal(t, []*model.RollupStat{
+		{GuildId: 1, UserId: 10, Period: "2024", Stat: MaxStreakStat, Value: 6},
+		{GuildId: 1, UserId: 10, Period: "2024", Stat: MessagesMetric, Value: 300},
+	}, got)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet db expectations")
+}
+
+func TestStats_GetPendingRollups(t *testing.T) {
+	mockDb, err := pgxmock.NewPool()
+	require.Nil(t, err, "failed to build mock")
+	defer mockDb.Close()
+	rows := mockDb.NewRows([]string{"guild_id", "user_id", "period", "stat", "value"}).
+		AddRow(uint64(1), uint64(10), "2024-12", MessagesMetric, 40)
+	mockDb.ExpectQuery(`WHERE period NOT IN \(SELECT period FROM yearly_rollups WHERE guild_id = \$1 AND period = ANY\(\$2\)\)`).
+		WithArgs(uint64(1), yearMonths("2024"), rollupMetrics, GameWinsMetric, MaxStreakStat, gameStatPrefix).
+		WillReturnRows(rows)
+	stats := New(mockDb)
+	got, err := stats.GetPendingRollups(context.Background(), 1, "2024")
+	require.NoError(t, err)
+	assert.Equal(t, []*model.RollupStat{
+		{GuildId: 1, UserId: 10, Period: "2024-12", Stat: MessagesMetric, Value: 40},
+	}, got)
+	assert.Nil(t, mockDb.ExpectationsWereMet(), "unmet db expectations")
+}
+
+func TestBuildWrapped(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	logCounter := func(metric string, userId uint64, period string, increment int) {
+		require.NoError(t, m.LogCounter(ctx, Counter{Metric: metric, GuildId: 1, UserId: userId, Period: period, ChannelId: 300, Increment: increment}))
+	}
+	logCounter(MessagesMetric, 10, "2024-01", 40)
+	logCounter(MessagesMetric, 10, "2024-02", 60)
+	logCounter(ReactionsMetric, 10, "2024-02", 5)
+	logCounter(MessagesMetric, 11, "2024-02", 3)
+	logCounter(ReactionsMetric, 11, "2024-02", 50)
+	logCounter(CursedPostsMetric, 11, "2024-02", 4)
+	logCounter(MessagesMetric, 12, "2024-02", 500) // opted out
+	// other periods & years aren't counted
+	logCounter(MessagesMetric, 10, "2024-W05", 99)
+	logCounter(MessagesMetric, 10, "2024", 99)
+	logCounter(MessagesMetric, 10, "2023-12", 99)
+	for _, play := range []struct {
+		period string
+		game   string
+		win    bool
+	}{
+		{"2024-01", "Wordle", true}, {"2024-01", "Wordle", true}, {"2024-01", "Wordle", true}, {"2024-01", "Wordle", false},
+		{"2024-02", "Wordle", true}, {"2024-02", "Framed", false}, {"2024-02", "Framed", false},
+		{"2024-02", "Framed", false}, {"2024-02", "Framed", false}, {"2024-02", "Framed", false},
+	} {
+		require.NoError(t, m.LogDailyGameActivity(ctx, model.DailyGamePlay{GuildId: 1, UserId: 11, Tries: 3, Win: play.win, Game: play.game}, play.period))
+		require.NoError(t, m.LogGamePlay(ctx, 1, 11, play.game, play.period))
+	}
+	// January is rolled up & cleaned up, February is still live
+	require.NoError(t, m.RollupPeriod(ctx, "2024-01"))
+	require.NoError(t, m.RemoveCountersForPeriod(ctx, "2024-01"))
+	require.NoError(t, m.RemoveDailyGameLeadersForPeriod(ctx, "2024-01"))
+	require.NoError(t, m.RemoveGamePlaysForPeriod(ctx, "2024-01"))
+
+	got, err := BuildWrapped(ctx, m, 1, "2024", []uint64{12})
+	require.NoError(t, err)
+	assert.Equal(t, []*WrappedMember{
+		{UserId: 10, Messages: 100, Reactions: 5},
+		{
+			UserId:             11,
+			Messages:           3,
+			Reactions:          50,
+			CursedPosts:        4,
+			GamesPlayed:        10,
+			GameWins:           4,
+			BestStreak:         3,
+			FavouriteGame:      "Framed",
+			FavouriteGamePlays: 5,
+		},
+	}, got.Members)
+	assert.Equal(t, map[string]int{"Wordle": 5, "Framed": 5}, got.Games)
+	assert.Equal(t, 47, got.Members[1].LurkerScore())
+
+	// building again doesn't double count the live month
+	again, err := BuildWrapped(ctx, m, 1, "2024", []uint64{12})
+	require.NoError(t, err)
+	assert.Equal(t, got, again)
+	assert.Len(t, m.rollups, 4, "live month should not be rolled up")
+}
+
+func TestWrapped_Sections(t *testing.T) {
+	wrapped := newWrapped(1, "2024", []*model.RollupStat{
+		{UserId: 10, Stat: MessagesMetric, Value: 100},
+		{UserId: 10, Stat: ReactionsMetric, Value: 5},
+		{UserId: 11, Stat: MessagesMetric, Value: 3},
+		{UserId: 11, Stat: ReactionsMetric, Value: 50},
+		{UserId: 11, Stat: MaxStreakStat, Value: 7},
+		{UserId: 11, Stat: gameStatPrefix + "Wordle", Value: 20},
+	}, nil)
+	want := []ReportSection{
+		{Title: "The year in numbers", Entries: []ReportEntry{
+			{Text: "103 messages from 2 members"},
+			{Text: "20 daily games played"},
+			{Text: "Most played game was Wordle with 20 plays"},
+		}},
+		{Title: "Superlatives", Entries: []ReportEntry{
+			{Text: "Chatterbox: <@10> with 100 messages"},
+			{Text: "Top lurker: <@11> with a lurker score of 47"},
+			{Text: "Gamer: <@11> with 20 games played"},
+			{Text: "Streaker: <@11> with a 7 game win streak"},
+		}},
+		{Title: "Members", Entries: []ReportEntry{
+			{Text: "<@10>: 100 messages, lurker score -95, 0 cursed posts"},
+			{Text: "<@11>: 3 messages, lurker score 47, 0 cursed posts, best streak 7, favourite game Wordle"},
+		}},
+	}
+	assert.Equal(t, want, wrapped.Sections())
+
+	member, ok := wrapped.Member(11)
+	require.True(t, ok)
+	assert.Contains(t, member.Section().Entries, ReportEntry{Text: "Favourite game: Wordle (20 plays)"})
+	_, ok = wrapped.Member(12)
+	assert.False(t, ok)
+}
@@ -64,3 +64,12 @@ func ContextFromDiscordReactionAdd(parent context.Context, r *discordgo.MessageR
 
 	return ctx
 }
+
+func ContextFromDiscordReactionRemove(parent context.Context, r *discordgo.MessageReactionRemove) context.Context {
+	ctx := context.WithValue(parent, "guildId", r.GuildID)
+	ctx = context.WithValue(ctx, "messageId", r.MessageID)
+	ctx = context.WithValue(ctx, "user", r.UserID)
+	ctx = context.WithValue(ctx, "type", ReactionEvtType)
+
+	return ctx
+}
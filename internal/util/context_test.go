@@ -169,3 +169,25 @@ func TestContextFromDiscordReactionAdd(t *testing.T) {
 		t.Errorf("expected guildId context value \"543210\" got %s", userId)
 	}
 }
+
+func TestContextFromDiscordReactionRemove(t *testing.T) {
+	r := &discordgo.MessageReactionRemove{
+		MessageReaction: &discordgo.MessageReaction{
+			UserID:    "543210",
+			MessageID: "8675309",
+			Emoji:     discordgo.Emoji{Name: "🔥"},
+			ChannelID: "796033412",
+			GuildID:   "9876543",
+		},
+	}
+	testCtx := ContextFromDiscordReactionRemove(context.Background(), r)
+	if etype, ok := testCtx.Value("type").(string); !ok || etype != ReactionEvtType {
+		t.Errorf("expected event type \""+ReactionEvtType+"\", got %s", etype)
+	}
+	if messageId, ok := testCtx.Value("messageId").(string); !ok || messageId != "8675309" {
+		t.Errorf("expected messageId context value \"8675309\" got %s", messageId)
+	}
+	if userId, ok := testCtx.Value("user").(string); !ok || userId != "543210" {
+		t.Errorf("expected user context value \"543210\" got %s", userId)
+	}
+}
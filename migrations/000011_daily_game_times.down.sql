@@ -0,0 +1,4 @@
+DROP INDEX game_times_guild_month;
+DROP INDEX game_times_guild_users;
+
+DROP TABLE daily_game_times;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS daily_game_times (
+    id SERIAL PRIMARY KEY,
+    guild_id NUMERIC NOT NULL,
+    user_id NUMERIC NOT NULL,
+    report_month VARCHAR(7) NOT NULL,
+    game VARCHAR(32) NOT NULL,
+    solve_seconds INTEGER NOT NULL
+);
+
+CREATE INDEX game_times_guild_users ON daily_game_times(guild_id, user_id);
+CREATE INDEX game_times_guild_month ON daily_game_times(guild_id, report_month);
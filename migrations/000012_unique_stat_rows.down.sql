@@ -0,0 +1 @@
+DROP INDEX win_stats_guild_user_month;
@@ -0,0 +1,17 @@
+-- merge any duplicate rows created by concurrent inserts before adding the unique index. The message stat tables are
+-- merged as they're moved into stat_counters instead, as that replaces them straight after.
+UPDATE daily_game_win_stats s
+SET play_count = totals.play_count,
+    guess_count = totals.guess_count,
+    win_count = totals.win_count,
+    max_streak = totals.max_streak
+FROM (
+    SELECT MIN(id) AS id, SUM(play_count) AS play_count, SUM(guess_count) AS guess_count, SUM(win_count) AS win_count, MAX(max_streak) AS max_streak
+    FROM daily_game_win_stats
+    GROUP BY guild_id, user_id, report_month
+    HAVING COUNT(*) > 1
+) totals
+WHERE s.id = totals.id;
+DELETE FROM daily_game_win_stats s USING daily_game_win_stats k
+WHERE s.guild_id = k.guild_id AND s.user_id = k.user_id AND s.report_month = k.report_month AND s.id > k.id;
+CREATE UNIQUE INDEX win_stats_guild_user_month ON daily_game_win_stats(guild_id, user_id, report_month);
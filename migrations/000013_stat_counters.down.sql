@@ -0,0 +1,52 @@
+CREATE TABLE IF NOT EXISTS discord_usage_stats (
+    id SERIAL PRIMARY KEY,
+    guild_id NUMERIC NOT NULL,
+    user_id NUMERIC NOT NULL,
+    report_month VARCHAR(7) NOT NULL,
+    message_count INTEGER DEFAULT 1
+);
+CREATE INDEX report_guild_users ON discord_usage_stats(guild_id, user_id);
+CREATE INDEX report_guild_months ON discord_usage_stats(guild_id, report_month);
+
+CREATE TABLE IF NOT EXISTS discord_reaction_stats (
+    id SERIAL PRIMARY KEY,
+    guild_id NUMERIC NOT NULL,
+    user_id NUMERIC NOT NULL,
+    report_month VARCHAR(7) NOT NULL,
+    message_count INTEGER NOT NULL DEFAULT 1
+);
+CREATE INDEX reaction_guild_users ON discord_reaction_stats(guild_id, user_id);
+CREATE INDEX reaction_guild_months ON discord_reaction_stats(guild_id, report_month);
+
+CREATE TABLE IF NOT EXISTS discord_cursed_channel_stats (
+    id SERIAL PRIMARY KEY,
+    guild_id NUMERIC NOT NULL,
+    user_id NUMERIC NOT NULL,
+    report_month VARCHAR(7) NOT NULL,
+    message_count INTEGER NOT NULL DEFAULT 1
+);
+CREATE INDEX cursed_channel_posts_users ON discord_reaction_stats(guild_id, user_id);
+CREATE INDEX cursed_channel_posts_guild_months ON discord_cursed_channel_stats(guild_id, report_month);
+
+CREATE TABLE IF NOT EXISTS discord_cursed_posts_stats (
+    id SERIAL PRIMARY KEY,
+    guild_id NUMERIC NOT NULL,
+    user_id NUMERIC NOT NULL,
+    report_month VARCHAR(7) NOT NULL,
+    message_count INTEGER NOT NULL DEFAULT 1
+);
+CREATE INDEX cursed_word_post_users ON discord_cursed_posts_stats(guild_id, user_id);
+CREATE INDEX cursed_word_guild_months ON discord_cursed_posts_stats(guild_id, report_month);
+
+INSERT INTO discord_usage_stats (guild_id, user_id, report_month, message_count)
+SELECT guild_id, user_id, period, value FROM stat_counters WHERE metric = 'messages' AND length(period) <= 7;
+INSERT INTO discord_reaction_stats (guild_id, user_id, report_month, message_count)
+SELECT guild_id, user_id, period, value FROM stat_counters WHERE metric = 'reactions' AND length(period) <= 7;
+INSERT INTO discord_cursed_channel_stats (guild_id, user_id, report_month, message_count)
+SELECT guild_id, user_id, period, value FROM stat_counters WHERE metric = 'cursed_channel_posts' AND length(period) <= 7;
+INSERT INTO discord_cursed_posts_stats (guild_id, user_id, report_month, message_count)
+SELECT guild_id, user_id, period, value FROM stat_counters WHERE metric = 'cursed_posts' AND length(period) <= 7;
+
+DROP INDEX stat_counters_guild_period;
+DROP INDEX stat_counters_metric_guild_user_period;
+DROP TABLE stat_counters;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS stat_counters (
+    id SERIAL PRIMARY KEY,
+    metric VARCHAR(64) NOT NULL,
+    guild_id NUMERIC NOT NULL,
+    user_id NUMERIC NOT NULL,
+    period VARCHAR(16) NOT NULL,
+    value INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX stat_counters_metric_guild_user_period ON stat_counters(metric, guild_id, user_id, period);
+CREATE INDEX stat_counters_guild_period ON stat_counters(guild_id, period);
+
+-- the old tables could have duplicate rows from concurrent inserts, so they're merged here
+INSERT INTO stat_counters (metric, guild_id, user_id, period, value)
+SELECT 'messages', guild_id, user_id, report_month, SUM(COALESCE(message_count, 0)) FROM discord_usage_stats
+GROUP BY guild_id, user_id, report_month;
+INSERT INTO stat_counters (metric, guild_id, user_id, period, value)
+SELECT 'reactions', guild_id, user_id, report_month, SUM(COALESCE(message_count, 0)) FROM discord_reaction_stats
+GROUP BY guild_id, user_id, report_month;
+INSERT INTO stat_counters (metric, guild_id, user_id, period, value)
+SELECT 'cursed_channel_posts', guild_id, user_id, report_month, SUM(message_count) FROM discord_cursed_channel_stats
+GROUP BY guild_id, user_id, report_month;
+INSERT INTO stat_counters (metric, guild_id, user_id, period, value)
+SELECT 'cursed_posts', guild_id, user_id, report_month, SUM(message_count) FROM discord_cursed_posts_stats
+GROUP BY guild_id, user_id, report_month;
+
+DROP TABLE discord_usage_stats;
+DROP TABLE discord_reaction_stats;
+DROP TABLE discord_cursed_channel_stats;
+DROP TABLE discord_cursed_posts_stats;
@@ -0,0 +1,13 @@
+-- only monthly periods fit the old report_month columns
+DELETE FROM stat_counters WHERE period !~ '^[0-9]{4}-[0-9]{2}$';
+DELETE FROM daily_game_win_stats WHERE period !~ '^[0-9]{4}-[0-9]{2}$';
+DELETE FROM daily_game_times WHERE period !~ '^[0-9]{4}-[0-9]{2}$';
+
+ALTER INDEX game_times_guild_period RENAME TO game_times_guild_month;
+ALTER TABLE daily_game_times ALTER COLUMN period TYPE VARCHAR(7);
+ALTER TABLE daily_game_times RENAME COLUMN period TO report_month;
+
+ALTER INDEX win_stats_guild_user_period RENAME TO win_stats_guild_user_month;
+ALTER INDEX win_stats_guild_period RENAME TO win_stats_guild_month;
+ALTER TABLE daily_game_win_stats ALTER COLUMN period TYPE VARCHAR(7);
+ALTER TABLE daily_game_win_stats RENAME COLUMN period TO report_month;
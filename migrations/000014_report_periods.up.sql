@@ -0,0 +1,8 @@
+ALTER TABLE daily_game_win_stats RENAME COLUMN report_month TO period;
+ALTER TABLE daily_game_win_stats ALTER COLUMN period TYPE VARCHAR(16);
+ALTER INDEX win_stats_guild_month RENAME TO win_stats_guild_period;
+ALTER INDEX win_stats_guild_user_month RENAME TO win_stats_guild_user_period;
+
+ALTER TABLE daily_game_times RENAME COLUMN report_month TO period;
+ALTER TABLE daily_game_times ALTER COLUMN period TYPE VARCHAR(16);
+ALTER INDEX game_times_guild_month RENAME TO game_times_guild_period;
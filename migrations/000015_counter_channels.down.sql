@@ -0,0 +1,18 @@
+DROP INDEX stat_counters_guild_period_channel;
+DROP INDEX stat_counters_metric_guild_user_period_channel;
+
+-- collapse per channel counts back into a single row per user
+UPDATE stat_counters s
+SET value = totals.value,
+    channel_id = 0
+FROM (
+    SELECT MIN(id) AS id, SUM(value) AS value
+    FROM stat_counters
+    GROUP BY metric, guild_id, user_id, period
+) totals
+WHERE s.id = totals.id;
+DELETE FROM stat_counters s USING stat_counters k
+WHERE s.metric = k.metric AND s.guild_id = k.guild_id AND s.user_id = k.user_id AND s.period = k.period AND s.id > k.id;
+
+ALTER TABLE stat_counters DROP COLUMN channel_id;
+CREATE UNIQUE INDEX stat_counters_metric_guild_user_period ON stat_counters(metric, guild_id, user_id, period);
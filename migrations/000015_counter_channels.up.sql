@@ -0,0 +1,4 @@
+ALTER TABLE stat_counters ADD COLUMN channel_id NUMERIC NOT NULL DEFAULT 0;
+DROP INDEX stat_counters_metric_guild_user_period;
+CREATE UNIQUE INDEX stat_counters_metric_guild_user_period_channel ON stat_counters(metric, guild_id, user_id, period, channel_id);
+CREATE INDEX stat_counters_guild_period_channel ON stat_counters(guild_id, period, channel_id);
@@ -0,0 +1,3 @@
+DROP INDEX reaction_counters_period;
+DROP INDEX reaction_counters_guild_period_message_emoji;
+DROP TABLE reaction_counters;
@@ -0,0 +1,2 @@
+DROP INDEX starboard_posts_message;
+DROP TABLE starboard_posts;
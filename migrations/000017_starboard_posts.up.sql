@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS starboard_posts (
+    id SERIAL PRIMARY KEY,
+    guild_id NUMERIC NOT NULL,
+    channel_id NUMERIC NOT NULL,
+    message_id NUMERIC NOT NULL,
+    starboard_message_id NUMERIC NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX starboard_posts_message ON starboard_posts(message_id);
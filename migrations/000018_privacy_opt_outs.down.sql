@@ -0,0 +1,2 @@
+DROP INDEX privacy_opt_outs_guild_user;
+DROP TABLE privacy_opt_outs;
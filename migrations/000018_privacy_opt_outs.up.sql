@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS privacy_opt_outs (
+    id SERIAL PRIMARY KEY,
+    guild_id NUMERIC NOT NULL,
+    user_id NUMERIC NOT NULL
+);
+
+CREATE UNIQUE INDEX privacy_opt_outs_guild_user ON privacy_opt_outs(guild_id, user_id);
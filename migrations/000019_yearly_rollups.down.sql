@@ -0,0 +1,6 @@
+DROP INDEX yearly_rollups_guild_period;
+DROP INDEX yearly_rollups_guild_user_period_stat;
+DROP TABLE yearly_rollups;
+DROP INDEX game_plays_period;
+DROP INDEX game_plays_guild_user_period_game;
+DROP TABLE game_plays;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS game_plays (
+    id SERIAL PRIMARY KEY,
+    guild_id NUMERIC NOT NULL,
+    user_id NUMERIC NOT NULL,
+    period VARCHAR(16) NOT NULL,
+    game VARCHAR(32) NOT NULL,
+    play_count INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX game_plays_guild_user_period_game ON game_plays(guild_id, user_id, period, game);
+CREATE INDEX game_plays_period ON game_plays(period);
+
+CREATE TABLE IF NOT EXISTS yearly_rollups (
+    id SERIAL PRIMARY KEY,
+    guild_id NUMERIC NOT NULL,
+    user_id NUMERIC NOT NULL,
+    period VARCHAR(16) NOT NULL,
+    stat VARCHAR(64) NOT NULL,
+    value INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX yearly_rollups_guild_user_period_stat ON yearly_rollups(guild_id, user_id, period, stat);
+CREATE INDEX yearly_rollups_guild_period ON yearly_rollups(guild_id, period);
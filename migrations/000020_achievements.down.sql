@@ -0,0 +1,4 @@
+DROP INDEX achievement_progress_guild_user_progress;
+DROP TABLE achievement_progress;
+DROP INDEX achievements_guild_user_achievement;
+DROP TABLE achievements;
@@ -0,0 +1,2 @@
+DROP INDEX cursed_word_allowlist_guilds;
+DROP TABLE cursed_word_allowlist;
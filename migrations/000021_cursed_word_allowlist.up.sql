@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS cursed_word_allowlist (
+    id SERIAL PRIMARY KEY,
+    guild_id NUMERIC NOT NULL,
+    word TEXT NOT NULL
+);
+
+CREATE INDEX cursed_word_allowlist_guilds ON cursed_word_allowlist(guild_id);
@@ -0,0 +1,2 @@
+DROP INDEX swear_jar_guild_user;
+DROP TABLE swear_jar;
@@ -0,0 +1,9 @@
+DROP TRIGGER privacy_opt_outs_cache_truncate ON privacy_opt_outs;
+DROP TRIGGER privacy_opt_outs_cache_invalidation ON privacy_opt_outs;
+DROP TRIGGER cursed_word_allowlist_cache_truncate ON cursed_word_allowlist;
+DROP TRIGGER cursed_word_allowlist_cache_invalidation ON cursed_word_allowlist;
+DROP TRIGGER cursed_word_list_cache_truncate ON cursed_word_list;
+DROP TRIGGER cursed_word_list_cache_invalidation ON cursed_word_list;
+DROP TRIGGER cursed_channels_cache_truncate ON cursed_channels;
+DROP TRIGGER cursed_channels_cache_invalidation ON cursed_channels;
+DROP FUNCTION notify_cache_invalidation();
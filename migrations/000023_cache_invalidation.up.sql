@@ -0,0 +1,35 @@
+CREATE OR REPLACE FUNCTION notify_cache_invalidation() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'TRUNCATE' THEN
+        PERFORM pg_notify('cache_invalidation', TG_TABLE_NAME);
+        RETURN NULL;
+    END IF;
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        PERFORM pg_notify('cache_invalidation', TG_TABLE_NAME || ':' || OLD.guild_id);
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        PERFORM pg_notify('cache_invalidation', TG_TABLE_NAME || ':' || NEW.guild_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER cursed_channels_cache_invalidation AFTER INSERT OR UPDATE OR DELETE ON cursed_channels
+    FOR EACH ROW EXECUTE FUNCTION notify_cache_invalidation();
+CREATE TRIGGER cursed_channels_cache_truncate AFTER TRUNCATE ON cursed_channels
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_cache_invalidation();
+
+CREATE TRIGGER cursed_word_list_cache_invalidation AFTER INSERT OR UPDATE OR DELETE ON cursed_word_list
+    FOR EACH ROW EXECUTE FUNCTION notify_cache_invalidation();
+CREATE TRIGGER cursed_word_list_cache_truncate AFTER TRUNCATE ON cursed_word_list
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_cache_invalidation();
+
+CREATE TRIGGER cursed_word_allowlist_cache_invalidation AFTER INSERT OR UPDATE OR DELETE ON cursed_word_allowlist
+    FOR EACH ROW EXECUTE FUNCTION notify_cache_invalidation();
+CREATE TRIGGER cursed_word_allowlist_cache_truncate AFTER TRUNCATE ON cursed_word_allowlist
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_cache_invalidation();
+
+CREATE TRIGGER privacy_opt_outs_cache_invalidation AFTER INSERT OR UPDATE OR DELETE ON privacy_opt_outs
+    FOR EACH ROW EXECUTE FUNCTION notify_cache_invalidation();
+CREATE TRIGGER privacy_opt_outs_cache_truncate AFTER TRUNCATE ON privacy_opt_outs
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_cache_invalidation();
@@ -0,0 +1,2 @@
+DROP INDEX infractions_guild_user;
+DROP TABLE infractions;
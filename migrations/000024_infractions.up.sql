@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS infractions (
+    id SERIAL PRIMARY KEY,
+    guild_id NUMERIC NOT NULL,
+    user_id NUMERIC NOT NULL,
+    moderator_id NUMERIC NOT NULL DEFAULT 0, -- 0 for infractions the bot recorded
+    kind VARCHAR(16) NOT NULL,
+    reason TEXT NOT NULL,
+    created_at NUMERIC NOT NULL, -- unix seconds
+    pardoned_by NUMERIC NOT NULL DEFAULT 0 -- 0 until pardoned
+);
+
+CREATE INDEX infractions_guild_user ON infractions(guild_id, user_id);
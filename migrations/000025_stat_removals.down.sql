@@ -0,0 +1,2 @@
+DROP INDEX stat_removals_guild_user;
+DROP TABLE stat_removals;